@@ -0,0 +1,202 @@
+package gologs
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WithAsync makes the logger write entries from a background goroutine, so
+// that slow sinks, such as network sinks or a busy disk, don't add to the
+// latency of log calls. Entries wait in a buffer of size entries; when it is
+// full, log calls wait for room, unless a drop policy is set with
+// WithAsyncDropPolicy. Flush waits until the buffer is empty, and Close
+// writes the remaining entries before closing the sinks.
+func WithAsync(size int) Option {
+	return func(o *options) {
+		o.async = size
+	}
+}
+
+// WithAsyncDropPolicy sets what happens in async mode when the buffer is
+// full. Defaults to BlockWhenFull, which never loses entries but lets a
+// slow sink delay log calls; the other policies keep log calls fast and
+// drop entries instead, which Dropped counts. DropLowest drops DEBUG entries
+// before INFO and INFO before WARN, and never drops ERROR and above; those
+// wait for room when nothing else can be dropped.
+func WithAsyncDropPolicy(policy DropPolicy) Option {
+	return func(o *options) {
+		o.asyncPolicy = policy
+	}
+}
+
+// Dropped returns the number of entries dropped in async mode because the
+// buffer was full.
+func (l *Logger) Dropped() int64 {
+	if l.async == nil {
+		return 0
+	}
+	return l.async.dropped.Load()
+}
+
+// WithAsyncBatch makes the logger, in async mode, collect the entries for
+// its outputs and write them with a single Write call once they reach size
+// bytes, or at the latest after interval, which defaults to a second. This
+// saves system calls for services logging many entries. Sinks added with WithSinks receive entries
+// one at a time, as before.
+func WithAsyncBatch(size int, interval time.Duration) Option {
+	return func(o *options) {
+		o.batchBytes = size
+		o.flushInterval = interval
+	}
+}
+
+// asyncWriter passes entries to a logger's sinks from a background
+// goroutine, in the order they were logged.
+type asyncWriter struct {
+	write   func(LogEntry)
+	policy  DropPolicy
+	dropped atomic.Int64
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	ring   []LogEntry
+	head   int
+	n      int
+	busy   bool
+	closed bool
+	done   chan struct{}
+}
+
+// newAsyncWriter starts a writer buffering up to size entries for write.
+func newAsyncWriter(size int, policy DropPolicy, write func(LogEntry)) *asyncWriter {
+	w := &asyncWriter{
+		write:  write,
+		policy: policy,
+		ring:   make([]LogEntry, max(size, 1)),
+		done:   make(chan struct{}),
+	}
+	w.cond = sync.NewCond(&w.mu)
+	go w.loop()
+	return w
+}
+
+// add queues an entry. If the buffer is full, it waits for room or drops
+// an entry, depending on the policy. Once the writer is closed, entries are
+// written right away.
+func (w *asyncWriter) add(entry LogEntry) {
+	w.mu.Lock()
+	for w.n == len(w.ring) && !w.closed && waitsForRoom(w.policy, entry, *w.at(w.lowest())) {
+		w.cond.Wait()
+	}
+	if w.closed {
+		w.mu.Unlock()
+		w.write(entry)
+		return
+	}
+	if w.n == len(w.ring) && !w.makeRoom(entry) {
+		w.dropped.Add(1)
+		w.mu.Unlock()
+		return
+	}
+	w.ring[(w.head+w.n)%len(w.ring)] = entry
+	w.n++
+	w.cond.Broadcast()
+	w.mu.Unlock()
+}
+
+// makeRoom drops a queued entry from the full buffer according to the
+// policy. It returns false if entry should be dropped instead.
+func (w *asyncWriter) makeRoom(entry LogEntry) bool {
+	drop := 0
+	switch w.policy {
+	case DropNewest:
+		return false
+	case DropLowest:
+		if drop = w.lowest(); entry.Severity < w.at(drop).Severity {
+			return false
+		}
+	}
+	if drop == 0 {
+		*w.at(0) = LogEntry{}
+		w.head = (w.head + 1) % len(w.ring)
+	} else {
+		// Close the gap, moving the newer entries forward.
+		for i := drop; i < w.n-1; i++ {
+			*w.at(i) = *w.at(i + 1)
+		}
+		*w.at(w.n - 1) = LogEntry{}
+	}
+	w.n--
+	w.dropped.Add(1)
+	return true
+}
+
+// lowest returns the index of the oldest of the queued entries with the
+// lowest severity.
+func (w *asyncWriter) lowest() int {
+	lowest := 0
+	for i := 1; i < w.n; i++ {
+		if w.at(i).Severity < w.at(lowest).Severity {
+			lowest = i
+		}
+	}
+	return lowest
+}
+
+// at returns the i-th queued entry, counting from the oldest.
+func (w *asyncWriter) at(i int) *LogEntry {
+	return &w.ring[(w.head+i)%len(w.ring)]
+}
+
+// loop writes queued entries until the writer is closed and the buffer is
+// empty.
+func (w *asyncWriter) loop() {
+	defer close(w.done)
+	for {
+		w.mu.Lock()
+		for w.n == 0 && !w.closed {
+			w.cond.Wait()
+		}
+		if w.n == 0 {
+			w.mu.Unlock()
+			return
+		}
+		entry := w.ring[w.head]
+		w.ring[w.head] = LogEntry{}
+		w.head = (w.head + 1) % len(w.ring)
+		w.n--
+		w.busy = true
+		w.cond.Broadcast()
+		w.mu.Unlock()
+
+		w.write(entry)
+
+		w.mu.Lock()
+		w.busy = false
+		w.cond.Broadcast()
+		w.mu.Unlock()
+	}
+}
+
+// wait waits until all queued entries have been written.
+func (w *asyncWriter) wait() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for w.n > 0 || w.busy {
+		w.cond.Wait()
+	}
+}
+
+// close writes the queued entries and stops the background goroutine.
+func (w *asyncWriter) close() {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	w.closed = true
+	w.cond.Broadcast()
+	w.mu.Unlock()
+	<-w.done
+}
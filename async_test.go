@@ -0,0 +1,241 @@
+package gologs
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tests that log calls return before a slow sink has written the entries,
+// and that Flush waits for them
+func TestAsync(t *testing.T) {
+	unblock := make(chan struct{})
+	inner := &memorySink{}
+	l := New(WithSinks(blockingSink{inner, unblock}), WithAsync(10))
+
+	done := make(chan struct{})
+	go func() {
+		for _, msg := range []string{"a", "b", "c"} {
+			l.Info(msg)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected log calls not to block on the sink")
+	}
+
+	close(unblock)
+	if err := l.Flush(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if msgs := inner.messages(); strings.Join(msgs, ",") != "a,b,c" {
+		t.Errorf("Expected all entries in order after Flush, got %v", msgs)
+	}
+	if inner.flushed != 1 {
+		t.Errorf("Expected the sink to be flushed, got %d", inner.flushed)
+	}
+}
+
+// tests that log calls wait for room when the buffer is full
+func TestAsyncFull(t *testing.T) {
+	unblock := make(chan struct{})
+	inner := &memorySink{}
+	l := New(WithSinks(blockingSink{inner, unblock}), WithAsync(1))
+
+	l.Info("written")
+	// wait until the first entry is being written
+	for {
+		l.async.mu.Lock()
+		busy := l.async.busy
+		l.async.mu.Unlock()
+		if busy {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	l.Info("queued")
+	done := make(chan struct{})
+	go func() {
+		l.Info("waiting")
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("Expected the log call to wait for room")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(unblock)
+	<-done
+	if err := l.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if msgs := inner.messages(); strings.Join(msgs, ",") != "written,queued,waiting" {
+		t.Errorf("Expected all entries in order, got %v", msgs)
+	}
+	if !inner.closed {
+		t.Errorf("Expected the sink to be closed")
+	}
+}
+
+// tests that PANIC and FATAL entries are written before the call returns
+func TestAsyncFatal(t *testing.T) {
+	inner := &memorySink{}
+	l := New(WithSinks(inner), WithAsync(10))
+	defer l.Close()
+	l.log(FATAL, "exiting", nil)
+	if msgs := inner.messages(); len(msgs) != 1 {
+		t.Errorf("Expected the fatal entry to be written, got %v", msgs)
+	}
+}
+
+// countingWriter counts the Write calls to a buffer.
+type countingWriter struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writes++
+	return w.buf.Write(p)
+}
+
+func (w *countingWriter) stats() (writes, lines int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.writes, strings.Count(w.buf.String(), "\n")
+}
+
+// tests that batched entries are written together on Flush
+func TestAsyncBatch(t *testing.T) {
+	w := &countingWriter{}
+	l := New(WithOutput(w), WithAsync(100), WithAsyncBatch(1<<20, time.Hour))
+	for i := 0; i < 10; i++ {
+		l.Info("entry %d", i)
+	}
+	if err := l.Flush(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if writes, lines := w.stats(); writes != 1 || lines != 10 {
+		t.Errorf("Expected 10 entries in 1 write, got %d entries in %d writes", lines, writes)
+	}
+}
+
+// tests that a batch is written once it reaches the size threshold
+func TestAsyncBatchSize(t *testing.T) {
+	w := &countingWriter{}
+	l := New(WithOutput(w), WithAsync(100), WithAsyncBatch(300, time.Hour), WithCallerInfo(false))
+	for i := 0; i < 10; i++ {
+		l.Info("entry %d", i)
+	}
+	l.async.wait()
+	writes, lines := w.stats()
+	if writes == 0 || writes >= 10 || lines == 0 {
+		t.Errorf("Expected full batches to be written, got %d entries in %d writes", lines, writes)
+	}
+	l.Close()
+	if _, lines := w.stats(); lines != 10 {
+		t.Errorf("Expected all entries after Close, got %d", lines)
+	}
+}
+
+// tests that a batch is written after the interval
+func TestAsyncBatchInterval(t *testing.T) {
+	w := &countingWriter{}
+	l := New(WithOutput(w), WithAsync(100), WithAsyncBatch(1<<20, 10*time.Millisecond))
+	defer l.Close()
+	l.Info("entry")
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, lines := w.stats(); lines == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the batch to be written after the interval")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// tests the drop policies of a full buffer
+func TestAsyncDropPolicy(t *testing.T) {
+	for policy, want := range map[DropPolicy]string{DropNewest: "a,b,c", DropOldest: "c,d,e", DropLowest: "a,c,d"} {
+		unblock := make(chan struct{})
+		inner := &memorySink{}
+		l := New(WithSinks(blockingSink{inner, unblock}), WithAsync(3), WithAsyncDropPolicy(policy), WithLevel(TRACE))
+		l.Info("0")
+		// wait until the first entry is being written
+		for {
+			l.async.mu.Lock()
+			busy := l.async.busy
+			l.async.mu.Unlock()
+			if busy {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		for _, e := range []struct {
+			level LogLevel
+			msg   string
+		}{{ERROR, "a"}, {DEBUG, "b"}, {INFO, "c"}, {WARN, "d"}, {TRACE, "e"}} {
+			l.log(e.level, e.msg, nil)
+		}
+		close(unblock)
+		l.Close()
+
+		if msgs := inner.messages(); strings.Join(msgs[1:], ",") != want {
+			t.Errorf("Expected %s for policy %d, got %v", want, policy, msgs)
+		}
+		if l.Dropped() != 2 {
+			t.Errorf("Expected 2 dropped entries for policy %d, got %d", policy, l.Dropped())
+		}
+	}
+}
+
+// tests that DropLowest never drops errors, but waits for room instead
+func TestAsyncDropLowestKeepsErrors(t *testing.T) {
+	unblock := make(chan struct{})
+	inner := &memorySink{}
+	l := New(WithSinks(blockingSink{inner, unblock}), WithAsync(2), WithAsyncDropPolicy(DropLowest))
+	l.Info("0")
+	// wait until the first entry is being written
+	for {
+		l.async.mu.Lock()
+		busy := l.async.busy
+		l.async.mu.Unlock()
+		if busy {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	l.log(ERROR, "a", nil)
+	l.log(ERROR, "b", nil)
+	l.log(WARN, "dropped", nil)
+	done := make(chan struct{})
+	go func() {
+		l.log(ERROR, "c", nil)
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("Expected the error to wait for room")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(unblock)
+	<-done
+	l.Close()
+	if msgs := inner.messages(); strings.Join(msgs[1:], ",") != "a,b,c" {
+		t.Errorf("Expected all errors to be written, got %v", msgs)
+	}
+	if l.Dropped() != 1 {
+		t.Errorf("Expected 1 dropped entry, got %d", l.Dropped())
+	}
+}
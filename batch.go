@@ -0,0 +1,346 @@
+package gologs
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// batcher collects entries and passes them to send in batches, from a
+// background goroutine, when a batch is full or the flush interval has
+// passed. Batches are sent one at a time and in order. At most ten batches
+// are held; entries written while the buffer is full are dropped with an
+// error.
+type batcher struct {
+	mu      sync.Mutex
+	pending []LogEntry
+	size    int
+	closed  bool
+	send    func([]LogEntry) error
+	failed  func([]LogEntry, error) error
+	sendMu  sync.Mutex
+	stats   sinkStats
+	full    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// newBatcher starts a batcher sending batches of up to size entries.
+func newBatcher(size int, interval time.Duration, send func([]LogEntry) error) *batcher {
+	b := &batcher{
+		size: size,
+		send: send,
+		full: make(chan struct{}, 1),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go b.loop(interval)
+	return b
+}
+
+// add queues an entry for the next batch.
+func (b *batcher) add(entry LogEntry) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return errors.New("batch: sink is closed")
+	}
+	if len(b.pending) >= 10*b.size {
+		return errors.New("batch: buffer full, entry dropped")
+	}
+	b.pending = append(b.pending, entry)
+	if len(b.pending) >= b.size {
+		select {
+		case b.full <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// batchingSink is implemented by the sinks sending entries in batches,
+// whose Write only queues the entry.
+type batchingSink interface {
+	batches() *batcher
+}
+
+// onFailure sets a function taking over the batches that failed to send.
+// A batch it returns nil for is no longer reported as an error by flush.
+func (b *batcher) onFailure(failed func([]LogEntry, error) error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failed = failed
+}
+
+// loop sends batches until the batcher is closed.
+func (b *batcher) loop(interval time.Duration) {
+	defer close(b.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+		case <-b.full:
+		}
+		if err := b.flush(); err != nil {
+			log.Printf("Failed to send log batch: %v", err)
+		}
+	}
+}
+
+// flush sends all queued entries.
+func (b *batcher) flush() error {
+	b.sendMu.Lock()
+	defer b.sendMu.Unlock()
+
+	var errs []error
+	for {
+		b.mu.Lock()
+		n := min(len(b.pending), b.size)
+		batch := b.pending[:n:n]
+		b.pending = b.pending[n:]
+		if len(b.pending) == 0 {
+			b.pending = nil
+		}
+		failed := b.failed
+		b.mu.Unlock()
+		if n == 0 {
+			return errors.Join(errs...)
+		}
+		err := b.send(batch)
+		b.stats.record(err, time.Now())
+		if err != nil && failed != nil {
+			if failedErr := failed(batch, err); failedErr != nil {
+				err = errors.Join(err, failedErr)
+			} else {
+				err = nil
+			}
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+}
+
+// health returns the results of the sent batches and the number of queued
+// entries.
+func (b *batcher) health() SinkHealth {
+	h := b.stats.health()
+	b.mu.Lock()
+	h.QueueDepth = len(b.pending)
+	b.mu.Unlock()
+	return h
+}
+
+// close stops the background goroutine and sends the remaining entries.
+func (b *batcher) close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.mu.Unlock()
+	close(b.stop)
+	<-b.done
+	return b.flush()
+}
+
+// HTTPOption configures a sink that sends batches of entries over HTTP.
+type HTTPOption func(*httpConfig)
+
+// httpConfig holds the settings shared by the HTTP sinks.
+type httpConfig struct {
+	client        *http.Client
+	header        http.Header
+	batchSize     int
+	flushInterval time.Duration
+	maxRetries    int
+	retryBackoff  time.Duration
+	stop          <-chan struct{}
+}
+
+// httpMaxRetryAfter caps the wait a Retry-After header asks for.
+const httpMaxRetryAfter = time.Minute
+
+// WithHTTPClient sets the client used to send requests. Defaults to a
+// client with a 10 second timeout.
+func WithHTTPClient(client *http.Client) HTTPOption {
+	return func(c *httpConfig) {
+		c.client = client
+	}
+}
+
+// WithHeader adds a header to every request, for example for
+// authentication.
+func WithHeader(key, value string) HTTPOption {
+	return func(c *httpConfig) {
+		c.header.Add(key, value)
+	}
+}
+
+// WithBasicAuth sets the Authorization header of every request to HTTP basic
+// authentication with the given credentials.
+func WithBasicAuth(username, password string) HTTPOption {
+	return func(c *httpConfig) {
+		auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+		c.header.Set("Authorization", "Basic "+auth)
+	}
+}
+
+// WithBatchSize sets the maximum number of entries sent in one request.
+// Defaults to 100.
+func WithBatchSize(size int) HTTPOption {
+	return func(c *httpConfig) {
+		c.batchSize = size
+	}
+}
+
+// WithFlushInterval sets how often queued entries are sent if the batch is
+// not full yet. Defaults to one second.
+func WithFlushInterval(interval time.Duration) HTTPOption {
+	return func(c *httpConfig) {
+		c.flushInterval = interval
+	}
+}
+
+// WithMaxRetries sets how often a request is retried after a network error,
+// 429 Too Many Requests or a 5xx status. Defaults to 3; 0 disables retries.
+func WithMaxRetries(retries int) HTTPOption {
+	return func(c *httpConfig) {
+		c.maxRetries = retries
+	}
+}
+
+// newHTTPConfig applies opts to the default HTTP sink settings.
+func newHTTPConfig(opts []HTTPOption) httpConfig {
+	c := httpConfig{
+		client:        &http.Client{Timeout: 10 * time.Second},
+		header:        make(http.Header),
+		batchSize:     100,
+		flushInterval: time.Second,
+		maxRetries:    3,
+		retryBackoff:  time.Second,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.batchSize < 1 {
+		c.batchSize = 1
+	}
+	return c
+}
+
+// startBatcher starts the batcher of a sink with the configured batch size
+// and flush interval. Retries stop waiting once the batcher is closed.
+func (c *httpConfig) startBatcher(send func([]LogEntry) error) *batcher {
+	b := newBatcher(c.batchSize, c.flushInterval, send)
+	c.stop = b.stop
+	return b
+}
+
+// wait waits for d, or until the batcher of the sink is closed.
+func (c *httpConfig) wait(d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-c.stop:
+	}
+}
+
+// httpStatusError is returned for responses with a status other than 2xx.
+type httpStatusError struct {
+	code       int
+	body       string
+	retryAfter time.Duration
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("http status %d: %s", e.code, e.body)
+}
+
+// post sends body to url with the configured headers. Network errors, 429
+// and 5xx responses are retried with an exponential backoff, waiting at
+// least as long as the Retry-After header asks for, up to a minute. Once the
+// sink is being closed, retries don't wait.
+func (c *httpConfig) post(url, contentType string, body []byte) error {
+	_, err := c.postResponse(url, contentType, body)
+	return err
+}
+
+// postResponse is like post, but also returns the body of the successful
+// response.
+func (c *httpConfig) postResponse(url, contentType string, body []byte) ([]byte, error) {
+	return c.request(http.MethodPost, url, http.Header{"Content-Type": {contentType}}, body, nil)
+}
+
+// request sends body to url with the configured headers plus header,
+// retrying like post, and returns the body of the successful response. If
+// sign is set, it is called on every attempt once all headers are set.
+func (c *httpConfig) request(method, url string, header http.Header, body []byte, sign func(*http.Request) error) ([]byte, error) {
+	backoff := c.retryBackoff
+	for attempt := 0; ; attempt++ {
+		resp, err := c.requestOnce(method, url, header, body, sign)
+		if err == nil {
+			return resp, nil
+		}
+		var statusErr *httpStatusError
+		if errors.As(err, &statusErr) && statusErr.code != http.StatusTooManyRequests && statusErr.code < 500 {
+			return nil, err
+		}
+		if attempt >= c.maxRetries {
+			return nil, err
+		}
+
+		wait := backoff
+		if statusErr != nil && statusErr.retryAfter > wait {
+			wait = statusErr.retryAfter
+		}
+		c.wait(wait)
+		backoff *= 2
+	}
+}
+
+// requestOnce sends a single request and returns the response body.
+func (c *httpConfig) requestOnce(method, url string, header http.Header, body []byte, sign func(*http.Request) error) ([]byte, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if sign != nil {
+		if err := sign(req); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return io.ReadAll(resp.Body)
+	}
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	statusErr := &httpStatusError{code: resp.StatusCode, body: string(bytes.TrimSpace(respBody))}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		statusErr.retryAfter = min(time.Duration(seconds)*time.Second, httpMaxRetryAfter)
+	}
+	return nil, statusErr
+}
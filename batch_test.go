@@ -0,0 +1,126 @@
+package gologs
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tests that entries are sent in batches of the configured size
+func TestBatcher(t *testing.T) {
+	var mu sync.Mutex
+	var sizes []int
+	b := newBatcher(3, time.Hour, func(entries []LogEntry) error {
+		mu.Lock()
+		defer mu.Unlock()
+		sizes = append(sizes, len(entries))
+		return nil
+	})
+	for i := 0; i < 7; i++ {
+		if err := b.add(LogEntry{Data: i}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if err := b.close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	total := 0
+	for _, n := range sizes {
+		if n > 3 {
+			t.Errorf("Expected batches of at most 3 entries, got %v", sizes)
+		}
+		total += n
+	}
+	if total != 7 {
+		t.Errorf("Expected 7 entries to be sent, got %v", sizes)
+	}
+	if err := b.add(LogEntry{}); err == nil {
+		t.Error("Expected error adding to closed batcher")
+	}
+}
+
+// tests that the batcher drops entries once ten batches are queued
+func TestBatcherFull(t *testing.T) {
+	block := make(chan struct{})
+	b := newBatcher(1, time.Hour, func([]LogEntry) error {
+		<-block
+		return nil
+	})
+	var err error
+	for i := 0; i < 20 && err == nil; i++ {
+		err = b.add(LogEntry{Data: i})
+	}
+	if err == nil {
+		t.Error("Expected error once the buffer is full")
+	}
+	close(block)
+	b.close()
+}
+
+// tests retrying requests on 429 and 5xx responses
+func TestHTTPRetry(t *testing.T) {
+	var mu sync.Mutex
+	var statuses = []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Header.Get("X-Api-Key") != "secret" {
+			t.Errorf("Expected API key header, got %v", r.Header)
+		}
+		w.WriteHeader(statuses[requests])
+		requests++
+	}))
+	defer server.Close()
+
+	c := newHTTPConfig([]HTTPOption{WithHeader("X-Api-Key", "secret")})
+	c.retryBackoff = time.Millisecond
+	if err := c.post(server.URL, "application/json", []byte("{}")); err != nil {
+		t.Errorf("Expected request to succeed after retries, got %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("Expected 3 requests, got %v", requests)
+	}
+
+	requests = 0
+	statuses = []int{http.StatusBadRequest, http.StatusOK}
+	err := c.post(server.URL, "application/json", []byte("{}"))
+	if fmt.Sprint(err) != "http status 400: " || requests != 1 {
+		t.Errorf("Expected 400 without retry, got %v after %v requests", err, requests)
+	}
+}
+
+// tests that Retry-After is capped and that closing the batcher cuts the
+// wait short
+func TestHTTPRetryAfter(t *testing.T) {
+	requested := make(chan struct{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "86400")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		requested <- struct{}{}
+	}))
+	defer server.Close()
+
+	c := newHTTPConfig(nil)
+	_, err := c.requestOnce(http.MethodPost, server.URL, nil, nil, nil)
+	if statusErr, ok := err.(*httpStatusError); !ok || statusErr.retryAfter != httpMaxRetryAfter {
+		t.Errorf("Expected Retry-After to be capped at %v, got %v", httpMaxRetryAfter, err)
+	}
+	<-requested
+
+	b := c.startBatcher(func([]LogEntry) error {
+		return c.post(server.URL, "application/json", []byte("{}"))
+	})
+	b.add(LogEntry{})
+	go b.flush()
+	<-requested
+	start := time.Now()
+	b.close()
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected close to cut the retry wait short, took %v", elapsed)
+	}
+}
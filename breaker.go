@@ -0,0 +1,157 @@
+package gologs
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a BreakerSink.
+type BreakerState int
+
+const (
+	// BreakerClosed passes entries to the wrapped sink.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen passes entries to the fallback sink.
+	BreakerOpen
+	// BreakerHalfOpen passes one entry to the wrapped sink to probe it.
+	BreakerHalfOpen
+)
+
+// String returns the name of the state.
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// BreakerSink is a Sink that stops writing to a failing sink, so that a
+// dead collector can't stall or spam the application with errors. After
+// a number of consecutive failed writes, the breaker opens and passes
+// entries to a fallback sink instead, or drops them if there is none. Once
+// the probe interval has passed, the next entry is written to the wrapped
+// sink again: if that succeeds, the breaker closes, otherwise it stays open
+// for another interval.
+type BreakerSink struct {
+	sink      Sink
+	fallback  Sink
+	threshold int
+	interval  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+}
+
+// NewBreakerSink returns a sink that opens after threshold consecutive
+// failures of sink and probes it every interval while open. fallback may be
+// nil.
+func NewBreakerSink(sink, fallback Sink, threshold int, interval time.Duration) *BreakerSink {
+	return &BreakerSink{
+		sink:      sink,
+		fallback:  fallback,
+		threshold: max(threshold, 1),
+		interval:  interval,
+		now:       time.Now,
+	}
+}
+
+// State returns the current state of the breaker.
+func (s *BreakerSink) State() BreakerState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// Write writes the entry to the wrapped sink, or to the fallback sink while
+// the breaker is open.
+func (s *BreakerSink) Write(entry LogEntry) error {
+	if !s.allow() {
+		if s.fallback == nil {
+			return nil
+		}
+		return s.fallback.Write(entry)
+	}
+	err := s.sink.Write(entry)
+	s.record(err)
+	if err != nil && s.fallback != nil {
+		return errors.Join(err, s.fallback.Write(entry))
+	}
+	return err
+}
+
+func (s *BreakerSink) stackLevel() LogLevel {
+	return min(sinkStackLevel(s.sink), sinkStackLevel(s.fallback))
+}
+
+// Ping pings the wrapped sink, or returns ErrPingUnsupported if it doesn't
+// implement Pinger. The fallback is not pinged: entries reaching it mean
+// the destination is down.
+func (s *BreakerSink) Ping() error {
+	return pingSink(s.sink)
+}
+
+// allow reports whether the wrapped sink may be used, moving an open
+// breaker to half-open once the probe interval has passed. Only one probe
+// is let through at a time.
+func (s *BreakerSink) allow() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch s.state {
+	case BreakerOpen:
+		if s.now().Sub(s.openedAt) < s.interval {
+			return false
+		}
+		s.state = BreakerHalfOpen
+		return true
+	case BreakerHalfOpen:
+		return false
+	}
+	return true
+}
+
+// record updates the breaker with the result of using the wrapped sink.
+func (s *BreakerSink) record(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		s.state = BreakerClosed
+		s.failures = 0
+		return
+	}
+	s.failures++
+	if s.state == BreakerHalfOpen || s.failures >= s.threshold {
+		s.state = BreakerOpen
+		s.openedAt = s.now()
+	}
+}
+
+// Flush flushes the fallback sink and, unless the breaker is open, the
+// wrapped sink. A failed flush of the wrapped sink counts as a failure.
+func (s *BreakerSink) Flush() error {
+	var errs []error
+	if s.State() == BreakerClosed {
+		err := s.sink.Flush()
+		s.record(err)
+		errs = append(errs, err)
+	}
+	if s.fallback != nil {
+		errs = append(errs, s.fallback.Flush())
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes the wrapped and the fallback sink.
+func (s *BreakerSink) Close() error {
+	err := s.sink.Close()
+	if s.fallback != nil {
+		err = errors.Join(err, s.fallback.Close())
+	}
+	return err
+}
@@ -0,0 +1,102 @@
+package gologs
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// failingSink is a memorySink whose writes fail while fail is set.
+type failingSink struct {
+	memorySink
+	fail   bool
+	writes int
+}
+
+func (s *failingSink) Write(entry LogEntry) error {
+	s.writes++
+	if s.fail {
+		return errors.New("collector down")
+	}
+	return s.memorySink.Write(entry)
+}
+
+// tests that the breaker opens after consecutive failures and closes after a
+// successful probe
+func TestBreakerSink(t *testing.T) {
+	primary := &failingSink{fail: true}
+	fallback := &memorySink{}
+	breaker := NewBreakerSink(primary, fallback, 3, time.Minute)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	breaker.now = func() time.Time { return now }
+
+	for i := 1; i <= 5; i++ {
+		err := breaker.Write(LogEntry{Data: "entry"})
+		if i <= 3 && err == nil {
+			t.Errorf("Expected error for entry %d, got %v", i, err)
+		}
+		if i > 3 && err != nil {
+			t.Errorf("Expected no error while open, got %v", err)
+		}
+	}
+	if breaker.State() != BreakerOpen {
+		t.Errorf("Expected open breaker, got %v", breaker.State())
+	}
+	if primary.writes != 3 {
+		t.Errorf("Expected 3 writes to the failing sink, got %d", primary.writes)
+	}
+	if msgs := fallback.messages(); len(msgs) != 5 {
+		t.Errorf("Expected all 5 entries in fallback, got %v", msgs)
+	}
+
+	// a failed probe keeps the breaker open for another interval
+	now = now.Add(time.Minute)
+	breaker.Write(LogEntry{Data: "probe 1"})
+	if breaker.State() != BreakerOpen || primary.writes != 4 {
+		t.Errorf("Expected open breaker after failed probe, got %v with %d writes", breaker.State(), primary.writes)
+	}
+	breaker.Write(LogEntry{Data: "skipped"})
+	if primary.writes != 4 {
+		t.Errorf("Expected no write before the next probe, got %d writes", primary.writes)
+	}
+
+	// a successful probe closes it
+	primary.fail = false
+	now = now.Add(time.Minute)
+	breaker.Write(LogEntry{Data: "probe 2"})
+	breaker.Write(LogEntry{Data: "after"})
+	if breaker.State() != BreakerClosed {
+		t.Errorf("Expected closed breaker, got %v", breaker.State())
+	}
+	if msgs := primary.messages(); strings.Join(msgs, ",") != "probe 2,after" {
+		t.Errorf("Expected entries after recovery in primary, got %v", msgs)
+	}
+}
+
+// tests that entries are dropped without error while open without fallback
+func TestBreakerSinkWithoutFallback(t *testing.T) {
+	primary := &failingSink{fail: true}
+	breaker := NewBreakerSink(primary, nil, 1, time.Hour)
+	if err := breaker.Write(LogEntry{}); err == nil {
+		t.Errorf("Expected error, got %v", err)
+	}
+	if err := breaker.Write(LogEntry{}); err != nil {
+		t.Errorf("Expected no error while open, got %v", err)
+	}
+	if err := breaker.Flush(); err != nil || primary.flushed != 0 {
+		t.Errorf("Expected no flush of the open sink, got %v and %d flushes", err, primary.flushed)
+	}
+	if err := breaker.Close(); err != nil || !primary.closed {
+		t.Errorf("Expected closed sink, got %v", err)
+	}
+}
+
+// tests the names of the breaker states
+func TestBreakerStateString(t *testing.T) {
+	for state, want := range map[BreakerState]string{BreakerClosed: "closed", BreakerOpen: "open", BreakerHalfOpen: "half-open"} {
+		if got := state.String(); got != want {
+			t.Errorf("Expected %s, got %s", want, got)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package gologs
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// WithBufferedOutput buffers up to size bytes for each of the logger's
+// outputs and writes them once the buffer is full, so that a busy service
+// doesn't make a system call for every entry. Buffered entries are written
+// at the latest after interval, which defaults to a second, and by Flush and
+// Close. PANIC and FATAL entries flush the logger right away, so they aren't
+// lost when the program stops.
+func WithBufferedOutput(size int, interval time.Duration) Option {
+	return func(o *options) {
+		o.bufferSize = size
+		o.flushInterval = interval
+	}
+}
+
+// flushOutputs writes the entries buffered or batched by the outputs of the
+// logger.
+func (l *Logger) flushOutputs() {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, w := range l.out.Load().writers {
+		if err := w.Flush(); err != nil {
+			log.Printf("Failed to write log entry: %v", err)
+		}
+	}
+}
+
+// flushLoop calls a function periodically from a background goroutine.
+type flushLoop struct {
+	once sync.Once
+	stop chan struct{}
+	done chan struct{}
+}
+
+// startFlushLoop calls fn every interval until the loop is closed.
+func startFlushLoop(interval time.Duration, fn func()) *flushLoop {
+	f := &flushLoop{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go func() {
+		defer close(f.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-f.stop:
+				return
+			case <-ticker.C:
+				fn()
+			}
+		}
+	}()
+	return f
+}
+
+// close stops the loop. It may be called more than once.
+func (f *flushLoop) close() {
+	f.once.Do(func() { close(f.stop) })
+	<-f.done
+}
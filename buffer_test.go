@@ -0,0 +1,110 @@
+package gologs
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tests that buffered entries are written together on Flush
+func TestBufferedOutput(t *testing.T) {
+	w := &countingWriter{}
+	l := New(WithOutput(w), WithBufferedOutput(1<<20, time.Hour))
+	defer l.Close()
+	for i := 0; i < 5; i++ {
+		l.Info("entry %d", i)
+	}
+	if writes, _ := w.stats(); writes != 0 {
+		t.Errorf("Expected no writes before Flush, got %d", writes)
+	}
+	if err := l.Flush(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if writes, lines := w.stats(); writes != 1 || lines != 5 {
+		t.Errorf("Expected 5 entries in 1 write, got %d entries in %d writes", lines, writes)
+	}
+}
+
+// tests that buffered entries are written after the interval
+func TestBufferedOutputInterval(t *testing.T) {
+	w := &countingWriter{}
+	l := New(WithOutput(w), WithBufferedOutput(1<<20, 10*time.Millisecond))
+	defer l.Close()
+	l.Info("entry")
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, lines := w.stats(); lines == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the buffer to be written after the interval")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// tests that FATAL entries flush the buffers right away
+func TestBufferedOutputFatal(t *testing.T) {
+	w := &countingWriter{}
+	inner := &memorySink{}
+	l := New(WithOutput(w), WithSinks(inner), WithBufferedOutput(1<<20, time.Hour))
+	defer l.Close()
+	l.Info("before")
+	l.log(FATAL, "exiting", nil)
+	if _, lines := w.stats(); lines != 2 {
+		t.Errorf("Expected both entries to be written, got %d", lines)
+	}
+	if inner.flushed != 1 {
+		t.Errorf("Expected the sinks to be flushed, got %d", inner.flushed)
+	}
+}
+
+// tests that Fatal flushes the buffers before exiting, also when the FATAL
+// entry is dropped
+func TestBufferedOutputFatalDropped(t *testing.T) {
+	code := 0
+	osExit = func(c int) { code = c }
+	defer func() { osExit = os.Exit }()
+	w := &countingWriter{}
+	l := New(WithOutput(w), WithBufferedOutput(1<<20, time.Hour))
+	defer l.Close()
+	l.Info("before")
+	l.SetLogLevel(OFF)
+	l.Fatal("exiting")
+	if _, lines := w.stats(); lines != 1 || code != 1 {
+		t.Errorf("Expected the buffered entry to be written and exit code 1, got %d entries and code %d", lines, code)
+	}
+}
+
+// tests that buffered entries go to the old file when it is reopened
+func TestBufferedOutputReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := New(withOutputs([]io.Writer{f}, []io.WriteCloser{f}), WithBufferedOutput(1<<20, time.Hour))
+	l.Info("before rotation")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Reopen(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	l.Info("after rotation")
+	if err := l.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	rotated, _ := os.ReadFile(path + ".1")
+	current, _ := os.ReadFile(path)
+	if !strings.Contains(string(rotated), "before rotation") || strings.Contains(string(rotated), "after rotation") {
+		t.Errorf("Expected only the old entry in the rotated file, got %q", rotated)
+	}
+	if !strings.Contains(string(current), "after rotation") || strings.Contains(string(current), "before rotation") {
+		t.Errorf("Expected only the new entry in the file, got %q", current)
+	}
+}
@@ -0,0 +1,204 @@
+package gologs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// CBOREncoder encodes entries as CBOR (RFC 8949) maps with the keys of the
+// JSON encoder, which are smaller and faster to encode than JSON for
+// high-volume shipping over the network. Entries follow each other without
+// framing, as CBOR data items are self-delimiting. Timestamps default to
+// nanoseconds since the Unix epoch.
+type CBOREncoder struct {
+	EncoderConfig
+}
+
+// cborFormat writes the values of CBOR entries.
+var cborFormat = binaryFormat{
+	mapHeader: appendCBORMapHeader,
+	str:       appendCBORString,
+	integer:   appendCBORInt,
+	value:     appendCBOR,
+	field:     appendCBORField,
+}
+
+// Encode appends the entry as a CBOR map.
+func (e CBOREncoder) Encode(entry LogEntry, buf *bytes.Buffer) error {
+	data, err := appendBinaryEntry(buf.AvailableBuffer(), entry, e.EncoderConfig, cborFormat)
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
+
+func (e CBOREncoder) withConfig(fn func(*EncoderConfig)) Encoder {
+	fn(&e.EncoderConfig)
+	return e
+}
+
+// CBOR major types.
+const (
+	cborUint   = 0 << 5
+	cborNegInt = 1 << 5
+	cborBytes  = 2 << 5
+	cborString = 3 << 5
+	cborArray  = 4 << 5
+	cborMap    = 5 << 5
+)
+
+// appendCBOR appends the CBOR encoding of v to buf. Strings, numbers,
+// booleans, byte slices, slices and maps with string keys are encoded
+// directly; other values are encoded through their JSON encoding.
+func appendCBOR(buf []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, 0xf6), nil
+	case bool:
+		if v {
+			return append(buf, 0xf5), nil
+		}
+		return append(buf, 0xf4), nil
+	case string:
+		return appendCBORString(buf, v), nil
+	case []byte:
+		return append(appendCBORHead(buf, cborBytes, uint64(len(v))), v...), nil
+	case int:
+		return appendCBORInt(buf, int64(v)), nil
+	case int8:
+		return appendCBORInt(buf, int64(v)), nil
+	case int16:
+		return appendCBORInt(buf, int64(v)), nil
+	case int32:
+		return appendCBORInt(buf, int64(v)), nil
+	case int64:
+		return appendCBORInt(buf, v), nil
+	case uint8:
+		return appendCBORHead(buf, cborUint, uint64(v)), nil
+	case uint16:
+		return appendCBORHead(buf, cborUint, uint64(v)), nil
+	case uint32:
+		return appendCBORHead(buf, cborUint, uint64(v)), nil
+	case uint:
+		return appendCBORHead(buf, cborUint, uint64(v)), nil
+	case uint64:
+		return appendCBORHead(buf, cborUint, v), nil
+	case float32:
+		return binary.BigEndian.AppendUint32(append(buf, 0xfa), math.Float32bits(v)), nil
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return appendCBORInt(buf, int64(v)), nil
+		}
+		return binary.BigEndian.AppendUint64(append(buf, 0xfb), math.Float64bits(v)), nil
+	case time.Duration:
+		return appendCBORInt(buf, int64(v)), nil
+	case time.Time:
+		return appendCBORString(buf, v.Format(time.RFC3339Nano)), nil
+	case error:
+		return appendCBORString(buf, v.Error()), nil
+	case []interface{}:
+		buf = appendCBORHead(buf, cborArray, uint64(len(v)))
+		for _, item := range v {
+			var err error
+			if buf, err = appendCBOR(buf, item); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case group:
+		buf = appendCBORMapHeader(buf, v.len())
+		for _, f := range v {
+			if f.Type == skipType {
+				continue
+			}
+			buf = appendCBORString(buf, f.Key)
+			var err error
+			if buf, err = appendCBORField(buf, f); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf = appendCBORMapHeader(buf, len(v))
+		for _, k := range keys {
+			buf = appendCBORString(buf, k)
+			var err error
+			if buf, err = appendCBOR(buf, v[k]); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	}
+
+	if _, ok := v.(json.Marshaler); !ok {
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.String {
+			return appendCBORString(buf, rv.String()), nil
+		}
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	return appendCBOR(buf, decoded)
+}
+
+// appendCBORField appends the value of a field.
+func appendCBORField(buf []byte, f Field) ([]byte, error) {
+	switch f.Type {
+	case StringType, ErrorType:
+		return appendCBORString(buf, f.str), nil
+	case IntType, DurationType:
+		return appendCBORInt(buf, f.integer), nil
+	case BoolType:
+		return appendCBOR(buf, f.integer == 1)
+	default:
+		return appendCBOR(buf, f.Value)
+	}
+}
+
+// appendCBORHead appends the initial byte of a data item of the major type,
+// followed by its argument n in the shortest form.
+func appendCBORHead(buf []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(buf, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(buf, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, major|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(buf, major|26), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, major|27), n)
+	}
+}
+
+func appendCBORInt(buf []byte, v int64) []byte {
+	if v < 0 {
+		return appendCBORHead(buf, cborNegInt, uint64(-1-v))
+	}
+	return appendCBORHead(buf, cborUint, uint64(v))
+}
+
+func appendCBORString(buf []byte, s string) []byte {
+	return append(appendCBORHead(buf, cborString, uint64(len(s))), s...)
+}
+
+func appendCBORMapHeader(buf []byte, n int) []byte {
+	return appendCBORHead(buf, cborMap, uint64(n))
+}
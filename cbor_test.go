@@ -0,0 +1,58 @@
+package gologs
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+	"time"
+)
+
+// tests encoding CBOR values against the examples of RFC 8949
+func TestCBORValues(t *testing.T) {
+	for _, tt := range []struct {
+		v    interface{}
+		want string
+	}{
+		{0, "00"},
+		{23, "17"},
+		{24, "1818"},
+		{1000, "1903e8"},
+		{1000000, "1a000f4240"},
+		{uint64(18446744073709551615), "1bffffffffffffffff"},
+		{-1, "20"},
+		{-1000, "3903e7"},
+		{1.1, "fb3ff199999999999a"},
+		{float32(100000), "fa47c35000"},
+		{false, "f4"},
+		{true, "f5"},
+		{nil, "f6"},
+		{[]byte{1, 2, 3, 4}, "4401020304"},
+		{"IETF", "6449455446"},
+		{"ü", "62c3bc"},
+		{[]interface{}{1, []interface{}{2, 3}}, "8201820203"},
+		{map[string]interface{}{"a": 1, "b": []interface{}{2, 3}}, "a26161016162820203"},
+	} {
+		data, err := appendCBOR(nil, tt.v)
+		if err != nil {
+			t.Fatalf("Expected no error encoding %v, got %v", tt.v, err)
+		}
+		if got := hex.EncodeToString(data); got != tt.want {
+			t.Errorf("Expected %s for %v, got %s", tt.want, tt.v, got)
+		}
+	}
+}
+
+// tests encoding entries as CBOR maps
+func TestCBOREncoder(t *testing.T) {
+	entry := LogEntry{Level: "INFO", Timestamp: time.Unix(1, 0), Data: "hi", Fields: []Field{Int("n", -2)}}
+	var buf bytes.Buffer
+	if err := (CBOREncoder{}).Encode(entry, &buf); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// {"level": "INFO", "timestamp": 1000000000, "data": "hi", "n": -2}
+	want := "a4" + "656c6576656c" + "64494e464f" + "6974696d657374616d70" + "1a3b9aca00" +
+		"6464617461" + "626869" + "616e" + "21"
+	if got := hex.EncodeToString(buf.Bytes()); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
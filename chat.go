@@ -0,0 +1,423 @@
+package gologs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// chatMaxEntries is the number of entries shown in one chat message; the
+// others of the batch are only counted.
+const chatMaxEntries = 10
+
+// ChatConfig configures which entries a ChatSink posts.
+type ChatConfig struct {
+	// MinLevel is the lowest level posted. The zero value, DEBUG, means
+	// ERROR; use TRACE to post all entries.
+	MinLevel LogLevel
+	// DedupWindow is how long an entry with the same level and message as
+	// a posted one is suppressed. The next posted copy has a "repeated"
+	// field with the number of suppressed copies. Defaults to 5 minutes; a
+	// negative window disables deduplication.
+	DedupWindow time.Duration
+}
+
+// ChatSink is a Sink that posts entries to a chat channel through an
+// incoming webhook of Slack, Discord or Microsoft Teams, formatted as rich
+// messages with a level emoji and color and the fields of each entry.
+//
+// To keep channels readable, entries are collected and posted as a single
+// message every 10 seconds by default (see WithFlushInterval), showing at
+// most 10 entries and counting the rest, and repeated entries are
+// suppressed; see ChatConfig.
+type ChatSink struct {
+	url      string
+	minLevel LogLevel
+	window   time.Duration
+	format   func(entries []LogEntry, more int) (interface{}, error)
+	config   httpConfig
+	batch    *batcher
+
+	mu   sync.Mutex
+	seen map[string]*chatSeen
+}
+
+// chatSeen records the last posted copy of an entry.
+type chatSeen struct {
+	last       time.Time
+	suppressed int
+}
+
+// NewSlackSink returns a sink posting to a Slack incoming webhook URL.
+func NewSlackSink(webhookURL string, cfg ChatConfig, opts ...HTTPOption) *ChatSink {
+	return newChatSink(webhookURL, cfg, opts, slackMessage)
+}
+
+// NewDiscordSink returns a sink posting to a Discord webhook URL.
+func NewDiscordSink(webhookURL string, cfg ChatConfig, opts ...HTTPOption) *ChatSink {
+	return newChatSink(webhookURL, cfg, opts, discordMessage)
+}
+
+// NewTeamsSink returns a sink posting to a Microsoft Teams incoming webhook
+// URL.
+func NewTeamsSink(webhookURL string, cfg ChatConfig, opts ...HTTPOption) *ChatSink {
+	return newChatSink(webhookURL, cfg, opts, teamsMessage)
+}
+
+func newChatSink(webhookURL string, cfg ChatConfig, opts []HTTPOption, format func([]LogEntry, int) (interface{}, error)) *ChatSink {
+	s := &ChatSink{
+		url:      webhookURL,
+		minLevel: cfg.MinLevel,
+		window:   cfg.DedupWindow,
+		format:   format,
+		config:   newHTTPConfig(append([]HTTPOption{WithFlushInterval(10 * time.Second)}, opts...)),
+		seen:     make(map[string]*chatSeen),
+	}
+	if s.minLevel == DEBUG {
+		s.minLevel = ERROR
+	}
+	if s.window == 0 {
+		s.window = 5 * time.Minute
+	}
+	s.batch = s.config.startBatcher(s.send)
+	return s
+}
+
+// Write queues the entry to be posted, unless its level is below the
+// minimum level or it repeats a recently posted entry.
+func (s *ChatSink) Write(entry LogEntry) error {
+	if entry.Severity < s.minLevel {
+		return nil
+	}
+	if s.window > 0 {
+		var msg bytes.Buffer
+		if err := appendMessageText(&msg, entry.Data); err != nil {
+			return err
+		}
+		suppressed, ok := s.dedup(entry.Level+"\x00"+msg.String(), entry.Timestamp)
+		if !ok {
+			return nil
+		}
+		if suppressed > 0 {
+			entry.Fields = append(entry.Fields[:len(entry.Fields):len(entry.Fields)], Int("repeated", suppressed))
+		}
+	}
+	return s.batch.add(entry)
+}
+
+// dedup reports whether an entry with the given key is posted, and how many
+// copies were suppressed since the last posted one.
+func (s *ChatSink) dedup(key string, now time.Time) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if seen, ok := s.seen[key]; ok {
+		if now.Sub(seen.last) < s.window {
+			seen.suppressed++
+			return 0, false
+		}
+		suppressed := seen.suppressed
+		*seen = chatSeen{last: now}
+		return suppressed, true
+	}
+	if len(s.seen) >= 1000 {
+		for k, seen := range s.seen {
+			if now.Sub(seen.last) >= s.window {
+				delete(s.seen, k)
+			}
+		}
+	}
+	s.seen[key] = &chatSeen{last: now}
+	return 0, true
+}
+
+// Flush posts all queued entries.
+func (s *ChatSink) Flush() error {
+	return s.batch.flush()
+}
+
+// Close posts all queued entries and stops the background goroutine.
+func (s *ChatSink) Close() error {
+	return s.batch.close()
+}
+
+// Health returns the results of the sent batches and the number of entries
+// waiting to be sent.
+func (s *ChatSink) Health() SinkHealth {
+	return s.batch.health()
+}
+
+func (s *ChatSink) batches() *batcher {
+	return s.batch
+}
+
+// send posts a batch of entries as one message.
+func (s *ChatSink) send(entries []LogEntry) error {
+	more := 0
+	if len(entries) > chatMaxEntries {
+		more = len(entries) - chatMaxEntries
+		entries = entries[:chatMaxEntries]
+	}
+	msg, err := s.format(entries, more)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if err := s.config.post(s.url, "application/json", body); err != nil {
+		return fmt.Errorf("chat: %w", err)
+	}
+	return nil
+}
+
+// chatEmoji returns the emoji shown for a level.
+func chatEmoji(level LogLevel) string {
+	switch {
+	case level >= PANIC:
+		return "🔥"
+	case level >= ERROR:
+		return "❌"
+	case level >= WARN:
+		return "⚠️"
+	case level >= INFO:
+		return "ℹ️"
+	default:
+		return "🐛"
+	}
+}
+
+// chatColor returns the RGB color of a level.
+func chatColor(level LogLevel) int {
+	switch {
+	case level >= PANIC:
+		return 0xb71c1c
+	case level >= ERROR:
+		return 0xe53935
+	case level >= WARN:
+		return 0xfb8c00
+	case level >= INFO:
+		return 0x1e88e5
+	default:
+		return 0x9e9e9e
+	}
+}
+
+// chatField is a field of an entry as text.
+type chatField struct {
+	key, value string
+}
+
+// chatEntry returns the title, message and fields of an entry.
+func chatEntry(e LogEntry) (string, string, []chatField, error) {
+	var msg bytes.Buffer
+	if err := appendMessageText(&msg, e.Data); err != nil {
+		return "", "", nil, err
+	}
+	var fields []chatField
+	for _, f := range e.Fields {
+		if f.Type == skipType {
+			continue
+		}
+		value, err := fieldText(f)
+		if err != nil {
+			return "", "", nil, err
+		}
+		fields = append(fields, chatField{f.Key, value})
+	}
+	return chatEmoji(e.Severity) + " " + e.Level, msg.String(), fields, nil
+}
+
+// chatSummary returns the plain text summary of a message.
+func chatSummary(entries []LogEntry, more int) string {
+	if len(entries) == 0 {
+		return ""
+	}
+	summary := entries[0].Level + ": "
+	var msg bytes.Buffer
+	if appendMessageText(&msg, entries[0].Data) == nil {
+		summary += msg.String()
+	}
+	if n := len(entries) - 1 + more; n > 0 {
+		summary += " (+" + strconv.Itoa(n) + " more)"
+	}
+	return summary
+}
+
+// chatFooter returns the source and caller of an entry.
+func chatFooter(e LogEntry) string {
+	switch {
+	case e.Source != "" && e.Caller != "":
+		return e.Source + " · " + e.Caller
+	case e.Source != "":
+		return e.Source
+	}
+	return e.Caller
+}
+
+// chatMore returns the note for entries left out of a message.
+func chatMore(more int) string {
+	if more == 1 {
+		return "…and 1 more entry"
+	}
+	return "…and " + strconv.Itoa(more) + " more entries"
+}
+
+type slackPayload struct {
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+type slackAttachment struct {
+	Color  string       `json:"color"`
+	Title  string       `json:"title,omitempty"`
+	Text   string       `json:"text"`
+	Fields []slackField `json:"fields,omitempty"`
+	Footer string       `json:"footer,omitempty"`
+	TS     int64        `json:"ts,omitempty"`
+}
+
+type slackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// slackMessage formats entries as a Slack message with one attachment per
+// entry.
+func slackMessage(entries []LogEntry, more int) (interface{}, error) {
+	msg := slackPayload{Text: chatSummary(entries, more)}
+	for _, e := range entries {
+		title, text, fields, err := chatEntry(e)
+		if err != nil {
+			return nil, err
+		}
+		a := slackAttachment{
+			Color:  fmt.Sprintf("#%06x", chatColor(e.Severity)),
+			Title:  title,
+			Text:   text,
+			Footer: chatFooter(e),
+			TS:     e.Timestamp.Unix(),
+		}
+		for _, f := range fields {
+			a.Fields = append(a.Fields, slackField{f.key, f.value, len(f.value) <= 40})
+		}
+		msg.Attachments = append(msg.Attachments, a)
+	}
+	if more > 0 {
+		msg.Attachments = append(msg.Attachments, slackAttachment{Color: "#9e9e9e", Text: chatMore(more)})
+	}
+	return msg, nil
+}
+
+type discordPayload struct {
+	Content string         `json:"content,omitempty"`
+	Embeds  []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Color       int            `json:"color"`
+	Fields      []discordField `json:"fields,omitempty"`
+	Footer      *discordFooter `json:"footer,omitempty"`
+	Timestamp   string         `json:"timestamp,omitempty"`
+}
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type discordFooter struct {
+	Text string `json:"text"`
+}
+
+// discordMessage formats entries as a Discord message with one embed per
+// entry.
+func discordMessage(entries []LogEntry, more int) (interface{}, error) {
+	var msg discordPayload
+	if more > 0 {
+		msg.Content = chatMore(more)
+	}
+	for _, e := range entries {
+		title, text, fields, err := chatEntry(e)
+		if err != nil {
+			return nil, err
+		}
+		embed := discordEmbed{
+			Title:       title,
+			Description: text,
+			Color:       chatColor(e.Severity),
+			Timestamp:   e.Timestamp.UTC().Format(time.RFC3339Nano),
+		}
+		for _, f := range fields {
+			embed.Fields = append(embed.Fields, discordField{f.key, f.value, len(f.value) <= 40})
+		}
+		if footer := chatFooter(e); footer != "" {
+			embed.Footer = &discordFooter{footer}
+		}
+		msg.Embeds = append(msg.Embeds, embed)
+	}
+	return msg, nil
+}
+
+type teamsPayload struct {
+	Type       string         `json:"@type"`
+	Context    string         `json:"@context"`
+	ThemeColor string         `json:"themeColor"`
+	Summary    string         `json:"summary"`
+	Sections   []teamsSection `json:"sections"`
+}
+
+type teamsSection struct {
+	ActivityTitle    string      `json:"activityTitle"`
+	ActivitySubtitle string      `json:"activitySubtitle,omitempty"`
+	Text             string      `json:"text,omitempty"`
+	Facts            []teamsFact `json:"facts,omitempty"`
+}
+
+type teamsFact struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// teamsMessage formats entries as a Teams message card with one section per
+// entry.
+func teamsMessage(entries []LogEntry, more int) (interface{}, error) {
+	if len(entries) == 0 {
+		return nil, errors.New("chat: no entries")
+	}
+	msg := teamsPayload{
+		Type:       "MessageCard",
+		Context:    "https://schema.org/extensions",
+		ThemeColor: fmt.Sprintf("%06X", chatColor(entries[0].Severity)),
+		Summary:    chatSummary(entries, more),
+	}
+	for _, e := range entries {
+		title, text, fields, err := chatEntry(e)
+		if err != nil {
+			return nil, err
+		}
+		section := teamsSection{
+			ActivityTitle:    title + ": " + text,
+			ActivitySubtitle: e.Timestamp.UTC().Format(time.RFC3339),
+		}
+		if footer := chatFooter(e); footer != "" {
+			section.Text = footer
+		}
+		for _, f := range fields {
+			section.Facts = append(section.Facts, teamsFact{f.key, f.value})
+		}
+		msg.Sections = append(msg.Sections, section)
+	}
+	if more > 0 {
+		msg.Sections = append(msg.Sections, teamsSection{ActivityTitle: chatMore(more)})
+	}
+	return msg, nil
+}
@@ -0,0 +1,142 @@
+package gologs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// chatServer returns a server collecting the JSON bodies posted to it.
+func chatServer(t *testing.T) (*httptest.Server, chan map[string]interface{}) {
+	bodies := make(chan map[string]interface{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var msg map[string]interface{}
+		if err := json.Unmarshal(body, &msg); err != nil {
+			t.Errorf("Expected JSON, got %s", body)
+		}
+		bodies <- msg
+	}))
+	return server, bodies
+}
+
+// tests that ERROR entries are posted as a Slack message with attachments
+func TestSlackSink(t *testing.T) {
+	server, bodies := chatServer(t)
+	defer server.Close()
+
+	sink := NewSlackSink(server.URL, ChatConfig{})
+	l := New(WithSinks(sink), WithCallerInfo(false))
+	l.Warn("Disk almost full")
+	l.Error("Payment failed", String("order", "A1"))
+	if err := l.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(bodies) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(bodies))
+	}
+	msg := <-bodies
+	if msg["text"] != "ERROR: Payment failed" {
+		t.Errorf("Expected summary text, got %v", msg["text"])
+	}
+	attachments := msg["attachments"].([]interface{})
+	if len(attachments) != 1 {
+		t.Fatalf("Expected 1 attachment, got %d", len(attachments))
+	}
+	a := attachments[0].(map[string]interface{})
+	if a["title"] != "❌ ERROR" || a["text"] != "Payment failed" || a["color"] != "#e53935" {
+		t.Errorf("Expected formatted attachment, got %v", a)
+	}
+	field := a["fields"].([]interface{})[0].(map[string]interface{})
+	if field["title"] != "order" || field["value"] != "A1" {
+		t.Errorf("Expected order field, got %v", field)
+	}
+}
+
+// tests that repeated entries are suppressed and counted
+func TestChatSinkDedup(t *testing.T) {
+	sink := NewDiscordSink("http://localhost", ChatConfig{DedupWindow: time.Minute})
+	defer sink.batch.close()
+	var queued []LogEntry
+	sink.batch.send = func(entries []LogEntry) error {
+		queued = append(queued, entries...)
+		return nil
+	}
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, offset := range []time.Duration{0, time.Second, 2 * time.Second, 2 * time.Minute} {
+		entry := LogEntry{Level: "ERROR", Severity: ERROR, Timestamp: start.Add(offset), Data: "Timeout"}
+		if err := sink.Write(entry); err != nil {
+			t.Fatalf("Expected no error for entry %d, got %v", i, err)
+		}
+	}
+	if err := sink.Flush(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(queued) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(queued))
+	}
+	if len(queued[0].Fields) != 0 {
+		t.Errorf("Expected no fields on the first entry, got %v", queued[0].Fields)
+	}
+	if f := queued[1].Fields; len(f) != 1 || f[0].Key != "repeated" || f[0].integer != 2 {
+		t.Errorf("Expected repeated=2, got %v", f)
+	}
+}
+
+// tests that large batches are shortened with a count of the other entries
+func TestDiscordMessage(t *testing.T) {
+	var entries []LogEntry
+	for i := 0; i < 12; i++ {
+		entries = append(entries, LogEntry{Level: "FATAL", Severity: FATAL, Data: fmt.Sprintf("Crash %d", i), Source: "api"})
+	}
+	server, bodies := chatServer(t)
+	defer server.Close()
+	sink := NewDiscordSink(server.URL, ChatConfig{})
+	defer sink.Close()
+	if err := sink.send(entries); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	msg := <-bodies
+	if msg["content"] != "…and 2 more entries" {
+		t.Errorf("Expected count of left out entries, got %v", msg["content"])
+	}
+	embeds := msg["embeds"].([]interface{})
+	if len(embeds) != chatMaxEntries {
+		t.Fatalf("Expected %d embeds, got %d", chatMaxEntries, len(embeds))
+	}
+	embed := embeds[0].(map[string]interface{})
+	if embed["title"] != "🔥 FATAL" || embed["description"] != "Crash 0" || embed["color"] != float64(0xb71c1c) {
+		t.Errorf("Expected formatted embed, got %v", embed)
+	}
+	if footer := embed["footer"].(map[string]interface{}); footer["text"] != "api" {
+		t.Errorf("Expected source in footer, got %v", footer)
+	}
+}
+
+// tests the Teams message card format
+func TestTeamsMessage(t *testing.T) {
+	entry := LogEntry{Level: "ERROR", Severity: ERROR, Data: "Payment failed", Fields: []Field{Int("attempt", 3)}}
+	msg, err := teamsMessage([]LogEntry{entry}, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	card := msg.(teamsPayload)
+	if card.Type != "MessageCard" || card.ThemeColor != "E53935" || card.Summary != "ERROR: Payment failed" {
+		t.Errorf("Expected message card, got %+v", card)
+	}
+	section := card.Sections[0]
+	if section.ActivityTitle != "❌ ERROR: Payment failed" {
+		t.Errorf("Expected activity title, got %q", section.ActivityTitle)
+	}
+	if len(section.Facts) != 1 || section.Facts[0] != (teamsFact{"attempt", "3"}) {
+		t.Errorf("Expected attempt fact, got %v", section.Facts)
+	}
+}
@@ -0,0 +1,179 @@
+package gologs
+
+import (
+	"bytes"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clickHouseDefaultColumns is the column mapping used when none is given.
+var clickHouseDefaultColumns = map[string]string{
+	"timestamp": "timestamp",
+	"level":     "level",
+	"severity":  "severity",
+	"message":   "message",
+	"source":    "source",
+	"caller":    "caller",
+	"fields":    "fields",
+}
+
+// ClickHouseSink is a Sink that inserts entries into a ClickHouse table
+// using the HTTP interface and the JSONEachRow format. It is meant for
+// services writing tens of thousands of entries per second: entries are
+// inserted in batches of 10000 by default, from a background goroutine;
+// see the HTTPOption functions for batching, retries and authentication.
+//
+// Each column of the table is mapped to a part of the entry: "timestamp",
+// "level", "severity" (the numeric LogLevel), "message", "source", "caller"
+// or "fields" (all fields as a JSON string), or else the value of the field
+// with that key. A table for the default mapping:
+//
+//	CREATE TABLE logs (
+//	    timestamp DateTime64(9),
+//	    level LowCardinality(String),
+//	    severity Int8,
+//	    message String,
+//	    source String,
+//	    caller String,
+//	    fields String
+//	) ENGINE = MergeTree ORDER BY timestamp
+type ClickHouseSink struct {
+	url     string
+	columns []string
+	sources []string
+	config  httpConfig
+	batch   *batcher
+}
+
+// NewClickHouseSink returns a sink inserting into table on the ClickHouse
+// server at serverURL, such as "http://clickhouse:8123". columns maps
+// column names to the parts of the entry they hold; if it is nil, the
+// columns timestamp, level, severity, message, source, caller and fields
+// are used.
+func NewClickHouseSink(serverURL, table string, columns map[string]string, opts ...HTTPOption) *ClickHouseSink {
+	if columns == nil {
+		columns = clickHouseDefaultColumns
+	}
+	s := &ClickHouseSink{
+		config: newHTTPConfig(append([]HTTPOption{WithBatchSize(10000)}, opts...)),
+	}
+	for column := range columns {
+		s.columns = append(s.columns, column)
+	}
+	sort.Strings(s.columns)
+	quoted := make([]string, len(s.columns))
+	for i, column := range s.columns {
+		s.sources = append(s.sources, columns[column])
+		quoted[i] = "`" + strings.ReplaceAll(column, "`", "\\`") + "`"
+	}
+
+	query := url.Values{}
+	query.Set("query", "INSERT INTO "+table+" ("+strings.Join(quoted, ", ")+") FORMAT JSONEachRow")
+	query.Set("date_time_input_format", "best_effort")
+	s.url = strings.TrimSuffix(serverURL, "/") + "/?" + query.Encode()
+	s.batch = s.config.startBatcher(s.send)
+	return s
+}
+
+// Write queues the entry to be inserted.
+func (s *ClickHouseSink) Write(entry LogEntry) error {
+	return s.batch.add(entry)
+}
+
+// Flush inserts all queued entries.
+func (s *ClickHouseSink) Flush() error {
+	return s.batch.flush()
+}
+
+// Close inserts all queued entries and stops the background goroutine.
+func (s *ClickHouseSink) Close() error {
+	return s.batch.close()
+}
+
+// Health returns the results of the sent batches and the number of entries
+// waiting to be sent.
+func (s *ClickHouseSink) Health() SinkHealth {
+	return s.batch.health()
+}
+
+func (s *ClickHouseSink) batches() *batcher {
+	return s.batch
+}
+
+// send inserts a batch of entries, one JSON object per line.
+func (s *ClickHouseSink) send(entries []LogEntry) error {
+	var buf bytes.Buffer
+	for _, e := range entries {
+		if err := s.appendRow(&buf, e); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+	}
+	return s.config.post(s.url, "application/x-ndjson", buf.Bytes())
+}
+
+// appendRow writes the columns of an entry as a JSON object. Columns mapped
+// to a missing field are left out, so they get their default value.
+func (s *ClickHouseSink) appendRow(buf *bytes.Buffer, e LogEntry) error {
+	buf.WriteByte('{')
+	first := true
+	for i, column := range s.columns {
+		mark := buf.Len()
+		if !first {
+			buf.WriteByte(',')
+		}
+		buf.Write(appendJSONString(buf.AvailableBuffer(), column))
+		buf.WriteByte(':')
+		ok, err := s.appendValue(buf, s.sources[i], e)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			buf.Truncate(mark)
+			continue
+		}
+		first = false
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// appendValue writes the part of the entry named by source. It returns
+// false if the entry has no such field.
+func (s *ClickHouseSink) appendValue(buf *bytes.Buffer, source string, e LogEntry) (bool, error) {
+	switch source {
+	case "timestamp":
+		buf.Write(appendJSONString(buf.AvailableBuffer(), e.Timestamp.UTC().Format(time.RFC3339Nano)))
+	case "level":
+		buf.Write(appendJSONString(buf.AvailableBuffer(), e.Level))
+	case "severity":
+		buf.WriteString(strconv.Itoa(int(e.Severity)))
+	case "message":
+		var msg bytes.Buffer
+		if err := appendMessageText(&msg, e.Data); err != nil {
+			return false, err
+		}
+		buf.Write(appendJSONString(buf.AvailableBuffer(), msg.String()))
+	case "source":
+		buf.Write(appendJSONString(buf.AvailableBuffer(), e.Source))
+	case "caller":
+		buf.Write(appendJSONString(buf.AvailableBuffer(), e.Caller))
+	case "fields":
+		var fields bytes.Buffer
+		if err := appendFieldsJSON(&fields, e.Fields); err != nil {
+			return false, err
+		}
+		buf.Write(appendJSONString(buf.AvailableBuffer(), fields.String()))
+	default:
+		for i := len(e.Fields) - 1; i >= 0; i-- {
+			if f := e.Fields[i]; f.Key == source && f.Type != skipType {
+				return true, appendFieldValue(buf, f)
+			}
+		}
+		return false, nil
+	}
+	return true, nil
+}
@@ -0,0 +1,99 @@
+package gologs
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// tests inserting entries with the default columns
+func TestClickHouseSink(t *testing.T) {
+	type request struct {
+		query string
+		body  string
+	}
+	requests := make(chan request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{r.URL.Query().Get("query"), string(body)}
+	}))
+	defer server.Close()
+
+	sink := NewClickHouseSink(server.URL, "logs", nil)
+	l := New(WithSinks(sink), WithCallerInfo(false))
+	l.Warn("Slow query", Int("took_ms", 950))
+	l.Info("Done")
+	if err := l.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	req := <-requests
+	if want := "INSERT INTO logs (`caller`, `fields`, `level`, `message`, `severity`, `source`, `timestamp`) FORMAT JSONEachRow"; req.query != want {
+		t.Errorf("Expected query %q, got %q", want, req.query)
+	}
+	lines := strings.Split(strings.TrimSuffix(req.body, "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 rows, got %q", req.body)
+	}
+	var row map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &row); err != nil {
+		t.Fatal(err)
+	}
+	if row["level"] != "WARN" || row["severity"] != float64(WARN) || row["message"] != "Slow query" || row["fields"] != `{"took_ms":950}` {
+		t.Errorf("Expected row values, got %v", row)
+	}
+	if ts, _ := row["timestamp"].(string); !strings.HasSuffix(ts, "Z") {
+		t.Errorf("Expected UTC timestamp, got %v", row["timestamp"])
+	}
+}
+
+// tests mapping columns to fields
+func TestClickHouseSinkColumns(t *testing.T) {
+	bodies := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- string(body)
+	}))
+	defer server.Close()
+
+	sink := NewClickHouseSink(server.URL, "requests", map[string]string{
+		"ts":     "timestamp",
+		"msg":    "message",
+		"status": "status",
+		"region": "region",
+	}, WithBatchSize(10))
+	l := New(WithSinks(sink), WithCallerInfo(false))
+	l.Info("Request handled", Int("status", 200))
+	if err := l.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var row map[string]interface{}
+	if err := json.Unmarshal([]byte(<-bodies), &row); err != nil {
+		t.Fatal(err)
+	}
+	if row["status"] != float64(200) || row["msg"] != "Request handled" || row["ts"] == nil {
+		t.Errorf("Expected mapped columns, got %v", row)
+	}
+	if _, ok := row["region"]; ok {
+		t.Errorf("Expected missing field to be left out, got %v", row)
+	}
+}
+
+// tests that server errors are reported
+func TestClickHouseSinkError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Code: 60. DB::Exception: Table default.logs does not exist", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	sink := NewClickHouseSink(server.URL, "logs", nil)
+	defer sink.Close()
+	sink.Write(LogEntry{Level: "INFO", Data: "lost"})
+	if err := sink.Flush(); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("Expected server error, got %v", err)
+	}
+}
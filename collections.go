@@ -0,0 +1,181 @@
+package gologs
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+)
+
+// Strings creates a field holding a list of strings.
+func Strings(key string, values []string) Field {
+	return Field{Key: key, Type: AnyType, Value: values}
+}
+
+// Ints creates a field holding a list of integers.
+func Ints(key string, values []int) Field {
+	return Field{Key: key, Type: AnyType, Value: values}
+}
+
+// Int64s creates a field holding a list of 64-bit integers.
+func Int64s(key string, values []int64) Field {
+	return Field{Key: key, Type: AnyType, Value: values}
+}
+
+// Float64s creates a field holding a list of floats.
+func Float64s(key string, values []float64) Field {
+	return Field{Key: key, Type: AnyType, Value: values}
+}
+
+// Bools creates a field holding a list of booleans.
+func Bools(key string, values []bool) Field {
+	return Field{Key: key, Type: AnyType, Value: values}
+}
+
+// Objects creates a field holding a list of objects, each written with its
+// MarshalLogObject method.
+func Objects[T LogObjectMarshaler](key string, objs []T) Field {
+	list := make(objects, len(objs))
+	for i, obj := range objs {
+		list[i] = obj
+	}
+	return Field{Key: key, Type: AnyType, Value: list}
+}
+
+// Group creates a field holding fields as a nested object:
+//
+//	logger.Info("Query done", gologs.Group("db", gologs.Int("rows", 3), gologs.String("table", "orders")))
+//	// {..., "db":{"rows":3,"table":"orders"}}
+func Group(key string, fields ...Field) Field {
+	return Field{Key: key, Type: AnyType, Value: group(fields)}
+}
+
+// objects is the value of an Objects field. It is resolved into a list of
+// groups before the entry is written.
+type objects []LogObjectMarshaler
+
+// list returns the fields of each object.
+func (o objects) list() []interface{} {
+	list := make([]interface{}, len(o))
+	for i, obj := range o {
+		list[i] = objectGroup(obj)
+	}
+	return list
+}
+
+// appendJSONList writes the lists and maps used by the field helpers above
+// to buf without going through encoding/json, and reports whether v was
+// one of them. Nil lists and maps are written as null, as encoding/json
+// does.
+func appendJSONList(buf *bytes.Buffer, v interface{}) (bool, error) {
+	switch v := v.(type) {
+	case []string:
+		if v == nil {
+			buf.WriteString("null")
+			return true, nil
+		}
+		dst := append(buf.AvailableBuffer(), '[')
+		for i, s := range v {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendJSONString(dst, s)
+		}
+		buf.Write(append(dst, ']'))
+	case []int:
+		if v == nil {
+			buf.WriteString("null")
+			return true, nil
+		}
+		dst := append(buf.AvailableBuffer(), '[')
+		for i, n := range v {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = strconv.AppendInt(dst, int64(n), 10)
+		}
+		buf.Write(append(dst, ']'))
+	case []int64:
+		if v == nil {
+			buf.WriteString("null")
+			return true, nil
+		}
+		dst := append(buf.AvailableBuffer(), '[')
+		for i, n := range v {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = strconv.AppendInt(dst, n, 10)
+		}
+		buf.Write(append(dst, ']'))
+	case []bool:
+		if v == nil {
+			buf.WriteString("null")
+			return true, nil
+		}
+		dst := append(buf.AvailableBuffer(), '[')
+		for i, b := range v {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = strconv.AppendBool(dst, b)
+		}
+		buf.Write(append(dst, ']'))
+	case []float64:
+		if v == nil {
+			buf.WriteString("null")
+			return true, nil
+		}
+		buf.WriteByte('[')
+		for i, f := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := appendJSONValue(buf, f); err != nil {
+				return true, err
+			}
+		}
+		buf.WriteByte(']')
+	case []interface{}:
+		if v == nil {
+			buf.WriteString("null")
+			return true, nil
+		}
+		buf.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := appendJSONValue(buf, item); err != nil {
+				return true, err
+			}
+		}
+		buf.WriteByte(']')
+	case objects:
+		return true, appendJSONValue(buf, v.list())
+	case map[string]interface{}:
+		if v == nil {
+			buf.WriteString("null")
+			return true, nil
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(appendJSONString(buf.AvailableBuffer(), k))
+			buf.WriteByte(':')
+			if err := appendJSONValue(buf, v[k]); err != nil {
+				return true, err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return false, nil
+	}
+	return true, nil
+}
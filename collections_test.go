@@ -0,0 +1,53 @@
+package gologs
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+// tests that lists and maps are encoded like encoding/json encodes them
+func TestAppendJSONList(t *testing.T) {
+	for _, v := range []interface{}{
+		[]string{"a", "<b>", "\n"}, []string{}, []string(nil),
+		[]int{1, -2}, []int64{1 << 40}, []bool{true, false}, []float64{1.5, 1e21, 0},
+		[]interface{}{"a", 1, nil, map[string]interface{}{"b": []string{"c"}}},
+		map[string]interface{}{"z": 1, "a": []interface{}{true}, "m": nil}, map[string]interface{}(nil),
+	} {
+		var buf bytes.Buffer
+		if err := appendJSONValue(&buf, v); err != nil {
+			t.Fatalf("Expected no error for %v, got %v", v, err)
+		}
+		want, _ := json.Marshal(v)
+		if buf.String() != string(want) {
+			t.Errorf("Expected %s, got %s", want, buf.String())
+		}
+	}
+	var buf bytes.Buffer
+	if err := appendJSONValue(&buf, []float64{math.NaN()}); err == nil {
+		t.Error("Expected an error for NaN")
+	}
+}
+
+// tests the list, object and group field helpers
+func TestCollectionFields(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(INFO, &out, WithCallerInfo(false))
+	calls := 0
+	users := []testUser{{ID: "u1", Email: "a@x.org", calls: &calls}, {ID: "u2", Email: "b@y.org", calls: &calls}}
+	l.Info("batch", Strings("tags", []string{"a", "b"}), Ints("ids", []int{1, 2}), Objects("users", users),
+		Group("db", Int("rows", 3), Group("pool", Bool("idle", true))))
+	want := `"tags":["a","b"],"ids":[1,2],"users":[{"id":"u1","email":"***@x.org"},{"id":"u2","email":"***@y.org"}],` +
+		`"db":{"rows":3,"pool":{"idle":true}}}`
+	if !strings.HasSuffix(strings.TrimSpace(out.String()), want) {
+		t.Errorf("Expected %s, got %s", want, out.String())
+	}
+
+	calls = 0
+	l.Debug("hidden", Objects("users", users))
+	if calls != 0 {
+		t.Errorf("Expected no call at a disabled level, got %d", calls)
+	}
+}
@@ -0,0 +1,376 @@
+package gologs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config describes a logger in a form that can be loaded from a JSON or YAML
+// file:
+//
+//	level: info
+//	format: json
+//	outputs: [stdout, /var/log/app.log]
+//	fields:
+//	  service: shop
+//
+// Sinks add destinations with their own output, format and level, for
+// example JSON to a file next to colored console output:
+//
+//	sinks:
+//	  - output: /var/log/app.log
+//	    format: json
+//	  - output: stderr
+//	    format: console
+//	    level: warn
+type Config struct {
+	// Level is a level name such as "debug" or "WARN". Defaults to INFO.
+	Level string `json:"level" yaml:"level"`
+	// Format is "json", "console", "logfmt", "gcp", "pretty" for indented
+	// JSON, or "msgpack", "cbor" or "protobuf" for binary output. Defaults
+	// to json.
+	Format string `json:"format" yaml:"format"`
+	// Outputs lists where entries are written: "stdout", "stderr" or file
+	// paths to append to. Defaults to stdout.
+	Outputs []string `json:"outputs" yaml:"outputs"`
+	// CallerInfo sets whether to include caller info. Defaults to true.
+	CallerInfo *bool `json:"caller_info" yaml:"caller_info"`
+	// TimestampFormat is the time.Format layout used for timestamps.
+	TimestampFormat string `json:"timestamp_format" yaml:"timestamp_format"`
+	// Fields are added to every entry.
+	Fields map[string]interface{} `json:"fields" yaml:"fields"`
+	// Sinks are additional destinations, each with its own format and level.
+	// If sinks are set and outputs are not, nothing is written to stdout.
+	Sinks []SinkConfig `json:"sinks" yaml:"sinks"`
+	// Rotation rotates the files of Outputs. Files are not rotated if it
+	// is unset.
+	Rotation *RotationConfig `json:"rotation" yaml:"rotation"`
+	// Sampling samples the entries of the logger. It is only read when the
+	// logger is created.
+	Sampling *SamplingSettings `json:"sampling" yaml:"sampling"`
+}
+
+// RotationConfig describes the rotation of an output file, as done by
+// FileSink:
+//
+//	rotation:
+//	  max_size_mb: 100
+//	  max_backups: 7
+//	  max_age: 168h
+//	  interval: daily
+//	  compress: true
+type RotationConfig struct {
+	// MaxSizeMB rotates the file when it would grow past this many
+	// megabytes. Zero disables size-based rotation.
+	MaxSizeMB int `json:"max_size_mb" yaml:"max_size_mb"`
+	// MaxBackups is the number of rotated files kept. Zero keeps all.
+	MaxBackups int `json:"max_backups" yaml:"max_backups"`
+	// MaxAge removes rotated files older than this duration, such as
+	// "168h".
+	MaxAge string `json:"max_age" yaml:"max_age"`
+	// Interval also rotates the file every "hourly", "daily", or a
+	// duration such as "6h".
+	Interval string `json:"interval" yaml:"interval"`
+	// Compress gzips rotated files.
+	Compress bool `json:"compress" yaml:"compress"`
+}
+
+// SamplingSettings describes the sampler of a logger, as configured by
+// SamplingConfig:
+//
+//	sampling:
+//	  tick: 1s
+//	  first: 100
+//	  thereafter: 10
+//	  rates:
+//	    debug: 0.1
+type SamplingSettings struct {
+	// Tick is the period over which entries are counted, such as "1s".
+	Tick string `json:"tick" yaml:"tick"`
+	// First and Thereafter limit how often the same message is logged per
+	// tick.
+	First      int `json:"first" yaml:"first"`
+	Thereafter int `json:"thereafter" yaml:"thereafter"`
+	// Rates maps level names to the fraction of their entries kept.
+	Rates map[string]float64 `json:"rates" yaml:"rates"`
+	// KeepLevel is the level from which entries are always kept. Defaults
+	// to ERROR.
+	KeepLevel string `json:"keep_level" yaml:"keep_level"`
+	// Budget enables adaptive sampling; see SamplingConfig.
+	Budget int `json:"budget" yaml:"budget"`
+}
+
+// SinkConfig describes an additional destination in a Config.
+type SinkConfig struct {
+	// Output is "stdout", "stderr" or a file path to append to.
+	Output string `json:"output" yaml:"output"`
+	// Format is "json", "console", "logfmt", "gcp", "pretty" for indented
+	// JSON, or "msgpack", "cbor" or "protobuf" for binary output. Defaults
+	// to json.
+	Format string `json:"format" yaml:"format"`
+	// Level is the minimum level written to the sink. Defaults to the level
+	// of the logger.
+	Level string `json:"level" yaml:"level"`
+	// Rotation rotates the file of Output. It is not rotated if Rotation
+	// is unset.
+	Rotation *RotationConfig `json:"rotation" yaml:"rotation"`
+}
+
+// LoadConfig reads a Config from a file. Files ending in .yaml or .yml are
+// parsed as YAML, all others as JSON.
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &cfg)
+	default:
+		err = json.Unmarshal(data, &cfg)
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// NewLoggerFromConfig creates a Logger from the config file at path. See
+// LoadConfig for the supported formats.
+func NewLoggerFromConfig(path string) (*Logger, error) {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := cfg.Options()
+	if err != nil {
+		return nil, err
+	}
+	return New(opts...), nil
+}
+
+// Options converts the config into logger options. Output files are opened
+// by Options; if an error is returned, any files it opened are closed again.
+func (c Config) Options() ([]Option, error) {
+	var opts []Option
+
+	if c.Level != "" {
+		level, err := ParseLogLevel(c.Level)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithLevel(level))
+	}
+
+	if c.Format != "" {
+		opt, err := formatOption(c.Format)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, opt)
+	}
+
+	if c.CallerInfo != nil {
+		opts = append(opts, WithCallerInfo(*c.CallerInfo))
+	}
+	if c.TimestampFormat != "" {
+		opts = append(opts, WithTimestampFormat(c.TimestampFormat))
+	}
+
+	if len(c.Fields) > 0 {
+		keys := make([]string, 0, len(c.Fields))
+		for k := range c.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fields := make([]Field, 0, len(keys))
+		for _, k := range keys {
+			fields = append(fields, anyField(k, c.Fields[k]))
+		}
+		opts = append(opts, WithFields(fields...))
+	}
+
+	if c.Sampling != nil {
+		cfg, err := c.Sampling.samplingConfig()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithSampler(NewSampler(cfg)))
+	}
+
+	var files []io.WriteCloser
+	if len(c.Outputs) > 0 {
+		writers := make([]io.Writer, 0, len(c.Outputs))
+		for _, name := range c.Outputs {
+			w, owned, err := openConfigOutput(name, c.Rotation)
+			if err != nil {
+				closeFiles(files)
+				return nil, err
+			}
+			writers = append(writers, w)
+			if owned != nil {
+				files = append(files, owned)
+			}
+		}
+		opts = append(opts, withOutputs(writers, files))
+	}
+
+	if len(c.Sinks) > 0 {
+		sinks := make([]Sink, 0, len(c.Sinks))
+		for _, sc := range c.Sinks {
+			sink, err := sc.newSink(c.TimestampFormat)
+			if err != nil {
+				closeFiles(files)
+				for _, s := range sinks {
+					s.Close()
+				}
+				return nil, err
+			}
+			sinks = append(sinks, sink)
+		}
+		opts = append(opts, withConfigSinks(sinks))
+	}
+
+	return opts, nil
+}
+
+// newSink opens the sink described by the config.
+func (c SinkConfig) newSink(timeFormat string) (Sink, error) {
+	var level LogLevel
+	if c.Level != "" {
+		var err error
+		if level, err = ParseLogLevel(c.Level); err != nil {
+			return nil, err
+		}
+	}
+
+	w, owned, err := openConfigOutput(c.Output, c.Rotation)
+	if err != nil {
+		return nil, err
+	}
+	encoder, err := encoderFor(c.Format, w)
+	if err != nil {
+		if owned != nil {
+			owned.Close()
+		}
+		return nil, err
+	}
+	if ce, ok := encoder.(configurableEncoder); ok && timeFormat != "" {
+		encoder = ce.withConfig(func(ec *EncoderConfig) {
+			ec.TimeFormat = timeFormat
+		})
+	}
+
+	ws := NewWriterSink(w, encoder)
+	if owned != nil {
+		ws.closer = owned
+	}
+	if c.Level == "" {
+		return ws, nil
+	}
+	return NewLevelSink(ws, level), nil
+}
+
+// closeFiles closes all files, ignoring errors.
+func closeFiles(files []io.WriteCloser) {
+	for _, f := range files {
+		f.Close()
+	}
+}
+
+// openConfigOutput opens an output of a config: stdout, stderr, or a file,
+// rotated if rotation is set. owned is the file, to be closed with the
+// output, or nil for stdout and stderr.
+func openConfigOutput(name string, rotation *RotationConfig) (w io.Writer, owned io.WriteCloser, err error) {
+	switch strings.ToLower(name) {
+	case "stdout", "stderr":
+		f, _ := openOutput(name)
+		return f, nil, nil
+	}
+	if rotation == nil {
+		f, err := openOutput(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		return f, f, nil
+	}
+	opts, err := rotation.fileSinkOptions()
+	if err != nil {
+		return nil, nil, err
+	}
+	sink, err := NewFileSink(name, rotation.MaxSizeMB, rotation.MaxBackups, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	rf := &rotatingFile{sink}
+	return rf, rf, nil
+}
+
+// fileSinkOptions returns the FileSink options of the rotation settings.
+func (r RotationConfig) fileSinkOptions() ([]FileSinkOption, error) {
+	var opts []FileSinkOption
+	if r.MaxAge != "" {
+		age, err := time.ParseDuration(r.MaxAge)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rotation max_age: %w", err)
+		}
+		opts = append(opts, WithMaxAge(age))
+	}
+	switch strings.ToLower(r.Interval) {
+	case "":
+	case "hourly":
+		opts = append(opts, WithRotationInterval(Hourly))
+	case "daily":
+		opts = append(opts, WithRotationInterval(Daily))
+	default:
+		interval, err := time.ParseDuration(r.Interval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rotation interval: %w", err)
+		}
+		opts = append(opts, WithRotationInterval(interval))
+	}
+	if r.Compress {
+		opts = append(opts, WithCompression())
+	}
+	return opts, nil
+}
+
+// samplingConfig converts the settings into a SamplingConfig.
+func (s SamplingSettings) samplingConfig() (SamplingConfig, error) {
+	cfg := SamplingConfig{First: s.First, Thereafter: s.Thereafter, Budget: s.Budget}
+	if s.Tick != "" {
+		tick, err := time.ParseDuration(s.Tick)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid sampling tick: %w", err)
+		}
+		cfg.Tick = tick
+	}
+	if s.KeepLevel != "" {
+		level, err := ParseLogLevel(s.KeepLevel)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.KeepLevel = level
+	}
+	if len(s.Rates) > 0 {
+		cfg.Rates = make(map[LogLevel]float64, len(s.Rates))
+		for name, rate := range s.Rates {
+			level, err := ParseLogLevel(name)
+			if err != nil {
+				return cfg, err
+			}
+			cfg.Rates[level] = rate
+		}
+	}
+	return cfg, nil
+}
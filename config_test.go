@@ -0,0 +1,230 @@
+package gologs
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// tests creating a logger from a YAML config file
+func TestNewLoggerFromYAMLConfig(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app.log")
+	configPath := filepath.Join(dir, "logging.yaml")
+	config := "level: warn\nformat: logfmt\ncaller_info: false\noutputs:\n  - " + logPath + "\nfields:\n  service: shop\n  replicas: 3\n"
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := NewLoggerFromConfig(configPath)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	l.Info("filtered")
+	l.Warn("Disk almost full")
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := string(data)
+	if strings.Contains(output, "filtered") {
+		t.Errorf("Expected INFO entry to be filtered, got %v", output)
+	}
+	expected := `level=warn msg="Disk almost full" replicas=3 service=shop`
+	if !strings.Contains(output, expected) {
+		t.Errorf("Expected %v in output, got %v", expected, output)
+	}
+}
+
+// tests creating a logger from a JSON config file with several outputs
+func TestNewLoggerFromJSONConfig(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.log")
+	second := filepath.Join(dir, "second.log")
+	configPath := filepath.Join(dir, "logging.json")
+	config := `{"level": "debug", "outputs": ["` + first + `", "` + second + `"], "fields": {"env": "test"}}`
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := NewLoggerFromConfig(configPath)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	l.Debug("Written twice")
+
+	for _, path := range []string{first, second} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), `"data":"Written twice","env":"test"`) {
+			t.Errorf("Expected entry in %v, got %v", path, string(data))
+		}
+	}
+}
+
+// tests a config writing JSON to one file and console output to another
+func TestConfigSinks(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "app.json")
+	consolePath := filepath.Join(dir, "app.txt")
+	configPath := filepath.Join(dir, "logging.yaml")
+	config := "level: debug\ncaller_info: false\nsinks:\n  - output: " + jsonPath + "\n    format: json\n  - output: " + consolePath + "\n    format: console\n    level: warn\n"
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := NewLoggerFromConfig(configPath)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	l.Debug("Cache miss")
+	l.Warn("Disk almost full")
+	if err := l.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := string(data)
+	if !strings.Contains(output, `"data":"Cache miss"`) || !strings.Contains(output, `"data":"Disk almost full"`) {
+		t.Errorf("Expected both entries as JSON, got %v", output)
+	}
+
+	data, err = os.ReadFile(consolePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output = string(data)
+	if strings.Contains(output, "Cache miss") {
+		t.Errorf("Expected DEBUG entry to be filtered from console sink, got %v", output)
+	}
+	if !strings.Contains(output, "WARN  Disk almost full") || strings.Contains(output, "\x1b[") {
+		t.Errorf("Expected uncolored console entry, got %v", output)
+	}
+}
+
+// tests that invalid configs are reported
+func TestConfigErrors(t *testing.T) {
+	if _, err := (Config{Level: "loud"}).Options(); err == nil {
+		t.Error("Expected error for unknown level")
+	}
+	if _, err := (Config{Format: "xml"}).Options(); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+	if _, err := (Config{Outputs: []string{filepath.Join(t.TempDir(), "missing", "app.log")}}).Options(); err == nil {
+		t.Error("Expected error for output in missing directory")
+	}
+	if _, err := (Config{Sinks: []SinkConfig{{Output: "stderr", Level: "loud"}}}).Options(); err == nil {
+		t.Error("Expected error for unknown sink level")
+	}
+	if _, err := (Config{Sinks: []SinkConfig{{Output: "stderr", Format: "xml"}}}).Options(); err == nil {
+		t.Error("Expected error for unknown sink format")
+	}
+	if _, err := NewLoggerFromConfig(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("Expected error for missing config file")
+	}
+}
+
+// tests that an invalid sink format doesn't close stdout or stderr
+func TestConfigSinkErrorKeepsStdio(t *testing.T) {
+	for _, output := range []string{"stdout", "stderr"} {
+		if _, err := (Config{Sinks: []SinkConfig{{Output: output, Format: "bogus"}}}).Options(); err == nil {
+			t.Errorf("Expected error for unknown format of %s sink", output)
+		}
+	}
+	for _, f := range []*os.File{os.Stdout, os.Stderr} {
+		if _, err := f.Stat(); err != nil {
+			t.Errorf("Expected %s to stay open, got %v", f.Name(), err)
+		}
+	}
+}
+
+// tests rotation and sampling settings in a YAML config file
+func TestConfigRotationAndSamplingYAML(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app.log")
+	configPath := filepath.Join(dir, "logging.yaml")
+	config := "level: debug\ncaller_info: false\noutputs: [" + logPath + "]\n" +
+		"rotation:\n  max_size_mb: 1\n  max_backups: 2\n  max_age: 168h\n  interval: daily\n" +
+		"sampling:\n  tick: 1m\n  rates:\n    debug: 0.5\n"
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := NewLoggerFromConfig(configPath)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		l.Debug("sampled")
+	}
+	payload := strings.Repeat("x", 300*1024)
+	for i := 0; i < 4; i++ {
+		l.Info(payload)
+	}
+	l.Close()
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), payload); n != 1 {
+		t.Errorf("Expected the file to be rotated after 3 large entries, got %d in it", n)
+	}
+	backups, _ := filepath.Glob(filepath.Join(dir, "app-*.log"))
+	if len(backups) != 1 {
+		t.Fatalf("Expected 1 backup, got %v", backups)
+	}
+	data, _ = os.ReadFile(backups[0])
+	if n := strings.Count(string(data), `"data":"sampled"`); n != 2 {
+		t.Errorf("Expected half of the DEBUG entries, got %d", n)
+	}
+}
+
+// tests rotation settings of a sink in a JSON config
+func TestConfigSinkRotationJSON(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app.log")
+	config := `{"sinks": [{"output": "` + logPath + `", "format": "logfmt", "rotation": {"max_size_mb": 1, "compress": true}}]}`
+	var cfg Config
+	if err := json.Unmarshal([]byte(config), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	opts, err := cfg.Options()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	l := New(opts...)
+	payload := strings.Repeat("x", 600*1024)
+	l.Info(payload)
+	l.Info(payload)
+	l.Close()
+
+	backups, _ := filepath.Glob(filepath.Join(dir, "app-*.log.gz"))
+	if len(backups) != 1 {
+		t.Errorf("Expected a compressed backup, got %v", backups)
+	}
+}
+
+// tests that invalid rotation and sampling settings are reported
+func TestConfigRotationErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	for _, cfg := range []Config{
+		{Outputs: []string{path}, Rotation: &RotationConfig{MaxAge: "a week"}},
+		{Outputs: []string{path}, Rotation: &RotationConfig{Interval: "weekly"}},
+		{Sinks: []SinkConfig{{Output: path, Rotation: &RotationConfig{MaxAge: "7"}}}},
+		{Sampling: &SamplingSettings{Tick: "often"}},
+		{Sampling: &SamplingSettings{Rates: map[string]float64{"chatty": 0.1}}},
+	} {
+		if _, err := cfg.Options(); err == nil {
+			t.Errorf("Expected error for %+v", cfg)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package gologs
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ANSI escape codes used by the console encoder.
+const (
+	colorReset   = "\x1b[0m"
+	colorRed     = "\x1b[31m"
+	colorYellow  = "\x1b[33m"
+	colorBlue    = "\x1b[34m"
+	colorMagenta = "\x1b[35m"
+	colorGray    = "\x1b[90m"
+)
+
+// levelColors maps level names to the color they are printed in.
+var levelColors = map[string]string{
+	"TRACE": colorGray,
+	"DEBUG": colorMagenta,
+	"INFO":  colorBlue,
+	"WARN":  colorYellow,
+	"ERROR": colorRed,
+	"PANIC": colorRed,
+	"FATAL": colorRed,
+}
+
+// ConsoleEncoder encodes entries as human-readable lines for local
+// development:
+//
+//	[15:04:05] INFO  Request handled status=200 path=/api/users
+//
+// Non-string messages are written as JSON. Field values containing spaces,
+// quotes or '=' are quoted.
+//
+// Timestamps default to the time of day (15:04:05).
+type ConsoleEncoder struct {
+	EncoderConfig
+	// Color enables per-level ANSI colors.
+	Color bool
+}
+
+// NewConsoleEncoder returns a ConsoleEncoder for output, with colors enabled
+// if output is a terminal and the NO_COLOR environment variable is not set.
+func NewConsoleEncoder(output io.Writer) ConsoleEncoder {
+	return ConsoleEncoder{Color: isTerminal(output) && os.Getenv("NO_COLOR") == ""}
+}
+
+// Encode appends the entry as a single line of text.
+func (e ConsoleEncoder) Encode(entry LogEntry, buf *bytes.Buffer) error {
+	buf.WriteByte('[')
+	buf.WriteString(formatTime(entry.Timestamp, e.timeFormat(time.TimeOnly)))
+	buf.WriteString("] ")
+
+	color := levelColors[entry.Level]
+	if e.Color && color != "" {
+		buf.WriteString(color)
+	}
+	buf.WriteString(e.level(entry.Level))
+	if e.Color && color != "" {
+		buf.WriteString(colorReset)
+	}
+	if n := 5 - len(entry.Level); n > 0 {
+		buf.WriteString(strings.Repeat(" ", n))
+	}
+	buf.WriteByte(' ')
+
+	if err := appendMessageText(buf, entry.Data); err != nil {
+		return err
+	}
+	for _, f := range entry.Fields {
+		if f.Type == skipType {
+			continue
+		}
+		buf.WriteByte(' ')
+		buf.WriteString(f.Key)
+		buf.WriteByte('=')
+		if err := appendFieldText(buf, f); err != nil {
+			return err
+		}
+	}
+	if entry.Source != "" {
+		buf.WriteString(" source=")
+		buf.WriteString(quoteText(entry.Source))
+	}
+	buf.WriteByte('\n')
+	return nil
+}
+
+func (e ConsoleEncoder) withConfig(fn func(*EncoderConfig)) Encoder {
+	fn(&e.EncoderConfig)
+	return e
+}
+
+// appendMessageText writes a message as text: strings as is, anything else
+// as JSON.
+func appendMessageText(buf *bytes.Buffer, message interface{}) error {
+	if s, ok := message.(string); ok {
+		buf.WriteString(s)
+		return nil
+	}
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
+
+// appendFieldText writes a field's value as text, quoting it when needed.
+func appendFieldText(buf *bytes.Buffer, f Field) error {
+	switch f.Type {
+	case StringType, ErrorType:
+		buf.WriteString(quoteText(f.str))
+	case IntType:
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), f.integer, 10))
+	case BoolType:
+		buf.Write(strconv.AppendBool(buf.AvailableBuffer(), f.integer == 1))
+	case DurationType:
+		buf.WriteString(time.Duration(f.integer).String())
+	default:
+		if s, ok := f.Value.(string); ok {
+			buf.WriteString(quoteText(s))
+			return nil
+		}
+		data, err := json.Marshal(f.Value)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(quoteText(string(data)))
+	}
+	return nil
+}
+
+// quoteText quotes s if it is empty or contains spaces, quotes, '=' or
+// control characters.
+func quoteText(s string) string {
+	if s == "" {
+		return `""`
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '"' || r == '=' || r == '\\' || r == 0x7f {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
+
+// isTerminal reports whether w is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
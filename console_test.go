@@ -0,0 +1,55 @@
+package gologs
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tests the console encoder output format
+func TestConsoleEncoder(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(DEBUG, &out, WithEncoder(ConsoleEncoder{}))
+	l.SetShowCallerInfo(false)
+	l.Info("Request handled", Int("status", 200), String("path", "/api/users"),
+		String("agent", "curl 8.0"), Dur("elapsed", 1500*time.Millisecond), Err(errors.New("none")))
+	output := out.String()
+	if !strings.HasPrefix(output, "[") {
+		t.Errorf("Expected line to start with time, got %v", output)
+	}
+	expected := `] INFO  Request handled status=200 path=/api/users agent="curl 8.0" elapsed=1.5s error=none` + "\n"
+	if !strings.HasSuffix(output, expected) {
+		t.Errorf("Expected %q, got %q", expected, output)
+	}
+}
+
+// tests that colors are only written when enabled
+func TestConsoleEncoderColor(t *testing.T) {
+	var buf bytes.Buffer
+	entry := LogEntry{Level: "ERROR", Data: map[string]int{"code": 1}}
+	if err := (ConsoleEncoder{Color: true}).Encode(entry, &buf); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	output := buf.String()
+	if !strings.Contains(output, colorRed+"ERROR"+colorReset) {
+		t.Errorf("Expected colored level, got %q", output)
+	}
+	if !strings.Contains(output, `{"code":1}`) {
+		t.Errorf("Expected JSON message, got %q", output)
+	}
+
+	if NewConsoleEncoder(&buf).Color {
+		t.Error("Expected colors to be disabled for non-terminal output")
+	}
+}
+
+// tests that WithAutoConsole keeps JSON output for non-terminals
+func TestWithAutoConsole(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(DEBUG, &out, WithAutoConsole())
+	if _, ok := l.out.Load().writers[0].encoder.(JSONEncoder); !ok {
+		t.Errorf("Expected JSON encoder for non-terminal output, got %T", l.out.Load().writers[0].encoder)
+	}
+}
@@ -0,0 +1,32 @@
+package gologs
+
+import (
+	"context"
+	"os"
+)
+
+// contextKey is the key under which a Logger is stored in a context.
+type contextKey struct{}
+
+// defaultLogger is returned by FromContext when the context holds no logger.
+var defaultLogger = NewLogger(INFO, os.Stdout)
+
+// NewContext returns a copy of ctx that carries the given logger.
+func NewContext(ctx context.Context, logger *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger stored in ctx. If ctx holds no logger, a
+// default logger writing INFO and above to stdout is returned.
+func FromContext(ctx context.Context) *Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*Logger); ok && logger != nil {
+		return logger
+	}
+	return defaultLogger
+}
+
+// WithContext returns a copy of ctx that carries the logger. It is shorthand
+// for NewContext(ctx, l).
+func (l *Logger) WithContext(ctx context.Context) context.Context {
+	return NewContext(ctx, l)
+}
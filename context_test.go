@@ -0,0 +1,31 @@
+package gologs
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+// tests storing a logger in a context and retrieving it
+func TestContextLogger(t *testing.T) {
+	var out bytes.Buffer
+	reqLogger := NewLogger(DEBUG, &out).Child(String("request_id", "req-1"))
+	ctx := reqLogger.WithContext(context.Background())
+
+	FromContext(ctx).Info("Handling request")
+	output := out.String()
+	if !strings.Contains(output, `"request_id":"req-1"`) {
+		t.Errorf("Expected request_id field in output, got %v", output)
+	}
+}
+
+// tests the fallback logger when the context has none
+func TestFromContextDefault(t *testing.T) {
+	if FromContext(context.Background()) != defaultLogger {
+		t.Error("Expected default logger for context without logger")
+	}
+	if FromContext(NewContext(context.Background(), nil)) != defaultLogger {
+		t.Error("Expected default logger for context with nil logger")
+	}
+}
@@ -0,0 +1,181 @@
+package gologs
+
+import (
+	"bytes"
+	"time"
+)
+
+// datadogMaxBatch is the maximum number of entries accepted by the Datadog
+// logs intake in one request.
+const datadogMaxBatch = 1000
+
+// datadogReservedKeys are the attributes written by DatadogSink itself.
+// Fields using one of these keys are written as "fields.<key>".
+var datadogReservedKeys = map[string]bool{
+	"ddsource":  true,
+	"ddtags":    true,
+	"hostname":  true,
+	"service":   true,
+	"status":    true,
+	"message":   true,
+	"timestamp": true,
+	"source":    true,
+	"caller":    true,
+}
+
+// DatadogAttributes are the reserved attributes added to every entry sent
+// by a DatadogSink.
+type DatadogAttributes struct {
+	// Service is the name of the service, used to correlate logs with APM.
+	Service string
+	// Source is the technology of the logs (ddsource). Defaults to "go".
+	Source string
+	// Tags are comma-separated tags (ddtags), such as "env:prod,version:1.2".
+	Tags string
+	// Hostname is the host of the logs. Defaults to the host name.
+	Hostname string
+}
+
+// DatadogSink is a Sink that posts entries to the Datadog logs intake.
+// Levels are mapped onto Datadog statuses, and an "error" field is sent as
+// error.message, so it shows up in the error tracking views. Entries are
+// sent in batches of at most 1000 from a background goroutine; see the
+// HTTPOption functions for batching and retries.
+type DatadogSink struct {
+	url    string
+	attrs  DatadogAttributes
+	config httpConfig
+	batch  *batcher
+}
+
+// NewDatadogSink returns a sink posting to the logs intake of the given
+// Datadog site, such as "datadoghq.com" (the default if site is empty) or
+// "datadoghq.eu", authenticated with apiKey.
+func NewDatadogSink(site, apiKey string, attrs DatadogAttributes, opts ...HTTPOption) *DatadogSink {
+	if site == "" {
+		site = "datadoghq.com"
+	}
+	if attrs.Source == "" {
+		attrs.Source = "go"
+	}
+	if attrs.Hostname == "" {
+		attrs.Hostname = hostname()
+	}
+	s := &DatadogSink{
+		url:    "https://http-intake.logs." + site + "/api/v2/logs",
+		attrs:  attrs,
+		config: newHTTPConfig(append([]HTTPOption{WithHeader("DD-API-KEY", apiKey)}, opts...)),
+	}
+	s.config.batchSize = min(s.config.batchSize, datadogMaxBatch)
+	s.batch = s.config.startBatcher(s.send)
+	return s
+}
+
+// Write queues the entry to be sent.
+func (s *DatadogSink) Write(entry LogEntry) error {
+	return s.batch.add(entry)
+}
+
+// Flush sends all queued entries.
+func (s *DatadogSink) Flush() error {
+	return s.batch.flush()
+}
+
+// Close sends all queued entries and stops the background goroutine.
+func (s *DatadogSink) Close() error {
+	return s.batch.close()
+}
+
+// Health returns the results of the sent batches and the number of entries
+// waiting to be sent.
+func (s *DatadogSink) Health() SinkHealth {
+	return s.batch.health()
+}
+
+func (s *DatadogSink) batches() *batcher {
+	return s.batch
+}
+
+// datadogStatus maps a LogLevel onto a Datadog status.
+func datadogStatus(level LogLevel) string {
+	switch {
+	case level >= FATAL:
+		return "alert"
+	case level == PANIC:
+		return "critical"
+	case level == ERROR:
+		return "error"
+	case level == WARN:
+		return "warning"
+	case level == INFO:
+		return "info"
+	default:
+		return "debug"
+	}
+}
+
+// send posts a batch of entries as a JSON array.
+func (s *DatadogSink) send(entries []LogEntry) error {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, e := range entries {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := s.appendEntry(&buf, e); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte(']')
+	return s.config.post(s.url, "application/json", buf.Bytes())
+}
+
+// appendEntry writes the entry as a Datadog log object.
+func (s *DatadogSink) appendEntry(buf *bytes.Buffer, e LogEntry) error {
+	attrs := [][2]string{
+		{"ddsource", s.attrs.Source},
+		{"ddtags", s.attrs.Tags},
+		{"hostname", s.attrs.Hostname},
+		{"service", s.attrs.Service},
+		{"status", datadogStatus(e.Severity)},
+		{"timestamp", e.Timestamp.Format(time.RFC3339Nano)},
+		{"source", e.Source},
+		{"caller", e.Caller},
+	}
+	buf.WriteString(`{"message":`)
+	var msg bytes.Buffer
+	if err := appendMessageText(&msg, e.Data); err != nil {
+		return err
+	}
+	buf.Write(appendJSONString(buf.AvailableBuffer(), msg.String()))
+	for _, kv := range attrs {
+		if kv[1] == "" {
+			continue
+		}
+		buf.WriteByte(',')
+		buf.Write(appendJSONString(buf.AvailableBuffer(), kv[0]))
+		buf.WriteByte(':')
+		buf.Write(appendJSONString(buf.AvailableBuffer(), kv[1]))
+	}
+
+	for _, f := range e.Fields {
+		if f.Type == skipType {
+			continue
+		}
+		key := f.Key
+		switch {
+		case key == "error" && f.Type == ErrorType:
+			key = "error.message"
+		case datadogReservedKeys[key]:
+			key = "fields." + key
+		}
+		buf.WriteByte(',')
+		buf.Write(appendJSONString(buf.AvailableBuffer(), key))
+		buf.WriteByte(':')
+		if err := appendFieldValue(buf, f); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
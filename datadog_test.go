@@ -0,0 +1,93 @@
+package gologs
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// tests that entries are posted with the API key and reserved attributes
+func TestDatadogSink(t *testing.T) {
+	type request struct {
+		apiKey string
+		body   []byte
+	}
+	requests := make(chan request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{r.Header.Get("DD-API-KEY"), body}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sink := NewDatadogSink("", "secret", DatadogAttributes{Service: "shop", Tags: "env:prod"})
+	sink.url = server.URL
+	l := New(WithSinks(sink), WithCallerInfo(false))
+	l.Error("Payment failed", String("order", "A1"), Err(errors.New("card declined")), String("status", "x"))
+	if err := l.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	req := <-requests
+	if req.apiKey != "secret" {
+		t.Errorf("Expected API key header, got %q", req.apiKey)
+	}
+	var entries []map[string]interface{}
+	if err := json.Unmarshal(req.body, &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	for key, want := range map[string]interface{}{
+		"message":       "Payment failed",
+		"status":        "error",
+		"service":       "shop",
+		"ddsource":      "go",
+		"ddtags":        "env:prod",
+		"hostname":      hostname(),
+		"order":         "A1",
+		"error.message": "card declined",
+		"fields.status": "x",
+	} {
+		if entry[key] != want {
+			t.Errorf("Expected %s=%v, got %v", key, want, entry[key])
+		}
+	}
+	if entry["timestamp"] == nil {
+		t.Errorf("Expected timestamp, got %v", entry)
+	}
+}
+
+// tests the mapping of levels onto Datadog statuses
+func TestDatadogStatus(t *testing.T) {
+	for level, want := range map[LogLevel]string{
+		TRACE: "debug",
+		DEBUG: "debug",
+		INFO:  "info",
+		WARN:  "warning",
+		ERROR: "error",
+		PANIC: "critical",
+		FATAL: "alert",
+	} {
+		if got := datadogStatus(level); got != want {
+			t.Errorf("Expected %s for %v, got %s", want, level, got)
+		}
+	}
+}
+
+// tests the intake URL for a site and the batch size limit
+func TestDatadogSinkDefaults(t *testing.T) {
+	sink := NewDatadogSink("datadoghq.eu", "secret", DatadogAttributes{}, WithBatchSize(5000))
+	defer sink.Close()
+	if sink.url != "https://http-intake.logs.datadoghq.eu/api/v2/logs" {
+		t.Errorf("Expected EU intake URL, got %s", sink.url)
+	}
+	if sink.config.batchSize != datadogMaxBatch {
+		t.Errorf("Expected batch size %d, got %d", datadogMaxBatch, sink.config.batchSize)
+	}
+}
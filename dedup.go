@@ -0,0 +1,103 @@
+package gologs
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+// WithDedup collapses identical entries logged one after the other within
+// window, like the "last message repeated N times" of syslog, so that an
+// error logged in a tight loop doesn't flood the output. The first entry is
+// written as usual; its repeats are counted and reported every window, and
+// before the next different entry, by a copy of the last repeat with a
+// "repeated" field holding their number. Entries are identical if they have
+// the same level, message, source and fields.
+func WithDedup(window time.Duration) Option {
+	return func(o *options) {
+		o.dedupWindow = window
+	}
+}
+
+// deduper holds back repeats of the last entry.
+type deduper struct {
+	window time.Duration
+	emit   func(LogEntry)
+
+	mu       sync.Mutex
+	last     LogEntry
+	lastSeen time.Time
+	repeat   LogEntry
+	repeats  int
+}
+
+func newDeduper(window time.Duration, emit func(LogEntry)) *deduper {
+	return &deduper{window: window, emit: emit}
+}
+
+// add reports whether the entry should be written, or false if it repeats
+// the last entry and is held back. Repeats held back so far are reported
+// before a different entry is written.
+func (d *deduper) add(entry LogEntry) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	repeated := entry.Timestamp.Sub(d.lastSeen) < d.window && sameEntry(entry, d.last)
+	d.lastSeen = entry.Timestamp
+	if repeated {
+		d.repeat = entry
+		d.repeats++
+		return false
+	}
+	d.flush()
+	d.last = entry
+	return true
+}
+
+// report reports the repeats held back so far, if any.
+func (d *deduper) report() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.flush()
+}
+
+// flush emits the entry reporting the repeats. The lock must be held, which
+// keeps the report ahead of the entry that ended the repeats.
+func (d *deduper) flush() {
+	if d.repeats == 0 {
+		return
+	}
+	entry := d.repeat
+	fields := make([]Field, 0, len(entry.Fields)+1)
+	fields = append(fields, entry.Fields...)
+	entry.Fields = append(fields, Int("repeated", d.repeats))
+	d.repeat, d.repeats = LogEntry{}, 0
+	d.emit(entry)
+}
+
+// sameEntry reports whether a repeats b. Only entries with a string message
+// are compared; PANIC and FATAL entries are never repeats.
+func sameEntry(a, b LogEntry) bool {
+	if a.Severity != b.Severity || a.Severity >= PANIC || a.Source != b.Source || len(a.Fields) != len(b.Fields) {
+		return false
+	}
+	msgA, okA := a.Data.(string)
+	msgB, okB := b.Data.(string)
+	if !okA || !okB || msgA != msgB {
+		return false
+	}
+	for i := range a.Fields {
+		if !sameField(a.Fields[i], b.Fields[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameField reports whether two fields have the same key and value.
+func sameField(a, b Field) bool {
+	if a.Key != b.Key || a.Type != b.Type || a.integer != b.integer || a.str != b.str {
+		return false
+	}
+	// Errors are compared by their message, which is in str.
+	return a.Type == ErrorType || reflect.DeepEqual(a.Value, b.Value)
+}
@@ -0,0 +1,78 @@
+package gologs
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tests collapsing repeated entries and reporting their number
+func TestDedup(t *testing.T) {
+	sink := &memorySink{}
+	l := New(WithSinks(sink), WithDedup(time.Hour))
+	for i := 0; i < 5; i++ {
+		l.Error("connection refused", Err(errors.New("dial tcp")))
+	}
+	if n := len(sink.messages()); n != 1 {
+		t.Errorf("Expected repeats to be held back, got %d entries", n)
+	}
+	l.Warn("giving up")
+	l.Close()
+
+	if msgs := sink.messages(); strings.Join(msgs, ",") != "connection refused,connection refused,giving up" {
+		t.Fatalf("Expected the repeats to be reported before the next entry, got %v", msgs)
+	}
+	fields := sink.entries[1].Fields
+	if last := fields[len(fields)-1]; last.Key != "repeated" || last.integer != 4 {
+		t.Errorf("Expected a repeated field with 4, got %+v", fields)
+	}
+	if len(sink.entries[0].Fields) != 1 {
+		t.Errorf("Expected the first entry to be unchanged, got %+v", sink.entries[0].Fields)
+	}
+}
+
+// tests which entries are repeats
+func TestDedupSameEntry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var reports []LogEntry
+	d := newDeduper(time.Second, func(e LogEntry) { reports = append(reports, e) })
+	entry := LogEntry{Severity: ERROR, Timestamp: now, Data: "failed", Fields: []Field{Int("attempt", 1)}}
+
+	written := 0
+	for _, e := range []LogEntry{
+		entry,
+		{Severity: ERROR, Timestamp: now, Data: "failed", Fields: []Field{Int("attempt", 1)}},
+		{Severity: ERROR, Timestamp: now, Data: "failed", Fields: []Field{Int("attempt", 2)}},
+		{Severity: WARN, Timestamp: now, Data: "failed", Fields: []Field{Int("attempt", 2)}},
+		{Severity: WARN, Timestamp: now.Add(2 * time.Second), Data: "failed", Fields: []Field{Int("attempt", 2)}},
+		{Severity: FATAL, Timestamp: now, Data: "exiting"},
+		{Severity: FATAL, Timestamp: now, Data: "exiting"},
+	} {
+		if d.add(e) {
+			written++
+		}
+	}
+	if written != 6 {
+		t.Errorf("Expected only the second entry to be held back, got %d written", written)
+	}
+	if len(reports) != 1 {
+		t.Errorf("Expected 1 report, got %d", len(reports))
+	}
+}
+
+// tests that repeats are reported periodically
+func TestDedupInterval(t *testing.T) {
+	sink := &memorySink{}
+	l := New(WithSinks(sink), WithDedup(10*time.Millisecond))
+	defer l.Close()
+	l.Info("polling")
+	l.Info("polling")
+	deadline := time.Now().Add(time.Second)
+	for len(sink.messages()) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the repeats to be reported after the window")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
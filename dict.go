@@ -0,0 +1,101 @@
+package gologs
+
+import "time"
+
+// Dictionary builds a nested object from typed fields, for one-off
+// structured values that don't deserve a struct or a map:
+//
+//	logger.Info("Order placed", gologs.Object("order", gologs.Dict().
+//		Str("id", id).
+//		Int("items", len(items)).
+//		Dict("total", gologs.Dict().Float64("amount", 12.5).Str("currency", "EUR"))))
+//	// {..., "order":{"id":"o1","items":3,"total":{"amount":12.5,"currency":"EUR"}}}
+//
+// A Dictionary implements LogObjectMarshaler, so it can be passed to Object
+// and Any, to the key/value methods, or as the message of Log. The methods
+// add a field and return d; a Dictionary must not be changed once it was
+// logged.
+type Dictionary struct {
+	fields []Field
+}
+
+// Dict returns an empty Dictionary.
+func Dict() *Dictionary {
+	return &Dictionary{}
+}
+
+// Str adds a string field.
+func (d *Dictionary) Str(key, value string) *Dictionary {
+	return d.add(String(key, value))
+}
+
+// Int adds an integer field.
+func (d *Dictionary) Int(key string, value int) *Dictionary {
+	return d.add(Int(key, value))
+}
+
+// Int64 adds a 64-bit integer field.
+func (d *Dictionary) Int64(key string, value int64) *Dictionary {
+	return d.add(Int64(key, value))
+}
+
+// Float64 adds a float field.
+func (d *Dictionary) Float64(key string, value float64) *Dictionary {
+	return d.add(Any(key, value))
+}
+
+// Bool adds a boolean field.
+func (d *Dictionary) Bool(key string, value bool) *Dictionary {
+	return d.add(Bool(key, value))
+}
+
+// Dur adds a duration field.
+func (d *Dictionary) Dur(key string, value time.Duration) *Dictionary {
+	return d.add(Dur(key, value))
+}
+
+// Time adds a time field.
+func (d *Dictionary) Time(key string, value time.Time) *Dictionary {
+	return d.add(Time(key, value))
+}
+
+// Err adds an "error" field holding err.Error(). A nil error adds nothing.
+func (d *Dictionary) Err(err error) *Dictionary {
+	return d.add(Err(err))
+}
+
+// Any adds a field holding an arbitrary value.
+func (d *Dictionary) Any(key string, value interface{}) *Dictionary {
+	return d.add(anyField(key, value))
+}
+
+// Dict adds a nested object.
+func (d *Dictionary) Dict(key string, dict *Dictionary) *Dictionary {
+	return d.add(Object(key, dict))
+}
+
+// Fields adds fields created with the field constructors.
+func (d *Dictionary) Fields(fields ...Field) *Dictionary {
+	d.fields = append(d.fields, fields...)
+	return d
+}
+
+func (d *Dictionary) add(f Field) *Dictionary {
+	d.fields = append(d.fields, f)
+	return d
+}
+
+// MarshalLogObject implements LogObjectMarshaler. A nil Dictionary has no
+// fields.
+func (d *Dictionary) MarshalLogObject(fields []Field) []Field {
+	if d == nil {
+		return fields
+	}
+	return append(fields, d.fields...)
+}
+
+// MarshalJSON encodes the fields as a JSON object, for dictionaries nested
+// in values encoded with encoding/json.
+func (d *Dictionary) MarshalJSON() ([]byte, error) {
+	return objectGroup(d).MarshalJSON()
+}
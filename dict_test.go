@@ -0,0 +1,53 @@
+package gologs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tests building nested objects with Dict
+func TestDict(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(INFO, &out, WithCallerInfo(false))
+	order := Dict().
+		Str("id", "o1").
+		Int("items", 3).
+		Bool("gift", false).
+		Dur("took", 2*time.Millisecond).
+		Err(nil).
+		Any("tags", []string{"eu"}).
+		Dict("total", Dict().Float64("amount", 12.5).Str("currency", "EUR"))
+	l.Info("Order placed", Object("order", order))
+	expected := `"order":{"id":"o1","items":3,"gift":false,"took":2000000,"tags":["eu"],"total":{"amount":12.5,"currency":"EUR"}}}`
+	if !strings.HasSuffix(strings.TrimSpace(out.String()), expected) {
+		t.Errorf("Expected %s, got %s", expected, out.String())
+	}
+	out.Reset()
+
+	l.Infow("Retrying", "attempt", Dict().Int("n", 2).Err(errors.New("timeout")))
+	if !strings.Contains(out.String(), `"attempt":{"n":2,"error":"timeout"}`) {
+		t.Errorf("Expected the dictionary as a key/value, got %s", out.String())
+	}
+	out.Reset()
+
+	var empty *Dictionary
+	l.Info("Nothing", Object("none", empty), Object("empty", Dict()))
+	if !strings.Contains(out.String(), `"none":{},"empty":{}`) {
+		t.Errorf("Expected empty objects, got %s", out.String())
+	}
+}
+
+// tests that dictionaries nested in other values are encoded
+func TestDictMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(map[string]interface{}{"d": Dict().Str("k", "v").Int("n", 1)})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(data) != `{"d":{"k":"v","n":1}}` {
+		t.Errorf("Expected the dictionary as an object, got %s", data)
+	}
+}
@@ -0,0 +1,74 @@
+package gologs
+
+import "time"
+
+// DurationFormat selects how duration fields are written.
+type DurationFormat int
+
+const (
+	// NanosecondDurations writes durations as integer nanoseconds, as
+	// encoding/json does.
+	NanosecondDurations DurationFormat = iota
+	// MillisecondDurations writes durations as fractional milliseconds,
+	// such as 1.5, the unit most dashboards expect.
+	MillisecondDurations
+	// StringDurations writes durations as strings such as "1.5ms", as
+	// returned by time.Duration.String.
+	StringDurations
+)
+
+// WithDurationFormat sets how the values of Dur fields, and time.Duration
+// values of other fields, are written by all encoders and sinks. Defaults
+// to NanosecondDurations. Durations in groups and Object fields are
+// converted too, those in lists, maps and messages are not.
+func WithDurationFormat(format DurationFormat) Option {
+	return func(o *options) {
+		o.durationFormat = format
+	}
+}
+
+// formatDurations returns fields with their durations converted to format,
+// and whether there were any. The fields are copied only if one of them
+// changes.
+func formatDurations(fields []Field, format DurationFormat) ([]Field, bool) {
+	copied := false
+	for i, f := range fields {
+		switch {
+		case f.Type == DurationType:
+			f = durationField(f.Key, time.Duration(f.integer), format)
+		case f.Type != AnyType:
+			continue
+		default:
+			switch v := f.Value.(type) {
+			case time.Duration:
+				f = durationField(f.Key, v, format)
+			case group:
+				g, changed := formatDurations(v, format)
+				if !changed {
+					continue
+				}
+				f.Value = group(g)
+			default:
+				continue
+			}
+		}
+		if !copied {
+			fields = append([]Field(nil), fields...)
+			copied = true
+		}
+		fields[i] = f
+	}
+	return fields, copied
+}
+
+// durationField creates a field holding d written in format.
+func durationField(key string, d time.Duration, format DurationFormat) Field {
+	switch format {
+	case MillisecondDurations:
+		return Field{Key: key, Type: AnyType, Value: float64(d) / float64(time.Millisecond)}
+	case StringDurations:
+		return String(key, d.String())
+	default:
+		return Dur(key, d)
+	}
+}
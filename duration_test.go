@@ -0,0 +1,44 @@
+package gologs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tests that durations are written in the configured format
+func TestDurationFormat(t *testing.T) {
+	tests := []struct {
+		format   DurationFormat
+		expected string
+	}{
+		{NanosecondDurations, `"timeout":2000000000,"elapsed":1500000,"db":{"query":250000}}`},
+		{MillisecondDurations, `"timeout":2000,"elapsed":1.5,"db":{"query":0.25}}`},
+		{StringDurations, `"timeout":"2s","elapsed":"1.5ms","db":{"query":"250µs"}}`},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		l := NewLogger(INFO, &out, WithCallerInfo(false), WithDurationFormat(tt.format))
+		l.With("timeout", 2*time.Second).Info("Request done", Dur("elapsed", 1500*time.Microsecond),
+			Group("db", Dur("query", 250*time.Microsecond)))
+		if !strings.HasSuffix(strings.TrimSpace(out.String()), tt.expected) {
+			t.Errorf("Expected %s, got %s", tt.expected, out.String())
+		}
+	}
+}
+
+// tests that formatting durations doesn't change the caller's fields
+func TestFormatDurationsCopies(t *testing.T) {
+	fields := []Field{String("a", "b"), Dur("elapsed", time.Second)}
+	formatted, changed := formatDurations(fields, StringDurations)
+	if !changed || formatted[1].str != "1s" {
+		t.Errorf("Expected the duration to be formatted, got %v", formatted[1])
+	}
+	if fields[1].Type != DurationType {
+		t.Errorf("Expected the original fields to be unchanged, got %v", fields[1])
+	}
+	if _, changed := formatDurations(fields[:1], StringDurations); changed {
+		t.Error("Expected no change without durations")
+	}
+}
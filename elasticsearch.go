@@ -0,0 +1,152 @@
+package gologs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ElasticsearchSink is a Sink that indexes entries in Elasticsearch or
+// OpenSearch using the _bulk API. Entries are sent in batches from a
+// background goroutine; see the HTTPOption functions for batching, retries
+// and authentication. Entries rejected with 429 Too Many Requests are
+// retried with a backoff, like whole requests are.
+type ElasticsearchSink struct {
+	url    string
+	index  string
+	config httpConfig
+	batch  *batcher
+}
+
+// NewElasticsearchSink returns a sink indexing entries in the cluster at url,
+// such as "https://es.example.com:9200". index is the name of the index or
+// data stream. A time layout in braces is replaced with the entry's UTC
+// timestamp, so "logs-{2006.01.02}" writes to one index per day, e.g.
+// "logs-2024.06.01".
+//
+// Use WithBasicAuth for basic authentication, or
+// WithHeader("Authorization", "ApiKey "+key) for an API key.
+func NewElasticsearchSink(url, index string, opts ...HTTPOption) *ElasticsearchSink {
+	s := &ElasticsearchSink{
+		url:    strings.TrimSuffix(url, "/") + "/_bulk",
+		index:  index,
+		config: newHTTPConfig(opts),
+	}
+	s.batch = s.config.startBatcher(s.send)
+	return s
+}
+
+// Write queues the entry to be indexed.
+func (s *ElasticsearchSink) Write(entry LogEntry) error {
+	return s.batch.add(entry)
+}
+
+// Flush indexes all queued entries.
+func (s *ElasticsearchSink) Flush() error {
+	return s.batch.flush()
+}
+
+// Close indexes all queued entries and stops the background goroutine.
+func (s *ElasticsearchSink) Close() error {
+	return s.batch.close()
+}
+
+// Health returns the results of the sent batches and the number of entries
+// waiting to be sent.
+func (s *ElasticsearchSink) Health() SinkHealth {
+	return s.batch.health()
+}
+
+func (s *ElasticsearchSink) batches() *batcher {
+	return s.batch
+}
+
+// indexName returns the index for an entry written at t.
+func (s *ElasticsearchSink) indexName(t time.Time) string {
+	start := strings.IndexByte(s.index, '{')
+	end := strings.LastIndexByte(s.index, '}')
+	if start < 0 || end < start {
+		return s.index
+	}
+	return s.index[:start] + t.UTC().Format(s.index[start+1:end]) + s.index[end+1:]
+}
+
+// bulkResponse is the part of a _bulk response used to find failed items.
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// send indexes a batch of entries. Items rejected with 429 are sent again.
+func (s *ElasticsearchSink) send(entries []LogEntry) error {
+	var rejected int
+	var reason string
+	backoff := s.config.retryBackoff
+	for attempt := 0; len(entries) > 0; attempt++ {
+		if attempt > 0 {
+			s.config.wait(backoff)
+			backoff *= 2
+		}
+
+		var body bytes.Buffer
+		for _, e := range entries {
+			action, err := json.Marshal(map[string]map[string]string{
+				"create": {"_index": s.indexName(e.Timestamp)},
+			})
+			if err != nil {
+				return err
+			}
+			body.Write(action)
+			body.WriteByte('\n')
+			if err := appendJSONEntry(&body, e, EncoderConfig{}); err != nil {
+				return err
+			}
+			body.WriteByte('\n')
+		}
+
+		data, err := s.config.postResponse(s.url, "application/x-ndjson", body.Bytes())
+		if err != nil {
+			return err
+		}
+		var resp bulkResponse
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return fmt.Errorf("elasticsearch: invalid bulk response: %w", err)
+		}
+		if !resp.Errors {
+			break
+		}
+
+		var retry []LogEntry
+		for i, item := range resp.Items {
+			for _, result := range item {
+				switch {
+				case result.Status == http.StatusTooManyRequests && i < len(entries) && attempt < s.config.maxRetries:
+					retry = append(retry, entries[i])
+				case result.Status >= 300:
+					rejected++
+					if reason == "" {
+						reason = fmt.Sprintf("status %d", result.Status)
+						if result.Error.Type != "" {
+							reason = result.Error.Type + ": " + result.Error.Reason
+						}
+					}
+				}
+			}
+		}
+		entries = retry
+	}
+
+	if rejected > 0 {
+		return fmt.Errorf("elasticsearch: %d entries rejected: %s", rejected, reason)
+	}
+	return nil
+}
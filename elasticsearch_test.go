@@ -0,0 +1,76 @@
+package gologs
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tests indexing entries with the bulk API, retrying items rejected with 429
+func TestElasticsearchSink(t *testing.T) {
+	var mu sync.Mutex
+	var requests [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_bulk" || r.Header.Get("Content-Type") != "application/x-ndjson" {
+			t.Errorf("Expected NDJSON bulk request, got %v %v", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		if r.Header.Get("Authorization") != "ApiKey secret" {
+			t.Errorf("Expected API key, got %v", r.Header.Get("Authorization"))
+		}
+		var lines []string
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		mu.Lock()
+		requests = append(requests, lines)
+		first := len(requests) == 1
+		mu.Unlock()
+
+		if first {
+			w.Write([]byte(`{"errors":true,"items":[{"create":{"status":201}},{"create":{"status":429,"error":{"type":"es_rejected_execution_exception"}}}]}`))
+			return
+		}
+		w.Write([]byte(`{"errors":false,"items":[{"create":{"status":201}}]}`))
+	}))
+	defer server.Close()
+
+	sink := NewElasticsearchSink(server.URL, "logs-{2006.01.02}", WithHeader("Authorization", "ApiKey secret"))
+	sink.config.retryBackoff = time.Millisecond
+	day := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	sink.Write(LogEntry{Level: "INFO", Timestamp: day, Data: "first"})
+	sink.Write(LogEntry{Level: "WARN", Timestamp: day, Data: "second"})
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %v", requests)
+	}
+	if requests[0][0] != `{"create":{"_index":"logs-2024.06.01"}}` {
+		t.Errorf("Expected templated index, got %v", requests[0][0])
+	}
+	if len(requests[1]) != 2 || !strings.Contains(requests[1][1], `"data":"second"`) {
+		t.Errorf("Expected only the rejected entry to be retried, got %v", requests[1])
+	}
+}
+
+// tests that items rejected for other reasons are reported
+func TestElasticsearchSinkRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"errors":true,"items":[{"create":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"failed to parse"}}}]}`))
+	}))
+	defer server.Close()
+
+	sink := NewElasticsearchSink(server.URL, "logs")
+	defer sink.Close()
+	sink.Write(LogEntry{Data: "bad"})
+	err := sink.Flush()
+	if err == nil || !strings.Contains(err.Error(), "1 entries rejected: mapper_parsing_exception") {
+		t.Errorf("Expected rejection error, got %v", err)
+	}
+}
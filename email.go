@@ -0,0 +1,194 @@
+package gologs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EmailConfig configures an EmailSink.
+type EmailConfig struct {
+	// Addr is the address of the SMTP server, such as
+	// "smtp.example.com:587". STARTTLS is used if the server supports it.
+	Addr string
+	// Auth authenticates with the server, for example smtp.PlainAuth. No
+	// authentication is used if it is nil.
+	Auth smtp.Auth
+	// From is the sender address.
+	From string
+	// To are the recipient addresses.
+	To []string
+	// SubjectPrefix is prepended to the subject, such as "[shop] ".
+	SubjectPrefix string
+	// MinLevel is the lowest level that sends an email. The zero value,
+	// DEBUG, means FATAL.
+	MinLevel LogLevel
+	// Context is the number of preceding entries included in an email.
+	// Defaults to 20; a negative number includes none.
+	Context int
+	// Interval is the minimum time between two emails. Entries that would
+	// send an email sooner are counted and mentioned in the next email.
+	// Defaults to 10 minutes.
+	Interval time.Duration
+}
+
+// EmailSink is a Sink that sends an email when an entry at or above a
+// minimum level (FATAL by default) is written, together with the entries
+// written before it, so the cause of a crash can be seen without access to
+// the logs. The email is sent from Write, so it has been delivered by the
+// time a Fatal call exits the program.
+//
+// The sink keeps the most recent entries it is given in memory; entries
+// filtered out by the logger's level don't reach it.
+type EmailSink struct {
+	addr     string
+	auth     smtp.Auth
+	from     string
+	to       []string
+	prefix   string
+	minLevel LogLevel
+	interval time.Duration
+	encoder  ConsoleEncoder
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+	mu         sync.Mutex
+	recent     []LogEntry
+	next       int
+	lastSent   time.Time
+	suppressed int
+}
+
+// NewEmailSink returns a sink sending emails as configured by cfg. It
+// returns an error if the server, sender or recipients are missing.
+func NewEmailSink(cfg EmailConfig) (*EmailSink, error) {
+	if cfg.Addr == "" || cfg.From == "" || len(cfg.To) == 0 {
+		return nil, errors.New("email: server address, sender and recipients are required")
+	}
+	s := &EmailSink{
+		addr:     cfg.Addr,
+		auth:     cfg.Auth,
+		from:     cfg.From,
+		to:       cfg.To,
+		prefix:   cfg.SubjectPrefix,
+		minLevel: cfg.MinLevel,
+		interval: cfg.Interval,
+		encoder:  ConsoleEncoder{EncoderConfig: EncoderConfig{TimeFormat: time.RFC3339Nano}},
+		sendMail: smtp.SendMail,
+	}
+	if s.minLevel == DEBUG {
+		s.minLevel = FATAL
+	}
+	if s.interval == 0 {
+		s.interval = 10 * time.Minute
+	}
+	context := cfg.Context
+	if context == 0 {
+		context = 20
+	}
+	s.recent = make([]LogEntry, 0, max(context, 0))
+	return s, nil
+}
+
+// Write records the entry and sends an email if its level is at least the
+// minimum level.
+func (s *EmailSink) Write(entry LogEntry) error {
+	s.mu.Lock()
+	if entry.Severity < s.minLevel {
+		s.remember(entry)
+		s.mu.Unlock()
+		return nil
+	}
+	if !s.lastSent.IsZero() && entry.Timestamp.Sub(s.lastSent) < s.interval {
+		s.suppressed++
+		s.remember(entry)
+		s.mu.Unlock()
+		return nil
+	}
+	context := s.context()
+	suppressed := s.suppressed
+	s.lastSent = entry.Timestamp
+	s.suppressed = 0
+	s.remember(entry)
+	s.mu.Unlock()
+
+	msg, err := s.message(entry, context, suppressed)
+	if err != nil {
+		return err
+	}
+	if err := s.sendMail(s.addr, s.auth, s.from, s.to, msg); err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	return nil
+}
+
+// remember adds an entry to the ring of recent entries.
+func (s *EmailSink) remember(entry LogEntry) {
+	if cap(s.recent) == 0 {
+		return
+	}
+	if len(s.recent) < cap(s.recent) {
+		s.recent = append(s.recent, entry)
+		return
+	}
+	s.recent[s.next] = entry
+	s.next = (s.next + 1) % len(s.recent)
+}
+
+// context returns the recent entries, oldest first.
+func (s *EmailSink) context() []LogEntry {
+	context := make([]LogEntry, 0, len(s.recent))
+	context = append(context, s.recent[s.next:]...)
+	return append(context, s.recent[:s.next]...)
+}
+
+// message returns the email for an entry.
+func (s *EmailSink) message(entry LogEntry, context []LogEntry, suppressed int) ([]byte, error) {
+	var subject bytes.Buffer
+	subject.WriteString(s.prefix + entry.Level + ": ")
+	if err := appendMessageText(&subject, entry.Data); err != nil {
+		return nil, err
+	}
+	var body bytes.Buffer
+	if err := s.encoder.Encode(entry, &body); err != nil {
+		return nil, err
+	}
+	if len(context) > 0 {
+		body.WriteString("\nPreceding entries:\n\n")
+		for _, e := range context {
+			if err := s.encoder.Encode(e, &body); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if suppressed > 0 {
+		body.WriteString("\n" + strconv.Itoa(suppressed) + " more entries at or above " +
+			logLevelString(s.minLevel) + " were not mailed since the last email.\n")
+	}
+
+	var msg bytes.Buffer
+	msg.WriteString("From: " + s.from + "\r\n")
+	msg.WriteString("To: " + strings.Join(s.to, ", ") + "\r\n")
+	msg.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", strings.Join(strings.Fields(subject.String()), " ")) + "\r\n")
+	msg.WriteString("Date: " + entry.Timestamp.Format(time.RFC1123Z) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body.String(), "\n", "\r\n"))
+	return msg.Bytes(), nil
+}
+
+// Flush does nothing; emails are sent as entries are written.
+func (s *EmailSink) Flush() error {
+	return nil
+}
+
+// Close does nothing; emails are sent as entries are written.
+func (s *EmailSink) Close() error {
+	return nil
+}
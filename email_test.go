@@ -0,0 +1,92 @@
+package gologs
+
+import (
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tests that a FATAL entry sends an email with the preceding entries
+func TestEmailSink(t *testing.T) {
+	sink, err := NewEmailSink(EmailConfig{
+		Addr:          "smtp.example.com:587",
+		From:          "app@example.com",
+		To:            []string{"ops@example.com", "dev@example.com"},
+		SubjectPrefix: "[shop] ",
+		Context:       2,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var sent []string
+	var recipients []string
+	sink.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sent = append(sent, string(msg))
+		recipients = to
+		return nil
+	}
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, msg := range []string{"Starting", "Connecting", "Retrying"} {
+		sink.Write(LogEntry{Level: "INFO", Severity: INFO, Timestamp: start.Add(time.Duration(i) * time.Second), Data: msg})
+	}
+	if err := sink.Write(LogEntry{Level: "FATAL", Severity: FATAL, Timestamp: start.Add(time.Minute), Data: "Database unreachable"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(sent) != 1 {
+		t.Fatalf("Expected 1 email, got %d", len(sent))
+	}
+	if len(recipients) != 2 {
+		t.Errorf("Expected 2 recipients, got %v", recipients)
+	}
+	msg := sent[0]
+	for _, want := range []string{
+		"To: ops@example.com, dev@example.com\r\n",
+		"Subject: [shop] FATAL: Database unreachable\r\n",
+		"FATAL Database unreachable\r\n",
+		"Preceding entries:",
+		"INFO  Connecting\r\n",
+		"INFO  Retrying\r\n",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("Expected email to contain %q, got %s", want, msg)
+		}
+	}
+	if strings.Contains(msg, "Starting") {
+		t.Errorf("Expected only the last 2 entries, got %s", msg)
+	}
+}
+
+// tests that emails are rate limited and suppressed entries are counted
+func TestEmailSinkInterval(t *testing.T) {
+	sink, err := NewEmailSink(EmailConfig{Addr: "localhost:25", From: "a@example.com", To: []string{"b@example.com"}, MinLevel: ERROR})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var sent []string
+	sink.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sent = append(sent, string(msg))
+		return nil
+	}
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, offset := range []time.Duration{0, time.Minute, 2 * time.Minute, 11 * time.Minute} {
+		sink.Write(LogEntry{Level: "ERROR", Severity: ERROR, Timestamp: start.Add(offset), Data: "Failed"})
+	}
+
+	if len(sent) != 2 {
+		t.Fatalf("Expected 2 emails, got %d", len(sent))
+	}
+	if !strings.Contains(sent[1], "2 more entries at or above ERROR were not mailed") {
+		t.Errorf("Expected count of suppressed entries, got %s", sent[1])
+	}
+}
+
+// tests that the server, sender and recipients are required
+func TestEmailSinkInvalidConfig(t *testing.T) {
+	if _, err := NewEmailSink(EmailConfig{Addr: "localhost:25", From: "a@example.com"}); err == nil {
+		t.Errorf("Expected error, got %v", err)
+	}
+}
@@ -0,0 +1,473 @@
+package gologs
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Encoder turns a log entry into bytes. Encode appends the complete record,
+// including any trailing newline or framing, to buf.
+type Encoder interface {
+	Encode(entry LogEntry, buf *bytes.Buffer) error
+}
+
+// EncoderConfig holds the settings shared by the built-in encoders. Logger
+// options such as WithTimestampFormat change these settings on the logger's
+// encoder.
+type EncoderConfig struct {
+	// TimeFormat is the time.Format layout used for timestamps, such as
+	// time.RFC3339, or one of the TimeFormatUnix formats. Each encoder has
+	// its own default.
+	TimeFormat string
+	// Keys renames the standard keys written by the JSON and logfmt
+	// encoders.
+	Keys KeyNames
+	// LevelNumbers makes the JSON and logfmt encoders write levels as
+	// numbers, for backends that sort and filter by severity. The number
+	// replaces the level name, or, if LevelNumberKey is set, is written
+	// under that key after it.
+	LevelNumbers   LevelNumbers
+	LevelNumberKey string
+	// Indent makes the JSON encoder write each entry as indented,
+	// multi-line JSON, indenting nested values by Indent. Meant for reading
+	// logs in a terminal during development; leave it empty for compact
+	// NDJSON in production.
+	Indent string
+	// NoHTMLEscape makes the JSON encoder write &, < and > as they are
+	// instead of as \u0026, \u003c and \u003e, which only matters when
+	// logs are embedded in HTML.
+	NoHTMLEscape bool
+	// LowercaseLevels makes the JSON and console encoders write level
+	// names in lower case, such as "info". The logfmt encoder always does.
+	LowercaseLevels bool
+}
+
+// LevelNumbers selects the numbers that levels are written as.
+type LevelNumbers int
+
+const (
+	// NoLevelNumbers writes level names only.
+	NoLevelNumbers LevelNumbers = iota
+	// SyslogLevelNumbers writes RFC 5424 severities, from 7 for DEBUG and
+	// TRACE down to 1 for FATAL. Lower numbers are more severe.
+	SyslogLevelNumbers
+	// OTelLevelNumbers writes OpenTelemetry severity numbers, from 1 for
+	// TRACE up to 21 for FATAL.
+	OTelLevelNumbers
+)
+
+// number returns the number of level, and false for NoLevelNumbers.
+func (n LevelNumbers) number(level LogLevel) (int, bool) {
+	switch n {
+	case SyslogLevelNumbers:
+		return syslogSeverity(level), true
+	case OTelLevelNumbers:
+		return otlpSeverity(level), true
+	default:
+		return 0, false
+	}
+}
+
+// KeyNames are the keys of the standard attributes of an entry, so that
+// the output can match what existing parsers and dashboards expect, such
+// as "severity", "@timestamp" and "msg". Empty keys keep the defaults of
+// the encoder. Fields whose key clashes with one of these keys are written
+// as "fields.<key>" by the JSON encoder.
+type KeyNames struct {
+	Level   string
+	Time    string
+	Message string
+	Source  string
+	Caller  string
+}
+
+// level returns the level name to write, in lower case if configured.
+func (c EncoderConfig) level(level string) string {
+	if c.LowercaseLevels {
+		return lowerLevel(level)
+	}
+	return level
+}
+
+// lowerLevel returns level in lower case, without allocating for the
+// standard levels.
+func lowerLevel(level string) string {
+	switch level {
+	case "TRACE":
+		return "trace"
+	case "DEBUG":
+		return "debug"
+	case "INFO":
+		return "info"
+	case "WARN":
+		return "warn"
+	case "ERROR":
+		return "error"
+	case "PANIC":
+		return "panic"
+	case "FATAL":
+		return "fatal"
+	default:
+		return strings.ToLower(level)
+	}
+}
+
+// keyOr returns key, or def if key is empty.
+func keyOr(key, def string) string {
+	if key != "" {
+		return key
+	}
+	return def
+}
+
+// reserved reports whether a field with key clashes with the standard keys
+// of the JSON encoder.
+func (c EncoderConfig) reserved(key string) bool {
+	k := c.Keys
+	return key == keyOr(k.Level, "level") || key == keyOr(k.Time, "timestamp") || key == keyOr(k.Message, "data") ||
+		key == keyOr(k.Source, "source") || key == keyOr(k.Caller, "caller") ||
+		c.LevelNumbers != NoLevelNumbers && key == c.LevelNumberKey
+}
+
+// Timestamp formats for EncoderConfig.TimeFormat and WithTimestampFormat
+// that write timestamps as whole seconds, milliseconds, microseconds or
+// nanoseconds since the Unix epoch. The JSON encoder writes them as
+// numbers.
+const (
+	TimeFormatUnix      = "unix"
+	TimeFormatUnixMilli = "unixmilli"
+	TimeFormatUnixMicro = "unixmicro"
+	TimeFormatUnixNano  = "unixnano"
+)
+
+// appendTime appends t formatted with layout to dst, and reports whether
+// layout is one of the numeric TimeFormatUnix formats.
+func appendTime(dst []byte, t time.Time, layout string) ([]byte, bool) {
+	if n, ok := unixTime(t, layout); ok {
+		return strconv.AppendInt(dst, n, 10), true
+	}
+	return t.AppendFormat(dst, layout), false
+}
+
+// unixTime returns t as a number for the TimeFormatUnix formats, and false
+// for other layouts.
+func unixTime(t time.Time, layout string) (int64, bool) {
+	switch layout {
+	case TimeFormatUnix:
+		return t.Unix(), true
+	case TimeFormatUnixMilli:
+		return t.UnixMilli(), true
+	case TimeFormatUnixMicro:
+		return t.UnixMicro(), true
+	case TimeFormatUnixNano:
+		return t.UnixNano(), true
+	default:
+		return 0, false
+	}
+}
+
+// formatTime returns t formatted with layout.
+func formatTime(t time.Time, layout string) string {
+	b, _ := appendTime(nil, t, layout)
+	return string(b)
+}
+
+// configurableEncoder is implemented by encoders that accept EncoderConfig
+// changes from Logger options.
+type configurableEncoder interface {
+	withConfig(fn func(*EncoderConfig)) Encoder
+}
+
+// timeFormat returns the configured time layout, or def if none is set.
+func (c EncoderConfig) timeFormat(def string) string {
+	if c.TimeFormat != "" {
+		return c.TimeFormat
+	}
+	return def
+}
+
+// bufferPool holds buffers for encoding entries, so that sinks don't
+// allocate a new one for every entry.
+var bufferPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// putBuffer returns buf to the pool. Buffers grown by unusually large
+// entries are dropped instead, so the pool doesn't hold on to them.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > 64<<10 {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// JSONEncoder encodes entries as JSON, one object per line. It is the
+// default encoder. Timestamps default to RFC 3339 with nanoseconds.
+type JSONEncoder struct {
+	EncoderConfig
+}
+
+// Encode appends the entry as a single line of JSON, or as indented JSON
+// if Indent is set.
+func (e JSONEncoder) Encode(entry LogEntry, buf *bytes.Buffer) error {
+	if e.Indent != "" {
+		compact := getBuffer()
+		defer putBuffer(compact)
+		if err := e.appendEntry(compact, entry); err != nil {
+			return err
+		}
+		if err := json.Indent(buf, compact.Bytes(), "", e.Indent); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		return nil
+	}
+	if err := e.appendEntry(buf, entry); err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+	return nil
+}
+
+// appendEntry writes the entry to buf as a JSON object, without escaping
+// HTML characters if NoHTMLEscape is set.
+func (e JSONEncoder) appendEntry(buf *bytes.Buffer, entry LogEntry) error {
+	start := buf.Len()
+	if err := appendJSONEntry(buf, entry, e.EncoderConfig); err != nil {
+		return err
+	}
+	if e.NoHTMLEscape {
+		buf.Truncate(start + len(unescapeHTML(buf.Bytes()[start:])))
+	}
+	return nil
+}
+
+// unescapeHTML replaces the escapes of &, < and > in the JSON data with the
+// characters themselves, in place, and returns the shortened data.
+func unescapeHTML(data []byte) []byte {
+	n := 0
+	for i := 0; i < len(data); i++ {
+		if data[i] != '\\' {
+			data[n] = data[i]
+			n++
+			continue
+		}
+		if i+5 < len(data) && data[i+1] == 'u' && data[i+2] == '0' && data[i+3] == '0' {
+			switch string(data[i+4 : i+6]) {
+			case "26":
+				data[n] = '&'
+			case "3c":
+				data[n] = '<'
+			case "3e":
+				data[n] = '>'
+			default:
+				data[n] = '\\'
+				n++
+				continue
+			}
+			n++
+			i += 5
+			continue
+		}
+		// Copy other escapes whole, so an escaped backslash can't start
+		// one of the escapes above.
+		data[n], data[n+1] = data[i], data[i+1]
+		n += 2
+		i++
+	}
+	return data[:n]
+}
+
+func (e JSONEncoder) withConfig(fn func(*EncoderConfig)) Encoder {
+	fn(&e.EncoderConfig)
+	return e
+}
+
+// MarshalJSON encodes the entry, writing its fields as top-level keys after
+// the standard ones, or in the layout of its SchemaVersion.
+func (e LogEntry) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := appendJSONEntry(&buf, e, EncoderConfig{}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// appendJSONTime writes t formatted with layout to buf as a JSON string, or
+// as a number for the TimeFormatUnix formats, without allocating unless the
+// formatted time needs escaping.
+func appendJSONTime(buf *bytes.Buffer, t time.Time, layout string) {
+	if dst, numeric := appendTime(buf.AvailableBuffer(), t, layout); numeric {
+		buf.Write(dst)
+		return
+	}
+	dst := append(buf.AvailableBuffer(), '"')
+	dst = t.AppendFormat(dst, layout)
+	for _, b := range dst[1:] {
+		if b < 0x20 || b >= utf8.RuneSelf || b == '"' || b == '\\' || b == '<' || b == '>' || b == '&' {
+			buf.Write(appendJSONString(buf.AvailableBuffer(), t.Format(layout)))
+			return
+		}
+	}
+	buf.Write(append(dst, '"'))
+}
+
+// appendJSONKey writes a key and a colon to buf, where def is the default
+// key already quoted.
+func appendJSONKey(buf *bytes.Buffer, key, def string) {
+	if key == "" {
+		buf.WriteString(def)
+	} else {
+		buf.Write(appendJSONString(buf.AvailableBuffer(), key))
+	}
+	buf.WriteByte(':')
+}
+
+// appendJSONEntry writes the entry to buf as a JSON object.
+func appendJSONEntry(buf *bytes.Buffer, e LogEntry, cfg EncoderConfig) error {
+	keys := cfg.Keys
+	buf.WriteByte('{')
+	appendJSONSchemaVersion(buf, e.SchemaVersion)
+	if e.Level != "" {
+		appendJSONKey(buf, keys.Level, `"level"`)
+		n, numeric := cfg.LevelNumbers.number(e.Severity)
+		if numeric && cfg.LevelNumberKey == "" {
+			buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(n), 10))
+		} else {
+			buf.Write(appendJSONString(buf.AvailableBuffer(), cfg.level(e.Level)))
+		}
+		buf.WriteByte(',')
+		if numeric && cfg.LevelNumberKey != "" {
+			appendJSONKey(buf, cfg.LevelNumberKey, "")
+			buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(n), 10))
+			buf.WriteByte(',')
+		}
+	}
+	appendJSONKey(buf, keys.Time, `"timestamp"`)
+	appendJSONTime(buf, e.Timestamp, cfg.timeFormat(time.RFC3339Nano))
+	if e.SchemaVersion >= SchemaV2 {
+		return appendJSONEntryV2(buf, e, cfg)
+	}
+	if e.Source != "" {
+		buf.WriteByte(',')
+		appendJSONKey(buf, keys.Source, `"source"`)
+		buf.Write(appendJSONString(buf.AvailableBuffer(), e.Source))
+	}
+	if e.Caller != "" {
+		buf.WriteByte(',')
+		appendJSONKey(buf, keys.Caller, `"caller"`)
+		buf.Write(appendJSONString(buf.AvailableBuffer(), e.Caller))
+	}
+	buf.WriteByte(',')
+	appendJSONKey(buf, keys.Message, `"data"`)
+	if err := appendJSONValue(buf, e.Data); err != nil {
+		return err
+	}
+
+	for _, f := range e.Fields {
+		if f.Type == skipType {
+			continue
+		}
+		key := f.Key
+		if cfg.reserved(key) || e.SchemaVersion != 0 && key == schemaVersionKey {
+			key = "fields." + key
+		}
+		buf.WriteByte(',')
+		buf.Write(appendJSONString(buf.AvailableBuffer(), key))
+		buf.WriteByte(':')
+		if err := appendFieldValue(buf, f); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// binaryFormat holds the functions writing the values of a binary encoding
+// such as MessagePack or CBOR, so that the encoders share the layout of
+// their entries.
+type binaryFormat struct {
+	mapHeader func(buf []byte, n int) []byte
+	str       func(buf []byte, s string) []byte
+	integer   func(buf []byte, v int64) []byte
+	value     func(buf []byte, v interface{}) ([]byte, error)
+	field     func(buf []byte, f Field) ([]byte, error)
+}
+
+// appendBinaryEntry appends the entry as a map with the keys of the JSON
+// encoder. Timestamps default to nanoseconds since the Unix epoch, and are
+// written as strings for layouts other than the TimeFormatUnix formats.
+func appendBinaryEntry(buf []byte, e LogEntry, cfg EncoderConfig, bf binaryFormat) ([]byte, error) {
+	keys := cfg.Keys
+	number, numeric := cfg.LevelNumbers.number(e.Severity)
+	n := 2
+	for _, s := range []string{e.Level, e.Source, e.Caller} {
+		if s != "" {
+			n++
+		}
+	}
+	if e.Level != "" && numeric && cfg.LevelNumberKey != "" {
+		n++
+	}
+	for _, f := range e.Fields {
+		if f.Type != skipType {
+			n++
+		}
+	}
+
+	buf = bf.mapHeader(buf, n)
+	if e.Level != "" {
+		buf = bf.str(buf, keyOr(keys.Level, "level"))
+		if numeric && cfg.LevelNumberKey == "" {
+			buf = bf.integer(buf, int64(number))
+		} else {
+			buf = bf.str(buf, cfg.level(e.Level))
+		}
+		if numeric && cfg.LevelNumberKey != "" {
+			buf = bf.str(buf, cfg.LevelNumberKey)
+			buf = bf.integer(buf, int64(number))
+		}
+	}
+	buf = bf.str(buf, keyOr(keys.Time, "timestamp"))
+	layout := cfg.timeFormat(TimeFormatUnixNano)
+	if t, ok := unixTime(e.Timestamp, layout); ok {
+		buf = bf.integer(buf, t)
+	} else {
+		buf = bf.str(buf, e.Timestamp.Format(layout))
+	}
+	if e.Source != "" {
+		buf = bf.str(buf, keyOr(keys.Source, "source"))
+		buf = bf.str(buf, e.Source)
+	}
+	if e.Caller != "" {
+		buf = bf.str(buf, keyOr(keys.Caller, "caller"))
+		buf = bf.str(buf, e.Caller)
+	}
+	buf = bf.str(buf, keyOr(keys.Message, "data"))
+	buf, err := bf.value(buf, e.Data)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range e.Fields {
+		if f.Type == skipType {
+			continue
+		}
+		key := f.Key
+		if cfg.reserved(key) {
+			key = "fields." + key
+		}
+		buf = bf.str(buf, key)
+		if buf, err = bf.field(buf, f); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
@@ -0,0 +1,240 @@
+package gologs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// levelMessageEncoder is a minimal custom encoder used in tests.
+type levelMessageEncoder struct{}
+
+func (levelMessageEncoder) Encode(entry LogEntry, buf *bytes.Buffer) error {
+	_, err := fmt.Fprintf(buf, "%s %v\n", entry.Level, entry.Data)
+	return err
+}
+
+// tests plugging in a custom encoder
+func TestCustomEncoder(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(DEBUG, &out, WithEncoder(levelMessageEncoder{}))
+	l.Info("Hello %s", "world")
+	l.Warn("Careful")
+	expected := "INFO Hello world\nWARN Careful\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+}
+
+// tests that the JSON encoder writes one valid JSON object per line
+func TestJSONEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	entry := LogEntry{Level: "INFO", Data: "message", Fields: []Field{Int("n", 1)}}
+	if err := (JSONEncoder{}).Encode(entry, &buf); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	line := buf.Bytes()
+	if line[len(line)-1] != '\n' {
+		t.Errorf("Expected trailing newline, got %q", line)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(line, &decoded); err != nil {
+		t.Errorf("Expected valid JSON, got %v", err)
+	}
+	if decoded["n"] != float64(1) {
+		t.Errorf("Expected field n=1, got %v", decoded["n"])
+	}
+}
+
+// tests that values written without encoding/json match its output
+func TestAppendJSONValue(t *testing.T) {
+	values := []interface{}{nil, "a \"quoted\" <tag>\n", true, -42, int8(-8), uint16(16), uint64(1 << 63),
+		1.5, 0.0, -0.000001, 1e-7, 123456789.0, 1e21, float32(3.14), float32(1e-7), []int{1}, map[string]bool{"ok": true}}
+	for _, v := range values {
+		var buf bytes.Buffer
+		if err := appendJSONValue(&buf, v); err != nil {
+			t.Errorf("Expected no error for %v, got %v", v, err)
+		}
+		want, _ := json.Marshal(v)
+		if buf.String() != string(want) {
+			t.Errorf("Expected %s for %#v, got %s", want, v, buf.String())
+		}
+	}
+	if err := appendJSONValue(&bytes.Buffer{}, math.NaN()); err == nil {
+		t.Errorf("Expected error for NaN, got %v", err)
+	}
+}
+
+// tests that encoding a plain message with typed fields doesn't allocate
+func TestJSONEncoderAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations differ under the race detector")
+	}
+	entry := LogEntry{Level: "INFO", Timestamp: time.Now(), Source: "main.go:10", Data: "request served",
+		Fields: []Field{String("path", "/"), Int("status", 200), Bool("cached", true), Dur("took", time.Millisecond)}}
+	buf := bytes.NewBuffer(make([]byte, 0, 1024))
+	allocs := testing.AllocsPerRun(100, func() {
+		buf.Reset()
+		(JSONEncoder{}).Encode(entry, buf)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocations, got %v", allocs)
+	}
+}
+
+// tests that timestamps needing escaping are still valid JSON
+func TestJSONEncoderTimeFormat(t *testing.T) {
+	var buf bytes.Buffer
+	entry := LogEntry{Timestamp: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), Data: "message"}
+	(JSONEncoder{EncoderConfig{TimeFormat: `"2006"`}}).Encode(entry, &buf)
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded["timestamp"] != `"2024"` {
+		t.Errorf("Expected escaped timestamp, got %s (%v)", buf.String(), err)
+	}
+}
+
+// tests the timestamp formats of the encoders
+func TestTimestampFormats(t *testing.T) {
+	entry := LogEntry{Level: "INFO", Timestamp: time.Date(2024, 1, 1, 12, 0, 0, 123456789, time.UTC), Data: "message"}
+	for format, want := range map[string]string{
+		time.RFC3339:        `"timestamp":"2024-01-01T12:00:00Z"`,
+		time.RFC3339Nano:    `"timestamp":"2024-01-01T12:00:00.123456789Z"`,
+		TimeFormatUnix:      `"timestamp":1704110400,`,
+		TimeFormatUnixMilli: `"timestamp":1704110400123,`,
+		TimeFormatUnixMicro: `"timestamp":1704110400123456,`,
+		TimeFormatUnixNano:  `"timestamp":1704110400123456789,`,
+	} {
+		var buf bytes.Buffer
+		(JSONEncoder{EncoderConfig{TimeFormat: format}}).Encode(entry, &buf)
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected %s for %s, got %s", want, format, buf.String())
+		}
+	}
+
+	var buf bytes.Buffer
+	(LogfmtEncoder{EncoderConfig{TimeFormat: TimeFormatUnixMilli}}).Encode(entry, &buf)
+	if !strings.HasPrefix(buf.String(), "ts=1704110400123 ") {
+		t.Errorf("Expected a numeric logfmt timestamp, got %s", buf.String())
+	}
+}
+
+func BenchmarkJSONEncoder(b *testing.B) {
+	entry := LogEntry{Level: "INFO", Timestamp: time.Now(), Source: "main.go:10", Data: "request served",
+		Fields: []Field{String("path", "/"), Int("status", 200), Dur("took", time.Millisecond)}}
+	var buf bytes.Buffer
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		(JSONEncoder{}).Encode(entry, &buf)
+	}
+}
+
+// tests renaming the standard keys
+func TestKeyNames(t *testing.T) {
+	keys := KeyNames{Level: "severity", Time: "@timestamp", Message: "msg", Source: "src"}
+	entry := LogEntry{Level: "INFO", Timestamp: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), Source: "main.go:10", Caller: "main",
+		Data: "started", Fields: []Field{String("msg", "field"), String("level", "kept")}}
+
+	var buf bytes.Buffer
+	(JSONEncoder{EncoderConfig{TimeFormat: time.RFC3339, Keys: keys}}).Encode(entry, &buf)
+	want := `{"severity":"INFO","@timestamp":"2024-01-01T12:00:00Z","src":"main.go:10","caller":"main","msg":"started","fields.msg":"field","level":"kept"}`
+	if strings.TrimSpace(buf.String()) != want {
+		t.Errorf("Expected %s, got %s", want, buf.String())
+	}
+
+	buf.Reset()
+	(LogfmtEncoder{EncoderConfig{TimeFormat: time.RFC3339, Keys: keys}}).Encode(entry, &buf)
+	want = `@timestamp=2024-01-01T12:00:00Z severity=info msg=started src=main.go:10 caller=main msg=field level=kept`
+	if strings.TrimSpace(buf.String()) != want {
+		t.Errorf("Expected %s, got %s", want, buf.String())
+	}
+}
+
+// tests writing levels as numbers instead of and alongside their names
+func TestLevelNumbers(t *testing.T) {
+	entry := LogEntry{Level: "WARN", Severity: WARN, Timestamp: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), Data: "slow",
+		Fields: []Field{String("severity", "field")}}
+	for _, tt := range []struct {
+		cfg        EncoderConfig
+		json, text string
+	}{
+		{EncoderConfig{LevelNumbers: SyslogLevelNumbers},
+			`{"level":4,"timestamp":"2024-01-01T12:00:00Z","data":"slow","severity":"field"}`,
+			`ts=2024-01-01T12:00:00Z level=4 msg=slow severity=field`},
+		{EncoderConfig{LevelNumbers: OTelLevelNumbers, LevelNumberKey: "severity"},
+			`{"level":"WARN","severity":13,"timestamp":"2024-01-01T12:00:00Z","data":"slow","fields.severity":"field"}`,
+			`ts=2024-01-01T12:00:00Z level=warn severity=13 msg=slow severity=field`},
+	} {
+		tt.cfg.TimeFormat = time.RFC3339
+		var buf bytes.Buffer
+		(JSONEncoder{tt.cfg}).Encode(entry, &buf)
+		if strings.TrimSpace(buf.String()) != tt.json {
+			t.Errorf("Expected %s, got %s", tt.json, buf.String())
+		}
+		buf.Reset()
+		(LogfmtEncoder{tt.cfg}).Encode(entry, &buf)
+		if strings.TrimSpace(buf.String()) != tt.text {
+			t.Errorf("Expected %s, got %s", tt.text, buf.String())
+		}
+	}
+}
+
+// tests writing level names in lower case
+func TestLowercaseLevels(t *testing.T) {
+	cfg := EncoderConfig{TimeFormat: time.TimeOnly, LowercaseLevels: true}
+	entry := LogEntry{Level: "WARN", Severity: WARN, Timestamp: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), Data: "slow"}
+
+	var buf bytes.Buffer
+	(JSONEncoder{cfg}).Encode(entry, &buf)
+	if want := `{"level":"warn","timestamp":"12:00:00","data":"slow"}`; strings.TrimSpace(buf.String()) != want {
+		t.Errorf("Expected %s, got %s", want, buf.String())
+	}
+	buf.Reset()
+	(ConsoleEncoder{EncoderConfig: cfg}).Encode(entry, &buf)
+	if want := "[12:00:00] warn  slow"; strings.TrimSpace(buf.String()) != want {
+		t.Errorf("Expected %q, got %q", want, buf.String())
+	}
+
+	l := NewLogger(INFO, &buf, WithLowercaseLevels(), WithCallerInfo(false))
+	buf.Reset()
+	l.Error("failed")
+	if !strings.HasPrefix(buf.String(), `{"level":"error",`) {
+		t.Errorf("Expected a lowercase level, got %s", buf.String())
+	}
+}
+
+// tests writing entries as indented JSON
+func TestPrettyJSON(t *testing.T) {
+	entry := LogEntry{Level: "INFO", Timestamp: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), Data: "started",
+		Fields: []Field{Int("port", 8080)}}
+	var buf bytes.Buffer
+	if err := (JSONEncoder{EncoderConfig{TimeFormat: time.RFC3339, Indent: "  "}}).Encode(entry, &buf); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := "{\n  \"level\": \"INFO\",\n  \"timestamp\": \"2024-01-01T12:00:00Z\",\n  \"data\": \"started\",\n  \"port\": 8080\n}\n"
+	if buf.String() != want {
+		t.Errorf("Expected %q, got %q", want, buf.String())
+	}
+}
+
+// tests writing HTML characters without escaping them
+func TestNoHTMLEscape(t *testing.T) {
+	entry := LogEntry{Timestamp: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), Data: `<a href="?x=1&y=2">`,
+		Fields: []Field{String("path", `C:\u003c`), Any("tags", []string{"<b>"})}}
+	var buf bytes.Buffer
+	(JSONEncoder{EncoderConfig{TimeFormat: time.RFC3339, NoHTMLEscape: true}}).Encode(entry, &buf)
+	want := `{"timestamp":"2024-01-01T12:00:00Z","data":"<a href=\"?x=1&y=2\">","path":"C:\\u003c","tags":["<b>"]}`
+	if strings.TrimSpace(buf.String()) != want {
+		t.Errorf("Expected %s, got %s", want, buf.String())
+	}
+
+	buf.Reset()
+	(JSONEncoder{EncoderConfig{TimeFormat: time.RFC3339}}).Encode(entry, &buf)
+	if !strings.Contains(buf.String(), `\u003ca href`) {
+		t.Errorf("Expected HTML characters to be escaped by default, got %s", buf.String())
+	}
+}
@@ -0,0 +1,153 @@
+package gologs
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Environment variables read by NewLoggerFromEnv.
+const (
+	EnvLogLevel  = "LOG_LEVEL"
+	EnvLogLevels = "LOG_LEVELS"
+	EnvLogFormat = "LOG_FORMAT"
+	EnvLogOutput = "LOG_OUTPUT"
+)
+
+// NewLoggerFromEnv creates a Logger configured from the environment:
+//
+//   - LOG_LEVEL: a level name such as "debug" or "WARN"
+//   - LOG_LEVELS: levels of named loggers, such as "db=DEBUG,*=WARN"; see
+//     SetNamedLevels
+//   - LOG_FORMAT: "json", "console", "logfmt", "gcp", "pretty", "msgpack",
+//     "cbor" or "protobuf"
+//   - LOG_OUTPUT: "stdout", "stderr" or the path of a file to append to
+//
+// Unset variables leave the configuration from opts, or the defaults of New,
+// in place. An error is returned for unknown values or if the output file
+// cannot be opened. A file opened for LOG_OUTPUT is closed by Close.
+func NewLoggerFromEnv(opts ...Option) (*Logger, error) {
+	envOpts := append([]Option(nil), opts...)
+
+	if value := os.Getenv(EnvLogLevel); value != "" {
+		level, err := ParseLogLevel(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", EnvLogLevel, err)
+		}
+		envOpts = append(envOpts, WithLevel(level))
+	}
+
+	if value := os.Getenv(EnvLogLevels); value != "" {
+		rules, err := parseLevelRules(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", EnvLogLevels, err)
+		}
+		envOpts = append(envOpts, func(o *options) {
+			o.namedLevels = rules
+		})
+	}
+
+	if value := os.Getenv(EnvLogFormat); value != "" {
+		opt, err := formatOption(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", EnvLogFormat, err)
+		}
+		envOpts = append(envOpts, opt)
+	}
+
+	// The output is opened last, once all other variables are valid.
+	if value := os.Getenv(EnvLogOutput); value != "" {
+		output, err := openOutput(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", EnvLogOutput, err)
+		}
+		if output == os.Stdout || output == os.Stderr {
+			envOpts = append(envOpts, WithOutput(output))
+		} else {
+			// The logger owns the file, so Close closes it.
+			envOpts = append(envOpts, withOutputs([]io.Writer{output}, []io.WriteCloser{output}))
+		}
+	}
+
+	return New(envOpts...), nil
+}
+
+// openOutput returns stdout or stderr for those names, and otherwise opens
+// the named file for appending.
+func openOutput(name string) (*os.File, error) {
+	switch strings.ToLower(name) {
+	case "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	}
+	return os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+}
+
+// formatOption returns the option selecting the named output format.
+func formatOption(format string) (Option, error) {
+	switch strings.ToLower(format) {
+	case "json":
+		return withJSONFormat(""), nil
+	case "console":
+		return withConsoleEncoder(), nil
+	case "logfmt":
+		return WithEncoder(LogfmtEncoder{}), nil
+	case "gcp":
+		return WithEncoder(GCPEncoder{}), nil
+	case "pretty":
+		return withJSONFormat(prettyIndent), nil
+	case "msgpack":
+		return WithEncoder(MsgpackEncoder{}), nil
+	case "cbor":
+		return WithEncoder(CBOREncoder{}), nil
+	case "protobuf":
+		return WithEncoder(ProtoEncoder{}), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+}
+
+// withJSONFormat selects JSON output, indented by indent, replacing the
+// indent of an earlier WithPrettyJSON or format.
+func withJSONFormat(indent string) Option {
+	return func(o *options) {
+		WithEncoder(JSONEncoder{})(o)
+		o.indent = indent
+	}
+}
+
+// encoderFor returns the encoder for the named format writing to w. Console
+// output is colored if w is a terminal.
+func encoderFor(format string, w io.Writer) (Encoder, error) {
+	switch strings.ToLower(format) {
+	case "", "json":
+		return JSONEncoder{}, nil
+	case "console":
+		return NewConsoleEncoder(w), nil
+	case "logfmt":
+		return LogfmtEncoder{}, nil
+	case "gcp":
+		return GCPEncoder{}, nil
+	case "pretty":
+		return JSONEncoder{EncoderConfig{Indent: prettyIndent}}, nil
+	case "msgpack":
+		return MsgpackEncoder{}, nil
+	case "cbor":
+		return CBOREncoder{}, nil
+	case "protobuf":
+		return ProtoEncoder{}, nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+}
+
+// withConsoleEncoder selects a ConsoleEncoder with colors enabled when the
+// final output is a terminal.
+func withConsoleEncoder() Option {
+	return func(o *options) {
+		o.encoder = ConsoleEncoder{}
+		o.autoConsole = true
+	}
+}
@@ -0,0 +1,105 @@
+package gologs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// tests configuring a logger from environment variables
+func TestNewLoggerFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	t.Setenv(EnvLogLevel, "warn")
+	t.Setenv(EnvLogFormat, "logfmt")
+	t.Setenv(EnvLogOutput, path)
+
+	l, err := NewLoggerFromEnv(WithCallerInfo(false))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	l.Info("filtered")
+	l.Warn("Disk almost full")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected log file to exist, got %v", err)
+	}
+	output := string(data)
+	if strings.Contains(output, "filtered") {
+		t.Errorf("Expected INFO entry to be filtered, got %v", output)
+	}
+	if !strings.Contains(output, `level=warn msg="Disk almost full"`) {
+		t.Errorf("Expected logfmt output, got %v", output)
+	}
+
+	file, ok := l.out.Load().writers[0].closer.(*os.File)
+	if !ok {
+		t.Fatalf("Expected the logger to own the log file, got %v", l.out.Load().writers[0].closer)
+	}
+	l.Close()
+	if _, err := file.Write([]byte("x")); err == nil {
+		t.Error("Expected Close to close the log file")
+	}
+}
+
+// tests that unset variables keep the defaults
+func TestNewLoggerFromEnvDefaults(t *testing.T) {
+	t.Setenv(EnvLogLevel, "")
+	t.Setenv(EnvLogFormat, "")
+	t.Setenv(EnvLogOutput, "")
+
+	l, err := NewLoggerFromEnv(WithLevel(ERROR))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if l.GetLogLevel() != ERROR {
+		t.Errorf("Expected level from options, got %v", l.GetLogLevel())
+	}
+	if l.out.Load().writers[0].writer != os.Stdout {
+		t.Errorf("Expected stdout output, got %v", l.out.Load().writers[0].writer)
+	}
+}
+
+// tests that invalid values are reported
+func TestNewLoggerFromEnvErrors(t *testing.T) {
+	t.Setenv(EnvLogLevel, "loud")
+	if _, err := NewLoggerFromEnv(); err == nil {
+		t.Error("Expected error for unknown level")
+	}
+
+	t.Setenv(EnvLogLevel, "")
+	t.Setenv(EnvLogFormat, "xml")
+	if _, err := NewLoggerFromEnv(); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+
+	// The output isn't opened for an invalid format.
+	path := filepath.Join(t.TempDir(), "app.log")
+	t.Setenv(EnvLogOutput, path)
+	if _, err := NewLoggerFromEnv(); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the log file not to be created, got %v", err)
+	}
+
+	t.Setenv(EnvLogOutput, "")
+	t.Setenv(EnvLogFormat, "")
+	t.Setenv(EnvLogLevels, "db")
+	if _, err := NewLoggerFromEnv(); err == nil {
+		t.Error("Expected error for a named level without a level")
+	}
+}
+
+// tests setting the levels of named loggers from the environment
+func TestNewLoggerFromEnvNamedLevels(t *testing.T) {
+	t.Setenv(EnvLogLevels, "db=debug, *=error")
+	l, err := NewLoggerFromEnv()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if l.Named("db").GetLogLevel() != DEBUG || l.Named("http").GetLogLevel() != ERROR || l.GetLogLevel() != INFO {
+		t.Errorf("Expected the named levels to be set")
+	}
+}
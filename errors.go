@@ -0,0 +1,136 @@
+package gologs
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+)
+
+// Err returns a copy of the logger that adds err to the entries it writes,
+// as structured fields rather than flattened into the message:
+//
+//   - "error": the message of err
+//   - "error_type": the Go type of err, such as "*fs.PathError"
+//   - "error_stack": the stack trace of err, if it has one
+//   - "error_causes": the errors err wraps, with their type, message and
+//     stack trace, if any. Errors joined with errors.Join or wrapped by
+//     fmt.Errorf with several %w verbs are expanded depth first.
+//   - "error_root": the message of the innermost cause, to group entries
+//     by. For joined errors, this follows the first of them.
+//
+// It returns the logger itself if err is nil.
+//
+//	logger.Err(err).Error("Operation failed")
+func (l *Logger) Err(err error) *Logger {
+	if err == nil {
+		return l
+	}
+	return l.withFields(errorFields(err))
+}
+
+// maxErrorCauses limits the number of causes listed for an error.
+const maxErrorCauses = 32
+
+// errorCause describes an error wrapped by a logged error.
+type errorCause struct {
+	Type    string   `json:"type"`
+	Message string   `json:"message"`
+	Stack   []string `json:"stack,omitempty"`
+}
+
+// errorFields returns the fields describing err.
+func errorFields(err error) []Field {
+	fields := []Field{Err(err), String("error_type", fmt.Sprintf("%T", err))}
+	if stack := errorStack(err); stack != nil {
+		fields = append(fields, Any("error_stack", stack))
+	}
+	if causes := appendCauses(nil, err); len(causes) > 0 {
+		fields = append(fields,
+			Any("error_causes", causes),
+			String("error_root", rootCause(err).Error()),
+		)
+	}
+	return fields
+}
+
+// appendCauses appends the errors wrapped by err, depth first.
+func appendCauses(causes []errorCause, err error) []errorCause {
+	for _, cause := range unwrapAll(err) {
+		if len(causes) == maxErrorCauses {
+			break
+		}
+		causes = append(causes, errorCause{
+			Type:    fmt.Sprintf("%T", cause),
+			Message: cause.Error(),
+			Stack:   errorStack(cause),
+		})
+		causes = appendCauses(causes, cause)
+	}
+	return causes
+}
+
+// rootCause follows the first error wrapped by err to the innermost one.
+func rootCause(err error) error {
+	for i := 0; i < maxErrorCauses; i++ {
+		wrapped := unwrapAll(err)
+		if len(wrapped) == 0 {
+			break
+		}
+		err = wrapped[0]
+	}
+	return err
+}
+
+// unwrapAll returns the non-nil errors wrapped by err.
+func unwrapAll(err error) []error {
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		if w := e.Unwrap(); w != nil {
+			return []error{w}
+		}
+	case interface{ Unwrap() []error }:
+		var wrapped []error
+		for _, w := range e.Unwrap() {
+			if w != nil {
+				wrapped = append(wrapped, w)
+			}
+		}
+		return wrapped
+	}
+	return nil
+}
+
+// errorStack returns the stack trace of err as "function file:line" frames,
+// innermost first, if err exposes one. It recognizes errors with a
+// Callers() []uintptr method, and errors with a StackTrace method returning
+// a slice of program counters, such as those of github.com/pkg/errors.
+func errorStack(err error) []string {
+	var pcs []uintptr
+	if e, ok := err.(interface{ Callers() []uintptr }); ok {
+		pcs = e.Callers()
+	} else if m := reflect.ValueOf(err).MethodByName("StackTrace"); m.IsValid() {
+		t := m.Type()
+		if t.NumIn() != 0 || t.NumOut() != 1 || t.Out(0).Kind() != reflect.Slice || t.Out(0).Elem().Kind() != reflect.Uintptr {
+			return nil
+		}
+		trace := m.Call(nil)[0]
+		pcs = make([]uintptr, trace.Len())
+		for i := range pcs {
+			pcs[i] = uintptr(trace.Index(i).Uint())
+		}
+	}
+	if len(pcs) == 0 {
+		return nil
+	}
+	frames := runtime.CallersFrames(pcs)
+	var stack []string
+	for {
+		frame, more := frames.Next()
+		stack = append(stack, frame.Function+" "+frame.File+":"+strconv.Itoa(frame.Line))
+		if !more {
+			break
+		}
+	}
+	return stack
+}
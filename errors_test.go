@@ -0,0 +1,119 @@
+package gologs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// decodeEntry logs with fn and decodes the JSON entry it writes.
+func decodeEntry(t *testing.T, fn func(l *Logger)) map[string]interface{} {
+	t.Helper()
+	var out bytes.Buffer
+	fn(New(WithOutput(&out), WithCallerInfo(false)))
+	var entry map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected a JSON entry, got %v: %s", err, out.String())
+	}
+	return entry
+}
+
+// tests logging an error with its type and causes
+func TestLoggerErr(t *testing.T) {
+	cause := &fs.PathError{Op: "open", Path: "config.yaml", Err: fs.ErrNotExist}
+	entry := decodeEntry(t, func(l *Logger) {
+		l.Err(fmt.Errorf("loading config: %w", cause)).Error("Startup failed")
+	})
+
+	if entry["error"] != "loading config: open config.yaml: file does not exist" || entry["error_type"] != "*fmt.wrapError" {
+		t.Errorf("Expected the message and type of the error, got %v", entry)
+	}
+	causes, _ := entry["error_causes"].([]interface{})
+	if len(causes) != 2 {
+		t.Fatalf("Expected 2 causes, got %v", entry["error_causes"])
+	}
+	if c := causes[0].(map[string]interface{}); c["type"] != "*fs.PathError" || c["message"] != "open config.yaml: file does not exist" {
+		t.Errorf("Expected the path error first, got %v", c)
+	}
+	if entry["error_root"] != "file does not exist" {
+		t.Errorf("Expected the root cause, got %v", entry["error_root"])
+	}
+
+	entry = decodeEntry(t, func(l *Logger) { l.Err(errors.New("plain")).Warn("Retrying") })
+	if _, ok := entry["error_causes"]; ok || entry["error_type"] != "*errors.errorString" {
+		t.Errorf("Expected no causes for an error without any, got %v", entry)
+	}
+	l := New()
+	if l.Err(nil) != l {
+		t.Errorf("Expected the logger itself for a nil error")
+	}
+}
+
+// tests expanding joined errors depth first
+func TestLoggerErrJoined(t *testing.T) {
+	timeout := errors.New("timeout")
+	err := errors.Join(fmt.Errorf("primary: %w", timeout), errors.New("replica down"))
+	entry := decodeEntry(t, func(l *Logger) { l.Err(err).Error("Write failed") })
+
+	var messages []string
+	for _, c := range entry["error_causes"].([]interface{}) {
+		messages = append(messages, c.(map[string]interface{})["message"].(string))
+	}
+	if strings.Join(messages, ",") != "primary: timeout,timeout,replica down" {
+		t.Errorf("Expected the causes depth first, got %v", messages)
+	}
+	if entry["error_root"] != "timeout" {
+		t.Errorf("Expected the root cause of the first error, got %v", entry["error_root"])
+	}
+}
+
+// stackError is an error recording the stack where it was created.
+type stackError struct {
+	msg string
+	pcs []uintptr
+}
+
+func newStackError(msg string) error {
+	pcs := make([]uintptr, 8)
+	return &stackError{msg, pcs[:runtime.Callers(1, pcs)]}
+}
+
+func (e *stackError) Error() string      { return e.msg }
+func (e *stackError) Callers() []uintptr { return e.pcs }
+
+// pkgFrame and pkgError mimic the stack traces of github.com/pkg/errors.
+type pkgFrame uintptr
+type pkgError struct{ error }
+
+func (e pkgError) StackTrace() []pkgFrame {
+	pcs := make([]uintptr, 8)
+	pcs = pcs[:runtime.Callers(1, pcs)]
+	frames := make([]pkgFrame, len(pcs))
+	for i, pc := range pcs {
+		frames[i] = pkgFrame(pc)
+	}
+	return frames
+}
+
+// tests the stack traces of errors that record them
+func TestLoggerErrStack(t *testing.T) {
+	entry := decodeEntry(t, func(l *Logger) {
+		l.Err(fmt.Errorf("request: %w", newStackError("boom"))).Error("Failed")
+	})
+	cause := entry["error_causes"].([]interface{})[0].(map[string]interface{})
+	stack, _ := cause["stack"].([]interface{})
+	if len(stack) == 0 || !strings.Contains(stack[0].(string), "newStackError") {
+		t.Errorf("Expected the stack of the cause, got %v", cause)
+	}
+
+	entry = decodeEntry(t, func(l *Logger) { l.Err(pkgError{errors.New("boom")}).Error("Failed") })
+	stack, _ = entry["error_stack"].([]interface{})
+	if len(stack) == 0 || !strings.Contains(stack[0].(string), "StackTrace") {
+		t.Errorf("Expected the stack of the error, got %v", entry["error_stack"])
+	}
+}
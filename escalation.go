@@ -0,0 +1,73 @@
+package gologs
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// EscalationConfig configures level escalation with WithEscalation.
+type EscalationConfig struct {
+	// Trigger is the level of the entries that start an escalation. The
+	// zero value, DEBUG, means ERROR.
+	Trigger LogLevel
+	// Level is the level the logger writes during an escalation. Defaults
+	// to DEBUG.
+	Level LogLevel
+	// Duration is how long an escalation lasts, and Entries how many
+	// entries below the logger's level it lets through. The escalation
+	// lasts until both are used up.
+	Duration time.Duration
+	Entries  int
+}
+
+// WithEscalation lowers the level of the logger for a while after an error,
+// so that the diagnostic context of a failure is captured without leaving
+// DEBUG on all the time. Each entry at the trigger level starts the
+// escalation over. Loggers derived from the logger share the escalation.
+func WithEscalation(cfg EscalationConfig) Option {
+	return func(o *options) {
+		o.escalation = &cfg
+	}
+}
+
+// escalation is the state of level escalation.
+type escalation struct {
+	cfg EscalationConfig
+	// until is when the escalation ends in Unix nanoseconds, and remaining
+	// the number of entries it still lets through.
+	until     atomic.Int64
+	remaining atomic.Int64
+}
+
+func newEscalation(cfg EscalationConfig) *escalation {
+	if cfg.Trigger == DEBUG {
+		cfg.Trigger = ERROR
+	}
+	return &escalation{cfg: cfg}
+}
+
+// enabled reports whether an escalation lets entries at level through.
+func (e *escalation) enabled(level LogLevel) bool {
+	if level < e.cfg.Level {
+		return false
+	}
+	if e.remaining.Load() > 0 {
+		return true
+	}
+	until := e.until.Load()
+	return until != 0 && time.Now().UnixNano() < until
+}
+
+// observe starts an escalation if the entry is at the trigger level, or
+// counts it against the escalation if it is below the logger's level.
+func (e *escalation) observe(entry LogEntry, level LogLevel) {
+	switch {
+	case entry.Severity >= e.cfg.Trigger:
+		if e.cfg.Duration > 0 {
+			e.until.Store(entry.Timestamp.Add(e.cfg.Duration).UnixNano())
+		}
+		e.remaining.Store(int64(e.cfg.Entries))
+	case entry.Severity < level:
+		e.remaining.Add(-1)
+	}
+}
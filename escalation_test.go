@@ -0,0 +1,54 @@
+package gologs
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// tests that an error lets a number of DEBUG entries through
+func TestEscalationEntries(t *testing.T) {
+	sink := &memorySink{}
+	l := New(WithSinks(sink), WithEscalation(EscalationConfig{Entries: 2}))
+	l.Debug("before")
+	l.Error("failed")
+	if !l.IsDebug() || l.IsTrace() {
+		t.Errorf("Expected DEBUG but not TRACE to be enabled after the error")
+	}
+	for _, msg := range []string{"a", "b", "c"} {
+		l.Child().Debug(msg)
+	}
+	l.Info("info")
+
+	if msgs := sink.messages(); strings.Join(msgs, ",") != "failed,a,b,info" {
+		t.Errorf("Expected 2 DEBUG entries after the error, got %v", msgs)
+	}
+}
+
+// tests that an escalation ends after its duration
+func TestEscalationDuration(t *testing.T) {
+	sink := &memorySink{}
+	l := New(WithSinks(sink), WithEscalation(EscalationConfig{Trigger: WARN, Duration: 20 * time.Millisecond}))
+	l.Warn("slow")
+	l.Debug("during")
+	time.Sleep(30 * time.Millisecond)
+	l.Debug("after")
+
+	if msgs := sink.messages(); strings.Join(msgs, ",") != "slow,during" {
+		t.Errorf("Expected DEBUG entries only during the escalation, got %v", msgs)
+	}
+}
+
+// tests that an escalation doesn't write the entries Once and Every suppress
+func TestEscalationOnce(t *testing.T) {
+	sink := &memorySink{}
+	l := New(WithSinks(sink), WithEscalation(EscalationConfig{Entries: 10}))
+	l.Error("failed")
+	for i := 0; i < 3; i++ {
+		l.Once("retry").Warn("retrying")
+		l.Every(time.Hour, "debug").Debug("state")
+	}
+	if msgs := sink.messages(); strings.Join(msgs, ",") != "failed,retrying,state" {
+		t.Errorf("Expected each key once during the escalation, got %v", msgs)
+	}
+}
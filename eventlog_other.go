@@ -0,0 +1,10 @@
+//go:build !windows
+
+package gologs
+
+import "errors"
+
+// openEventLog reports that the event log is only available on Windows.
+func openEventLog(source string) (eventLog, error) {
+	return nil, errors.New("eventlog: the Windows Event Log is only available on Windows")
+}
@@ -0,0 +1,56 @@
+package gologs
+
+import (
+	"fmt"
+	"testing"
+)
+
+// fakeEventLog records the events written to it.
+type fakeEventLog struct {
+	events []string
+	closed bool
+}
+
+func (f *fakeEventLog) Info(id uint32, msg string) error {
+	f.events = append(f.events, fmt.Sprintf("info %d %s", id, msg))
+	return nil
+}
+
+func (f *fakeEventLog) Warning(id uint32, msg string) error {
+	f.events = append(f.events, fmt.Sprintf("warning %d %s", id, msg))
+	return nil
+}
+
+func (f *fakeEventLog) Error(id uint32, msg string) error {
+	f.events = append(f.events, fmt.Sprintf("error %d %s", id, msg))
+	return nil
+}
+
+func (f *fakeEventLog) Close() error {
+	f.closed = true
+	return nil
+}
+
+// tests the mapping of entries onto event log events
+func TestEventLogSink(t *testing.T) {
+	log := &fakeEventLog{}
+	l := New(WithLevel(DEBUG), WithSinks(newEventLogSink(log, WithEventID(7))))
+	l.Debug("Cache miss")
+	l.Info("Started", Int("port", 8080))
+	l.Warn("Disk almost full")
+	l.Log("Payment failed").Error(String("order", "A 1"))
+	l.Close()
+
+	expected := []string{
+		"info 7 Cache miss",
+		"info 7 Started port=8080",
+		"warning 7 Disk almost full",
+		`error 7 Payment failed order="A 1"`,
+	}
+	if fmt.Sprint(log.events) != fmt.Sprint(expected) {
+		t.Errorf("Expected events %q, got %q", expected, log.events)
+	}
+	if !log.closed {
+		t.Error("Expected event log to be closed")
+	}
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package gologs
+
+import "golang.org/x/sys/windows/svc/eventlog"
+
+// openEventLog opens the event log for source.
+func openEventLog(source string) (eventLog, error) {
+	return eventlog.Open(source)
+}
@@ -0,0 +1,401 @@
+package gologs
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// FieldType tells the encoder how a Field's value is stored.
+type FieldType uint8
+
+// Field types.
+const (
+	AnyType FieldType = iota
+	StringType
+	IntType
+	BoolType
+	DurationType
+	ErrorType
+	skipType
+	lazyType
+)
+
+// Field is a key/value pair attached to a log entry. Fields created with the
+// typed constructors (String, Int, ...) are encoded without reflection; a
+// Field literal with only Key and Value set is encoded with encoding/json.
+type Field struct {
+	Key     string
+	Type    FieldType
+	Value   interface{}
+	integer int64
+	str     string
+}
+
+// String creates a string field.
+func String(key, value string) Field {
+	return Field{Key: key, Type: StringType, str: value}
+}
+
+// Int creates an integer field.
+func Int(key string, value int) Field {
+	return Field{Key: key, Type: IntType, integer: int64(value)}
+}
+
+// Int64 creates a 64-bit integer field.
+func Int64(key string, value int64) Field {
+	return Field{Key: key, Type: IntType, integer: value}
+}
+
+// Bool creates a boolean field.
+func Bool(key string, value bool) Field {
+	var i int64
+	if value {
+		i = 1
+	}
+	return Field{Key: key, Type: BoolType, integer: i}
+}
+
+// Dur creates a duration field, encoded in nanoseconds unless the logger
+// sets another format with WithDurationFormat.
+func Dur(key string, value time.Duration) Field {
+	return Field{Key: key, Type: DurationType, integer: int64(value)}
+}
+
+// Time creates a time field, encoded as an RFC 3339 string with
+// nanoseconds in JSON.
+func Time(key string, value time.Time) Field {
+	return Field{Key: key, Type: AnyType, Value: value}
+}
+
+// Err creates an "error" field holding err.Error(). A nil error adds nothing.
+func Err(err error) Field {
+	if err == nil {
+		return Field{Type: skipType}
+	}
+	return Field{Key: "error", Type: ErrorType, Value: err, str: err.Error()}
+}
+
+// Any creates a field holding an arbitrary value, encoded with encoding/json.
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, Type: AnyType, Value: value}
+}
+
+// reservedKeys are the keys used by LogEntry itself. Fields using one of
+// these keys are written as "fields.<key>" so they don't clash.
+var reservedKeys = map[string]bool{
+	"level":     true,
+	"timestamp": true,
+	"source":    true,
+	"caller":    true,
+	"data":      true,
+}
+
+// With returns a copy of the logger that adds the given key/value pair to
+// every entry it writes.
+func (l *Logger) With(key string, value interface{}) *Logger {
+	return l.withFields([]Field{Any(key, value)})
+}
+
+// WithFields returns a copy of the logger that adds the given fields to every
+// entry it writes. Keys are added in sorted order.
+func (l *Logger) WithFields(fields map[string]interface{}) *Logger {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	list := make([]Field, 0, len(keys))
+	for _, k := range keys {
+		list = append(list, Any(k, fields[k]))
+	}
+	return l.withFields(list)
+}
+
+// Child returns a new logger that stamps the given fields on every entry, in
+// addition to the fields bound to l. Changes to the child don't affect l.
+func (l *Logger) Child(fields ...Field) *Logger {
+	return l.withFields(fields)
+}
+
+// withFields returns a copy of the logger with fields appended to its own.
+func (l *Logger) withFields(fields []Field) *Logger {
+	child := *l
+	if l.group != nil {
+		child.group = l.group.withFields(fields)
+		return &child
+	}
+	child.fields = make([]Field, 0, len(l.fields)+len(fields))
+	child.fields = append(child.fields, l.fields...)
+	child.fields = append(child.fields, fields...)
+	return &child
+}
+
+// splitFields separates Field values from the formatting arguments passed to
+// the level methods. v is returned as is when it holds no fields.
+func splitFields(v []interface{}) ([]interface{}, []Field) {
+	n := 0
+	for _, arg := range v {
+		if _, ok := arg.(Field); ok {
+			n++
+		}
+	}
+	if n == 0 {
+		return v, nil
+	}
+
+	args := make([]interface{}, 0, len(v)-n)
+	fields := make([]Field, 0, n)
+	for _, arg := range v {
+		if f, ok := arg.(Field); ok {
+			fields = append(fields, f)
+		} else {
+			args = append(args, arg)
+		}
+	}
+	return args, fields
+}
+
+// badKey is the key used for a value that has no key in a key/value list.
+const badKey = "!BADKEY"
+
+// fieldsFromKeysAndValues converts alternating keys and values into fields,
+// following the same rules as log/slog:
+//   - a Field is used as is
+//   - a string followed by a value becomes a field with that key
+//   - anything else, including a string at the end of the list, becomes a
+//     field with the key "!BADKEY"
+func fieldsFromKeysAndValues(keysAndValues []interface{}) []Field {
+	if len(keysAndValues) == 0 {
+		return nil
+	}
+	fields := make([]Field, 0, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); {
+		switch k := keysAndValues[i].(type) {
+		case Field:
+			fields = append(fields, k)
+			i++
+		case string:
+			if i+1 == len(keysAndValues) {
+				fields = append(fields, String(badKey, k))
+				i++
+				continue
+			}
+			fields = append(fields, anyField(k, keysAndValues[i+1]))
+			i += 2
+		default:
+			fields = append(fields, anyField(badKey, k))
+			i++
+		}
+	}
+	return fields
+}
+
+// anyField creates a field for value, using a typed field where possible.
+func anyField(key string, value interface{}) Field {
+	switch v := value.(type) {
+	case string:
+		return String(key, v)
+	case int:
+		return Int(key, v)
+	case int64:
+		return Int64(key, v)
+	case bool:
+		return Bool(key, v)
+	case time.Duration:
+		return Dur(key, v)
+	case error:
+		f := Err(v)
+		f.Key = key
+		return f
+	case Lazy:
+		return Field{Key: key, Type: lazyType, Value: v}
+	default:
+		return Any(key, v)
+	}
+}
+
+// fieldValue returns the value of a field as a Go value.
+func fieldValue(f Field) interface{} {
+	switch f.Type {
+	case StringType, ErrorType:
+		return f.str
+	case IntType:
+		return f.integer
+	case BoolType:
+		return f.integer == 1
+	case DurationType:
+		return time.Duration(f.integer)
+	}
+	return f.Value
+}
+
+// appendFieldValue writes the JSON encoding of a field's value to buf.
+func appendFieldValue(buf *bytes.Buffer, f Field) error {
+	switch f.Type {
+	case StringType, ErrorType:
+		buf.Write(appendJSONString(buf.AvailableBuffer(), f.str))
+	case IntType, DurationType:
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), f.integer, 10))
+	case BoolType:
+		buf.Write(strconv.AppendBool(buf.AvailableBuffer(), f.integer == 1))
+	default:
+		return appendJSONValue(buf, f.Value)
+	}
+	return nil
+}
+
+// appendJSONValue writes v to buf as JSON. Strings, numbers, booleans, nil,
+// times, groups, objects and the lists and maps of appendJSONList are written
+// directly; other values go through encoding/json.
+func appendJSONValue(buf *bytes.Buffer, v interface{}) error {
+	dst := buf.AvailableBuffer()
+	switch v := v.(type) {
+	case nil:
+		dst = append(dst, "null"...)
+	case string:
+		dst = appendJSONString(dst, v)
+	case bool:
+		dst = strconv.AppendBool(dst, v)
+	case int:
+		dst = strconv.AppendInt(dst, int64(v), 10)
+	case int8:
+		dst = strconv.AppendInt(dst, int64(v), 10)
+	case int16:
+		dst = strconv.AppendInt(dst, int64(v), 10)
+	case int32:
+		dst = strconv.AppendInt(dst, int64(v), 10)
+	case int64:
+		dst = strconv.AppendInt(dst, v, 10)
+	case uint:
+		dst = strconv.AppendUint(dst, uint64(v), 10)
+	case uint8:
+		dst = strconv.AppendUint(dst, uint64(v), 10)
+	case uint16:
+		dst = strconv.AppendUint(dst, uint64(v), 10)
+	case uint32:
+		dst = strconv.AppendUint(dst, uint64(v), 10)
+	case uint64:
+		dst = strconv.AppendUint(dst, v, 10)
+	case float32:
+		if !isFinite(float64(v)) {
+			return marshalJSONValue(buf, v)
+		}
+		dst = appendJSONFloat(dst, float64(v), 32)
+	case float64:
+		if !isFinite(v) {
+			return marshalJSONValue(buf, v)
+		}
+		dst = appendJSONFloat(dst, v, 64)
+	case time.Time:
+		appendJSONTime(buf, v, time.RFC3339Nano)
+		return nil
+	case group:
+		return appendJSONGroup(buf, v)
+	case LogObjectMarshaler:
+		return appendJSONGroup(buf, objectGroup(v))
+	default:
+		if ok, err := appendJSONList(buf, v); ok {
+			return err
+		}
+		return marshalJSONValue(buf, v)
+	}
+	buf.Write(dst)
+	return nil
+}
+
+// marshalJSONValue writes v to buf using encoding/json.
+func marshalJSONValue(buf *bytes.Buffer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
+
+func isFinite(f float64) bool {
+	return !math.IsInf(f, 0) && !math.IsNaN(f)
+}
+
+// appendJSONFloat appends a finite float the way encoding/json formats it:
+// in decimal notation, or in exponent notation for very small or large
+// values.
+func appendJSONFloat(dst []byte, f float64, bits int) []byte {
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	dst = strconv.AppendFloat(dst, f, format, -1, bits)
+	if format == 'e' {
+		// Shorten e-09 to e-9.
+		if n := len(dst); n >= 4 && dst[n-4] == 'e' && dst[n-3] == '-' && dst[n-2] == '0' {
+			dst[n-2] = dst[n-1]
+			dst = dst[:n-1]
+		}
+	}
+	return dst
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString appends s to dst as a quoted JSON string, escaping it the
+// same way encoding/json does.
+func appendJSONString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		b := s[i]
+		if b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch b {
+			case '"', '\\':
+				dst = append(dst, '\\', b)
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			case '\b':
+				dst = append(dst, '\\', 'b')
+			case '\f':
+				dst = append(dst, '\\', 'f')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, string(utf8.RuneError)...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
@@ -0,0 +1,149 @@
+package gologs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tests adding a single field with With
+func TestWith(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(DEBUG, &out).With("request_id", "abc123")
+	l.Info("Handling request")
+	output := out.String()
+	if !strings.Contains(output, `"request_id":"abc123"`) {
+		t.Errorf("Expected request_id field in output, got %v", output)
+	}
+	if !strings.Contains(output, `"data":"Handling request"`) {
+		t.Errorf("Expected message in output, got %v", output)
+	}
+}
+
+// tests adding several fields with WithFields
+func TestWithFields(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(DEBUG, &out).WithFields(map[string]interface{}{
+		"user_id": 42,
+		"admin":   true,
+	})
+	l.Log("login").Info()
+	output := out.String()
+	if !strings.Contains(output, `"admin":true,"user_id":42`) {
+		t.Errorf("Expected sorted fields in output, got %v", output)
+	}
+}
+
+// tests that With does not modify the parent logger
+func TestWithDoesNotModifyParent(t *testing.T) {
+	var out bytes.Buffer
+	parent := NewLogger(DEBUG, &out)
+	parent.With("component", "db")
+	parent.Info("parent message")
+	output := out.String()
+	if strings.Contains(output, "component") {
+		t.Errorf("Expected parent logger to have no fields, got %v", output)
+	}
+}
+
+// tests that fields using reserved keys are renamed
+func TestReservedFieldKeys(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(DEBUG, &out).With("level", "custom")
+	l.Info("message")
+	output := out.String()
+	if !strings.Contains(output, `"fields.level":"custom"`) {
+		t.Errorf("Expected reserved key to be renamed, got %v", output)
+	}
+	if !strings.Contains(output, `"level":"INFO"`) {
+		t.Errorf("Expected INFO level in output, got %v", output)
+	}
+}
+
+// tests typed fields passed to the level methods
+func TestTypedFields(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(DEBUG, &out)
+	l.Info("User %s logged in", "john", Int("user_id", 42), Bool("admin", false),
+		Dur("elapsed", 1500*time.Millisecond), String("ip", "10.0.0.1"))
+	output := out.String()
+	if !strings.Contains(output, `"data":"User john logged in"`) {
+		t.Errorf("Expected fields to be left out of formatting, got %v", output)
+	}
+	expected := `"user_id":42,"admin":false,"elapsed":1500000000,"ip":"10.0.0.1"`
+	if !strings.Contains(output, expected) {
+		t.Errorf("Expected %v in output, got %v", expected, output)
+	}
+}
+
+// tests that time fields are written as RFC 3339 strings
+func TestTimeField(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(DEBUG, &out)
+	deadline := time.Date(2024, 5, 1, 12, 30, 0, 500, time.UTC)
+	l.Info("Job scheduled", Time("deadline", deadline))
+	expected := `"deadline":"2024-05-01T12:30:00.0000005Z"`
+	if !strings.Contains(out.String(), expected) {
+		t.Errorf("Expected %v in output, got %v", expected, out.String())
+	}
+}
+
+// tests the error field
+func TestErrField(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(DEBUG, &out)
+	l.Log("query failed").Error(Err(errors.New("connection <reset>")))
+	output := out.String()
+	if !strings.Contains(output, `"error":"connection \u003creset\u003e"`) {
+		t.Errorf("Expected escaped error field in output, got %v", output)
+	}
+	out.Reset()
+
+	l.Error("no error", Err(nil))
+	output = out.String()
+	if strings.Contains(output, `"error":`) {
+		t.Errorf("Expected nil error to be skipped, got %v", output)
+	}
+}
+
+// tests that string fields are escaped the same way as encoding/json
+func TestAppendJSONString(t *testing.T) {
+	inputs := []string{"plain", "quote\"back\\slash", "tab\tnew\nline", "\x01ctrl", "<&>", "\u2028", "bad\xffutf8", "ünïcödé"}
+	for _, in := range inputs {
+		expected, _ := json.Marshal(in)
+		got := appendJSONString(nil, in)
+		if string(got) != string(expected) {
+			t.Errorf("Expected %s, got %s", expected, got)
+		}
+	}
+	for b := 0; b < 0x80; b++ {
+		in := string(rune(b))
+		expected, _ := json.Marshal(in)
+		if got := appendJSONString(nil, in); string(got) != string(expected) {
+			t.Errorf("Expected %s for byte %#x, got %s", expected, b, got)
+		}
+	}
+}
+
+// tests that child loggers inherit the fields of their parent
+func TestChild(t *testing.T) {
+	var out bytes.Buffer
+	service := NewLogger(DEBUG, &out).Child(String("service", "shop"))
+	payments := service.Child(String("component", "payments"))
+	payments.Info("Charge created", Int("amount", 100))
+	output := out.String()
+	expected := `"service":"shop","component":"payments","amount":100`
+	if !strings.Contains(output, expected) {
+		t.Errorf("Expected %v in output, got %v", expected, output)
+	}
+	out.Reset()
+
+	service.Info("Service message")
+	output = out.String()
+	if strings.Contains(output, "payments") {
+		t.Errorf("Expected parent logger to be unaffected by child, got %v", output)
+	}
+}
@@ -0,0 +1,375 @@
+package gologs
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the layout of the timestamp in backup file names.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// Rotation intervals for WithRotationInterval.
+const (
+	Hourly = time.Hour
+	Daily  = 24 * time.Hour
+)
+
+// FileSink is a Sink that writes entries to a file and rotates it when it
+// grows past a maximum size or, optionally, at a fixed interval. Rotated files
+// are renamed with the time of rotation, e.g. app.log becomes
+// app-2023-10-15T14-30-45.123.log, and can be gzip compressed. Backups beyond
+// the configured count or age are removed.
+//
+// Compression and removal of old backups happen in a background goroutine,
+// which is stopped by Close.
+type FileSink struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	maxAge     time.Duration
+	interval   time.Duration
+	compress   bool
+	encoder    Encoder
+	file       *os.File
+	size       int64
+	period     time.Time
+	now        func() time.Time
+
+	cleanup chan struct{}
+	done    chan struct{}
+}
+
+// FileSinkOption configures a FileSink.
+type FileSinkOption func(*FileSink)
+
+// WithFileEncoder sets the encoder of a FileSink. Defaults to JSONEncoder.
+func WithFileEncoder(encoder Encoder) FileSinkOption {
+	return func(s *FileSink) {
+		s.encoder = encoder
+	}
+}
+
+// WithRotationInterval rotates the file when a new interval starts, such as
+// every hour (Hourly) or every day (Daily), in addition to size-based
+// rotation. Intervals are aligned to UTC, so Daily rotates at midnight UTC.
+func WithRotationInterval(interval time.Duration) FileSinkOption {
+	return func(s *FileSink) {
+		s.interval = interval
+	}
+}
+
+// WithMaxAge removes backups that were rotated more than maxAge ago.
+func WithMaxAge(maxAge time.Duration) FileSinkOption {
+	return func(s *FileSink) {
+		s.maxAge = maxAge
+	}
+}
+
+// WithCompression gzips rotated files, adding ".gz" to their name.
+func WithCompression() FileSinkOption {
+	return func(s *FileSink) {
+		s.compress = true
+	}
+}
+
+// withClock sets the function the sink uses to get the current time.
+func withClock(now func() time.Time) FileSinkOption {
+	return func(s *FileSink) {
+		s.now = now
+	}
+}
+
+// NewFileSink opens path for appending, creating it and its directory if
+// needed. The file is rotated when writing an entry would make it larger
+// than maxSizeMB megabytes; 0 disables size-based rotation. At most
+// maxBackups rotated files are kept; 0 keeps all of them.
+func NewFileSink(path string, maxSizeMB, maxBackups int, opts ...FileSinkOption) (*FileSink, error) {
+	s := &FileSink{
+		path:       path,
+		maxSize:    int64(maxSizeMB) * 1024 * 1024,
+		maxBackups: maxBackups,
+		encoder:    JSONEncoder{},
+		now:        time.Now,
+		cleanup:    make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	go s.cleanupLoop()
+	s.requestCleanup()
+	return s, nil
+}
+
+// Write encodes the entry and appends it to the file, rotating first if the
+// entry would not fit.
+func (s *FileSink) Write(entry LogEntry) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := s.encoder.Encode(entry, buf); err != nil {
+		return err
+	}
+
+	_, err := s.write(buf.Bytes())
+	return err
+}
+
+// write appends p to the file, rotating first if it would not fit.
+func (s *FileSink) write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.shouldRotate(int64(len(p))) {
+		if err := s.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := s.file.Write(p)
+	s.size += int64(n)
+	return n, err
+}
+
+// rotatingFile is a FileSink used as the writer of a logger output, for
+// the outputs of a Config with rotation settings. The logger's encoder is
+// used instead of the sink's.
+type rotatingFile struct {
+	sink *FileSink
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	return f.sink.write(p)
+}
+
+func (f *rotatingFile) Close() error {
+	return f.sink.Close()
+}
+
+// Flush does nothing; entries are written to the file unbuffered.
+func (s *FileSink) Flush() error {
+	return nil
+}
+
+// Close closes the file and waits for pending compression and cleanup to
+// finish.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	err := s.file.Close()
+	s.mu.Unlock()
+
+	close(s.cleanup)
+	<-s.done
+	return err
+}
+
+// Reopen closes the file and opens it again by name. Call it after an
+// external tool such as logrotate has moved the file away, so that writing
+// continues in a new file at the original path.
+func (s *FileSink) Reopen() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	return s.open()
+}
+
+// shouldRotate reports whether the file must be rotated before writing n
+// more bytes. s.mu must be held.
+func (s *FileSink) shouldRotate(n int64) bool {
+	if s.size == 0 {
+		return false
+	}
+	if s.maxSize > 0 && s.size+n > s.maxSize {
+		return true
+	}
+	return s.interval > 0 && !s.now().Truncate(s.interval).Equal(s.period)
+}
+
+// open opens the file at s.path for appending.
+func (s *FileSink) open() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.file = f
+	s.size = info.Size()
+	if s.interval > 0 {
+		s.period = info.ModTime().Truncate(s.interval)
+		if s.size == 0 {
+			s.period = s.now().Truncate(s.interval)
+		}
+	}
+	return nil
+}
+
+// rotate moves the current file to a backup name, opens a new file and
+// schedules compression and cleanup of backups. s.mu must be held.
+func (s *FileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(s.path, s.backupName(s.now())); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := s.open(); err != nil {
+		return err
+	}
+	s.requestCleanup()
+	return nil
+}
+
+// requestCleanup asks the background goroutine to compress and remove
+// backups. Requests made while one is pending are merged.
+func (s *FileSink) requestCleanup() {
+	select {
+	case s.cleanup <- struct{}{}:
+	default:
+	}
+}
+
+// cleanupLoop compresses and removes backups until the sink is closed.
+func (s *FileSink) cleanupLoop() {
+	defer close(s.done)
+	for range s.cleanup {
+		if err := s.compressBackups(); err != nil {
+			log.Printf("Failed to compress log backups: %v", err)
+		}
+		if err := s.prune(); err != nil {
+			log.Printf("Failed to remove old log backups: %v", err)
+		}
+	}
+}
+
+// backupName returns the name of the backup file for a rotation at t.
+func (s *FileSink) backupName(t time.Time) string {
+	ext := filepath.Ext(s.path)
+	return strings.TrimSuffix(s.path, ext) + "-" + t.Format(backupTimeFormat) + ext
+}
+
+// backup is a rotated log file.
+type backup struct {
+	path       string
+	time       time.Time
+	compressed bool
+}
+
+// backups returns the rotated files of the sink, newest first.
+func (s *FileSink) backups() ([]backup, error) {
+	dir := filepath.Dir(s.path)
+	ext := filepath.Ext(s.path)
+	prefix := strings.TrimSuffix(filepath.Base(s.path), ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	// Backup names hold the time of the sink's clock, local time outside
+	// tests, so they are read back in its location.
+	loc := s.now().Location()
+	var backups []backup
+	for _, e := range entries {
+		name := e.Name()
+		base := strings.TrimSuffix(name, ".gz")
+		if e.IsDir() || !strings.HasPrefix(base, prefix) || !strings.HasSuffix(base, ext) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(base, prefix), ext)
+		t, err := time.ParseInLocation(backupTimeFormat, stamp, loc)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{
+			path:       filepath.Join(dir, name),
+			time:       t,
+			compressed: base != name,
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].time.After(backups[j].time)
+	})
+	return backups, nil
+}
+
+// prune removes the oldest backups beyond maxBackups and those older than
+// maxAge.
+func (s *FileSink) prune() error {
+	if s.maxBackups <= 0 && s.maxAge <= 0 {
+		return nil
+	}
+	backups, err := s.backups()
+	if err != nil {
+		return err
+	}
+	var cutoff time.Time
+	if s.maxAge > 0 {
+		cutoff = s.now().Add(-s.maxAge)
+	}
+	for i, b := range backups {
+		expired := s.maxAge > 0 && b.time.Before(cutoff)
+		if (s.maxBackups > 0 && i >= s.maxBackups) || expired {
+			if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compressBackups gzips all uncompressed backups if compression is enabled.
+func (s *FileSink) compressBackups() error {
+	if !s.compress {
+		return nil
+	}
+	backups, err := s.backups()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, b := range backups {
+		if !b.compressed {
+			errs = append(errs, gzipFile(b.path))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// gzipFile compresses path to path.gz and removes the original.
+func gzipFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	err = errors.Join(err, zw.Close(), out.Close())
+	if err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	return os.Remove(path)
+}
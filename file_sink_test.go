@@ -0,0 +1,176 @@
+package gologs
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testClock is a settable clock for file sink tests.
+type testClock struct {
+	mu      sync.Mutex
+	current time.Time
+}
+
+func (c *testClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.current
+}
+
+func (c *testClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.current = c.current.Add(d)
+}
+
+// tests writing entries to a file sink
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+	sink, err := NewFileSink(path, 1, 3, WithFileEncoder(LogfmtEncoder{}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	l := New(WithSinks(sink))
+	l.Info("Written to file")
+	if err := l.Close(); err != nil {
+		t.Errorf("Expected no error on Close, got %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `msg="Written to file"`) {
+		t.Errorf("Expected entry in file, got %v", string(data))
+	}
+}
+
+// tests size-based rotation and pruning of backups
+func TestFileSinkRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	clock := &testClock{current: time.Date(2023, 10, 15, 14, 30, 0, 0, time.UTC)}
+	sink, err := NewFileSink(path, 1, 2, withClock(clock.now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := New(WithSinks(sink), WithCallerInfo(false))
+
+	// Each entry is a bit over 300KB, so three fit in a file and the 16
+	// entries cause five rotations.
+	payload := strings.Repeat("x", 300*1024)
+	for i := 0; i < 16; i++ {
+		clock.advance(time.Second)
+		l.Info(payload)
+	}
+	l.Close()
+
+	backups, err := sink.backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("Expected 2 backups to be kept, got %d", len(backups))
+	}
+	if filepath.Base(backups[0].path) != "app-2023-10-15T14-30-16.000.log" {
+		t.Errorf("Expected newest backup first, got %v", backups[0].path)
+	}
+	for _, b := range append(backups, backup{path: path}) {
+		info, err := os.Stat(b.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() > 1024*1024 {
+			t.Errorf("Expected %v to be at most 1MB, got %d bytes", b.path, info.Size())
+		}
+	}
+}
+
+// tests time-based rotation, compression and removal of old backups
+func TestFileSinkTimeRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	clock := &testClock{current: time.Date(2023, 10, 15, 23, 0, 0, 0, time.UTC)}
+	sink, err := NewFileSink(path, 0, 0, WithRotationInterval(Hourly), WithMaxAge(90*time.Minute),
+		WithCompression(), withClock(clock.now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := New(WithSinks(sink), WithCallerInfo(false))
+
+	l.Info("first hour")
+	clock.advance(time.Hour)
+	l.Info("second hour")
+	clock.advance(time.Hour)
+	l.Info("third hour")
+	clock.advance(time.Hour)
+	l.Info("fourth hour")
+	l.Close()
+
+	backups, err := sink.backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Backups are named after the time of rotation; the one rotated at
+	// midnight is more than 90 minutes old at 02:00.
+	if len(backups) != 2 {
+		t.Fatalf("Expected backups older than 90 minutes to be removed, got %v", backups)
+	}
+	if filepath.Base(backups[0].path) != "app-2023-10-16T02-00-00.000.log.gz" || !backups[0].compressed {
+		t.Errorf("Expected compressed backup of the third hour, got %v", backups[0].path)
+	}
+
+	f, err := os.Open(backups[0].path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "third hour") {
+		t.Errorf("Expected third hour entry in backup, got %v", string(data))
+	}
+}
+
+// tests that backups are removed by age correctly outside UTC, where their
+// names hold local time
+func TestFileSinkMaxAgeLocalTime(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("EST", -5*60*60)
+	defer func() { time.Local = local }()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	clock := &testClock{current: time.Date(2023, 10, 15, 23, 0, 0, 0, time.Local)}
+	sink, err := NewFileSink(path, 0, 0, WithRotationInterval(Hourly), WithMaxAge(90*time.Minute), withClock(clock.now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := New(WithSinks(sink), WithCallerInfo(false))
+	l.Info("first hour")
+	for i := 0; i < 3; i++ {
+		clock.advance(time.Hour)
+		l.Info("next hour")
+	}
+	l.Close()
+
+	backups, err := sink.backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 || filepath.Base(backups[0].path) != "app-2023-10-16T02-00-00.000.log" {
+		t.Errorf("Expected the 2 backups of the last 90 minutes, got %v", backups)
+	}
+}
@@ -0,0 +1,41 @@
+package gologs
+
+import "sort"
+
+// WithFlattenMaps sets whether messages that are maps with string keys, or
+// objects of LogObjectMarshaler types, are written as top-level fields of
+// the entry instead of as the message, as search indexes with a mapping per
+// field expect. The keys are added after the other fields in sorted order,
+// and the message is left empty. Nested maps stay nested.
+func WithFlattenMaps(flatten bool) Option {
+	return func(o *options) {
+		o.flattenMaps = flatten
+	}
+}
+
+// flattenMessage returns the entry with the keys of a map or object
+// message moved to its fields.
+func flattenMessage(entry LogEntry) LogEntry {
+	var flat []Field
+	switch m := entry.Data.(type) {
+	case map[string]interface{}:
+		for k, v := range m {
+			flat = append(flat, anyField(k, v))
+		}
+		sort.Slice(flat, func(i, j int) bool { return flat[i].Key < flat[j].Key })
+	case map[string]string:
+		for k, v := range m {
+			flat = append(flat, String(k, v))
+		}
+		sort.Slice(flat, func(i, j int) bool { return flat[i].Key < flat[j].Key })
+	case group:
+		flat = m
+	default:
+		return entry
+	}
+	fields := make([]Field, 0, len(entry.Fields)+len(flat))
+	fields = append(fields, entry.Fields...)
+	entry.Fields = resolveFields(append(fields, flat...))
+	entry.Data = ""
+	return entry
+}
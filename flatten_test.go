@@ -0,0 +1,28 @@
+package gologs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// tests writing the keys of map messages as top-level fields
+func TestWithFlattenMaps(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(INFO, &out, WithCallerInfo(false), WithFlattenMaps(true)).Child(String("service", "shop"))
+	l.Log(map[string]interface{}{"order": 42, "status": "paid", "items": map[string]int{"apples": 2}}).Info(Int("attempt", 1))
+	l.Log(map[string]string{"b": "2", "a": "1"}).Info()
+	l.Log("plain").Info()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	wants := []string{
+		`"data":"","service":"shop","attempt":1,"items":{"apples":2},"order":42,"status":"paid"}`,
+		`"data":"","service":"shop","a":"1","b":"2"}`,
+		`"data":"plain","service":"shop"}`,
+	}
+	for i, want := range wants {
+		if i >= len(lines) || !strings.HasSuffix(lines[i], want) {
+			t.Errorf("Expected %s, got %v", want, lines)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package gologs
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// FluentSink is a Sink that sends entries to fluentd or fluent-bit using the
+// forward protocol. Entries are sent in batches of up to 100 entries, at
+// least once a second, from a background goroutine. Each entry is sent as a
+// record with the same keys as JSONEncoder writes, and the entry's timestamp
+// as event time.
+//
+// If a batch can't be sent, the sink reconnects and sends it again once.
+type FluentSink struct {
+	addr       string
+	tag        string
+	sharedKey  string
+	requireAck bool
+	timeout    time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+	batch  *batcher
+}
+
+// FluentOption configures a FluentSink.
+type FluentOption func(*FluentSink)
+
+// WithFluentSharedKey authenticates with the server using the handshake of
+// the forward protocol and the given shared key. The server must be
+// configured with the same key (<security> shared_key in fluentd).
+func WithFluentSharedKey(key string) FluentOption {
+	return func(s *FluentSink) {
+		s.sharedKey = key
+	}
+}
+
+// WithFluentAck waits for the server to acknowledge each batch, so that
+// batches lost with a connection are sent again.
+func WithFluentAck() FluentOption {
+	return func(s *FluentSink) {
+		s.requireAck = true
+	}
+}
+
+// WithFluentTimeout sets the timeout for connecting, writing and waiting for
+// acknowledgements. Defaults to 5 seconds.
+func WithFluentTimeout(timeout time.Duration) FluentOption {
+	return func(s *FluentSink) {
+		s.timeout = timeout
+	}
+}
+
+// NewFluentSink returns a sink sending entries with the given tag to the
+// forward input at addr, such as "localhost:24224".
+func NewFluentSink(addr, tag string, opts ...FluentOption) *FluentSink {
+	s := &FluentSink{
+		addr:    addr,
+		tag:     tag,
+		timeout: 5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.batch = newBatcher(100, time.Second, s.send)
+	return s
+}
+
+// Write queues the entry to be sent.
+func (s *FluentSink) Write(entry LogEntry) error {
+	return s.batch.add(entry)
+}
+
+// Flush sends all queued entries.
+func (s *FluentSink) Flush() error {
+	return s.batch.flush()
+}
+
+// Close sends all queued entries and closes the connection.
+func (s *FluentSink) Close() error {
+	err := s.batch.close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		err = errors.Join(err, s.conn.Close())
+		s.conn = nil
+	}
+	return err
+}
+
+// Health returns the results of the sent batches and the number of entries
+// waiting to be sent.
+func (s *FluentSink) Health() SinkHealth {
+	return s.batch.health()
+}
+
+func (s *FluentSink) batches() *batcher {
+	return s.batch
+}
+
+// send sends a batch of entries in forward mode.
+func (s *FluentSink) send(entries []LogEntry) error {
+	buf := appendMsgpackArrayHeader(nil, 3)
+	buf = appendMsgpackString(buf, s.tag)
+	buf = appendMsgpackArrayHeader(buf, len(entries))
+	for _, e := range entries {
+		var err error
+		buf = appendMsgpackArrayHeader(buf, 2)
+		buf = appendFluentTime(buf, e.Timestamp)
+		if buf, err = appendFluentRecord(buf, e); err != nil {
+			return err
+		}
+	}
+
+	var chunk string
+	if s.requireAck {
+		var id [16]byte
+		if _, err := rand.Read(id[:]); err != nil {
+			return err
+		}
+		chunk = base64.StdEncoding.EncodeToString(id[:])
+		buf = appendMsgpackMapHeader(buf, 2)
+		buf = appendMsgpackString(buf, "chunk")
+		buf = appendMsgpackString(buf, chunk)
+	} else {
+		buf = appendMsgpackMapHeader(buf, 1)
+	}
+	buf = appendMsgpackString(buf, "size")
+	buf = appendMsgpackInt(buf, int64(len(entries)))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.sendOnce(buf, chunk)
+	if err != nil {
+		err = s.sendOnce(buf, chunk)
+	}
+	return err
+}
+
+// sendOnce writes a message, connecting first if needed, and waits for the
+// acknowledgement of chunk if it is set. The connection is closed on error.
+func (s *FluentSink) sendOnce(msg []byte, chunk string) error {
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return err
+		}
+	}
+	err := s.writeAndAck(msg, chunk)
+	if err != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+	return err
+}
+
+func (s *FluentSink) writeAndAck(msg []byte, chunk string) error {
+	s.conn.SetDeadline(time.Now().Add(s.timeout))
+	if _, err := s.conn.Write(msg); err != nil {
+		return err
+	}
+	if chunk == "" {
+		return nil
+	}
+	resp, err := readMsgpack(s.reader)
+	if err != nil {
+		return err
+	}
+	if m, ok := resp.(map[string]interface{}); !ok || m["ack"] != chunk {
+		return fmt.Errorf("fluent: unexpected response %v", resp)
+	}
+	return nil
+}
+
+// connect opens the connection and performs the handshake if a shared key
+// is set.
+func (s *FluentSink) connect() error {
+	conn, err := net.DialTimeout("tcp", s.addr, s.timeout)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+	s.reader = bufio.NewReader(conn)
+	if s.sharedKey == "" {
+		return nil
+	}
+	if err := s.handshake(); err != nil {
+		conn.Close()
+		s.conn = nil
+		return fmt.Errorf("fluent: handshake failed: %w", err)
+	}
+	return nil
+}
+
+// handshake answers the server's HELO with a PING and checks its PONG.
+func (s *FluentSink) handshake() error {
+	s.conn.SetDeadline(time.Now().Add(s.timeout))
+	helo, err := readMsgpack(s.reader)
+	if err != nil {
+		return err
+	}
+	fields, ok := helo.([]interface{})
+	if !ok || len(fields) < 2 || fields[0] != "HELO" {
+		return fmt.Errorf("expected HELO, got %v", helo)
+	}
+	options, _ := fields[1].(map[string]interface{})
+	nonce := msgpackBytes(options["nonce"])
+	authSalt := msgpackBytes(options["auth"])
+	if len(authSalt) > 0 {
+		return errors.New("user authentication is not supported")
+	}
+
+	var random [16]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return err
+	}
+	salt := []byte(hex.EncodeToString(random[:]))
+	host := hostname()
+	ping := appendMsgpackArrayHeader(nil, 6)
+	ping = appendMsgpackString(ping, "PING")
+	ping = appendMsgpackString(ping, host)
+	ping = appendMsgpackString(ping, string(salt))
+	ping = appendMsgpackString(ping, fluentDigest(salt, host, nonce, s.sharedKey))
+	ping = appendMsgpackString(ping, "")
+	ping = appendMsgpackString(ping, "")
+	if _, err := s.conn.Write(ping); err != nil {
+		return err
+	}
+
+	pong, err := readMsgpack(s.reader)
+	if err != nil {
+		return err
+	}
+	fields, ok = pong.([]interface{})
+	if !ok || len(fields) < 5 || fields[0] != "PONG" {
+		return fmt.Errorf("expected PONG, got %v", pong)
+	}
+	if fields[1] != true {
+		return fmt.Errorf("rejected: %v", fields[2])
+	}
+	serverHost, _ := fields[3].(string)
+	if fields[4] != fluentDigest(salt, serverHost, nonce, s.sharedKey) {
+		return errors.New("server digest mismatch")
+	}
+	return nil
+}
+
+// fluentDigest returns the hex SHA-512 digest used by the handshake.
+func fluentDigest(salt []byte, host string, nonce []byte, key string) string {
+	h := sha512.New()
+	h.Write(salt)
+	h.Write([]byte(host))
+	h.Write(nonce)
+	h.Write([]byte(key))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// msgpackBytes returns a decoded string or binary value as bytes.
+func msgpackBytes(v interface{}) []byte {
+	switch v := v.(type) {
+	case []byte:
+		return v
+	case string:
+		return []byte(v)
+	}
+	return nil
+}
+
+// appendFluentTime appends t as an EventTime extension.
+func appendFluentTime(buf []byte, t time.Time) []byte {
+	buf = append(buf, 0xd7, 0x00)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(t.Unix()))
+	return binary.BigEndian.AppendUint32(buf, uint32(t.Nanosecond()))
+}
+
+// appendFluentRecord appends the entry as a record map.
+func appendFluentRecord(buf []byte, e LogEntry) ([]byte, error) {
+	n := 1
+	for _, s := range []string{e.Level, e.Source, e.Caller} {
+		if s != "" {
+			n++
+		}
+	}
+	for _, f := range e.Fields {
+		if f.Type != skipType {
+			n++
+		}
+	}
+
+	buf = appendMsgpackMapHeader(buf, n)
+	for _, kv := range [][2]string{{"level", e.Level}, {"source", e.Source}, {"caller", e.Caller}} {
+		if kv[1] != "" {
+			buf = appendMsgpackString(buf, kv[0])
+			buf = appendMsgpackString(buf, kv[1])
+		}
+	}
+	buf = appendMsgpackString(buf, "data")
+	buf, err := appendMsgpack(buf, e.Data)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range e.Fields {
+		if f.Type == skipType {
+			continue
+		}
+		key := f.Key
+		if reservedKeys[key] {
+			key = "fields." + key
+		}
+		buf = appendMsgpackString(buf, key)
+		if buf, err = appendMsgpackField(buf, f); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
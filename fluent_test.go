@@ -0,0 +1,104 @@
+package gologs
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+)
+
+// fakeFluentd accepts a single connection, performs the handshake with key
+// and acknowledges the messages it receives.
+func fakeFluentd(t *testing.T, key string) (string, <-chan []interface{}) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	messages := make(chan []interface{}, 10)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		nonce := "server-nonce"
+
+		helo := appendMsgpackArrayHeader(nil, 2)
+		helo = appendMsgpackString(helo, "HELO")
+		helo, _ = appendMsgpack(helo, map[string]interface{}{"nonce": []byte(nonce), "auth": "", "keepalive": true})
+		conn.Write(helo)
+		ping, err := readMsgpack(r)
+		if err != nil {
+			return
+		}
+		fields := ping.([]interface{})
+		salt := []byte(fields[2].(string))
+		ok := fields[3] == fluentDigest(salt, fields[1].(string), []byte(nonce), key)
+		pong := appendMsgpackArrayHeader(nil, 5)
+		pong = appendMsgpackString(pong, "PONG")
+		pong, _ = appendMsgpack(pong, ok)
+		pong = appendMsgpackString(pong, "")
+		pong = appendMsgpackString(pong, "fluentd")
+		pong = appendMsgpackString(pong, fluentDigest(salt, "fluentd", []byte(nonce), key))
+		conn.Write(pong)
+
+		for {
+			msg, err := readMsgpack(r)
+			if err != nil {
+				return
+			}
+			fields := msg.([]interface{})
+			messages <- fields
+			ack, _ := appendMsgpack(nil, map[string]interface{}{"ack": fields[2].(map[string]interface{})["chunk"]})
+			conn.Write(ack)
+		}
+	}()
+	return ln.Addr().String(), messages
+}
+
+// tests sending entries in forward mode with handshake and acknowledgements
+func TestFluentSink(t *testing.T) {
+	addr, messages := fakeFluentd(t, "secret")
+	sink := NewFluentSink(addr, "shop.app", WithFluentSharedKey("secret"), WithFluentAck())
+	l := New(WithSinks(sink), WithCallerInfo(false))
+	l.Info("first", Int("user_id", 42))
+	l.Warn("second")
+	if err := l.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var msg []interface{}
+	select {
+	case msg = <-messages:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected message to be received")
+	}
+	if msg[0] != "shop.app" {
+		t.Errorf("Expected tag shop.app, got %v", msg[0])
+	}
+	entries := msg[1].([]interface{})
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %v", entries)
+	}
+	first := entries[0].([]interface{})
+	if _, ok := first[0].(time.Time); !ok {
+		t.Errorf("Expected event time, got %T", first[0])
+	}
+	record := first[1].(map[string]interface{})
+	if record["level"] != "INFO" || record["data"] != "first" || record["user_id"] != int64(42) {
+		t.Errorf("Expected record with level, data and fields, got %v", record)
+	}
+}
+
+// tests that a wrong shared key fails the handshake
+func TestFluentSinkWrongKey(t *testing.T) {
+	addr, _ := fakeFluentd(t, "secret")
+	sink := NewFluentSink(addr, "shop.app", WithFluentSharedKey("wrong"), WithFluentTimeout(time.Second))
+	defer sink.Close()
+	sink.Write(LogEntry{Data: "rejected"})
+	if err := sink.Flush(); err == nil {
+		t.Error("Expected handshake to fail")
+	}
+}
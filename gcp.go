@@ -0,0 +1,225 @@
+package gologs
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Special keys of the Cloud Logging structured logging format.
+const (
+	gcpSourceLocationKey = "logging.googleapis.com/sourceLocation"
+	gcpTraceKey          = "logging.googleapis.com/trace"
+	gcpSpanIDKey         = "logging.googleapis.com/spanId"
+	gcpTraceSampledKey   = "logging.googleapis.com/trace_sampled"
+)
+
+// gcpReservedKeys are the keys written by GCPEncoder itself. Fields using
+// one of these keys are written as "fields.<key>".
+var gcpReservedKeys = map[string]bool{
+	"severity":           true,
+	"time":               true,
+	"message":            true,
+	gcpSourceLocationKey: true,
+	gcpTraceKey:          true,
+	gcpSpanIDKey:         true,
+	gcpTraceSampledKey:   true,
+}
+
+// GCPEncoder encodes entries in the structured logging format of Google
+// Cloud Logging, one JSON object per line, so that entries written to stdout
+// on Cloud Run, GKE or App Engine get the right severity, timestamp and
+// source location:
+//
+//	{"severity":"WARNING","time":"2023-10-15T14:30:45.123456789Z","message":"Disk almost full","free_mb":12}
+//
+// The fields "trace_id", "span_id" and "trace_sampled" are used to link the
+// entry to a Cloud Trace trace instead of being written as is.
+type GCPEncoder struct {
+	EncoderConfig
+	// ProjectID is the project of the trace linked by the "trace_id" field.
+	// Without it, trace_id is written as a plain field.
+	ProjectID string
+}
+
+// gcpSeverity maps a LogLevel onto a Cloud Logging severity.
+func gcpSeverity(level LogLevel) string {
+	switch {
+	case level >= FATAL:
+		return "ALERT"
+	case level == PANIC:
+		return "CRITICAL"
+	case level == ERROR:
+		return "ERROR"
+	case level == WARN:
+		return "WARNING"
+	case level == INFO:
+		return "INFO"
+	default:
+		return "DEBUG"
+	}
+}
+
+// Encode appends the entry as a single line of JSON.
+func (e GCPEncoder) Encode(entry LogEntry, buf *bytes.Buffer) error {
+	buf.WriteString(`{"severity":"`)
+	buf.WriteString(gcpSeverity(entry.Severity))
+	buf.WriteString(`","time":`)
+	buf.Write(appendJSONString(buf.AvailableBuffer(), formatTime(entry.Timestamp, e.timeFormat(time.RFC3339Nano))))
+	buf.WriteString(`,"message":`)
+	var msg bytes.Buffer
+	if err := appendMessageText(&msg, entry.Data); err != nil {
+		return err
+	}
+	buf.Write(appendJSONString(buf.AvailableBuffer(), msg.String()))
+
+	if i := strings.LastIndexByte(entry.Source, ':'); i > 0 {
+		buf.WriteString(`,"` + gcpSourceLocationKey + `":{"file":`)
+		buf.Write(appendJSONString(buf.AvailableBuffer(), entry.Source[:i]))
+		buf.WriteString(`,"line":`)
+		buf.Write(appendJSONString(buf.AvailableBuffer(), entry.Source[i+1:]))
+		if entry.Caller != "" {
+			buf.WriteString(`,"function":`)
+			buf.Write(appendJSONString(buf.AvailableBuffer(), entry.Caller))
+		}
+		buf.WriteByte('}')
+	}
+
+	for _, f := range entry.Fields {
+		if f.Type == skipType {
+			continue
+		}
+		key := f.Key
+		switch {
+		case key == "trace_id" && e.ProjectID != "" && f.Type == StringType:
+			buf.WriteString(`,"` + gcpTraceKey + `":`)
+			buf.Write(appendJSONString(buf.AvailableBuffer(), "projects/"+e.ProjectID+"/traces/"+f.str))
+			continue
+		case key == "span_id" && f.Type == StringType:
+			key = gcpSpanIDKey
+		case key == "trace_sampled" && f.Type == BoolType:
+			key = gcpTraceSampledKey
+		case gcpReservedKeys[key]:
+			key = "fields." + key
+		}
+		buf.WriteByte(',')
+		buf.Write(appendJSONString(buf.AvailableBuffer(), key))
+		buf.WriteByte(':')
+		if err := appendFieldValue(buf, f); err != nil {
+			return err
+		}
+	}
+	buf.WriteString("}\n")
+	return nil
+}
+
+func (e GCPEncoder) withConfig(fn func(*EncoderConfig)) Encoder {
+	fn(&e.EncoderConfig)
+	return e
+}
+
+// CloudLoggingSink is a Sink that writes entries directly to Google Cloud
+// Logging with the entries.write API, for programs running outside of
+// Google Cloud or without a logging agent. Entries are sent in batches from
+// a background goroutine; see the HTTPOption functions for batching and
+// retries.
+//
+// The sink doesn't authenticate by itself. Pass an authenticated client,
+// for example from golang.org/x/oauth2/google:
+//
+//	client, err := google.DefaultClient(ctx, "https://www.googleapis.com/auth/logging.write")
+//	sink := gologs.NewCloudLoggingSink("my-project", "shop", gologs.WithHTTPClient(client))
+type CloudLoggingSink struct {
+	url     string
+	logName string
+	encoder GCPEncoder
+	config  httpConfig
+	batch   *batcher
+}
+
+// NewCloudLoggingSink returns a sink writing to the log logName in the
+// given project. Entries are written for the "global" monitored resource.
+func NewCloudLoggingSink(projectID, logName string, opts ...HTTPOption) *CloudLoggingSink {
+	s := &CloudLoggingSink{
+		url:     "https://logging.googleapis.com/v2/entries:write",
+		logName: "projects/" + projectID + "/logs/" + logName,
+		encoder: GCPEncoder{ProjectID: projectID},
+		config:  newHTTPConfig(opts),
+	}
+	s.batch = s.config.startBatcher(s.send)
+	return s
+}
+
+// Write queues the entry to be written.
+func (s *CloudLoggingSink) Write(entry LogEntry) error {
+	return s.batch.add(entry)
+}
+
+// Flush writes all queued entries.
+func (s *CloudLoggingSink) Flush() error {
+	return s.batch.flush()
+}
+
+// Close writes all queued entries and stops the background goroutine.
+func (s *CloudLoggingSink) Close() error {
+	return s.batch.close()
+}
+
+// Health returns the results of the sent batches and the number of entries
+// waiting to be sent.
+func (s *CloudLoggingSink) Health() SinkHealth {
+	return s.batch.health()
+}
+
+func (s *CloudLoggingSink) batches() *batcher {
+	return s.batch
+}
+
+// send writes a batch of entries. Each entry is encoded with GCPEncoder and
+// its special keys are moved into the corresponding LogEntry fields.
+func (s *CloudLoggingSink) send(entries []LogEntry) error {
+	apiEntries := make([]map[string]json.RawMessage, 0, len(entries))
+	var buf bytes.Buffer
+	for _, e := range entries {
+		buf.Reset()
+		if err := s.encoder.Encode(e, &buf); err != nil {
+			return err
+		}
+		var payload map[string]json.RawMessage
+		if err := json.Unmarshal(buf.Bytes(), &payload); err != nil {
+			return err
+		}
+
+		entry := map[string]json.RawMessage{"jsonPayload": nil}
+		for from, to := range map[string]string{
+			"severity":           "severity",
+			"time":               "timestamp",
+			gcpSourceLocationKey: "sourceLocation",
+			gcpTraceKey:          "trace",
+			gcpSpanIDKey:         "spanId",
+			gcpTraceSampledKey:   "traceSampled",
+		} {
+			if v, ok := payload[from]; ok {
+				entry[to] = v
+				delete(payload, from)
+			}
+		}
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		entry["jsonPayload"] = data
+		apiEntries = append(apiEntries, entry)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"logName":  s.logName,
+		"resource": map[string]string{"type": "global"},
+		"entries":  apiEntries,
+	})
+	if err != nil {
+		return err
+	}
+	return s.config.post(s.url, "application/json", body)
+}
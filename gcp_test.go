@@ -0,0 +1,80 @@
+package gologs
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// tests the Cloud Logging structured logging format
+func TestGCPEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(WithOutput(&buf), WithEncoder(GCPEncoder{ProjectID: "shop-prod"}))
+	l.Warn("Disk almost full", Int("free_mb", 12), String("trace_id", "abc123"),
+		String("span_id", "def"), Bool("trace_sampled", true), String("message", "clash"))
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+	expected := map[string]interface{}{
+		"severity":                             "WARNING",
+		"message":                              "Disk almost full",
+		"free_mb":                              float64(12),
+		"logging.googleapis.com/trace":         "projects/shop-prod/traces/abc123",
+		"logging.googleapis.com/spanId":        "def",
+		"logging.googleapis.com/trace_sampled": true,
+		"fields.message":                       "clash",
+	}
+	for k, v := range expected {
+		if entry[k] != v {
+			t.Errorf("Expected %v=%v, got %v", k, v, entry[k])
+		}
+	}
+	location, _ := entry["logging.googleapis.com/sourceLocation"].(map[string]interface{})
+	if location["function"] != "TestGCPEncoder" || !strings.HasSuffix(location["file"].(string), "gcp_test.go") {
+		t.Errorf("Expected source location, got %v", entry["logging.googleapis.com/sourceLocation"])
+	}
+}
+
+// tests writing entries with the entries.write API
+func TestCloudLoggingSink(t *testing.T) {
+	bodies := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+	}))
+	defer server.Close()
+
+	sink := NewCloudLoggingSink("shop-prod", "app")
+	sink.url = server.URL
+	l := New(WithSinks(sink), WithCallerInfo(false))
+	l.Error("Payment failed", String("order", "A1"), String("trace_id", "abc"))
+	if err := l.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var req struct {
+		LogName  string                   `json:"logName"`
+		Resource map[string]string        `json:"resource"`
+		Entries  []map[string]interface{} `json:"entries"`
+	}
+	if err := json.Unmarshal(<-bodies, &req); err != nil {
+		t.Fatal(err)
+	}
+	if req.LogName != "projects/shop-prod/logs/app" || req.Resource["type"] != "global" {
+		t.Errorf("Expected log name and resource, got %+v", req)
+	}
+	entry := req.Entries[0]
+	if entry["severity"] != "ERROR" || entry["trace"] != "projects/shop-prod/traces/abc" || entry["timestamp"] == nil {
+		t.Errorf("Expected severity, trace and timestamp, got %v", entry)
+	}
+	payload := entry["jsonPayload"].(map[string]interface{})
+	if payload["message"] != "Payment failed" || payload["order"] != "A1" || payload["severity"] != nil {
+		t.Errorf("Expected message and fields in payload, got %v", payload)
+	}
+}
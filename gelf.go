@@ -0,0 +1,202 @@
+package gologs
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// GELF chunking limits. Graylog accepts at most 128 chunks per message.
+const (
+	gelfChunkSize = 8192
+	gelfMaxChunks = 128
+)
+
+// gelfFraming is how a GELFEncoder terminates messages.
+type gelfFraming uint8
+
+const (
+	gelfNewline gelfFraming = iota
+	gelfNull
+	gelfNone
+)
+
+// hostname returns the name of the host, or "localhost" if it is unknown.
+var hostname = sync.OnceValue(func() string {
+	name, err := os.Hostname()
+	if err != nil || name == "" {
+		return "localhost"
+	}
+	return name
+})
+
+// GELFEncoder encodes entries in the Graylog Extended Log Format (GELF 1.1),
+// one JSON object per line:
+//
+//	{"version":"1.1","host":"web01","short_message":"Request handled","timestamp":1697380245.123456,"level":6,"_status":200}
+//
+// Levels are mapped onto syslog severities like SyslogSink does. Fields are
+// added as additional fields with a "_" prefix; characters not allowed in
+// GELF field names are replaced with '_', and boolean and structured values
+// are written as strings. For multi-line messages, short_message holds the
+// first line and full_message the whole message.
+type GELFEncoder struct {
+	// Host is the host field of the messages. Defaults to the host name.
+	Host    string
+	framing gelfFraming
+}
+
+// Encode appends the entry as a GELF message.
+func (e GELFEncoder) Encode(entry LogEntry, buf *bytes.Buffer) error {
+	var msg bytes.Buffer
+	if err := appendMessageText(&msg, entry.Data); err != nil {
+		return err
+	}
+	message := msg.String()
+
+	host := e.Host
+	if host == "" {
+		host = hostname()
+	}
+	buf.WriteString(`{"version":"1.1","host":`)
+	buf.Write(appendJSONString(buf.AvailableBuffer(), host))
+	short, _, multiline := strings.Cut(message, "\n")
+	buf.WriteString(`,"short_message":`)
+	buf.Write(appendJSONString(buf.AvailableBuffer(), short))
+	if multiline {
+		buf.WriteString(`,"full_message":`)
+		buf.Write(appendJSONString(buf.AvailableBuffer(), message))
+	}
+	micros := entry.Timestamp.UnixMicro()
+	buf.WriteString(`,"timestamp":`)
+	buf.WriteString(strconv.FormatInt(micros/1e6, 10))
+	buf.WriteByte('.')
+	frac := strconv.FormatInt(micros%1e6+1e6, 10)
+	buf.WriteString(frac[1:])
+	buf.WriteString(`,"level":`)
+	buf.WriteString(strconv.Itoa(syslogSeverity(entry.Severity)))
+
+	if i := strings.LastIndexByte(entry.Source, ':'); i > 0 {
+		buf.WriteString(`,"_file":`)
+		buf.Write(appendJSONString(buf.AvailableBuffer(), entry.Source[:i]))
+		buf.WriteString(`,"_line":`)
+		buf.WriteString(entry.Source[i+1:])
+	}
+	if entry.Caller != "" {
+		buf.WriteString(`,"_caller":`)
+		buf.Write(appendJSONString(buf.AvailableBuffer(), entry.Caller))
+	}
+	for _, f := range entry.Fields {
+		if f.Type == skipType {
+			continue
+		}
+		buf.WriteByte(',')
+		buf.Write(appendJSONString(buf.AvailableBuffer(), gelfKey(f.Key)))
+		buf.WriteByte(':')
+		switch f.Type {
+		case StringType, ErrorType:
+			buf.Write(appendJSONString(buf.AvailableBuffer(), f.str))
+		case IntType, DurationType:
+			buf.WriteString(strconv.FormatInt(f.integer, 10))
+		case BoolType:
+			buf.Write(appendJSONString(buf.AvailableBuffer(), strconv.FormatBool(f.integer == 1)))
+		default:
+			if err := appendGELFValue(buf, f.Value); err != nil {
+				return err
+			}
+		}
+	}
+	buf.WriteByte('}')
+
+	switch e.framing {
+	case gelfNewline:
+		buf.WriteByte('\n')
+	case gelfNull:
+		buf.WriteByte(0)
+	}
+	return nil
+}
+
+// appendGELFValue writes numbers as is and anything else as a string.
+func appendGELFValue(buf *bytes.Buffer, value interface{}) error {
+	if s, ok := value.(string); ok {
+		buf.Write(appendJSONString(buf.AvailableBuffer(), s))
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if _, err := strconv.ParseFloat(string(data), 64); err == nil {
+		buf.Write(data)
+		return nil
+	}
+	buf.Write(appendJSONString(buf.AvailableBuffer(), string(data)))
+	return nil
+}
+
+// gelfKey returns the additional field name for a field key. "_id" is
+// reserved in GELF, so the key "id" becomes "__id".
+func gelfKey(key string) string {
+	if key == "id" {
+		return "__id"
+	}
+	return "_" + strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '_'
+	}, key)
+}
+
+// NewGraylogSink returns a NetworkSink sending GELF messages to a Graylog
+// GELF input at addr. network is "udp" or "tcp". Over UDP, messages larger
+// than 8192 bytes are split into GELF chunks; over TCP they are separated by
+// null bytes.
+func NewGraylogSink(network, addr string, opts ...NetworkOption) *NetworkSink {
+	encoder := GELFEncoder{framing: gelfNull}
+	udp := strings.HasPrefix(network, "udp")
+	if udp {
+		encoder.framing = gelfNone
+	}
+	s := NewNetworkSink(network, addr, opts...)
+	s.encoder = encoder
+	if udp {
+		s.split = gelfChunks
+	}
+	return s
+}
+
+// gelfChunks splits a message into GELF chunks if it doesn't fit into a
+// single datagram.
+func gelfChunks(msg []byte) ([][]byte, error) {
+	if len(msg) <= gelfChunkSize {
+		return [][]byte{msg}, nil
+	}
+	const header = 12
+	payload := gelfChunkSize - header
+	count := (len(msg) + payload - 1) / payload
+	if count > gelfMaxChunks {
+		return nil, errors.New("gelf: message too large")
+	}
+
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+	chunks := make([][]byte, 0, count)
+	for i := 0; i < count; i++ {
+		part := msg[i*payload : min((i+1)*payload, len(msg))]
+		chunk := make([]byte, 0, header+len(part))
+		chunk = append(chunk, 0x1e, 0x0f)
+		chunk = append(chunk, id[:]...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunks = append(chunks, append(chunk, part...))
+	}
+	return chunks, nil
+}
@@ -0,0 +1,108 @@
+package gologs
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tests encoding entries as GELF
+func TestGELFEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(WithOutput(&buf), WithEncoder(GELFEncoder{Host: "web01"}), WithCallerInfo(false))
+	l.Warn("Disk almost full\nfree: 12 MB", Int("free_mb", 12), Bool("critical", false),
+		String("mount point", "/var"), String("id", "abc"), Any("tags", []string{"a"}))
+
+	output := buf.String()
+	if !strings.HasSuffix(output, "}\n") {
+		t.Errorf("Expected newline-terminated message, got %q", output)
+	}
+	var msg map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &msg); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+	expected := map[string]interface{}{
+		"version":       "1.1",
+		"host":          "web01",
+		"short_message": "Disk almost full",
+		"full_message":  "Disk almost full\nfree: 12 MB",
+		"level":         float64(4),
+		"_free_mb":      float64(12),
+		"_critical":     "false",
+		"_mount_point":  "/var",
+		"__id":          "abc",
+		"_tags":         `["a"]`,
+	}
+	for k, v := range expected {
+		if msg[k] != v {
+			t.Errorf("Expected %v=%v, got %v", k, v, msg[k])
+		}
+	}
+	if _, ok := msg["timestamp"].(float64); !ok {
+		t.Errorf("Expected numeric timestamp, got %v", msg["timestamp"])
+	}
+}
+
+// tests splitting large messages into GELF chunks
+func TestGELFChunks(t *testing.T) {
+	msg := bytes.Repeat([]byte("x"), 2*gelfChunkSize)
+	chunks, err := gelfChunks(msg)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(chunks) != 3 {
+		t.Fatalf("Expected 3 chunks, got %v", len(chunks))
+	}
+	var joined []byte
+	for i, c := range chunks {
+		if len(c) > gelfChunkSize || c[0] != 0x1e || c[1] != 0x0f || c[10] != byte(i) || c[11] != 3 {
+			t.Errorf("Expected valid chunk header for chunk %v, got %v", i, c[:12])
+		}
+		if !bytes.Equal(c[2:10], chunks[0][2:10]) {
+			t.Errorf("Expected same message id in all chunks")
+		}
+		joined = append(joined, c[12:]...)
+	}
+	if !bytes.Equal(joined, msg) {
+		t.Error("Expected chunks to add up to the message")
+	}
+	if _, err := gelfChunks(bytes.Repeat([]byte("x"), 129*gelfChunkSize)); err == nil {
+		t.Error("Expected error for message needing more than 128 chunks")
+	}
+}
+
+// tests sending null-delimited GELF messages over TCP
+func TestGraylogSinkTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		msg, _ := bufio.NewReader(conn).ReadString(0)
+		received <- msg
+	}()
+
+	sink := NewGraylogSink("tcp", ln.Addr().String())
+	defer sink.Close()
+	New(WithSinks(sink)).Error("Payment failed")
+
+	select {
+	case msg := <-received:
+		if !strings.HasSuffix(msg, "}\x00") || !strings.Contains(msg, `"short_message":"Payment failed"`) {
+			t.Errorf("Expected null-terminated GELF message, got %q", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected message to be received")
+	}
+}
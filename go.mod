@@ -1,3 +1,9 @@
 module github.com/phasi/go-logs
 
-go 1.22.3
+go 1.24
+
+require github.com/go-logr/logr v1.4.2
+
+require gopkg.in/yaml.v3 v3.0.1
+
+require golang.org/x/sys v0.30.0
@@ -0,0 +1,104 @@
+package gologs
+
+import "bytes"
+
+// WithGroup returns a copy of the logger that nests the fields added after
+// it, by later calls such as With and Child and by each log call, under an
+// object named name, like the groups of slog:
+//
+//	logger.WithGroup("db").Info("Query done", gologs.Int("rows", 3))
+//	// {..., "data":"Query done", "db":{"rows":3}}
+//
+// This keeps the field names of different subsystems from colliding. Fields
+// bound before the call stay where they are, groups can be nested, and
+// groups without fields are left out. An empty name returns l.
+func (l *Logger) WithGroup(name string) *Logger {
+	if name == "" {
+		return l
+	}
+	child := *l
+	child.group = &openGroup{name: name, parent: l.group}
+	return &child
+}
+
+// openGroup is a group opened with WithGroup, with the fields added to the
+// logger while it was the innermost group.
+type openGroup struct {
+	name   string
+	parent *openGroup
+	fields []Field
+}
+
+// withFields returns a copy of the group with fields appended to its own.
+func (g *openGroup) withFields(fields []Field) *openGroup {
+	child := *g
+	child.fields = make([]Field, 0, len(g.fields)+len(fields))
+	child.fields = append(child.fields, g.fields...)
+	child.fields = append(child.fields, fields...)
+	return &child
+}
+
+// nest returns the fields of a log call nested under the open groups, as
+// the fields to add to the logger's own.
+func (g *openGroup) nest(fields []Field) []Field {
+	for ; g != nil; g = g.parent {
+		inner := fields
+		if len(g.fields) > 0 {
+			inner = make([]Field, 0, len(g.fields)+len(fields))
+			inner = append(inner, g.fields...)
+			inner = append(inner, fields...)
+		}
+		if len(inner) == 0 {
+			fields = nil
+			continue
+		}
+		fields = []Field{{Key: g.name, Type: AnyType, Value: group(inner)}}
+	}
+	return fields
+}
+
+// group is the value of a field holding the fields of a group. Encoders
+// write it as a nested object.
+type group []Field
+
+// MarshalJSON encodes the fields as a JSON object.
+func (g group) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := appendJSONGroup(&buf, g); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// appendJSONGroup writes the fields to buf as a JSON object.
+func appendJSONGroup(buf *bytes.Buffer, g group) error {
+	buf.WriteByte('{')
+	first := true
+	for _, f := range g {
+		if f.Type == skipType {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		buf.Write(appendJSONString(buf.AvailableBuffer(), f.Key))
+		buf.WriteByte(':')
+		if err := appendFieldValue(buf, f); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// len returns the number of fields written for the group.
+func (g group) len() int {
+	n := 0
+	for _, f := range g {
+		if f.Type != skipType {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,44 @@
+package gologs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// tests nesting fields under groups
+func TestWithGroup(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(INFO, &out, WithCallerInfo(false), WithTimestampFormat(TimeFormatUnix)).Child(String("service", "shop"))
+	db := l.WithGroup("db").Child(String("name", "orders"))
+	db.Info("query", Int("rows", 3))
+	db.WithGroup("pool").Info("conn", Int("open", 2))
+	db.WithGroup("empty").Info("none")
+	l.WithGroup("unused").Info("top")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	wants := []string{
+		`"data":"query","service":"shop","db":{"name":"orders","rows":3}}`,
+		`"data":"conn","service":"shop","db":{"name":"orders","pool":{"open":2}}}`,
+		`"data":"none","service":"shop","db":{"name":"orders"}}`,
+		`"data":"top","service":"shop"}`,
+	}
+	if len(lines) != len(wants) {
+		t.Fatalf("Expected %d entries, got %v", len(wants), lines)
+	}
+	for i, want := range wants {
+		if !strings.HasSuffix(lines[i], want) {
+			t.Errorf("Expected %s, got %s", want, lines[i])
+		}
+	}
+}
+
+// tests that lazy fields in groups are computed
+func TestWithGroupLazy(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(INFO, &out, WithCallerInfo(false)).WithGroup("req")
+	l.Info("handled", LazyField("size", func() any { return 42 }))
+	if !strings.Contains(out.String(), `"req":{"size":42}`) {
+		t.Errorf("Expected the lazy field to be computed, got %s", out.String())
+	}
+}
@@ -0,0 +1,63 @@
+package gologs
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// levelPayload is the request and response body of the level handler.
+type levelPayload struct {
+	Level string `json:"level"`
+}
+
+// LevelHandler returns an http.Handler for inspecting and changing the
+// logger's level at runtime, for example at /debug/loglevel:
+//
+//	GET                          -> {"level":"INFO"}
+//	PUT {"level":"debug"}        -> {"level":"DEBUG"}
+//	PUT ?level=debug             -> {"level":"DEBUG"}
+//
+// The level is shared with child loggers, so changing it affects them too.
+func (l *Logger) LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			level, err := levelFromRequest(r)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			l.SetLogLevel(level)
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "only GET and PUT are supported"})
+			return
+		}
+		writeJSON(w, http.StatusOK, levelPayload{Level: logLevelString(l.GetLogLevel())})
+	})
+}
+
+// levelFromRequest reads the requested level from the query string or a
+// JSON body.
+func levelFromRequest(r *http.Request) (LogLevel, error) {
+	if value := r.URL.Query().Get("level"); value != "" {
+		return ParseLogLevel(value)
+	}
+	var payload levelPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return DEBUG, errors.New("request body must be a JSON object with a level")
+	}
+	if payload.Level == "" {
+		return DEBUG, errors.New("level must be set")
+	}
+	return ParseLogLevel(payload.Level)
+}
+
+// writeJSON writes v as the JSON response body.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
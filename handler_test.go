@@ -0,0 +1,65 @@
+package gologs
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// tests reading and changing the level over HTTP
+func TestLevelHandler(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(INFO, &out)
+	handler := l.LevelHandler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/loglevel", nil))
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != `{"level":"INFO"}` {
+		t.Errorf("Expected current level, got %d %v", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/debug/loglevel", strings.NewReader(`{"level":"debug"}`)))
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != `{"level":"DEBUG"}` {
+		t.Errorf("Expected updated level, got %d %v", rec.Code, rec.Body.String())
+	}
+	if l.GetLogLevel() != DEBUG {
+		t.Errorf("Expected DEBUG level, got %v", l.GetLogLevel())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/debug/loglevel?level=warn", nil))
+	if l.GetLogLevel() != WARN {
+		t.Errorf("Expected WARN level, got %v", l.GetLogLevel())
+	}
+}
+
+// tests invalid requests to the level handler
+func TestLevelHandlerErrors(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(INFO, &out)
+	handler := l.LevelHandler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"level":"loud"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for unknown level, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`not json`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid body, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "GET, PUT" {
+		t.Errorf("Expected 405 for POST, got %d", rec.Code)
+	}
+	if l.GetLogLevel() != INFO {
+		t.Errorf("Expected level to be unchanged, got %v", l.GetLogLevel())
+	}
+}
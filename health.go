@@ -0,0 +1,197 @@
+package gologs
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SinkHealth is the delivery status of a sink.
+type SinkHealth struct {
+	// Sink is the sink the status is about.
+	Sink Sink
+	// LastError is the most recent error of the sink, and LastErrorTime
+	// when it happened.
+	LastError     error
+	LastErrorTime time.Time
+	// LastSuccess is when the sink last delivered an entry.
+	LastSuccess time.Time
+	// QueueDepth is the number of entries waiting to be delivered, for
+	// sinks that queue entries.
+	QueueDepth int
+}
+
+// Healthy reports whether the sink delivered an entry since its last error.
+func (h SinkHealth) Healthy() bool {
+	return h.LastError == nil || h.LastSuccess.After(h.LastErrorTime)
+}
+
+// HealthReporter is implemented by sinks that deliver entries in the
+// background, such as the HTTP sinks, and know more about their status
+// than the results of Write.
+type HealthReporter interface {
+	Health() SinkHealth
+}
+
+// Pinger is implemented by sinks that can check whether their destination
+// is reachable, such as NetworkSink and the Kafka, NATS, Redis, MQTT,
+// Postgres and syslog sinks. Wrapping sinks such as LevelSink, RetrySink,
+// SpillSink and BreakerSink ping the sink they wrap.
+//
+// Ping is not part of the Sink interface, so that existing sinks keep
+// working. The reachability of sinks without it is unknown, and PingSinks
+// reports them with ErrPingUnsupported.
+type Pinger interface {
+	Ping() error
+}
+
+// ErrPingUnsupported is reported for sinks that don't implement Pinger,
+// and by wrapping sinks whose wrapped sink doesn't.
+var ErrPingUnsupported = errors.New("sink doesn't support ping")
+
+// pingSink pings s, or returns ErrPingUnsupported if it isn't a Pinger.
+func pingSink(s Sink) error {
+	if p, ok := s.(Pinger); ok {
+		return p.Ping()
+	}
+	return ErrPingUnsupported
+}
+
+// PingResult is the result of pinging a sink.
+type PingResult struct {
+	Sink Sink
+	// Err is nil if the sink is reachable, and ErrPingUnsupported if its
+	// reachability is unknown.
+	Err error
+}
+
+// SinkHealth returns the status of each sink of the logger, including the
+// writers of its outputs, in the order the sinks were added. The status is
+// built from the results of Write, and from Health for sinks that
+// implement HealthReporter, which deliver entries after Write returns. Use
+// it in readiness probes to check that log delivery works.
+func (l *Logger) SinkHealth() []SinkHealth {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	out := l.out.Load()
+	var health []SinkHealth
+	i := 0
+	out.each(func(s Sink) error {
+		h := out.stats[i].health()
+		i++
+		h.Sink = s
+		if r, ok := s.(HealthReporter); ok {
+			h = mergeHealth(h, r.Health())
+		}
+		health = append(health, h)
+		return nil
+	})
+	return health
+}
+
+// Ping calls Ping on all sinks of the logger that implement Pinger. Sinks
+// that don't are skipped, so use PingSinks to tell them apart from
+// reachable ones.
+func (l *Logger) Ping() error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.out.Load().each(func(s Sink) error {
+		if err := pingSink(s); !errors.Is(err, ErrPingUnsupported) {
+			return err
+		}
+		return nil
+	})
+}
+
+// PingSinks pings each sink of the logger, including the writers of its
+// outputs, in the order the sinks were added.
+func (l *Logger) PingSinks() []PingResult {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	var results []PingResult
+	l.out.Load().each(func(s Sink) error {
+		results = append(results, PingResult{Sink: s, Err: pingSink(s)})
+		return nil
+	})
+	return results
+}
+
+// mergeHealth adds the status reported by a sink to the status tracked by
+// the logger. A reporting sink accepts entries before delivering them, so
+// its own LastSuccess is used, and the most recent error of both.
+func mergeHealth(h, reported SinkHealth) SinkHealth {
+	if reported.LastError != nil && !reported.LastErrorTime.Before(h.LastErrorTime) {
+		h.LastError = reported.LastError
+		h.LastErrorTime = reported.LastErrorTime
+	}
+	h.LastSuccess = reported.LastSuccess
+	h.QueueDepth = reported.QueueDepth
+	return h
+}
+
+// errorReportInterval is the minimum time between two reports of failed
+// writes.
+const errorReportInterval = 10 * time.Second
+
+// errorReporter reports failed writes through the log package. As sinks
+// may fail for every entry, for example while a batching sink's buffer is
+// full, at most one failure is reported per errorReportInterval, with the
+// number of failures left out since the last report. SinkHealth has the
+// status of each sink.
+type errorReporter struct {
+	mu         sync.Mutex
+	last       time.Time
+	suppressed int
+}
+
+// report reports a failed write at time t, unless one was reported less
+// than errorReportInterval ago.
+func (r *errorReporter) report(err error, t time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.last.IsZero() && t.Sub(r.last) < errorReportInterval {
+		r.suppressed++
+		return
+	}
+	if r.suppressed > 0 {
+		log.Printf("Failed to write log entry: %v (%d more failures since the last report)", err, r.suppressed)
+	} else {
+		log.Printf("Failed to write log entry: %v", err)
+	}
+	r.last = t
+	r.suppressed = 0
+}
+
+// sinkStats tracks the results of a sink. Successes are recorded without
+// locking, as they happen for every entry.
+type sinkStats struct {
+	lastSuccess atomic.Int64
+	mu          sync.Mutex
+	lastErr     error
+	lastErrTime time.Time
+}
+
+// record records the result of delivering entries at time t.
+func (s *sinkStats) record(err error, t time.Time) {
+	if err == nil {
+		s.lastSuccess.Store(t.UnixNano())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastErr = err
+	s.lastErrTime = t
+}
+
+// health returns the recorded status, without Sink and QueueDepth.
+func (s *sinkStats) health() SinkHealth {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h := SinkHealth{LastError: s.lastErr, LastErrorTime: s.lastErrTime}
+	if ns := s.lastSuccess.Load(); ns != 0 {
+		h.LastSuccess = time.Unix(0, ns)
+	}
+	return h
+}
@@ -0,0 +1,218 @@
+package gologs
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tests that the health of each sink reflects the results of its writes
+func TestSinkHealth(t *testing.T) {
+	good := &memorySink{}
+	bad := &failingSink{fail: true}
+	l := New(WithSinks(good, bad))
+
+	l.Info("first")
+	health := l.SinkHealth()
+	if len(health) != 2 || health[0].Sink != good || health[1].Sink != bad {
+		t.Fatalf("Expected the health of both sinks, got %+v", health)
+	}
+	if !health[0].Healthy() || health[0].LastSuccess.IsZero() || health[0].LastError != nil {
+		t.Errorf("Expected a healthy sink, got %+v", health[0])
+	}
+	if health[1].Healthy() || health[1].LastError == nil || !health[1].LastSuccess.IsZero() {
+		t.Errorf("Expected an unhealthy sink, got %+v", health[1])
+	}
+
+	bad.fail = false
+	time.Sleep(time.Millisecond)
+	l.Info("second")
+	if health := l.SinkHealth(); !health[1].Healthy() || health[1].LastError == nil {
+		t.Errorf("Expected a recovered sink with its last error, got %+v", health[1])
+	}
+}
+
+// tests that sinks delivering in the background report their own status
+func TestSinkHealthReporter(t *testing.T) {
+	fail := true
+	b := newBatcher(10, time.Hour, func([]LogEntry) error {
+		if fail {
+			return errors.New("collector down")
+		}
+		return nil
+	})
+	defer b.close()
+	sink := &reportingSink{b}
+	l := New(WithSinks(sink))
+
+	l.Info("queued")
+	l.Info("queued")
+	if h := l.SinkHealth()[0]; h.QueueDepth != 2 || !h.LastSuccess.IsZero() {
+		t.Errorf("Expected 2 queued entries and no delivery, got %+v", h)
+	}
+	b.flush()
+	if h := l.SinkHealth()[0]; h.Healthy() || h.QueueDepth != 0 {
+		t.Errorf("Expected an unhealthy sink after a failed batch, got %+v", h)
+	}
+	// accepted entries don't make the sink healthy
+	l.Info("queued")
+	if h := l.SinkHealth()[0]; h.Healthy() {
+		t.Errorf("Expected an unhealthy sink until a batch is sent, got %+v", h)
+	}
+	fail = false
+	b.flush()
+	if h := l.SinkHealth()[0]; !h.Healthy() {
+		t.Errorf("Expected a healthy sink after a sent batch, got %+v", h)
+	}
+}
+
+// reportingSink queues entries in a batcher and reports its health.
+type reportingSink struct {
+	batch *batcher
+}
+
+func (s *reportingSink) Write(entry LogEntry) error { return s.batch.add(entry) }
+func (s *reportingSink) Flush() error               { return s.batch.flush() }
+func (s *reportingSink) Close() error               { return s.batch.close() }
+func (s *reportingSink) Health() SinkHealth         { return s.batch.health() }
+func (s *reportingSink) batches() *batcher          { return s.batch }
+
+// tests pinging the sinks of a logger
+func TestPing(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	network := NewNetworkSink("tcp", addr)
+	defer network.Close()
+	l := New(WithSinks(&memorySink{}, NewLevelSink(network, ERROR)))
+
+	if err := l.Ping(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	network.Close()
+	listener.Close()
+	if err := l.Ping(); err == nil {
+		t.Errorf("Expected error for a closed sink, got %v", err)
+	}
+}
+
+// tests that sinks without Ping are reported as unknown
+func TestPingSinks(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	network := NewNetworkSink("tcp", listener.Addr().String())
+	defer network.Close()
+	l := New(WithSinks(&memorySink{}, NewLevelSink(&memorySink{}, ERROR), network))
+
+	results := l.PingSinks()
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %+v", results)
+	}
+	for i, want := range []error{ErrPingUnsupported, ErrPingUnsupported, nil} {
+		if results[i].Err != want {
+			t.Errorf("Expected %v for sink %d, got %v", want, i, results[i].Err)
+		}
+	}
+	if results[2].Sink != network {
+		t.Errorf("Expected the sinks in order, got %+v", results)
+	}
+	if err := l.Ping(); err != nil {
+		t.Errorf("Expected Ping to skip the sinks without Ping, got %v", err)
+	}
+}
+
+// tests that the wrapper sinks ping the sink they wrap
+func TestPingWrappers(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	network := NewNetworkSink("tcp", addr)
+	defer network.Close()
+	spill, err := NewSpillSink(network, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer spill.Close()
+	retry := NewRetrySink(network)
+	defer retry.Close()
+	wrappers := map[string]Sink{
+		"retry":   retry,
+		"spill":   spill,
+		"breaker": NewBreakerSink(network, &memorySink{}, 3, time.Second),
+	}
+	for name, sink := range wrappers {
+		if err := New(WithSinks(sink)).Ping(); err == nil {
+			t.Errorf("Expected error for an unreachable sink wrapped in a %s sink, got %v", name, err)
+		}
+	}
+}
+
+// tests that the connection-based sinks report unreachable destinations
+func TestPingUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	kafka := NewKafkaSink([]string{addr}, "logs")
+	defer kafka.Close()
+	nats, err := NewNATSSink("nats://"+addr, "logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nats.Close()
+	redis, err := NewRedisSink("redis://"+addr, "logs", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer redis.Close()
+	mqtt, err := NewMQTTSink("mqtt://"+addr, "logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mqtt.Close()
+
+	sinks := map[string]Pinger{"kafka": kafka, "nats": nats, "redis": redis, "mqtt": mqtt}
+	for name, sink := range sinks {
+		if err := sink.Ping(); err == nil {
+			t.Errorf("Expected error for an unreachable %s server, got %v", name, err)
+		}
+	}
+}
+
+// tests that failed writes are reported at most once per interval
+func TestErrorReporter(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	var r errorReporter
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	err := errors.New("batch: buffer full, entry dropped")
+	for i := 0; i < 100; i++ {
+		r.report(err, now.Add(time.Duration(i)*time.Millisecond))
+	}
+	if n := strings.Count(buf.String(), "Failed to write log entry"); n != 1 {
+		t.Errorf("Expected 1 report, got %d: %v", n, buf.String())
+	}
+	r.report(err, now.Add(errorReportInterval))
+	if !strings.Contains(buf.String(), "(99 more failures since the last report)") {
+		t.Errorf("Expected the number of unreported failures, got %v", buf.String())
+	}
+}
@@ -0,0 +1,175 @@
+package gologs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// journalSocket is the socket of the native journald protocol.
+const journalSocket = "/run/systemd/journal/socket"
+
+// JournalSink is a Sink that sends entries to the systemd journal using its
+// native protocol, so they can be filtered by priority and field with
+// journalctl:
+//
+//	journalctl -p warning USER_ID=42
+//
+// Entries are sent with MESSAGE, PRIORITY (mapped like SyslogSink),
+// SYSLOG_IDENTIFIER and, if caller info is enabled, CODE_FILE, CODE_LINE and
+// CODE_FUNC. Fields are added with their keys upper-cased and characters
+// not allowed by journald replaced with '_'.
+//
+// Entries are sent as single datagrams, so very large entries may be
+// rejected by the socket.
+type JournalSink struct {
+	mu         sync.Mutex
+	socket     string
+	identifier string
+	conn       net.Conn
+	buf        bytes.Buffer
+}
+
+// JournalOption configures a JournalSink.
+type JournalOption func(*JournalSink)
+
+// WithJournalIdentifier sets SYSLOG_IDENTIFIER for entries sent by a
+// JournalSink. Defaults to the name of the program.
+func WithJournalIdentifier(identifier string) JournalOption {
+	return func(s *JournalSink) {
+		s.identifier = identifier
+	}
+}
+
+// withJournalSocket sets the socket a JournalSink sends to.
+func withJournalSocket(path string) JournalOption {
+	return func(s *JournalSink) {
+		s.socket = path
+	}
+}
+
+// NewJournalSink connects to the systemd journal. It returns an error if
+// journald is not running.
+func NewJournalSink(opts ...JournalOption) (*JournalSink, error) {
+	s := &JournalSink{
+		socket:     journalSocket,
+		identifier: filepath.Base(os.Args[0]),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	conn, err := net.Dial("unixgram", s.socket)
+	if err != nil {
+		return nil, err
+	}
+	s.conn = conn
+	return s, nil
+}
+
+// Write sends the entry to the journal.
+func (s *JournalSink) Write(entry LogEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buf.Reset()
+	var msg bytes.Buffer
+	if err := appendMessageText(&msg, entry.Data); err != nil {
+		return err
+	}
+	appendJournalField(&s.buf, "MESSAGE", msg.String())
+	appendJournalField(&s.buf, "PRIORITY", strconv.Itoa(syslogSeverity(entry.Severity)))
+	if s.identifier != "" {
+		appendJournalField(&s.buf, "SYSLOG_IDENTIFIER", s.identifier)
+	}
+	if i := strings.LastIndexByte(entry.Source, ':'); i > 0 {
+		appendJournalField(&s.buf, "CODE_FILE", entry.Source[:i])
+		appendJournalField(&s.buf, "CODE_LINE", entry.Source[i+1:])
+	}
+	if entry.Caller != "" {
+		appendJournalField(&s.buf, "CODE_FUNC", entry.Caller)
+	}
+	for _, f := range entry.Fields {
+		if f.Type == skipType {
+			continue
+		}
+		value, err := fieldText(f)
+		if err != nil {
+			return err
+		}
+		appendJournalField(&s.buf, journalKey(f.Key), value)
+	}
+
+	_, err := s.conn.Write(s.buf.Bytes())
+	return err
+}
+
+// appendJournalField writes a field in the native journal format. Values
+// containing newlines are written with an explicit length.
+func appendJournalField(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key)
+	if !strings.Contains(value, "\n") {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteByte('\n')
+	buf.Write(binary.LittleEndian.AppendUint64(buf.AvailableBuffer(), uint64(len(value))))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// journalKey converts a field key into a valid journal field name: upper
+// case letters, digits and '_', starting with a letter, at most 64
+// characters.
+func journalKey(key string) string {
+	name := []byte(strings.ToUpper(key))
+	for i, c := range name {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			name[i] = '_'
+		}
+	}
+	if len(name) == 0 || name[0] < 'A' || name[0] > 'Z' {
+		name = append([]byte("X_"), name...)
+	}
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return string(name)
+}
+
+// fieldText returns a field's value as unquoted text.
+func fieldText(f Field) (string, error) {
+	switch f.Type {
+	case StringType, ErrorType:
+		return f.str, nil
+	case IntType:
+		return strconv.FormatInt(f.integer, 10), nil
+	case BoolType:
+		return strconv.FormatBool(f.integer == 1), nil
+	case DurationType:
+		return time.Duration(f.integer).String(), nil
+	}
+	if s, ok := f.Value.(string); ok {
+		return s, nil
+	}
+	data, err := json.Marshal(f.Value)
+	return string(data), err
+}
+
+// Flush does nothing; entries are sent as they are written.
+func (s *JournalSink) Flush() error {
+	return nil
+}
+
+// Close closes the connection to the journal.
+func (s *JournalSink) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,72 @@
+package gologs
+
+import (
+	"encoding/binary"
+	"net"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tests sending entries with the native journal protocol
+func TestJournalSink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets not available")
+	}
+	path := filepath.Join(t.TempDir(), "socket")
+	conn, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer conn.Close()
+
+	sink, err := NewJournalSink(withJournalSocket(path), WithJournalIdentifier("shop"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer sink.Close()
+	l := New(WithSinks(sink))
+	l.Warn("Disk almost full", Int("free_mb", 12), String("mount-point", "/var"), String("trace", "a\nb"))
+
+	buf := make([]byte, 4096)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(buf[:n])
+	for _, expected := range []string{
+		"MESSAGE=Disk almost full\n",
+		"PRIORITY=4\n",
+		"SYSLOG_IDENTIFIER=shop\n",
+		"CODE_FUNC=TestJournalSink\n",
+		"FREE_MB=12\n",
+		"MOUNT_POINT=/var\n",
+		"TRACE\n" + string(binary.LittleEndian.AppendUint64(nil, 3)) + "a\nb\n",
+	} {
+		if !strings.Contains(msg, expected) {
+			t.Errorf("Expected %q in message, got %q", expected, msg)
+		}
+	}
+	if !strings.Contains(msg, "CODE_FILE=") || !strings.Contains(msg, "journal_test.go\nCODE_LINE=") {
+		t.Errorf("Expected code location in message, got %q", msg)
+	}
+}
+
+// tests converting field keys into journal field names
+func TestJournalKey(t *testing.T) {
+	expected := map[string]string{
+		"user_id":     "USER_ID",
+		"http.status": "HTTP_STATUS",
+		"_private":    "X__PRIVATE",
+		"2fa":         "X_2FA",
+		"":            "X_",
+	}
+	for key, name := range expected {
+		if got := journalKey(key); got != name {
+			t.Errorf("Expected %v for %q, got %v", name, key, got)
+		}
+	}
+}
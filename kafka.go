@@ -0,0 +1,667 @@
+package gologs
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Kafka API keys and the versions used by KafkaSink.
+const (
+	kafkaProduceKey      = 0
+	kafkaProduceVersion  = 3
+	kafkaMetadataKey     = 3
+	kafkaMetadataVersion = 1
+)
+
+// kafkaClientID identifies the sink in broker logs and quotas.
+const kafkaClientID = "gologs"
+
+// kafkaErrors names the broker error codes a producer commonly gets.
+var kafkaErrors = map[int16]string{
+	2:  "corrupt message",
+	3:  "unknown topic or partition",
+	5:  "leader not available",
+	6:  "not leader for partition",
+	7:  "request timed out",
+	10: "message too large",
+	19: "not enough replicas",
+	20: "not enough replicas after append",
+	29: "topic authorization failed",
+}
+
+// kafkaError returns an error for a broker error code.
+func kafkaError(code int16) error {
+	if name, ok := kafkaErrors[code]; ok {
+		return fmt.Errorf("kafka: %s (error code %d)", name, code)
+	}
+	return fmt.Errorf("kafka: error code %d", code)
+}
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// KafkaAcks is the number of acknowledgements the leader of a partition
+// waits for before answering a produce request.
+type KafkaAcks int16
+
+const (
+	// KafkaAckNone doesn't wait for the broker. Entries may be lost
+	// without an error.
+	KafkaAckNone KafkaAcks = 0
+	// KafkaAckLeader waits until the leader has written the entries.
+	KafkaAckLeader KafkaAcks = 1
+	// KafkaAckAll waits until all in-sync replicas have written the entries.
+	KafkaAckAll KafkaAcks = -1
+)
+
+// KafkaCompression is the compression codec of the record batches.
+type KafkaCompression int16
+
+const (
+	KafkaCompressionNone KafkaCompression = 0
+	KafkaCompressionGzip KafkaCompression = 1
+)
+
+// KafkaSink is a Sink that produces entries to a Kafka topic, one encoded
+// entry (JSON by default) per record. Entries are sent in batches of up to
+// 100 entries, at least once a second, from a background goroutine.
+//
+// Entries with a key field are partitioned by the hash of the key like the
+// Java client does, so all entries with the same key end up in the same
+// partition and in order. Other entries are spread over the partitions one
+// batch at a time.
+//
+// The sink speaks the Kafka protocol itself (Kafka 0.11 or newer) and only
+// supports plaintext connections without authentication. If a batch can't
+// be sent, the sink reloads the topic's metadata, reconnects and sends it
+// again once.
+type KafkaSink struct {
+	brokers     []string
+	topic       string
+	keyField    string
+	partitioner func(entry LogEntry, partitions int) int
+	acks        KafkaAcks
+	compression KafkaCompression
+	encoder     Encoder
+	timeout     time.Duration
+
+	mu          sync.Mutex
+	correlation int32
+	next        int
+	partitions  []int32
+	leaders     map[int32]int32
+	addrs       map[int32]string
+	conns       map[int32]*kafkaConn
+	batch       *batcher
+}
+
+type kafkaConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// KafkaOption configures a KafkaSink.
+type KafkaOption func(*KafkaSink)
+
+// WithKafkaKeyField uses the value of the field key, such as "tenant_id",
+// as the record key.
+func WithKafkaKeyField(key string) KafkaOption {
+	return func(s *KafkaSink) {
+		s.keyField = key
+	}
+}
+
+// WithKafkaPartitioner sets the function choosing the partition of each
+// entry. It returns an index between 0 and partitions-1; the record key is
+// still set from the key field.
+func WithKafkaPartitioner(fn func(entry LogEntry, partitions int) int) KafkaOption {
+	return func(s *KafkaSink) {
+		s.partitioner = fn
+	}
+}
+
+// WithKafkaAcks sets the acknowledgements to wait for. Defaults to
+// KafkaAckAll.
+func WithKafkaAcks(acks KafkaAcks) KafkaOption {
+	return func(s *KafkaSink) {
+		s.acks = acks
+	}
+}
+
+// WithKafkaCompression sets the compression of the record batches.
+// Defaults to KafkaCompressionNone.
+func WithKafkaCompression(compression KafkaCompression) KafkaOption {
+	return func(s *KafkaSink) {
+		s.compression = compression
+	}
+}
+
+// WithKafkaEncoder sets the encoder of the record values. Defaults to
+// JSONEncoder. The trailing newline is removed.
+func WithKafkaEncoder(encoder Encoder) KafkaOption {
+	return func(s *KafkaSink) {
+		s.encoder = encoder
+	}
+}
+
+// WithKafkaTimeout sets the timeout for connecting and for each request.
+// Defaults to 10 seconds.
+func WithKafkaTimeout(timeout time.Duration) KafkaOption {
+	return func(s *KafkaSink) {
+		s.timeout = timeout
+	}
+}
+
+// NewKafkaSink returns a sink producing entries to topic. brokers are the
+// bootstrap brokers, such as "kafka-1:9092"; the other brokers of the
+// cluster are discovered from them.
+func NewKafkaSink(brokers []string, topic string, opts ...KafkaOption) *KafkaSink {
+	s := &KafkaSink{
+		brokers: brokers,
+		topic:   topic,
+		acks:    KafkaAckAll,
+		encoder: JSONEncoder{},
+		timeout: 10 * time.Second,
+		conns:   make(map[int32]*kafkaConn),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.batch = newBatcher(100, time.Second, s.send)
+	return s
+}
+
+// Write queues the entry to be sent.
+func (s *KafkaSink) Write(entry LogEntry) error {
+	return s.batch.add(entry)
+}
+
+// Flush sends all queued entries.
+func (s *KafkaSink) Flush() error {
+	return s.batch.flush()
+}
+
+// Close sends all queued entries and closes the connections.
+func (s *KafkaSink) Close() error {
+	err := s.batch.close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeConns()
+	return err
+}
+
+// Health returns the results of the sent batches and the number of entries
+// waiting to be sent.
+func (s *KafkaSink) Health() SinkHealth {
+	return s.batch.health()
+}
+
+func (s *KafkaSink) batches() *batcher {
+	return s.batch
+}
+
+// Ping fetches the metadata of the topic from one of the bootstrap
+// brokers, and returns an error if none of them answered.
+func (s *KafkaSink) Ping() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loadMetadata()
+}
+
+func (s *KafkaSink) closeConns() {
+	for id, conn := range s.conns {
+		conn.Close()
+		delete(s.conns, id)
+	}
+}
+
+// kafkaRecord is an encoded entry.
+type kafkaRecord struct {
+	key       []byte
+	value     []byte
+	timestamp int64
+}
+
+// send produces a batch of entries.
+func (s *KafkaSink) send(entries []LogEntry) error {
+	records := make([]kafkaRecord, len(entries))
+	var buf bytes.Buffer
+	for i, e := range entries {
+		buf.Reset()
+		if err := s.encoder.Encode(e, &buf); err != nil {
+			return err
+		}
+		records[i] = kafkaRecord{
+			value:     bytes.Clone(bytes.TrimSuffix(buf.Bytes(), []byte("\n"))),
+			timestamp: e.Timestamp.UnixMilli(),
+		}
+		if s.keyField == "" {
+			continue
+		}
+		for _, f := range e.Fields {
+			if f.Key == s.keyField && f.Type != skipType {
+				key, err := fieldText(f)
+				if err != nil {
+					return err
+				}
+				records[i].key = []byte(key)
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.produce(entries, records)
+	if err != nil {
+		s.closeConns()
+		s.partitions = nil
+		err = s.produce(entries, records)
+	}
+	return err
+}
+
+// produce sends the records to the leaders of their partitions.
+func (s *KafkaSink) produce(entries []LogEntry, records []kafkaRecord) error {
+	if s.partitions == nil {
+		if err := s.loadMetadata(); err != nil {
+			return err
+		}
+	}
+
+	n := len(s.partitions)
+	sticky := s.next % n
+	s.next++
+	byPartition := make(map[int32][]kafkaRecord)
+	for i, r := range records {
+		var index int
+		switch {
+		case s.partitioner != nil:
+			index = s.partitioner(entries[i], n)
+			if index < 0 || index >= n {
+				return fmt.Errorf("kafka: partitioner returned %d for %d partitions", index, n)
+			}
+		case r.key != nil:
+			index = int(kafkaMurmur2(r.key)&0x7fffffff) % n
+		default:
+			index = sticky
+		}
+		p := s.partitions[index]
+		byPartition[p] = append(byPartition[p], r)
+	}
+
+	byLeader := make(map[int32][]int32)
+	for p := range byPartition {
+		leader := s.leaders[p]
+		byLeader[leader] = append(byLeader[leader], p)
+	}
+	for leader, partitions := range byLeader {
+		sort.Slice(partitions, func(i, j int) bool { return partitions[i] < partitions[j] })
+		body, err := s.appendProduceRequest(nil, partitions, byPartition)
+		if err != nil {
+			return err
+		}
+		conn, err := s.conn(leader)
+		if err != nil {
+			return err
+		}
+		resp, err := s.request(conn, kafkaProduceKey, kafkaProduceVersion, body, s.acks != KafkaAckNone)
+		if err != nil {
+			return err
+		}
+		if s.acks != KafkaAckNone {
+			if err := checkProduceResponse(resp); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// appendProduceRequest appends a produce request with a record batch for
+// each partition.
+func (s *KafkaSink) appendProduceRequest(buf []byte, partitions []int32, records map[int32][]kafkaRecord) ([]byte, error) {
+	buf = binary.BigEndian.AppendUint16(buf, 0xffff) // no transactional id
+	buf = binary.BigEndian.AppendUint16(buf, uint16(s.acks))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(s.timeout.Milliseconds()))
+	buf = binary.BigEndian.AppendUint32(buf, 1)
+	buf = appendKafkaString(buf, s.topic)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(partitions)))
+	for _, p := range partitions {
+		batch, err := appendKafkaRecordBatch(nil, records[p], s.compression)
+		if err != nil {
+			return nil, err
+		}
+		buf = binary.BigEndian.AppendUint32(buf, uint32(p))
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(batch)))
+		buf = append(buf, batch...)
+	}
+	return buf, nil
+}
+
+// checkProduceResponse returns the first partition error of a produce
+// response.
+func checkProduceResponse(resp []byte) error {
+	r := kafkaReader{data: resp}
+	for topics := r.int32(); topics > 0 && r.err == nil; topics-- {
+		r.string()
+		for partitions := r.int32(); partitions > 0 && r.err == nil; partitions-- {
+			partition := r.int32()
+			code := r.int16()
+			r.int64() // base offset
+			r.int64() // log append time
+			if code != 0 {
+				return fmt.Errorf("partition %d: %w", partition, kafkaError(code))
+			}
+		}
+	}
+	return r.err
+}
+
+// loadMetadata loads the partitions of the topic, their leaders and the
+// addresses of the brokers from one of the bootstrap brokers.
+func (s *KafkaSink) loadMetadata() error {
+	var errs []error
+	for _, addr := range s.brokers {
+		conn, err := s.dial(addr)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		body := binary.BigEndian.AppendUint32(nil, 1)
+		body = appendKafkaString(body, s.topic)
+		resp, err := s.request(conn, kafkaMetadataKey, kafkaMetadataVersion, body, true)
+		conn.Close()
+		if err == nil {
+			err = s.parseMetadata(resp)
+		}
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return errors.New("kafka: no brokers")
+	}
+	return errors.Join(errs...)
+}
+
+// parseMetadata reads a metadata response.
+func (s *KafkaSink) parseMetadata(resp []byte) error {
+	r := kafkaReader{data: resp}
+	addrs := make(map[int32]string)
+	for brokers := r.int32(); brokers > 0 && r.err == nil; brokers-- {
+		id := r.int32()
+		host := r.string()
+		port := r.int32()
+		r.string() // rack
+		addrs[id] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	r.int32() // controller id
+
+	var partitions []int32
+	leaders := make(map[int32]int32)
+	for topics := r.int32(); topics > 0 && r.err == nil; topics-- {
+		code := r.int16()
+		name := r.string()
+		r.int8() // is internal
+		if code != 0 {
+			return fmt.Errorf("topic %s: %w", name, kafkaError(code))
+		}
+		for n := r.int32(); n > 0 && r.err == nil; n-- {
+			r.int16() // partition error, e.g. no leader while one is elected
+			p := r.int32()
+			leader := r.int32()
+			for i := r.int32(); i > 0 && r.err == nil; i-- {
+				r.int32() // replicas
+			}
+			for i := r.int32(); i > 0 && r.err == nil; i-- {
+				r.int32() // in-sync replicas
+			}
+			if name == s.topic {
+				partitions = append(partitions, p)
+				leaders[p] = leader
+			}
+		}
+	}
+	if r.err != nil {
+		return r.err
+	}
+	if len(partitions) == 0 {
+		return fmt.Errorf("kafka: topic %s has no partitions", s.topic)
+	}
+	for _, leader := range leaders {
+		if _, ok := addrs[leader]; !ok {
+			return kafkaError(5)
+		}
+	}
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i] < partitions[j] })
+	s.partitions, s.leaders, s.addrs = partitions, leaders, addrs
+	return nil
+}
+
+// conn returns the connection to a broker, connecting if needed.
+func (s *KafkaSink) conn(id int32) (*kafkaConn, error) {
+	if conn, ok := s.conns[id]; ok {
+		return conn, nil
+	}
+	conn, err := s.dial(s.addrs[id])
+	if err != nil {
+		return nil, err
+	}
+	s.conns[id] = conn
+	return conn, nil
+}
+
+func (s *KafkaSink) dial(addr string) (*kafkaConn, error) {
+	conn, err := net.DialTimeout("tcp", addr, s.timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &kafkaConn{Conn: conn, reader: bufio.NewReader(conn)}, nil
+}
+
+// request sends a request and returns the body of the response, if one is
+// expected.
+func (s *KafkaSink) request(conn *kafkaConn, apiKey, version int16, body []byte, response bool) ([]byte, error) {
+	s.correlation++
+	msg := make([]byte, 4, 4+14+len(body))
+	msg = binary.BigEndian.AppendUint16(msg, uint16(apiKey))
+	msg = binary.BigEndian.AppendUint16(msg, uint16(version))
+	msg = binary.BigEndian.AppendUint32(msg, uint32(s.correlation))
+	msg = appendKafkaString(msg, kafkaClientID)
+	msg = append(msg, body...)
+	binary.BigEndian.PutUint32(msg, uint32(len(msg)-4))
+
+	conn.SetDeadline(time.Now().Add(s.timeout))
+	if _, err := conn.Write(msg); err != nil {
+		return nil, err
+	}
+	if !response {
+		return nil, nil
+	}
+	var header [8]byte
+	if _, err := io.ReadFull(conn.reader, header[:]); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(header[:4])
+	if size < 4 || size > 64<<20 {
+		return nil, fmt.Errorf("kafka: invalid response size %d", size)
+	}
+	if id := int32(binary.BigEndian.Uint32(header[4:])); id != s.correlation {
+		return nil, fmt.Errorf("kafka: unexpected correlation id %d", id)
+	}
+	resp := make([]byte, size-4)
+	_, err := io.ReadFull(conn.reader, resp)
+	return resp, err
+}
+
+// appendKafkaRecordBatch appends the records as a record batch (magic 2).
+func appendKafkaRecordBatch(buf []byte, records []kafkaRecord, compression KafkaCompression) ([]byte, error) {
+	first, last := records[0].timestamp, records[0].timestamp
+	for _, r := range records {
+		first, last = min(first, r.timestamp), max(last, r.timestamp)
+	}
+	var body []byte
+	for i, r := range records {
+		var rec []byte
+		rec = append(rec, 0) // attributes
+		rec = binary.AppendVarint(rec, r.timestamp-first)
+		rec = binary.AppendVarint(rec, int64(i))
+		if r.key == nil {
+			rec = binary.AppendVarint(rec, -1)
+		} else {
+			rec = binary.AppendVarint(rec, int64(len(r.key)))
+			rec = append(rec, r.key...)
+		}
+		rec = binary.AppendVarint(rec, int64(len(r.value)))
+		rec = append(rec, r.value...)
+		rec = binary.AppendVarint(rec, 0) // headers
+		body = binary.AppendVarint(body, int64(len(rec)))
+		body = append(body, rec...)
+	}
+	if compression == KafkaCompressionGzip {
+		var compressed bytes.Buffer
+		zw := gzip.NewWriter(&compressed)
+		zw.Write(body)
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		body = compressed.Bytes()
+	}
+
+	start := len(buf)
+	buf = binary.BigEndian.AppendUint64(buf, 0)          // base offset
+	buf = binary.BigEndian.AppendUint32(buf, 0)          // batch length
+	buf = binary.BigEndian.AppendUint32(buf, 0xffffffff) // partition leader epoch
+	buf = append(buf, 2)                                 // magic
+	buf = binary.BigEndian.AppendUint32(buf, 0)          // crc
+	crcStart := len(buf)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(compression))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(records)-1))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(first))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(last))
+	buf = binary.BigEndian.AppendUint64(buf, 0xffffffffffffffff) // producer id
+	buf = binary.BigEndian.AppendUint16(buf, 0xffff)             // producer epoch
+	buf = binary.BigEndian.AppendUint32(buf, 0xffffffff)         // base sequence
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(records)))
+	buf = append(buf, body...)
+
+	binary.BigEndian.PutUint32(buf[start+8:], uint32(len(buf)-start-12))
+	binary.BigEndian.PutUint32(buf[crcStart-4:], crc32.Checksum(buf[crcStart:], crc32c))
+	return buf, nil
+}
+
+// kafkaMurmur2 is the murmur2 hash used by the Java client to partition
+// records by key.
+func kafkaMurmur2(data []byte) int32 {
+	const (
+		seed = 0x9747b28c
+		m    = 0x5bd1e995
+	)
+	h := uint32(seed) ^ uint32(len(data))
+	n := len(data) &^ 3
+	for i := 0; i < n; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= m
+		k ^= k >> 24
+		k *= m
+		h *= m
+		h ^= k
+	}
+	switch len(data) & 3 {
+	case 3:
+		h ^= uint32(data[n+2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(data[n+1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(data[n])
+		h *= m
+	}
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+	return int32(h)
+}
+
+func appendKafkaString(buf []byte, s string) []byte {
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(s)))
+	return append(buf, s...)
+}
+
+// kafkaReader reads the fields of a response. After the first error, all
+// reads return zero values and err is set.
+type kafkaReader struct {
+	data []byte
+	err  error
+}
+
+func (r *kafkaReader) read(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.data) {
+		r.err = errors.New("kafka: malformed response")
+		return nil
+	}
+	b := r.data[:n:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *kafkaReader) int8() int8 {
+	if b := r.read(1); b != nil {
+		return int8(b[0])
+	}
+	return 0
+}
+
+func (r *kafkaReader) int16() int16 {
+	if b := r.read(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (r *kafkaReader) int32() int32 {
+	if b := r.read(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (r *kafkaReader) int64() int64 {
+	if b := r.read(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+// string reads a string; null strings are returned as "".
+func (r *kafkaReader) string() string {
+	n := r.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(r.read(int(n)))
+}
+
+// bytes reads a byte array; null arrays are returned as nil.
+func (r *kafkaReader) bytes() []byte {
+	n := r.int32()
+	if n < 0 {
+		return nil
+	}
+	return r.read(int(n))
+}
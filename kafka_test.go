@@ -0,0 +1,275 @@
+package gologs
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// fakeKafkaRecord is a record received by fakeKafka.
+type fakeKafkaRecord struct {
+	partition int32
+	acks      int16
+	key       []byte
+	value     []byte
+}
+
+// fakeKafka is a single broker leading all partitions of a topic. It
+// answers metadata requests and decodes the record batches of produce
+// requests.
+func fakeKafka(t *testing.T, topic string, partitions int) (string, <-chan fakeKafkaRecord) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	host, portText, _ := net.SplitHostPort(ln.Addr().String())
+	port, _ := strconv.Atoi(portText)
+	records := make(chan fakeKafkaRecord, 100)
+
+	serve := func(conn net.Conn) {
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			var size [4]byte
+			if _, err := io.ReadFull(r, size[:]); err != nil {
+				return
+			}
+			msg := make([]byte, binary.BigEndian.Uint32(size[:]))
+			if _, err := io.ReadFull(r, msg); err != nil {
+				return
+			}
+			req := kafkaReader{data: msg}
+			apiKey := req.int16()
+			req.int16()
+			correlation := req.int32()
+			req.string()
+
+			resp := binary.BigEndian.AppendUint32(nil, uint32(correlation))
+			switch apiKey {
+			case kafkaMetadataKey:
+				resp = binary.BigEndian.AppendUint32(resp, 1)
+				resp = binary.BigEndian.AppendUint32(resp, 7)
+				resp = appendKafkaString(resp, host)
+				resp = binary.BigEndian.AppendUint32(resp, uint32(port))
+				resp = binary.BigEndian.AppendUint16(resp, 0xffff)
+				resp = binary.BigEndian.AppendUint32(resp, 7)
+				resp = binary.BigEndian.AppendUint32(resp, 1)
+				resp = binary.BigEndian.AppendUint16(resp, 0)
+				resp = appendKafkaString(resp, topic)
+				resp = append(resp, 0)
+				resp = binary.BigEndian.AppendUint32(resp, uint32(partitions))
+				for p := 0; p < partitions; p++ {
+					resp = binary.BigEndian.AppendUint16(resp, 0)
+					resp = binary.BigEndian.AppendUint32(resp, uint32(p))
+					resp = binary.BigEndian.AppendUint32(resp, 7)
+					resp = binary.BigEndian.AppendUint32(resp, 0)
+					resp = binary.BigEndian.AppendUint32(resp, 0)
+				}
+			case kafkaProduceKey:
+				req.string()
+				acks := req.int16()
+				req.int32()
+				req.int32()
+				req.string()
+				var partitionResults []byte
+				n := req.int32()
+				for i := int32(0); i < n; i++ {
+					p := req.int32()
+					for _, rec := range decodeKafkaRecordBatch(t, req.bytes()) {
+						rec.partition, rec.acks = p, acks
+						records <- rec
+					}
+					partitionResults = binary.BigEndian.AppendUint32(partitionResults, uint32(p))
+					partitionResults = binary.BigEndian.AppendUint16(partitionResults, 0)
+					partitionResults = binary.BigEndian.AppendUint64(partitionResults, 0)
+					partitionResults = binary.BigEndian.AppendUint64(partitionResults, 0)
+				}
+				if acks == 0 {
+					continue
+				}
+				resp = binary.BigEndian.AppendUint32(resp, 1)
+				resp = appendKafkaString(resp, topic)
+				resp = binary.BigEndian.AppendUint32(resp, uint32(n))
+				resp = append(resp, partitionResults...)
+				resp = binary.BigEndian.AppendUint32(resp, 0)
+			}
+			conn.Write(append(binary.BigEndian.AppendUint32(nil, uint32(len(resp))), resp...))
+		}
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+	return ln.Addr().String(), records
+}
+
+// decodeKafkaRecordBatch checks the CRC of a record batch and returns its
+// records.
+func decodeKafkaRecordBatch(t *testing.T, batch []byte) []fakeKafkaRecord {
+	if len(batch) < 61 || batch[16] != 2 {
+		t.Errorf("Expected record batch with magic 2, got %v", batch)
+		return nil
+	}
+	if int(binary.BigEndian.Uint32(batch[8:])) != len(batch)-12 {
+		t.Errorf("Expected batch length %d, got %d", len(batch)-12, binary.BigEndian.Uint32(batch[8:]))
+	}
+	if crc := crc32.Checksum(batch[21:], crc32.MakeTable(crc32.Castagnoli)); crc != binary.BigEndian.Uint32(batch[17:]) {
+		t.Errorf("Expected valid CRC")
+	}
+	body := batch[61:]
+	if compression := binary.BigEndian.Uint16(batch[21:]); compression == 1 {
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if body, err = io.ReadAll(zr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var records []fakeKafkaRecord
+	for count := binary.BigEndian.Uint32(batch[57:]); count > 0; count-- {
+		length, n := binary.Varint(body)
+		rec := body[n : n+int(length)]
+		body = body[n+int(length):]
+		rec = rec[1:] // attributes
+		_, n = binary.Varint(rec)
+		rec = rec[n:]
+		_, n = binary.Varint(rec)
+		rec = rec[n:]
+		var r fakeKafkaRecord
+		keyLen, n := binary.Varint(rec)
+		rec = rec[n:]
+		if keyLen >= 0 {
+			r.key, rec = rec[:keyLen], rec[keyLen:]
+		}
+		valueLen, n := binary.Varint(rec)
+		r.value = rec[n : n+int(valueLen)]
+		records = append(records, r)
+	}
+	return records
+}
+
+// receiveKafka returns the next n records.
+func receiveKafka(t *testing.T, records <-chan fakeKafkaRecord, n int) []fakeKafkaRecord {
+	var received []fakeKafkaRecord
+	for i := 0; i < n; i++ {
+		select {
+		case r := <-records:
+			received = append(received, r)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected %d records, got %d", n, len(received))
+		}
+	}
+	return received
+}
+
+// tests producing entries partitioned by a key field
+func TestKafkaSink(t *testing.T) {
+	addr, records := fakeKafka(t, "logs", 4)
+	sink := NewKafkaSink([]string{addr}, "logs", WithKafkaKeyField("tenant_id"))
+	l := New(WithSinks(sink), WithCallerInfo(false))
+	l.Info("first", String("tenant_id", "acme"))
+	l.Info("second", String("tenant_id", "acme"))
+	l.Warn("third", String("tenant_id", "globex"))
+	if err := l.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	received := receiveKafka(t, records, 3)
+	partitions := map[string]int32{}
+	for _, r := range received {
+		var entry map[string]interface{}
+		if err := json.Unmarshal(r.value, &entry); err != nil {
+			t.Fatalf("Expected JSON value, got %s", r.value)
+		}
+		if entry["tenant_id"] != string(r.key) {
+			t.Errorf("Expected key %v, got %s", entry["tenant_id"], r.key)
+		}
+		if r.acks != -1 {
+			t.Errorf("Expected acks -1, got %d", r.acks)
+		}
+		if want := int32(kafkaMurmur2(r.key)&0x7fffffff) % 4; r.partition != want {
+			t.Errorf("Expected partition %d for key %s, got %d", want, r.key, r.partition)
+		}
+		if p, ok := partitions[string(r.key)]; ok && p != r.partition {
+			t.Errorf("Expected key %s in a single partition, got %d and %d", r.key, p, r.partition)
+		}
+		partitions[string(r.key)] = r.partition
+	}
+}
+
+// tests gzip compression, a custom partitioner and acks
+func TestKafkaSinkOptions(t *testing.T) {
+	addr, records := fakeKafka(t, "logs", 3)
+	sink := NewKafkaSink([]string{addr}, "logs",
+		WithKafkaCompression(KafkaCompressionGzip),
+		WithKafkaAcks(KafkaAckNone),
+		WithKafkaEncoder(LogfmtEncoder{}),
+		WithKafkaPartitioner(func(entry LogEntry, partitions int) int { return partitions - 1 }),
+	)
+	l := New(WithSinks(sink), WithCallerInfo(false))
+	l.Info("compressed")
+	if err := l.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	r := receiveKafka(t, records, 1)[0]
+	if r.partition != 2 || r.acks != 0 || r.key != nil {
+		t.Errorf("Expected partition 2 without key and acks, got %+v", r)
+	}
+	if !bytes.Contains(r.value, []byte("msg=compressed")) || bytes.HasSuffix(r.value, []byte("\n")) {
+		t.Errorf("Expected logfmt value without newline, got %q", r.value)
+	}
+}
+
+// tests that an unknown topic is reported
+func TestKafkaSinkUnknownTopic(t *testing.T) {
+	addr, _ := fakeKafka(t, "logs", 1)
+	sink := NewKafkaSink([]string{addr}, "other")
+	defer sink.Close()
+	sink.Write(LogEntry{Level: "INFO", Data: "lost"})
+	if err := sink.Flush(); err == nil {
+		t.Error("Expected error for unknown topic")
+	}
+}
+
+// tests pinging the bootstrap brokers
+func TestKafkaSinkPing(t *testing.T) {
+	addr, _ := fakeKafka(t, "logs", 1)
+	sink := NewKafkaSink([]string{addr}, "logs")
+	defer sink.Close()
+	if err := sink.Ping(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+// tests the hash against values of the Java client
+func TestKafkaMurmur2(t *testing.T) {
+	for key, want := range map[string]int32{
+		"21":                         -973932308,
+		"foobar":                     -790332482,
+		"a-little-bit-long-string":   -985981536,
+		"a-little-bit-longer-string": -1486304829,
+		"lkjh234lh9fiuh90y23oiuhsafujhadof229phr9h19h89h8": -58897971,
+		"abc": 479470107,
+	} {
+		if got := kafkaMurmur2([]byte(key)); got != want {
+			t.Errorf("Expected %d for %q, got %d", want, key, got)
+		}
+	}
+}
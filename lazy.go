@@ -0,0 +1,92 @@
+package gologs
+
+import "fmt"
+
+// Lazy is a value that is only computed if the entry it belongs to is
+// written, so that expensive dumps cost nothing at a disabled level. It can
+// be passed as a formatting argument, as a value to the key/value methods,
+// as the message of Log, or as a field with LazyField:
+//
+//	logger.Debug("state: %s", gologs.Lazy(func() any { return dumpState() }))
+//	logger.Debugw("Cache miss", "stats", gologs.Lazy(cache.Stats))
+type Lazy func() any
+
+// Format implements fmt.Formatter by formatting the computed value. The
+// level methods only format their message once the level check passed.
+func (f Lazy) Format(s fmt.State, verb rune) {
+	fmt.Fprintf(s, fmt.FormatString(s, verb), f())
+}
+
+// LazyField creates a field whose value is computed by fn once the entry
+// passed the level check, sampling and rate limits. Bound to a logger with
+// Child or WithFields, fn is called for every entry.
+func LazyField(key string, fn func() any) Field {
+	return Field{Key: key, Type: lazyType, Value: Lazy(fn)}
+}
+
+// resolveLazy computes the lazy message and fields of the entry, and
+// turns the objects of LogObjectMarshaler values into their fields.
+func resolveLazy(entry LogEntry) LogEntry {
+	if lazy, ok := entry.Data.(Lazy); ok {
+		entry.Data = lazy()
+	}
+	if obj, ok := entry.Data.(LogObjectMarshaler); ok {
+		entry.Data = objectGroup(obj)
+	}
+	entry.Fields = resolveFields(entry.Fields)
+	return entry
+}
+
+// resolveFields returns fields with the lazy fields replaced by the values
+// they compute, and objects by their fields. fields itself is never
+// modified, as it may be shared with the logger.
+func resolveFields(fields []Field) []Field {
+	for i := range fields {
+		if !needsResolve(fields[i]) {
+			continue
+		}
+		resolved := make([]Field, len(fields))
+		copy(resolved, fields)
+		for j := i; j < len(resolved); j++ {
+			if needsResolve(resolved[j]) {
+				resolved[j] = resolveField(resolved[j])
+			}
+		}
+		return resolved
+	}
+	return fields
+}
+
+// needsResolve reports whether a field is lazy, holds an object, or is a
+// group holding such fields.
+func needsResolve(f Field) bool {
+	if f.Type != AnyType && f.Type != lazyType {
+		return false
+	}
+	switch v := f.Value.(type) {
+	case Lazy, LogObjectMarshaler, objects:
+		return true
+	case group:
+		for _, f := range v {
+			if needsResolve(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolveField returns the field with its value computed.
+func resolveField(f Field) Field {
+	switch v := f.Value.(type) {
+	case Lazy:
+		return resolveField(anyField(f.Key, v()))
+	case LogObjectMarshaler:
+		f.Type, f.Value = AnyType, objectGroup(v)
+	case objects:
+		f.Value = v.list()
+	case group:
+		f.Value = group(resolveFields(v))
+	}
+	return f
+}
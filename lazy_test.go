@@ -0,0 +1,49 @@
+package gologs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// tests that lazy values are not computed at a disabled level
+func TestLazyDisabled(t *testing.T) {
+	calls := 0
+	expensive := Lazy(func() any {
+		calls++
+		return "dump"
+	})
+	l := New(WithOutput(&bytes.Buffer{}), WithLevel(INFO))
+	l.Debug("state: %v", expensive)
+	l.Debugw("state", "dump", expensive)
+	l.Log(expensive).Debug(LazyField("dump", expensive))
+	if calls != 0 {
+		t.Errorf("Expected no calls at a disabled level, got %d", calls)
+	}
+}
+
+// tests that lazy values are computed when the entry is written
+func TestLazy(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(WithOutput(&buf), WithCallerInfo(false))
+	n := 0
+	counter := func() any {
+		n++
+		return n
+	}
+	child := l.Child(LazyField("n", counter))
+	child.Info("state: %5.1f", Lazy(func() any { return 1.5 }))
+	child.Infow("pairs", "answer", Lazy(func() any { return 42 }))
+	l.Log(Lazy(func() any { return "computed" })).Info()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	for i, want := range []string{
+		`"data":"state:   1.5","n":1`,
+		`"data":"pairs","n":2,"answer":42`,
+		`"data":"computed"`,
+	} {
+		if !strings.Contains(lines[i], want) {
+			t.Errorf("Expected %s, got %s", want, lines[i])
+		}
+	}
+}
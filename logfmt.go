@@ -0,0 +1,99 @@
+package gologs
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogfmtEncoder encodes entries in logfmt:
+//
+//	ts=2023-10-15T14:30:45.123456Z level=info msg="Request handled" status=200
+//
+// Levels are written in lower case. Non-string messages are written as
+// quoted JSON. Timestamps default to RFC 3339 with nanoseconds.
+type LogfmtEncoder struct {
+	EncoderConfig
+}
+
+// Encode appends the entry as a single logfmt line.
+func (e LogfmtEncoder) Encode(entry LogEntry, buf *bytes.Buffer) error {
+	keys := e.Keys
+	buf.WriteString(logfmtKey(keyOr(keys.Time, "ts")))
+	buf.WriteByte('=')
+	buf.WriteString(quoteText(formatTime(entry.Timestamp, e.timeFormat(time.RFC3339Nano))))
+	buf.WriteByte(' ')
+	buf.WriteString(logfmtKey(keyOr(keys.Level, "level")))
+	buf.WriteByte('=')
+	n, numeric := e.LevelNumbers.number(entry.Severity)
+	if numeric && e.LevelNumberKey == "" {
+		buf.WriteString(strconv.Itoa(n))
+	} else {
+		buf.WriteString(lowerLevel(entry.Level))
+	}
+	if numeric && e.LevelNumberKey != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(logfmtKey(e.LevelNumberKey))
+		buf.WriteByte('=')
+		buf.WriteString(strconv.Itoa(n))
+	}
+
+	buf.WriteByte(' ')
+	buf.WriteString(logfmtKey(keyOr(keys.Message, "msg")))
+	buf.WriteByte('=')
+	if s, ok := entry.Data.(string); ok {
+		buf.WriteString(quoteText(s))
+	} else {
+		data, err := json.Marshal(entry.Data)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(quoteText(string(data)))
+	}
+
+	if entry.Source != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(logfmtKey(keyOr(keys.Source, "source")))
+		buf.WriteByte('=')
+		buf.WriteString(quoteText(entry.Source))
+	}
+	if entry.Caller != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(logfmtKey(keyOr(keys.Caller, "caller")))
+		buf.WriteByte('=')
+		buf.WriteString(quoteText(entry.Caller))
+	}
+	for _, f := range entry.Fields {
+		if f.Type == skipType {
+			continue
+		}
+		buf.WriteByte(' ')
+		buf.WriteString(logfmtKey(f.Key))
+		buf.WriteByte('=')
+		if err := appendFieldText(buf, f); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('\n')
+	return nil
+}
+
+func (e LogfmtEncoder) withConfig(fn func(*EncoderConfig)) Encoder {
+	fn(&e.EncoderConfig)
+	return e
+}
+
+// logfmtKey replaces characters that are not allowed in logfmt keys with '_'.
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == 0x7f {
+			return '_'
+		}
+		return r
+	}, key)
+}
@@ -0,0 +1,40 @@
+package gologs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tests the logfmt encoder output format
+func TestLogfmtEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	entry := LogEntry{
+		Level:     "INFO",
+		Timestamp: time.Date(2023, 10, 15, 14, 30, 45, 0, time.UTC),
+		Data:      "Request handled",
+		Fields:    []Field{Int("status", 200), String("path", "/api/users"), String("bad key", "x=y")},
+	}
+	if err := (LogfmtEncoder{}).Encode(entry, &buf); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := `ts=2023-10-15T14:30:45Z level=info msg="Request handled" status=200 path=/api/users bad_key="x=y"` + "\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+// tests selecting the logfmt encoder on a logger
+func TestLogfmtLogger(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(DEBUG, &out, WithEncoder(LogfmtEncoder{}))
+	l.Log(map[string]int{"id": 1}).Warn()
+	output := out.String()
+	if !strings.Contains(output, `level=warn msg="{\"id\":1}"`) {
+		t.Errorf("Expected logfmt output with JSON message, got %v", output)
+	}
+	if !strings.Contains(output, "caller=TestLogfmtLogger") {
+		t.Errorf("Expected caller in output, got %v", output)
+	}
+}
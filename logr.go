@@ -0,0 +1,94 @@
+package gologs
+
+import "github.com/go-logr/logr"
+
+// logrSink is a logr.LogSink that writes through a Logger.
+type logrSink struct {
+	logger    *Logger
+	name      string
+	callDepth int
+}
+
+// LogrSink returns a logr.LogSink backed by l, so controller-runtime and other
+// logr consumers can write through gologs:
+//
+//	log := logr.New(gologs.LogrSink(logger))
+//
+// V-level 0 maps onto INFO, V-level 1 onto DEBUG and higher V-levels onto
+// TRACE. Key/value pairs
+// become fields, and names added with WithName are written as the "logger"
+// field, joined with dots.
+func LogrSink(l *Logger) logr.LogSink {
+	return &logrSink{logger: l}
+}
+
+// levelFromV maps a logr V-level onto a LogLevel.
+func levelFromV(v int) LogLevel {
+	switch {
+	case v > 1:
+		return TRACE
+	case v == 1:
+		return DEBUG
+	default:
+		return INFO
+	}
+}
+
+// Init receives the call depth of the logr.Logger wrapping the sink.
+func (s *logrSink) Init(info logr.RuntimeInfo) {
+	s.callDepth = info.CallDepth
+}
+
+// Enabled reports whether entries at the given V-level are written, or
+// kept by the flight recorder.
+func (s *logrSink) Enabled(level int) bool {
+	return s.logger.accepts(levelFromV(level))
+}
+
+// Info logs a non-error message at the given V-level.
+func (s *logrSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	s.logger.logDepth(s.callDepth, levelFromV(level), msg, s.fields(nil, keysAndValues))
+}
+
+// Error logs an error message with the error as the "error" field.
+func (s *logrSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	s.logger.logDepth(s.callDepth, ERROR, msg, s.fields(err, keysAndValues))
+}
+
+// WithValues returns a sink that adds the key/value pairs to every entry.
+func (s *logrSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	s2 := *s
+	s2.logger = s.logger.withFields(fieldsFromKeysAndValues(keysAndValues))
+	return &s2
+}
+
+// WithName returns a sink with name appended to its name.
+func (s *logrSink) WithName(name string) logr.LogSink {
+	s2 := *s
+	if s2.name == "" {
+		s2.name = name
+	} else {
+		s2.name += "." + name
+	}
+	return &s2
+}
+
+// WithCallDepth returns a sink that skips depth more stack frames when
+// looking up the caller.
+func (s *logrSink) WithCallDepth(depth int) logr.LogSink {
+	s2 := *s
+	s2.callDepth += depth
+	return &s2
+}
+
+// fields builds the fields for a single entry.
+func (s *logrSink) fields(err error, keysAndValues []interface{}) []Field {
+	fields := make([]Field, 0, len(keysAndValues)/2+2)
+	if s.name != "" {
+		fields = append(fields, String("logger", s.name))
+	}
+	if err != nil {
+		fields = append(fields, Err(err))
+	}
+	return append(fields, fieldsFromKeysAndValues(keysAndValues)...)
+}
@@ -0,0 +1,82 @@
+package gologs
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+)
+
+// tests logging through a logr.Logger
+func TestLogrSink(t *testing.T) {
+	var out bytes.Buffer
+	log := logr.New(LogrSink(NewLogger(DEBUG, &out)))
+
+	log.WithName("controller").WithValues("namespace", "default").Info("Reconciling", "name", "web")
+	output := out.String()
+	if !strings.Contains(output, `"level":"INFO"`) {
+		t.Errorf("Expected INFO level in output, got %v", output)
+	}
+	if !strings.Contains(output, `"namespace":"default","logger":"controller","name":"web"`) {
+		t.Errorf("Expected name and values in output, got %v", output)
+	}
+	if !strings.Contains(output, `"caller":"TestLogrSink"`) {
+		t.Errorf("Expected caller of logr call in output, got %v", output)
+	}
+	out.Reset()
+
+	log.V(1).Info("Details")
+	if !strings.Contains(out.String(), `"level":"DEBUG"`) {
+		t.Errorf("Expected DEBUG level for V(1), got %v", out.String())
+	}
+	out.Reset()
+
+	log.Error(errors.New("not found"), "Reconcile failed")
+	output = out.String()
+	if !strings.Contains(output, `"level":"ERROR"`) || !strings.Contains(output, `"error":"not found"`) {
+		t.Errorf("Expected ERROR level with error field, got %v", output)
+	}
+}
+
+// tests that V-levels above 0 are disabled at INFO level
+func TestLogrSinkEnabled(t *testing.T) {
+	var out bytes.Buffer
+	log := logr.New(LogrSink(NewLogger(INFO, &out)))
+	if log.V(1).Enabled() {
+		t.Error("Expected V(1) to be disabled for INFO logger")
+	}
+	log.V(2).Info("filtered")
+	if out.Len() != 0 {
+		t.Errorf("Expected no output, got %v", out.String())
+	}
+}
+
+// tests that V-levels below the logger's level go to the flight recorder
+func TestLogrSinkFlightRecorder(t *testing.T) {
+	sink := &memorySink{}
+	log := logr.New(LogrSink(New(WithSinks(sink), WithLevel(INFO), WithFlightRecorder(10, DEBUG))))
+	log.V(1).Info("Details")
+	if msgs := sink.messages(); len(msgs) != 0 {
+		t.Errorf("Expected the entry to be kept back, got %v", msgs)
+	}
+	log.Error(errors.New("not found"), "Reconcile failed")
+	if msgs := sink.messages(); strings.Join(msgs, ",") != "Details,Reconcile failed" {
+		t.Errorf("Expected the recorded entry before the error, got %v", msgs)
+	}
+}
+
+// tests converting key/value lists into fields
+func TestFieldsFromKeysAndValues(t *testing.T) {
+	fields := fieldsFromKeysAndValues([]interface{}{"a", 1, 2, Bool("b", true), "dangling"})
+	keys := []string{"a", badKey, "b", badKey}
+	if len(fields) != len(keys) {
+		t.Fatalf("Expected %d fields, got %d", len(keys), len(fields))
+	}
+	for i, k := range keys {
+		if fields[i].Key != k {
+			t.Errorf("Expected key %v at %d, got %v", k, i, fields[i].Key)
+		}
+	}
+}
@@ -1,13 +1,15 @@
 package gologs
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -16,111 +18,447 @@ type LogLevel int
 
 // Log levels.
 const (
-	DEBUG LogLevel = iota
+	TRACE LogLevel = iota - 1
+	DEBUG
 	INFO
 	WARN
 	ERROR
+	PANIC
 	FATAL
+	// OFF disables all output. FATAL and PANIC entries are suppressed too,
+	// but Fatal still exits and Panic still panics.
+	OFF
+	// DISABLED is an alias for OFF.
+	DISABLED = OFF
 )
 
 // Logger represents a simple logger with different log levels.
+//
+// A Logger is safe for concurrent use by multiple goroutines. Each entry is
+// written to the output with a single Write call, and writes are serialized
+// so entries from different goroutines never interleave. Loggers derived
+// with With, WithFields or Child share the level and the sinks of their
+// parent.
 type Logger struct {
-	logLevel       LogLevel
+	logLevel       *atomic.Int32
+	verbosity      *atomic.Int32
+	mu             *sync.RWMutex
+	out            *atomic.Pointer[output]
 	logger         *log.Logger
-	output         io.Writer
 	showCallerInfo bool
+	callerSkip     int
+	shortSource    bool
+	location       *time.Location
+	fields         []Field
+	sampler        Sampler
+	limiter        *rateLimiter
+	dedup          *deduper
+	escalation     *escalation
+	recorder       *flightRecorder
+	async          *asyncWriter
+	names          *nameRegistry
+	seq            *atomic.Uint64
+	flattenMaps    bool
+	controlChars   ControlChars
+	durationFormat DurationFormat
+	messageTypes   bool
+	schemaVersion  SchemaVersion
+	maxMessageSize int
+	maxEntrySize   int
+	// named is the level of a logger returned by Named.
+	named *namedLevel
+	// group is the innermost group opened with WithGroup.
+	group *openGroup
+	// marks are the keys of Once and Every.
+	marks *sync.Map
+	// v is the verbosity the logger needs to write entries, set by V.
+	v int32
+	// discarded is set on the loggers returned by Once and Every for keys
+	// that are not due. They write nothing.
+	discarded bool
+	// loops are background goroutines stopped by Close.
+	loops []*flushLoop
 }
 
 // NewLogger creates a new Logger instance with the given log level and output.
-func NewLogger(logLevel LogLevel, output io.Writer) *Logger {
-	return &Logger{
-		logLevel:       logLevel,
-		logger:         log.New(output, "", 0),
-		output:         output,
-		showCallerInfo: true,
-	}
+// Additional options may be given; see New.
+func NewLogger(logLevel LogLevel, output io.Writer, opts ...Option) *Logger {
+	return New(append([]Option{WithLevel(logLevel), WithOutput(output)}, opts...)...)
 }
 
-// setLogLevel sets the log level for the logger.
+// SetLogLevel sets the log level for the logger. It is safe to call while
+// other goroutines are logging. On a named logger, it sets the level shared
+// with the logger it was derived from; SetNamedLevel sets the level of a
+// name alone.
 func (l *Logger) SetLogLevel(logLevel LogLevel) {
-	l.logLevel = logLevel
+	l.logLevel.Store(int32(logLevel))
+}
+
+// GetLogLevel returns the current log level of the logger. For a named
+// logger, this is the level set for its name, if any.
+func (l *Logger) GetLogLevel() LogLevel {
+	if l.named != nil {
+		if level := l.named.level.Load(); level != noLevel {
+			return LogLevel(level)
+		}
+	}
+	return LogLevel(l.logLevel.Load())
 }
 
-// SetShowSource sets whether to include source file and line number in logs.
-// Defaults to false.
+// Enabled reports whether the logger writes entries at level, like
+// slog.Handler.Enabled. It takes escalations and, for loggers returned by V,
+// the verbosity into account. Loggers returned by Once and Every for keys
+// that are not due are never enabled, not even during an escalation. Use it to skip collecting data for an entry
+// that won't be written:
+//
+//	if logger.Enabled(gologs.DEBUG) {
+//		logger.Debug("Cache contents: %v", cache.Dump())
+//	}
+func (l *Logger) Enabled(level LogLevel) bool {
+	if l.discarded || !l.verbose() {
+		return false
+	}
+	if level >= l.GetLogLevel() {
+		return true
+	}
+	return l.escalation != nil && l.escalation.enabled(level)
+}
+
+// IsTrace reports whether the logger writes TRACE entries.
+func (l *Logger) IsTrace() bool {
+	return l.Enabled(TRACE)
+}
+
+// IsDebug reports whether the logger writes DEBUG entries.
+func (l *Logger) IsDebug() bool {
+	return l.Enabled(DEBUG)
+}
+
+// SetShowCallerInfo sets whether to include source file and line number in
+// logs. Defaults to true. It should be called before the logger is shared
+// between goroutines.
 func (l *Logger) SetShowCallerInfo(show bool) {
 	l.showCallerInfo = show
 }
 
-func (l *Logger) log(level LogLevel, message interface{}) {
+// AddCallerSkip returns a copy of the logger that skips skip more stack
+// frames when looking up the caller, in addition to those skipped by l. A
+// package wrapping the logger uses it to report the caller of its own
+// functions:
+//
+//	var wrapped = logger.AddCallerSkip(1)
+//
+//	func Warn(msg string) {
+//		wrapped.Warn(msg)
+//	}
+func (l *Logger) AddCallerSkip(skip int) *Logger {
+	child := *l
+	child.callerSkip += skip
+	return &child
+}
+
+func (l *Logger) log(level LogLevel, message interface{}, fields []Field) {
+	l.logDepth(1, level, message, fields)
+}
+
+// logDepth is like log, but skips depth additional stack frames when looking
+// up the caller. log itself is one frame.
+func (l *Logger) logDepth(depth int, level LogLevel, message interface{}, fields []Field) {
 
-	if level < l.logLevel {
+	if !l.accepts(level) {
 		return
 	}
+	// The entry stays on the stack. Its fields are not pooled, as sinks
+	// such as the batching ones keep entries after Write returns.
 	entry := LogEntry{
 		Level:     logLevelString(level),
-		Timestamp: time.Now(),
+		Severity:  level,
+		Timestamp: l.now(),
 		Data:      message,
+		Fields:    l.entryFields(fields),
 	}
 
 	// Include source file and line number if enabled
 	if l.showCallerInfo {
-		file, line, funcName := getCallerInfo(3)
-		if file != "?" {
-			entry.Source = fmt.Sprintf("%s:%d", file, line)
-			if funcName != "?" {
-				entry.Caller = funcName
-			}
+		entry.Source, entry.Caller = callerSite(3 + depth + l.callerSkip)
+		if l.shortSource {
+			entry.Source = shortSource(entry.Source)
 		}
 	}
+	if level >= l.out.Load().stackLevel {
+		entry.pcs = callers(3 + depth + l.callerSkip)
+	}
 
-	entryJSON, err := json.Marshal(entry)
-	if err != nil {
-		log.Printf("Failed to marshal log entry: %v", err)
+	l.write(entry)
+}
+
+// now returns the current time in the location of the logger's timestamps.
+func (l *Logger) now() time.Time {
+	if l.location != nil {
+		return time.Now().In(l.location)
+	}
+	return time.Now()
+}
+
+// accepts reports whether the logger builds entries at level: those it
+// writes, and those its flight recorder keeps. The recorder doesn't keep
+// the entries of discarded loggers, so they aren't written out with the
+// next error either.
+func (l *Logger) accepts(level LogLevel) bool {
+	if l.discarded {
+		return false
+	}
+	return l.Enabled(level) || l.recorder != nil && level >= l.recorder.level && l.verbose()
+}
+
+// entryFields returns the logger's own fields followed by the given ones.
+func (l *Logger) entryFields(fields []Field) []Field {
+	if l.group != nil {
+		fields = l.group.nest(fields)
+	}
+	if len(fields) == 0 {
+		return l.fields
+	}
+	all := make([]Field, 0, len(l.fields)+len(fields))
+	all = append(all, l.fields...)
+	return append(all, fields...)
+}
+
+// write passes the entry on to emit, unless the sampler or the rate limit
+// drops it, or it repeats the last entry. Lazy values are computed once the
+// entry passed the sampler and the rate limit. Entries below the level go to
+// the flight recorder, which is written out before errors.
+func (l *Logger) write(entry LogEntry) {
+	if l.recorder != nil && !l.Enabled(entry.Severity) {
+		l.recorder.add(entry)
 		return
 	}
+	if l.escalation != nil {
+		l.escalation.observe(entry, l.GetLogLevel())
+	}
+	if l.sampler != nil && !l.sampler.Sample(entry) {
+		return
+	}
+	if l.limiter != nil && !l.limiter.allow(entry) {
+		return
+	}
+	entry = resolveLazy(entry)
+	if l.dedup != nil && !l.dedup.add(entry) {
+		return
+	}
+	if l.recorder != nil && entry.Severity >= ERROR {
+		for _, recorded := range l.recorder.drain() {
+			l.emit(resolveLazy(recorded))
+		}
+	}
+	l.emit(entry)
+}
+
+// emit flattens, sanitizes, truncates and numbers the entry as configured
+// and passes it to all sinks of the logger, or queues it for the background
+// goroutine in async mode.
+func (l *Logger) emit(entry LogEntry) {
+	entry = textMessage(entry, l.messageTypes)
+	if l.flattenMaps {
+		entry = flattenMessage(entry)
+	}
+	if l.controlChars != KeepControlChars {
+		entry = sanitizeEntry(entry, l.controlChars)
+	}
+	if l.durationFormat != NanosecondDurations {
+		entry.Fields, _ = formatDurations(entry.Fields, l.durationFormat)
+	}
+	entry.SchemaVersion = l.schemaVersion
+	if l.maxMessageSize > 0 || l.maxEntrySize > 0 {
+		entry = l.limitSize(entry)
+	}
+	if l.seq != nil {
+		entry = l.withSeq(entry)
+	}
+	if l.async != nil {
+		l.async.add(entry)
+	} else {
+		l.deliver(entry)
+	}
+	if entry.Severity >= PANIC {
+		// The program is likely about to stop, so write out what the
+		// sinks buffered.
+		l.Flush()
+	}
+}
 
-	_, err = l.output.Write(entryJSON)
+// deliver writes the entry to all sinks of the logger.
+func (l *Logger) deliver(entry LogEntry) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	out := l.out.Load()
+	i := 0
+	err := out.each(func(s Sink) error {
+		err := s.Write(entry)
+		out.stats[i].record(err, entry.Timestamp)
+		i++
+		return err
+	})
 	if err != nil {
-		log.Printf("Failed to write log entry: %v", err)
+		out.errs.report(err, time.Now())
+	}
+}
+
+// The level methods format their message with fmt.Sprintf. Any Field values
+// among the arguments are left out of the formatting and added to the entry
+// as structured fields instead. Formatting only happens once the level check
+// passed, so calls at a disabled level are cheap.
+
+// logf formats the message and logs it if level is enabled.
+func (l *Logger) logf(level LogLevel, format string, v []any) {
+	if !l.accepts(level) {
 		return
 	}
+	args, fields := splitFields(v)
+	if len(args) == 0 {
+		l.logDepth(1, level, plainMessage(format), fields)
+		return
+	}
+	l.logDepth(1, level, formatMessage(format, args), fields)
+}
 
-	_, err = l.output.Write([]byte("\n"))
-	if err != nil {
-		log.Printf("Failed to write newline after log entry: %v", err)
+// plainMessages caches messages logged without arguments as interface
+// values, as converting a string to one allocates. Only short messages are
+// cached, up to maxPlainMessages of them, so messages built at run time
+// don't grow the cache without bound.
+var plainMessages = struct {
+	sync.RWMutex
+	m map[string]interface{}
+}{m: make(map[string]interface{})}
+
+const (
+	maxPlainMessages      = 4096
+	maxPlainMessageLength = 256
+)
+
+// plainMessage returns the formatted message for a format without
+// arguments, converted to an interface value only the first time it is
+// logged.
+func plainMessage(format string) interface{} {
+	plainMessages.RLock()
+	message, ok := plainMessages.m[format]
+	plainMessages.RUnlock()
+	if ok {
+		return message
+	}
+	message = formatMessage(format, nil)
+	if len(format) <= maxPlainMessageLength {
+		plainMessages.Lock()
+		if len(plainMessages.m) < maxPlainMessages {
+			plainMessages.m[format] = message
+		}
+		plainMessages.Unlock()
 	}
+	return message
+}
+
+// formatMessage formats a message with fmt.Sprintf. A format without
+// arguments or verbs is used as is.
+func formatMessage(format string, args []any) string {
+	if len(args) == 0 && strings.IndexByte(format, '%') < 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Trace logs a trace message, for output more detailed than DEBUG.
+func (l *Logger) Trace(format string, v ...any) {
+	l.logf(TRACE, format, v)
 }
 
 // Info logs an informational message.
 func (l *Logger) Info(format string, v ...any) {
-	message := fmt.Sprintf(format, v...)
-	l.log(INFO, message)
+	l.logf(INFO, format, v)
 }
 
 // Debug logs a debug message.
 func (l *Logger) Debug(format string, v ...any) {
-	message := fmt.Sprintf(format, v...)
-	l.log(DEBUG, message)
+	l.logf(DEBUG, format, v)
 }
 
 // Warn logs a warning message.
 func (l *Logger) Warn(format string, v ...any) {
-	message := fmt.Sprintf(format, v...)
-	l.log(WARN, message)
+	l.logf(WARN, format, v)
 }
 
 // Error logs an error message.
 func (l *Logger) Error(format string, v ...any) {
-	message := fmt.Sprintf(format, v...)
-	l.log(ERROR, message)
+	l.logf(ERROR, format, v)
+}
+
+// Panic logs a message at PANIC level and then panics with it. Unlike Fatal,
+// deferred functions run and the panic can be recovered.
+func (l *Logger) Panic(format string, v ...any) {
+	args, fields := splitFields(v)
+	message := formatMessage(format, args)
+	l.log(PANIC, message, fields)
+	panic(message)
+}
+
+// osExit is os.Exit, replaced in tests.
+var osExit = os.Exit
+
+// exit flushes the sinks and exits the program. emit only flushes for the
+// entries it writes, and a FATAL entry may have been dropped by the level,
+// the sampler or the rate limit, or by a discarded logger.
+func (l *Logger) exit() {
+	l.Flush()
+	osExit(1)
 }
 
 // Fatal logs a fatal message and exits the program.
 func (l *Logger) Fatal(format string, v ...any) {
-	message := fmt.Sprintf(format, v...)
-	l.log(FATAL, message)
-	os.Exit(1)
+	l.logf(FATAL, format, v)
+	l.exit()
+}
+
+// The w-suffixed level methods log msg as is, with keysAndValues added as
+// fields. keysAndValues alternate between string keys and values; Field
+// values may be mixed in and are used as is. A value without a string key,
+// such as the last element of an odd-length list, is added under the key
+// "!BADKEY".
+
+// Tracew logs a trace message with key/value pairs.
+func (l *Logger) Tracew(msg string, keysAndValues ...any) {
+	l.log(TRACE, msg, fieldsFromKeysAndValues(keysAndValues))
+}
+
+// Infow logs an informational message with key/value pairs.
+func (l *Logger) Infow(msg string, keysAndValues ...any) {
+	l.log(INFO, msg, fieldsFromKeysAndValues(keysAndValues))
+}
+
+// Debugw logs a debug message with key/value pairs.
+func (l *Logger) Debugw(msg string, keysAndValues ...any) {
+	l.log(DEBUG, msg, fieldsFromKeysAndValues(keysAndValues))
+}
+
+// Warnw logs a warning message with key/value pairs.
+func (l *Logger) Warnw(msg string, keysAndValues ...any) {
+	l.log(WARN, msg, fieldsFromKeysAndValues(keysAndValues))
+}
+
+// Errorw logs an error message with key/value pairs.
+func (l *Logger) Errorw(msg string, keysAndValues ...any) {
+	l.log(ERROR, msg, fieldsFromKeysAndValues(keysAndValues))
+}
+
+// Panicw logs a message with key/value pairs at PANIC level and then panics
+// with the message.
+func (l *Logger) Panicw(msg string, keysAndValues ...any) {
+	l.log(PANIC, msg, fieldsFromKeysAndValues(keysAndValues))
+	panic(msg)
+}
+
+// Fatalw logs a fatal message with key/value pairs and exits the program.
+func (l *Logger) Fatalw(msg string, keysAndValues ...any) {
+	l.log(FATAL, msg, fieldsFromKeysAndValues(keysAndValues))
+	l.exit()
 }
 
 // CustomLogEntry represents a log entry that can be chained with level methods
@@ -129,7 +467,8 @@ type CustomLogEntry struct {
 	message interface{}
 }
 
-// Log accepts a message and returns a CustomLogEntry for method chaining
+// Log accepts a message and returns a CustomLogEntry for method chaining.
+// The level methods of CustomLogEntry accept optional fields to add to the entry.
 func (l *Logger) Log(message interface{}) *CustomLogEntry {
 	return &CustomLogEntry{
 		logger:  l,
@@ -137,35 +476,48 @@ func (l *Logger) Log(message interface{}) *CustomLogEntry {
 	}
 }
 
+// Trace logs the message at TRACE level
+func (c *CustomLogEntry) Trace(fields ...Field) {
+	c.logger.log(TRACE, c.message, fields)
+}
+
 // Info logs the message at INFO level
-func (c *CustomLogEntry) Info() {
-	c.logger.log(INFO, c.message)
+func (c *CustomLogEntry) Info(fields ...Field) {
+	c.logger.log(INFO, c.message, fields)
 }
 
 // Debug logs the message at DEBUG level
-func (c *CustomLogEntry) Debug() {
-	c.logger.log(DEBUG, c.message)
+func (c *CustomLogEntry) Debug(fields ...Field) {
+	c.logger.log(DEBUG, c.message, fields)
 }
 
 // Warn logs the message at WARN level
-func (c *CustomLogEntry) Warn() {
-	c.logger.log(WARN, c.message)
+func (c *CustomLogEntry) Warn(fields ...Field) {
+	c.logger.log(WARN, c.message, fields)
 }
 
 // Error logs the message at ERROR level
-func (c *CustomLogEntry) Error() {
-	c.logger.log(ERROR, c.message)
+func (c *CustomLogEntry) Error(fields ...Field) {
+	c.logger.log(ERROR, c.message, fields)
+}
+
+// Panic logs the message at PANIC level and then panics with it
+func (c *CustomLogEntry) Panic(fields ...Field) {
+	c.logger.log(PANIC, c.message, fields)
+	panic(c.message)
 }
 
 // Fatal logs the message at FATAL level and exits the program
-func (c *CustomLogEntry) Fatal() {
-	c.logger.log(FATAL, c.message)
-	os.Exit(1)
+func (c *CustomLogEntry) Fatal(fields ...Field) {
+	c.logger.log(FATAL, c.message, fields)
+	c.logger.exit()
 }
 
 // logLevelString converts a LogLevel to a string representation.
 func logLevelString(logLevel LogLevel) string {
 	switch logLevel {
+	case TRACE:
+		return "TRACE"
 	case INFO:
 		return "INFO"
 	case DEBUG:
@@ -174,8 +526,12 @@ func logLevelString(logLevel LogLevel) string {
 		return "WARN"
 	case ERROR:
 		return "ERROR"
+	case PANIC:
+		return "PANIC"
 	case FATAL:
 		return "FATAL"
+	case OFF:
+		return "OFF"
 	default:
 		return "UNKNOWN"
 	}
@@ -184,6 +540,8 @@ func logLevelString(logLevel LogLevel) string {
 // LogLevelFromString converts a string to a LogLevel.
 func LogLevelFromString(level string) LogLevel {
 	switch level {
+	case "TRACE":
+		return TRACE
 	case "INFO":
 		return INFO
 	case "DEBUG":
@@ -192,19 +550,58 @@ func LogLevelFromString(level string) LogLevel {
 		return WARN
 	case "ERROR":
 		return ERROR
+	case "PANIC":
+		return PANIC
 	case "FATAL":
 		return FATAL
+	case "OFF", "DISABLED":
+		return OFF
 	default:
 		return DEBUG
 	}
 }
 
+// ParseLogLevel converts a level name such as "info" or "WARN" to a LogLevel.
+// Unlike LogLevelFromString it is case-insensitive and returns an error for
+// unknown names.
+func ParseLogLevel(level string) (LogLevel, error) {
+	switch strings.ToUpper(strings.TrimSpace(level)) {
+	case "TRACE":
+		return TRACE, nil
+	case "DEBUG":
+		return DEBUG, nil
+	case "INFO":
+		return INFO, nil
+	case "WARN", "WARNING":
+		return WARN, nil
+	case "ERROR":
+		return ERROR, nil
+	case "PANIC":
+		return PANIC, nil
+	case "FATAL":
+		return FATAL, nil
+	case "OFF", "DISABLED":
+		return OFF, nil
+	default:
+		return DEBUG, fmt.Errorf("unknown log level %q", level)
+	}
+}
+
+// LogEntry is a single log entry as passed to encoders and sinks.
 type LogEntry struct {
 	Level     string      `json:"level,omitempty"`
+	Severity  LogLevel    `json:"-"`
 	Timestamp time.Time   `json:"timestamp,omitempty"`
 	Source    string      `json:"source,omitempty"`
 	Caller    string      `json:"caller,omitempty"`
 	Data      interface{} `json:"data"`
+	Fields    []Field     `json:"-"`
+	// SchemaVersion is the layout of the entry in JSON, set with
+	// WithSchemaVersion, or 0 for unversioned entries.
+	SchemaVersion SchemaVersion `json:"schema_version,omitempty"`
+	// pcs is the stack of the logging call, captured for sinks that send
+	// it.
+	pcs []uintptr
 }
 
 func shortFuncName(full string) string {
@@ -213,6 +610,60 @@ func shortFuncName(full string) string {
 	return strings.Split(last, ".")[1] // after package name
 }
 
+// shortSource trims the "file:line" source location to the directory of the
+// file, its name and the line.
+func shortSource(source string) string {
+	i := strings.LastIndexByte(source, '/')
+	if i < 0 {
+		return source
+	}
+	if j := strings.LastIndexByte(source[:i], '/'); j >= 0 {
+		return source[j+1:]
+	}
+	return source
+}
+
+// callerSites caches the source location and function name by program
+// counter, so that looking up the caller of a log call only allocates the
+// first time a call site logs.
+var callerSites = struct {
+	sync.RWMutex
+	m map[uintptr][2]string
+}{m: make(map[uintptr][2]string)}
+
+// callerSite returns the "file:line" source location and the function name
+// of the caller skip frames up, as counted by getCallerInfo. Both are empty
+// if the caller is unknown.
+func callerSite(skip int) (source, caller string) {
+	var pcs [1]uintptr
+	if runtime.Callers(skip+1, pcs[:]) == 0 {
+		return "", ""
+	}
+	callerSites.RLock()
+	site, ok := callerSites.m[pcs[0]]
+	callerSites.RUnlock()
+	if !ok {
+		file, line, funcName := getCallerInfo(skip + 1)
+		if file != "?" {
+			site[0] = file + ":" + strconv.Itoa(line)
+			if funcName != "?" {
+				site[1] = funcName
+			}
+		}
+		callerSites.Lock()
+		callerSites.m[pcs[0]] = site
+		callerSites.Unlock()
+	}
+	return site[0], site[1]
+}
+
+// callers returns the program counters of the stack, starting skip frames
+// above the caller as with callerSite.
+func callers(skip int) []uintptr {
+	pcs := make([]uintptr, 64)
+	return pcs[:runtime.Callers(skip+1, pcs)]
+}
+
 func getCallerInfo(skip int) (file string, line int, funcName string) {
 	// pc = program counter
 	pc, file, line, ok := runtime.Caller(skip)
@@ -2,9 +2,13 @@ package gologs
 
 import (
 	"bytes"
+	"encoding/json"
+	"io"
 	"os"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 var logger *Logger
@@ -29,6 +33,27 @@ func TestDebug(t *testing.T) {
 	buf.Reset()
 }
 
+// tests trace log level
+func TestTrace(t *testing.T) {
+	logger.Trace("This is a filtered trace message")
+	if strings.Contains(buf.String(), "This is a filtered trace message") {
+		t.Errorf("Expected trace message to be filtered at DEBUG level")
+	}
+	buf.Reset()
+
+	traceLogger := NewLogger(TRACE, &buf)
+	traceLogger.Trace("This is a trace message")
+	traceLogger.Log("Custom trace").Trace()
+	output := buf.String()
+	if !strings.Contains(output, "This is a trace message") || !strings.Contains(output, "Custom trace") {
+		t.Errorf("Expected trace messages, got %v", output)
+	}
+	if !strings.Contains(output, `"level":"TRACE"`) {
+		t.Errorf("Expected TRACE level in output, got %v", output)
+	}
+	buf.Reset()
+}
+
 // tests info log level
 func TestInfo(t *testing.T) {
 	logger.Info("This is an info message")
@@ -66,7 +91,7 @@ func TestFatal(t *testing.T) {
 	// Note: This would need a more sophisticated test setup to properly test os.Exit()
 	// For this test, we'll create a separate logger method that doesn't exit
 	testLogger := NewLogger(DEBUG, &buf)
-	testLogger.log(FATAL, "This is a fatal message")
+	testLogger.log(FATAL, "This is a fatal message", nil)
 	output := buf.String()
 	if !strings.Contains(output, "This is a fatal message") {
 		t.Errorf("Expected 'This is a fatal message', got %v", output)
@@ -77,6 +102,22 @@ func TestFatal(t *testing.T) {
 	buf.Reset()
 }
 
+// tests that Panic logs the message and then panics with it
+func TestPanic(t *testing.T) {
+	defer func() {
+		r := recover()
+		if r != "Panic number 42" {
+			t.Errorf("Expected panic with 'Panic number 42', got %v", r)
+		}
+		output := buf.String()
+		if !strings.Contains(output, `"level":"PANIC"`) {
+			t.Errorf("Expected PANIC level in output, got %v", output)
+		}
+		buf.Reset()
+	}()
+	logger.Panic("Panic number %d", 42)
+}
+
 // tests debug log level with formatting
 func TestDebugFormatting(t *testing.T) {
 	logger.Debug("User %s has %d points", "John", 42)
@@ -171,6 +212,43 @@ func TestCustomLogComplexObjects(t *testing.T) {
 	buf.Reset()
 }
 
+// tests the key/value logging methods
+func TestKeyValueLogging(t *testing.T) {
+	logger.Infow("User logged in", "user", "john", "id", 42)
+	output := buf.String()
+	if !strings.Contains(output, `"level":"INFO"`) {
+		t.Errorf("Expected INFO level in output, got %v", output)
+	}
+	if !strings.Contains(output, `"data":"User logged in","user":"john","id":42`) {
+		t.Errorf("Expected message and fields in output, got %v", output)
+	}
+	if !strings.Contains(output, `"caller":"TestKeyValueLogging"`) {
+		t.Errorf("Expected caller in output, got %v", output)
+	}
+	buf.Reset()
+
+	logger.Warnw("Odd arguments", "key", "value", 42, "dangling")
+	output = buf.String()
+	if !strings.Contains(output, `"key":"value","!BADKEY":42,"!BADKEY":"dangling"`) {
+		t.Errorf("Expected bad keys in output, got %v", output)
+	}
+	buf.Reset()
+
+	logger.Errorw("Typed", Int("code", 7))
+	output = buf.String()
+	if !strings.Contains(output, `"code":7`) {
+		t.Errorf("Expected typed field in output, got %v", output)
+	}
+	buf.Reset()
+
+	logger.Debugw("Debug with fields", "n", 1)
+	output = buf.String()
+	if !strings.Contains(output, `"level":"DEBUG"`) {
+		t.Errorf("Expected DEBUG level in output, got %v", output)
+	}
+	buf.Reset()
+}
+
 // test if log level filtering works
 func TestLogLevelFilter(t *testing.T) {
 	logger.SetLogLevel(INFO)
@@ -182,8 +260,86 @@ func TestLogLevelFilter(t *testing.T) {
 	buf.Reset()
 }
 
+// tests the level checks
+func TestEnabled(t *testing.T) {
+	l := NewLogger(DEBUG, io.Discard)
+	if !l.Enabled(DEBUG) || !l.Enabled(ERROR) || l.Enabled(TRACE) {
+		t.Errorf("Expected DEBUG and above to be enabled")
+	}
+	if !l.IsDebug() || l.IsTrace() {
+		t.Errorf("Expected IsDebug but not IsTrace at DEBUG level")
+	}
+	l.SetLogLevel(WARN)
+	if l.IsDebug() || l.Enabled(INFO) || !l.Enabled(WARN) {
+		t.Errorf("Expected the checks to follow SetLogLevel")
+	}
+}
+
+// countingStringer counts how often it is formatted.
+type countingStringer struct{ calls *int }
+
+func (s countingStringer) String() string {
+	*s.calls++
+	return "formatted"
+}
+
+// tests that messages at a disabled level are not formatted
+func TestDisabledLevelSkipsFormatting(t *testing.T) {
+	var calls int
+	l := NewLogger(INFO, io.Discard)
+	l.Debug("value: %s", countingStringer{&calls})
+	if calls != 0 {
+		t.Errorf("Expected no formatting at a disabled level, got %d calls", calls)
+	}
+	l.Info("value: %s", countingStringer{&calls})
+	if calls != 1 {
+		t.Errorf("Expected formatting at an enabled level, got %d calls", calls)
+	}
+}
+
+// tests that a format without arguments is still formatted if it has verbs
+func TestFormatWithoutArguments(t *testing.T) {
+	for format, want := range map[string]string{"plain": "plain", "100%% done": "100% done", "%d": "%!d(MISSING)"} {
+		if got := formatMessage(format, nil); got != want {
+			t.Errorf("Expected %q for %q, got %q", want, format, got)
+		}
+	}
+}
+
+// tests that plain messages are formatted once, and long ones not cached
+func TestPlainMessage(t *testing.T) {
+	if got := plainMessage("100%% done"); got != "100% done" {
+		t.Errorf("Expected %q, got %v", "100% done", got)
+	}
+	long := strings.Repeat("x", maxPlainMessageLength+1)
+	if got := plainMessage(long); got != long {
+		t.Errorf("Expected the long message, got %v", got)
+	}
+	plainMessages.RLock()
+	_, cached := plainMessages.m[long]
+	plainMessages.RUnlock()
+	if cached {
+		t.Error("Expected long messages not to be cached")
+	}
+}
+
+// tests that the OFF level suppresses all output
+func TestLogLevelOff(t *testing.T) {
+	offLogger := NewLogger(DEBUG, &buf)
+	offLogger.SetLogLevel(OFF)
+	offLogger.Error("This is an error message")
+	offLogger.log(FATAL, "This is a fatal message", nil)
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output at OFF level, got %v", buf.String())
+	}
+	buf.Reset()
+}
+
 // tests setting log level to string
 func TestLogLevelString(t *testing.T) {
+	if logLevelString(TRACE) != "TRACE" {
+		t.Errorf("Expected 'TRACE', got %v", logLevelString(TRACE))
+	}
 	if logLevelString(DEBUG) != "DEBUG" {
 		t.Errorf("Expected 'DEBUG', got %v", logLevelString(DEBUG))
 	}
@@ -196,6 +352,9 @@ func TestLogLevelString(t *testing.T) {
 	if logLevelString(ERROR) != "ERROR" {
 		t.Errorf("Expected 'ERROR', got %v", logLevelString(ERROR))
 	}
+	if logLevelString(PANIC) != "PANIC" {
+		t.Errorf("Expected 'PANIC', got %v", logLevelString(PANIC))
+	}
 	if logLevelString(FATAL) != "FATAL" {
 		t.Errorf("Expected 'FATAL', got %v", logLevelString(FATAL))
 	}
@@ -203,6 +362,9 @@ func TestLogLevelString(t *testing.T) {
 
 // tests setting log level from string
 func TestLogLevelFromString(t *testing.T) {
+	if LogLevelFromString("TRACE") != TRACE {
+		t.Errorf("Expected Trace, got %v", LogLevelFromString("TRACE"))
+	}
 	if LogLevelFromString("DEBUG") != DEBUG {
 		t.Errorf("Expected Debug, got %v", LogLevelFromString("DEBUG"))
 	}
@@ -215,9 +377,63 @@ func TestLogLevelFromString(t *testing.T) {
 	if LogLevelFromString("ERROR") != ERROR {
 		t.Errorf("Expected Error, got %v", LogLevelFromString("ERROR"))
 	}
+	if LogLevelFromString("PANIC") != PANIC {
+		t.Errorf("Expected Panic, got %v", LogLevelFromString("PANIC"))
+	}
 	if LogLevelFromString("FATAL") != FATAL {
 		t.Errorf("Expected Fatal, got %v", LogLevelFromString("FATAL"))
 	}
+	if LogLevelFromString("OFF") != OFF || LogLevelFromString("DISABLED") != OFF {
+		t.Errorf("Expected Off, got %v", LogLevelFromString("OFF"))
+	}
+}
+
+// tests logging and changing the level from many goroutines
+func TestConcurrentLogging(t *testing.T) {
+	var out bytes.Buffer
+	concurrentLogger := NewLogger(DEBUG, &out)
+	child := concurrentLogger.Child(String("component", "worker"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				child.Info("Worker %d iteration %d", i, j)
+				concurrentLogger.SetLogLevel(INFO)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 1000 {
+		t.Fatalf("Expected 1000 lines, got %d", len(lines))
+	}
+	for _, line := range lines {
+		if !json.Valid([]byte(line)) {
+			t.Fatalf("Expected valid JSON line, got %v", line)
+		}
+	}
+	if child.GetLogLevel() != INFO {
+		t.Errorf("Expected child to share level with parent, got %v", child.GetLogLevel())
+	}
+}
+
+// tests parsing level names
+func TestParseLogLevel(t *testing.T) {
+	level, err := ParseLogLevel("warning")
+	if err != nil || level != WARN {
+		t.Errorf("Expected WARN, got %v (%v)", level, err)
+	}
+	level, err = ParseLogLevel(" Debug ")
+	if err != nil || level != DEBUG {
+		t.Errorf("Expected DEBUG, got %v (%v)", level, err)
+	}
+	if _, err := ParseLogLevel("verbose"); err == nil {
+		t.Error("Expected error for unknown level")
+	}
 }
 
 func TestPrintExampleLog(t *testing.T) {
@@ -225,3 +441,174 @@ func TestPrintExampleLog(t *testing.T) {
 	stdoutLogger.Info("This is an example log message")
 	stdoutLogger.Log("This is a custom log entry with caller info").Debug()
 }
+
+// wrappedWarn stands for a function of a package wrapping the logger.
+func wrappedWarn(l *Logger, msg string) {
+	l.Warn(msg)
+}
+
+// tests skipping the frames of a wrapper and short source locations
+func TestCallerSkip(t *testing.T) {
+	sink := &memorySink{}
+	l := New(WithSinks(sink), WithShortSource(true))
+	wrappedWarn(l, "direct")
+	wrappedWarn(l.AddCallerSkip(1), "skipped")
+	wrappedWarn(New(WithSinks(sink), WithCallerSkip(1)), "option")
+
+	if e := sink.entries[0]; e.Caller != "wrappedWarn" {
+		t.Errorf("Expected the wrapper as the caller, got %s in %s", e.Caller, e.Source)
+	}
+	if e := sink.entries[1]; e.Caller != "TestCallerSkip" || strings.Count(e.Source, "/") != 1 {
+		t.Errorf("Expected the caller of the wrapper with a short source, got %s in %s", e.Caller, e.Source)
+	}
+	if e := sink.entries[2]; e.Caller != "TestCallerSkip" || !strings.HasPrefix(e.Source, "/") {
+		t.Errorf("Expected the caller of the wrapper with the full path, got %s in %s", e.Caller, e.Source)
+	}
+	if got := shortSource("/src/app/server/handler.go:42"); got != "server/handler.go:42" {
+		t.Errorf("Expected server/handler.go:42, got %s", got)
+	}
+}
+
+// tests that looking up the caller doesn't allocate once a call site is known
+func TestCallerInfoAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations differ under the race detector")
+	}
+	logWith := func(l *Logger) func() {
+		return func() { l.Info("request served") }
+	}
+	without := testing.AllocsPerRun(100, logWith(NewLogger(INFO, io.Discard, WithCallerInfo(false))))
+	with := testing.AllocsPerRun(100, logWith(NewLogger(INFO, io.Discard)))
+	if with != without {
+		t.Errorf("Expected %v allocations with caller info, got %v", without, with)
+	}
+}
+
+// tests the allocations of the hot path, to catch performance regressions
+func TestLoggerAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations differ under the race detector")
+	}
+	l := NewLogger(INFO, io.Discard)
+	plain := NewLogger(INFO, io.Discard, WithCallerInfo(false))
+	child := plain.Child(String("service", "api"), Int("pid", 42))
+	for _, c := range []struct {
+		name string
+		max  float64
+		log  func()
+	}{
+		{"disabled level", 0, func() { l.Debug("served %s with %d", "/", 200) }},
+		{"message", 0, func() { plain.Info("request served") }},
+		{"caller info", 0, func() { l.Info("request served") }},
+		{"child fields", 0, func() { child.Info("request served") }},
+		{"formatted", 2, func() { plain.Info("served %s with %d", "/", 200) }},
+		{"key/values", 3, func() { plain.Infow("request served", "path", "/", "status", 200) }},
+		{"typed fields", 5, func() { plain.Info("request served", String("path", "/"), Int("status", 200)) }},
+	} {
+		if allocs := testing.AllocsPerRun(100, c.log); allocs > c.max {
+			t.Errorf("Expected at most %v allocations for %s, got %v", c.max, c.name, allocs)
+		}
+	}
+}
+
+func BenchmarkLoggerInfo(b *testing.B) {
+	l := NewLogger(INFO, io.Discard, WithCallerInfo(false))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("request served")
+	}
+}
+
+func BenchmarkLoggerCallerInfo(b *testing.B) {
+	l := NewLogger(INFO, io.Discard)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("request served")
+	}
+}
+
+func BenchmarkLoggerDisabled(b *testing.B) {
+	l := NewLogger(INFO, io.Discard)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Debug("served %s with %d", "/", 200)
+	}
+}
+
+func BenchmarkLoggerFields(b *testing.B) {
+	l := NewLogger(INFO, io.Discard, WithCallerInfo(false))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("request served", String("path", "/"), Int("status", 200))
+	}
+}
+
+func BenchmarkLoggerFormatted(b *testing.B) {
+	l := NewLogger(INFO, io.Discard, WithCallerInfo(false))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("served %s with %d", "/", 200)
+	}
+}
+
+func BenchmarkLoggerKeyValues(b *testing.B) {
+	l := NewLogger(INFO, io.Discard, WithCallerInfo(false))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Infow("request served", "path", "/", "status", 200)
+	}
+}
+
+func BenchmarkLoggerChild(b *testing.B) {
+	l := NewLogger(INFO, io.Discard, WithCallerInfo(false)).Child(String("service", "api"), Int("pid", 42))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("request served")
+	}
+}
+
+func BenchmarkLoggerConsole(b *testing.B) {
+	l := NewLogger(INFO, io.Discard, WithCallerInfo(false), WithEncoder(ConsoleEncoder{}))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("request served", String("path", "/"), Int("status", 200))
+	}
+}
+
+func BenchmarkLoggerLogfmt(b *testing.B) {
+	l := NewLogger(INFO, io.Discard, WithCallerInfo(false), WithEncoder(LogfmtEncoder{}))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("request served", String("path", "/"), Int("status", 200))
+	}
+}
+
+func BenchmarkLoggerAsync(b *testing.B) {
+	l := NewLogger(INFO, io.Discard, WithCallerInfo(false), WithAsync(10000))
+	defer l.Close()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("request served", Int("status", 200))
+	}
+	l.Flush()
+}
+
+func BenchmarkLoggerAsyncBatch(b *testing.B) {
+	l := NewLogger(INFO, io.Discard, WithCallerInfo(false), WithAsync(10000), WithAsyncBatch(64<<10, time.Second))
+	defer l.Close()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("request served", Int("status", 200))
+	}
+	l.Flush()
+}
+
+func BenchmarkLoggerParallel(b *testing.B) {
+	l := NewLogger(INFO, io.Discard)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Info("request served", Int("status", 200))
+		}
+	})
+}
@@ -0,0 +1,156 @@
+package gologs
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LokiSink is a Sink that pushes entries to Grafana Loki using the push API.
+// Each entry is sent as a JSON line in a stream identified by the sink's
+// static labels plus labels taken from the entry. Entries are sent in
+// batches from a background goroutine; see the HTTPOption functions for
+// batching, retries and authentication.
+type LokiSink struct {
+	url       string
+	labels    map[string]string
+	labelKeys []string
+	config    httpConfig
+	batch     *batcher
+}
+
+// NewLokiSink returns a sink pushing to the Loki server at url, such as
+// "http://loki:3100". If url has no path, "/loki/api/v1/push" is appended.
+// labels are added to every stream. labelKeys lists fields whose values are
+// used as additional labels; the key "level" adds the entry's level. Keep
+// the number of distinct label values small, as each combination is a
+// separate stream in Loki.
+func NewLokiSink(url string, labels map[string]string, labelKeys []string, opts ...HTTPOption) *LokiSink {
+	s := &LokiSink{
+		url:       lokiPushURL(url),
+		labels:    labels,
+		labelKeys: labelKeys,
+		config:    newHTTPConfig(opts),
+	}
+	s.batch = s.config.startBatcher(s.send)
+	return s
+}
+
+// lokiPushURL appends the push path to rawURL if it has no path.
+func lokiPushURL(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && (u.Path == "" || u.Path == "/") {
+		return strings.TrimSuffix(rawURL, "/") + "/loki/api/v1/push"
+	}
+	return rawURL
+}
+
+// Write queues the entry to be pushed.
+func (s *LokiSink) Write(entry LogEntry) error {
+	return s.batch.add(entry)
+}
+
+// Flush pushes all queued entries.
+func (s *LokiSink) Flush() error {
+	return s.batch.flush()
+}
+
+// Close pushes all queued entries and stops the background goroutine.
+func (s *LokiSink) Close() error {
+	return s.batch.close()
+}
+
+// Health returns the results of the sent batches and the number of entries
+// waiting to be sent.
+func (s *LokiSink) Health() SinkHealth {
+	return s.batch.health()
+}
+
+func (s *LokiSink) batches() *batcher {
+	return s.batch
+}
+
+// lokiStream is a stream in a push request.
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// send pushes a batch of entries, grouped into streams by their labels.
+func (s *LokiSink) send(entries []LogEntry) error {
+	var streams []*lokiStream
+	byLabels := make(map[string]*lokiStream)
+	var buf bytes.Buffer
+	for _, e := range entries {
+		labels, err := s.entryLabels(e)
+		if err != nil {
+			return err
+		}
+		key := lokiStreamKey(labels)
+		stream := byLabels[key]
+		if stream == nil {
+			stream = &lokiStream{Stream: labels}
+			byLabels[key] = stream
+			streams = append(streams, stream)
+		}
+
+		buf.Reset()
+		if err := appendJSONEntry(&buf, e, EncoderConfig{}); err != nil {
+			return err
+		}
+		stream.Values = append(stream.Values, [2]string{
+			strconv.FormatInt(e.Timestamp.UnixNano(), 10),
+			buf.String(),
+		})
+	}
+
+	body, err := json.Marshal(map[string][]*lokiStream{"streams": streams})
+	if err != nil {
+		return err
+	}
+	return s.config.post(s.url, "application/json", body)
+}
+
+// entryLabels returns the labels of the stream the entry belongs to.
+func (s *LokiSink) entryLabels(e LogEntry) (map[string]string, error) {
+	labels := make(map[string]string, len(s.labels)+len(s.labelKeys))
+	for k, v := range s.labels {
+		labels[k] = v
+	}
+	for _, key := range s.labelKeys {
+		if key == "level" {
+			labels["level"] = strings.ToLower(e.Level)
+			continue
+		}
+		for _, f := range e.Fields {
+			if f.Key != key || f.Type == skipType {
+				continue
+			}
+			value, err := fieldText(f)
+			if err != nil {
+				return nil, err
+			}
+			labels[key] = value
+		}
+	}
+	return labels, nil
+}
+
+// lokiStreamKey returns a string identifying a set of labels.
+func lokiStreamKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(strconv.Quote(k))
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(labels[k]))
+		b.WriteByte(',')
+	}
+	return b.String()
+}
@@ -0,0 +1,60 @@
+package gologs
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// tests pushing entries to Loki grouped into streams by label
+func TestLokiSink(t *testing.T) {
+	bodies := make(chan []byte, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/loki/api/v1/push" {
+			t.Errorf("Expected push path, got %v", r.URL.Path)
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "tenant" || pass != "key" {
+			t.Errorf("Expected basic auth, got %v", r.Header.Get("Authorization"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sink := NewLokiSink(server.URL, map[string]string{"app": "shop"}, []string{"level", "region"},
+		WithBasicAuth("tenant", "key"))
+	l := New(WithSinks(sink), WithCallerInfo(false))
+	l.Info("first", String("region", "eu"))
+	l.Info("second", String("region", "eu"))
+	l.Error("third", String("region", "us"))
+	if err := l.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var req struct {
+		Streams []lokiStream `json:"streams"`
+	}
+	if err := json.Unmarshal(<-bodies, &req); err != nil {
+		t.Fatal(err)
+	}
+	if len(req.Streams) != 2 {
+		t.Fatalf("Expected 2 streams, got %+v", req.Streams)
+	}
+	eu := req.Streams[0]
+	if eu.Stream["app"] != "shop" || eu.Stream["level"] != "info" || eu.Stream["region"] != "eu" {
+		t.Errorf("Expected app, level and region labels, got %v", eu.Stream)
+	}
+	if len(eu.Values) != 2 {
+		t.Fatalf("Expected 2 entries in first stream, got %v", eu.Values)
+	}
+	var line map[string]interface{}
+	if err := json.Unmarshal([]byte(eu.Values[0][1]), &line); err != nil || line["data"] != "first" {
+		t.Errorf("Expected JSON line for first entry, got %v", eu.Values[0][1])
+	}
+	if us := req.Streams[1]; us.Stream["level"] != "error" || us.Stream["region"] != "us" {
+		t.Errorf("Expected error stream for us, got %v", us.Stream)
+	}
+}
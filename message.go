@@ -0,0 +1,67 @@
+package gologs
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// WithMessageTypes sets whether messages written as text because they
+// implement error, encoding.TextMarshaler or fmt.Stringer are marked with
+// a "data_type" field holding their Go type, such as "*net.OpError", so
+// entries stay searchable by type.
+func WithMessageTypes(types bool) Option {
+	return func(o *options) {
+		o.messageTypes = types
+	}
+}
+
+// textMessage returns the entry with a message implementing error,
+// encoding.TextMarshaler or fmt.Stringer replaced by its text, checked in
+// that order, as their JSON encoding is often an empty object or a bare
+// number. Messages implementing json.Marshaler keep their own encoding,
+// and nil pointers are left as they are. With types set, the entry gets a
+// "data_type" field for a replaced message.
+func textMessage(entry LogEntry, types bool) LogEntry {
+	var text string
+	switch v := entry.Data.(type) {
+	case nil, string, json.Marshaler:
+		return entry
+	case error:
+		if isNilPointer(v) {
+			return entry
+		}
+		text = v.Error()
+	case encoding.TextMarshaler:
+		if isNilPointer(v) {
+			return entry
+		}
+		data, err := v.MarshalText()
+		if err != nil {
+			return entry
+		}
+		text = string(data)
+	case fmt.Stringer:
+		if isNilPointer(v) {
+			return entry
+		}
+		text = v.String()
+	default:
+		return entry
+	}
+	if types {
+		fields := make([]Field, 0, len(entry.Fields)+1)
+		fields = append(fields, entry.Fields...)
+		entry.Fields = append(fields, String("data_type", fmt.Sprintf("%T", entry.Data)))
+	}
+	entry.Data = text
+	return entry
+}
+
+// isNilPointer reports whether v is a nil pointer, whose methods may
+// panic.
+func isNilPointer(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
@@ -0,0 +1,71 @@
+package gologs
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testStatus int
+
+func (s testStatus) String() string {
+	return [...]string{"pending", "active"}[s]
+}
+
+type testHost struct {
+	name string
+}
+
+func (h *testHost) String() string {
+	return "host " + h.name
+}
+
+// tests that messages implementing error, TextMarshaler and Stringer are
+// written as text
+func TestTextMessage(t *testing.T) {
+	var nilHost *testHost
+	tests := []struct {
+		message  interface{}
+		expected string
+	}{
+		{errors.New("connection reset"), `"data":"connection reset"`},
+		{net.ParseIP("10.0.0.1"), `"data":"10.0.0.1"`},
+		{testStatus(1), `"data":"active"`},
+		{&testHost{name: "db1"}, `"data":"host db1"`},
+		{1500 * time.Millisecond, `"data":"1.5s"`},
+		{time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), `"data":"2024-05-01T00:00:00Z"`},
+		{nilHost, `"data":null`},
+		{42, `"data":42`},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		l := NewLogger(INFO, &out, WithCallerInfo(false))
+		l.Log(tt.message).Info()
+		if !strings.Contains(out.String(), tt.expected) {
+			t.Errorf("Expected %s, got %s", tt.expected, out.String())
+		}
+		if strings.Contains(out.String(), `"data_type"`) {
+			t.Errorf("Expected no type without WithMessageTypes, got %s", out.String())
+		}
+	}
+}
+
+// tests that WithMessageTypes adds the type of messages written as text
+func TestMessageTypes(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(INFO, &out, WithCallerInfo(false), WithMessageTypes(true))
+	l.Log(testStatus(0)).Info(String("job", "import"))
+	expected := `"data":"pending","job":"import","data_type":"gologs.testStatus"}`
+	if !strings.HasSuffix(strings.TrimSpace(out.String()), expected) {
+		t.Errorf("Expected %s, got %s", expected, out.String())
+	}
+	out.Reset()
+
+	l.Log("plain").Info()
+	if strings.Contains(out.String(), `"data_type"`) {
+		t.Errorf("Expected no type for a string message, got %s", out.String())
+	}
+}
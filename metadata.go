@@ -0,0 +1,95 @@
+package gologs
+
+import (
+	"os"
+	"runtime/debug"
+)
+
+// WithHostname adds the name of the host, as reported by os.Hostname, to
+// every entry as the "host" field. Nothing is added if the name is unknown.
+func WithHostname() Option {
+	host, err := os.Hostname()
+	return func(o *options) {
+		if err == nil {
+			o.fields = append(o.fields, String("host", host))
+		}
+	}
+}
+
+// WithPID adds the process ID to every entry as the "pid" field.
+func WithPID() Option {
+	return func(o *options) {
+		o.fields = append(o.fields, Int("pid", os.Getpid()))
+	}
+}
+
+// WithService adds the name of the service to every entry as the "service"
+// field.
+func WithService(name string) Option {
+	return func(o *options) {
+		o.fields = append(o.fields, String("service", name))
+	}
+}
+
+// WithEnvironment adds the deployment environment, such as "prod" or
+// "staging", to every entry as the "env" field.
+func WithEnvironment(env string) Option {
+	return func(o *options) {
+		o.fields = append(o.fields, String("env", env))
+	}
+}
+
+// BuildTime is the time the program was built, added by WithBuildInfo as
+// the "build_time" field. Go doesn't record it, so set it when building:
+//
+//	go build -ldflags "-X github.com/phasi/go-logs.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var BuildTime string
+
+// WithBuildInfo adds the build of the program, as read by
+// debug.ReadBuildInfo, to every entry, so that each entry identifies the
+// exact build it came from:
+//
+//   - "version": the version of the main module, unless it is "(devel)"
+//   - "revision": the VCS revision the program was built from
+//   - "revision_time": the commit time of that revision, not the build time
+//   - "modified": true if the working tree had uncommitted changes
+//   - "build_time": BuildTime, if it was set
+//   - "go_version": the Go version the program was built with
+//
+// The VCS fields are only available for binaries built with go build in a
+// repository, not with go run or go test.
+func WithBuildInfo() Option {
+	info, ok := debug.ReadBuildInfo()
+	return func(o *options) {
+		if ok {
+			o.fields = append(o.fields, buildInfoFields(info, BuildTime)...)
+		}
+	}
+}
+
+// buildInfoFields returns the fields describing a build.
+func buildInfoFields(info *debug.BuildInfo, buildTime string) []Field {
+	var fields []Field
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		fields = append(fields, String("version", v))
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			fields = append(fields, String("revision", s.Value))
+		case "vcs.time":
+			fields = append(fields, String("revision_time", s.Value))
+		case "vcs.modified":
+			if s.Value == "true" {
+				fields = append(fields, Bool("modified", true))
+			}
+		}
+	}
+	if buildTime != "" {
+		fields = append(fields, String("build_time", buildTime))
+	}
+	if info.GoVersion != "" {
+		fields = append(fields, String("go_version", info.GoVersion))
+	}
+	return fields
+}
@@ -0,0 +1,55 @@
+package gologs
+
+import (
+	"os"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+// tests the metadata options
+func TestMetadata(t *testing.T) {
+	sink := &memorySink{}
+	l := New(WithSinks(sink), WithHostname(), WithPID(), WithService("shop"), WithEnvironment("prod"))
+	l.Info("started")
+
+	host, _ := os.Hostname()
+	want := map[string]interface{}{"host": host, "pid": int64(os.Getpid()), "service": "shop", "env": "prod"}
+	fields := sink.entries[0].Fields
+	if len(fields) != len(want) {
+		t.Fatalf("Expected %d fields, got %+v", len(want), fields)
+	}
+	for _, f := range fields {
+		if fieldValue(f) != want[f.Key] {
+			t.Errorf("Expected %v for %s, got %v", want[f.Key], f.Key, fieldValue(f))
+		}
+	}
+}
+
+// tests the fields describing a build
+func TestBuildInfoFields(t *testing.T) {
+	info := &debug.BuildInfo{
+		GoVersion: "go1.22.3",
+		Main:      debug.Module{Path: "example.com/shop", Version: "v1.4.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "GOOS", Value: "linux"},
+			{Key: "vcs.revision", Value: "3de2d72"},
+			{Key: "vcs.time", Value: "2024-01-01T12:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+	var got []string
+	for _, f := range buildInfoFields(info, "2024-01-02T08:30:00Z") {
+		text, _ := fieldText(f)
+		got = append(got, f.Key+"="+text)
+	}
+	if want := "version=v1.4.0,revision=3de2d72,revision_time=2024-01-01T12:00:00Z,modified=true,build_time=2024-01-02T08:30:00Z,go_version=go1.22.3"; strings.Join(got, ",") != want {
+		t.Errorf("Expected %s, got %v", want, got)
+	}
+
+	info.Main.Version = "(devel)"
+	info.Settings = nil
+	if fields := buildInfoFields(info, ""); len(fields) != 1 || fields[0].Key != "go_version" {
+		t.Errorf("Expected only the Go version for a development build, got %+v", fields)
+	}
+}
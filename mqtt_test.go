@@ -0,0 +1,165 @@
+package gologs
+
+import (
+	"bufio"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeMQTTMessage is a message published to fakeMQTT.
+type fakeMQTTMessage struct {
+	topic   string
+	qos     byte
+	retain  bool
+	payload string
+}
+
+// fakeMQTT accepts a single connection, answers CONNECT with the given
+// return code and acknowledges published messages according to their QoS.
+// The body of the CONNECT packet is sent to connects.
+func fakeMQTT(t *testing.T, code byte) (string, <-chan []byte, <-chan fakeMQTTMessage) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	connects := make(chan []byte, 1)
+	messages := make(chan fakeMQTTMessage, 10)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			typ, body, err := readMQTTPacket(r)
+			if err != nil {
+				return
+			}
+			switch typ >> 4 {
+			case mqttConnect:
+				connects <- body
+				conn.Write([]byte{mqttConnack << 4, 2, 0, code})
+			case mqttPublish:
+				n := binary.BigEndian.Uint16(body)
+				msg := fakeMQTTMessage{topic: string(body[2 : 2+n]), qos: typ >> 1 & 3, retain: typ&1 == 1}
+				body = body[2+n:]
+				var id []byte
+				if msg.qos > 0 {
+					id, body = body[:2], body[2:]
+				}
+				msg.payload = string(body)
+				messages <- msg
+				switch msg.qos {
+				case 1:
+					conn.Write(appendMQTTPacket(nil, mqttPuback<<4, id))
+				case 2:
+					conn.Write(appendMQTTPacket(nil, mqttPubrec<<4, id))
+				}
+			case mqttPubrel:
+				conn.Write(appendMQTTPacket(nil, mqttPubcomp<<4, body))
+			case mqttDisconnect:
+				return
+			}
+		}
+	}()
+	return ln.Addr().String(), connects, messages
+}
+
+// receiveMQTT returns the next message.
+func receiveMQTT(t *testing.T, messages <-chan fakeMQTTMessage) fakeMQTTMessage {
+	select {
+	case msg := <-messages:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected message")
+		return fakeMQTTMessage{}
+	}
+}
+
+// tests publishing with QoS 1 and per-level topics
+func TestMQTTSink(t *testing.T) {
+	addr, connects, messages := fakeMQTT(t, 0)
+	sink, err := NewMQTTSink("tcp://device:secret@"+addr, "devices/42/logs",
+		WithMQTTQoS(1), WithMQTTLevelTopics(), WithMQTTClientID("device-42"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	l := New(WithSinks(sink), WithCallerInfo(false))
+	l.Info("Booted")
+	l.Error("Sensor offline")
+	if err := l.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	connect := string(<-connects)
+	for _, want := range []string{"MQTT", "device-42", "device", "secret"} {
+		if !strings.Contains(connect, want) {
+			t.Errorf("Expected %q in CONNECT, got %q", want, connect)
+		}
+	}
+	for _, want := range []string{"devices/42/logs/info", "devices/42/logs/error"} {
+		msg := receiveMQTT(t, messages)
+		if msg.topic != want || msg.qos != 1 {
+			t.Errorf("Expected QoS 1 message on %s, got %+v", want, msg)
+		}
+		if strings.HasSuffix(msg.payload, "\n") || !strings.HasPrefix(msg.payload, "{") {
+			t.Errorf("Expected JSON payload, got %q", msg.payload)
+		}
+	}
+}
+
+// tests the exactly once handshake of QoS 2 and retained messages
+func TestMQTTSinkQoS2(t *testing.T) {
+	addr, _, messages := fakeMQTT(t, 0)
+	sink, err := NewMQTTSink(addr, "logs", WithMQTTQoS(2), WithMQTTRetain())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer sink.Close()
+	sink.Write(LogEntry{Level: "INFO", Data: "exactly once"})
+	if err := sink.Flush(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if msg := receiveMQTT(t, messages); msg.qos != 2 || !msg.retain || msg.topic != "logs" {
+		t.Errorf("Expected retained QoS 2 message, got %+v", msg)
+	}
+}
+
+// tests that refused connections are reported
+func TestMQTTSinkRefused(t *testing.T) {
+	addr, _, _ := fakeMQTT(t, 5)
+	sink, err := NewMQTTSink(addr, "logs", WithMQTTTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer sink.Close()
+	sink.Write(LogEntry{Level: "INFO", Data: "refused"})
+	if err := sink.Flush(); err == nil || !strings.Contains(err.Error(), "not authorized") {
+		t.Errorf("Expected not authorized error, got %v", err)
+	}
+}
+
+// tests that Ping reports a refused connection
+func TestMQTTSinkPing(t *testing.T) {
+	addr, _, _ := fakeMQTT(t, 5)
+	sink, err := NewMQTTSink(addr, "logs", WithMQTTTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer sink.Close()
+	if err := sink.Ping(); err == nil || !strings.Contains(err.Error(), "not authorized") {
+		t.Errorf("Expected not authorized error, got %v", err)
+	}
+}
+
+// tests that wildcard topics are rejected
+func TestMQTTSinkInvalidTopic(t *testing.T) {
+	if _, err := NewMQTTSink("localhost", "logs/#"); err == nil {
+		t.Error("Expected error for wildcard topic")
+	}
+}
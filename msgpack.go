@@ -0,0 +1,408 @@
+package gologs
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// MsgpackEncoder encodes entries as MessagePack maps with the keys of the
+// JSON encoder, which are smaller and faster to encode than JSON for
+// high-volume shipping over the network. Entries follow each other without
+// framing, as MessagePack values are self-delimiting. Timestamps default to
+// nanoseconds since the Unix epoch.
+type MsgpackEncoder struct {
+	EncoderConfig
+}
+
+// msgpackFormat writes the values of MessagePack entries.
+var msgpackFormat = binaryFormat{
+	mapHeader: appendMsgpackMapHeader,
+	str:       appendMsgpackString,
+	integer:   appendMsgpackInt,
+	value:     appendMsgpack,
+	field:     appendMsgpackField,
+}
+
+// Encode appends the entry as a MessagePack map.
+func (e MsgpackEncoder) Encode(entry LogEntry, buf *bytes.Buffer) error {
+	data, err := appendBinaryEntry(buf.AvailableBuffer(), entry, e.EncoderConfig, msgpackFormat)
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
+
+func (e MsgpackEncoder) withConfig(fn func(*EncoderConfig)) Encoder {
+	fn(&e.EncoderConfig)
+	return e
+}
+
+// appendMsgpack appends the MessagePack encoding of v to buf. Strings,
+// numbers, booleans, byte slices, slices and maps with string keys are
+// encoded directly; other values are encoded through their JSON encoding.
+func appendMsgpack(buf []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, 0xc0), nil
+	case bool:
+		if v {
+			return append(buf, 0xc3), nil
+		}
+		return append(buf, 0xc2), nil
+	case string:
+		return appendMsgpackString(buf, v), nil
+	case []byte:
+		return appendMsgpackBinary(buf, v), nil
+	case int:
+		return appendMsgpackInt(buf, int64(v)), nil
+	case int8:
+		return appendMsgpackInt(buf, int64(v)), nil
+	case int16:
+		return appendMsgpackInt(buf, int64(v)), nil
+	case int32:
+		return appendMsgpackInt(buf, int64(v)), nil
+	case int64:
+		return appendMsgpackInt(buf, v), nil
+	case uint8:
+		return appendMsgpackUint(buf, uint64(v)), nil
+	case uint16:
+		return appendMsgpackUint(buf, uint64(v)), nil
+	case uint32:
+		return appendMsgpackUint(buf, uint64(v)), nil
+	case uint:
+		return appendMsgpackUint(buf, uint64(v)), nil
+	case uint64:
+		return appendMsgpackUint(buf, v), nil
+	case float32:
+		buf = append(buf, 0xca)
+		return binary.BigEndian.AppendUint32(buf, math.Float32bits(v)), nil
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return appendMsgpackInt(buf, int64(v)), nil
+		}
+		buf = append(buf, 0xcb)
+		return binary.BigEndian.AppendUint64(buf, math.Float64bits(v)), nil
+	case time.Duration:
+		return appendMsgpackInt(buf, int64(v)), nil
+	case time.Time:
+		return appendMsgpackString(buf, v.Format(time.RFC3339Nano)), nil
+	case error:
+		return appendMsgpackString(buf, v.Error()), nil
+	case []interface{}:
+		buf = appendMsgpackArrayHeader(buf, len(v))
+		for _, item := range v {
+			var err error
+			if buf, err = appendMsgpack(buf, item); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case group:
+		buf = appendMsgpackMapHeader(buf, v.len())
+		for _, f := range v {
+			if f.Type == skipType {
+				continue
+			}
+			buf = appendMsgpackString(buf, f.Key)
+			var err error
+			if buf, err = appendMsgpackField(buf, f); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf = appendMsgpackMapHeader(buf, len(v))
+		for _, k := range keys {
+			buf = appendMsgpackString(buf, k)
+			var err error
+			if buf, err = appendMsgpack(buf, v[k]); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	}
+
+	if _, ok := v.(json.Marshaler); !ok {
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.String {
+			return appendMsgpackString(buf, rv.String()), nil
+		}
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	return appendMsgpack(buf, decoded)
+}
+
+// appendMsgpackField appends the value of a field.
+func appendMsgpackField(buf []byte, f Field) ([]byte, error) {
+	switch f.Type {
+	case StringType, ErrorType:
+		return appendMsgpackString(buf, f.str), nil
+	case IntType, DurationType:
+		return appendMsgpackInt(buf, f.integer), nil
+	case BoolType:
+		return appendMsgpack(buf, f.integer == 1)
+	default:
+		return appendMsgpack(buf, f.Value)
+	}
+}
+
+func appendMsgpackInt(buf []byte, v int64) []byte {
+	switch {
+	case v >= 0:
+		return appendMsgpackUint(buf, uint64(v))
+	case v >= -32:
+		return append(buf, byte(v))
+	case v >= math.MinInt8:
+		return append(buf, 0xd0, byte(v))
+	case v >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xd1), uint16(v))
+	case v >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(buf, 0xd2), uint32(v))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, 0xd3), uint64(v))
+	}
+}
+
+func appendMsgpackUint(buf []byte, v uint64) []byte {
+	switch {
+	case v <= 0x7f:
+		return append(buf, byte(v))
+	case v <= math.MaxUint8:
+		return append(buf, 0xcc, byte(v))
+	case v <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xcd), uint16(v))
+	case v <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(buf, 0xce), uint32(v))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, 0xcf), v)
+	}
+}
+
+func appendMsgpackString(buf []byte, s string) []byte {
+	n := len(s)
+	switch {
+	case n <= 31:
+		buf = append(buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		buf = append(buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		buf = binary.BigEndian.AppendUint16(append(buf, 0xda), uint16(n))
+	default:
+		buf = binary.BigEndian.AppendUint32(append(buf, 0xdb), uint32(n))
+	}
+	return append(buf, s...)
+}
+
+func appendMsgpackBinary(buf []byte, b []byte) []byte {
+	n := len(b)
+	switch {
+	case n <= math.MaxUint8:
+		buf = append(buf, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		buf = binary.BigEndian.AppendUint16(append(buf, 0xc5), uint16(n))
+	default:
+		buf = binary.BigEndian.AppendUint32(append(buf, 0xc6), uint32(n))
+	}
+	return append(buf, b...)
+}
+
+func appendMsgpackArrayHeader(buf []byte, n int) []byte {
+	switch {
+	case n <= 15:
+		return append(buf, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xdc), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(buf, 0xdd), uint32(n))
+	}
+}
+
+func appendMsgpackMapHeader(buf []byte, n int) []byte {
+	switch {
+	case n <= 15:
+		return append(buf, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xde), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(buf, 0xdf), uint32(n))
+	}
+}
+
+// readMsgpack decodes a single MessagePack value from r. Maps are decoded
+// as map[string]interface{}, arrays as []interface{}, strings as string,
+// binary data as []byte and integers as int64. The EventTime extension of
+// the fluent forward protocol is decoded as time.Time, other extensions as
+// their raw data.
+func readMsgpack(r *bufio.Reader) (interface{}, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case b <= 0x7f:
+		return int64(b), nil
+	case b >= 0xe0:
+		return int64(int8(b)), nil
+	case b&0xf0 == 0x80:
+		return readMsgpackMap(r, int(b&0x0f))
+	case b&0xf0 == 0x90:
+		return readMsgpackArray(r, int(b&0x0f))
+	case b&0xe0 == 0xa0:
+		return readMsgpackString(r, int(b&0x1f))
+	}
+
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := readMsgpackLength(r, b-0xc4)
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, n)
+		_, err = io.ReadFull(r, data)
+		return data, err
+	case 0xc7, 0xc8, 0xc9:
+		n, err := readMsgpackLength(r, b-0xc7)
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackExt(r, n)
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return readMsgpackExt(r, 1<<(b-0xd4))
+	case 0xca:
+		v, err := readMsgpackUint(r, 4)
+		return float64(math.Float32frombits(uint32(v))), err
+	case 0xcb:
+		v, err := readMsgpackUint(r, 8)
+		return math.Float64frombits(v), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		v, err := readMsgpackUint(r, 1<<(b-0xcc))
+		return int64(v), err
+	case 0xd0:
+		v, err := readMsgpackUint(r, 1)
+		return int64(int8(v)), err
+	case 0xd1:
+		v, err := readMsgpackUint(r, 2)
+		return int64(int16(v)), err
+	case 0xd2:
+		v, err := readMsgpackUint(r, 4)
+		return int64(int32(v)), err
+	case 0xd3:
+		v, err := readMsgpackUint(r, 8)
+		return int64(v), err
+	case 0xd9, 0xda, 0xdb:
+		n, err := readMsgpackLength(r, b-0xd9)
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackString(r, n)
+	case 0xdc, 0xdd:
+		n, err := readMsgpackLength(r, b-0xdc+1)
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackArray(r, n)
+	case 0xde, 0xdf:
+		n, err := readMsgpackLength(r, b-0xde+1)
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackMap(r, n)
+	}
+	return nil, fmt.Errorf("msgpack: unsupported type 0x%02x", b)
+}
+
+// readMsgpackLength reads a length of 1, 2 or 4 bytes for size 0, 1 or 2.
+func readMsgpackLength(r *bufio.Reader, size byte) (int, error) {
+	v, err := readMsgpackUint(r, 1<<size)
+	if v > math.MaxInt32 {
+		return 0, errors.New("msgpack: length too large")
+	}
+	return int(v), err
+}
+
+func readMsgpackUint(r *bufio.Reader, n int) (uint64, error) {
+	var b [8]byte
+	if _, err := io.ReadFull(r, b[8-n:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(b[:]), nil
+}
+
+// readMsgpackExt reads the type and data of an extension value.
+func readMsgpackExt(r *bufio.Reader, n int) (interface{}, error) {
+	typ, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	if typ == 0 && n == 8 {
+		sec := binary.BigEndian.Uint32(data[:4])
+		nsec := binary.BigEndian.Uint32(data[4:])
+		return time.Unix(int64(sec), int64(nsec)), nil
+	}
+	return data, nil
+}
+
+func readMsgpackString(r *bufio.Reader, n int) (string, error) {
+	data := make([]byte, n)
+	_, err := io.ReadFull(r, data)
+	return string(data), err
+}
+
+func readMsgpackArray(r *bufio.Reader, n int) ([]interface{}, error) {
+	items := make([]interface{}, 0, min(n, 1024))
+	for i := 0; i < n; i++ {
+		item, err := readMsgpack(r)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func readMsgpackMap(r *bufio.Reader, n int) (map[string]interface{}, error) {
+	m := make(map[string]interface{}, min(n, 1024))
+	for i := 0; i < n; i++ {
+		key, err := readMsgpack(r)
+		if err != nil {
+			return nil, err
+		}
+		value, err := readMsgpack(r)
+		if err != nil {
+			return nil, err
+		}
+		m[fmt.Sprint(key)] = value
+	}
+	return m, nil
+}
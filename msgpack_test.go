@@ -0,0 +1,78 @@
+package gologs
+
+import (
+	"bufio"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tests encoding and decoding MessagePack values
+func TestMsgpackRoundTrip(t *testing.T) {
+	values := []interface{}{
+		nil, true, false, int64(0), int64(127), int64(128), int64(-1), int64(-33), int64(-200),
+		int64(70000), int64(-70000), int64(1) << 40, 1.5, "short", strings.Repeat("x", 300),
+		[]byte{1, 2, 3},
+		[]interface{}{int64(1), "two", []interface{}{}},
+		map[string]interface{}{"a": int64(1), "b": map[string]interface{}{"c": "d"}},
+	}
+	for _, v := range values {
+		data, err := appendMsgpack(nil, v)
+		if err != nil {
+			t.Fatalf("Expected no error encoding %v, got %v", v, err)
+		}
+		decoded, err := readMsgpack(bufio.NewReader(bytes.NewReader(data)))
+		if err != nil {
+			t.Fatalf("Expected no error decoding %v, got %v", v, err)
+		}
+		if !reflect.DeepEqual(decoded, v) {
+			t.Errorf("Expected %#v, got %#v", v, decoded)
+		}
+	}
+}
+
+// tests that other values are encoded through their JSON encoding
+func TestMsgpackStruct(t *testing.T) {
+	data, err := appendMsgpack(nil, struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}{"shop", 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, _ := readMsgpack(bufio.NewReader(bytes.NewReader(data)))
+	expected := map[string]interface{}{"name": "shop", "count": int64(3)}
+	if !reflect.DeepEqual(decoded, expected) {
+		t.Errorf("Expected %v, got %v", expected, decoded)
+	}
+}
+
+// tests encoding entries as MessagePack maps
+func TestMsgpackEncoder(t *testing.T) {
+	entry := LogEntry{Level: "WARN", Severity: WARN, Timestamp: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), Source: "main.go:10",
+		Data: "slow", Fields: []Field{Dur("took", time.Second), String("level", "field"), Bool("cached", true)}}
+	var buf bytes.Buffer
+	for _, e := range []Encoder{MsgpackEncoder{}, MsgpackEncoder{EncoderConfig{TimeFormat: time.RFC3339, LevelNumbers: SyslogLevelNumbers}}} {
+		if err := e.Encode(entry, &buf); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	r := bufio.NewReader(&buf)
+	for _, want := range []map[string]interface{}{
+		{"level": "WARN", "timestamp": entry.Timestamp.UnixNano(), "source": "main.go:10", "data": "slow",
+			"took": int64(time.Second), "fields.level": "field", "cached": true},
+		{"level": int64(4), "timestamp": "2024-01-01T12:00:00Z", "source": "main.go:10", "data": "slow",
+			"took": int64(time.Second), "fields.level": "field", "cached": true},
+	} {
+		decoded, err := readMsgpack(r)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !reflect.DeepEqual(decoded, want) {
+			t.Errorf("Expected %v, got %v", want, decoded)
+		}
+	}
+}
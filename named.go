@@ -0,0 +1,170 @@
+package gologs
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// noLevel marks a named logger without a level of its own.
+const noLevel = math.MinInt32
+
+// Named returns a logger for a subsystem such as "db" or "http". Its name is
+// written as the "logger" field, and names of loggers derived from named
+// loggers are joined with dots, as in "db.pool". The level of a named logger
+// can be set apart from the others with SetNamedLevels; otherwise it shares
+// the level of l.
+func (l *Logger) Named(name string) *Logger {
+	if l.named != nil {
+		name = l.named.name + "." + name
+	}
+	child := *l
+	child.named = l.names.get(name)
+	child.fields = make([]Field, 0, len(l.fields)+1)
+	for _, f := range l.fields {
+		if l.named == nil || f.Key != "logger" {
+			child.fields = append(child.fields, f)
+		}
+	}
+	child.fields = append(child.fields, String("logger", name))
+	return &child
+}
+
+// Named returns a logger for a subsystem derived from the default logger,
+// which FromContext returns for contexts without a logger.
+func Named(name string) *Logger {
+	return defaultLogger.Named(name)
+}
+
+// SetNamedLevels sets the levels of named loggers from a comma-separated
+// list of name=level pairs, such as "db=DEBUG,http.*=WARN,*=INFO". Names
+// may be patterns as understood by path.Match. A named logger gets the
+// level of its own name if listed, or else that of the longest pattern
+// matching it; loggers matching none keep the shared level. The list
+// replaces the previous one; an empty list removes all named levels.
+func (l *Logger) SetNamedLevels(spec string) error {
+	rules, err := parseLevelRules(spec)
+	if err != nil {
+		return err
+	}
+	l.names.set(rules)
+	return nil
+}
+
+// SetNamedLevel sets the level of the named loggers matching pattern, in
+// addition to the levels set before.
+func (l *Logger) SetNamedLevel(pattern string, level LogLevel) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid logger name pattern %q: %w", pattern, err)
+	}
+	l.names.add(levelRule{pattern, level})
+	return nil
+}
+
+// levelRule sets the level of the named loggers matching pattern.
+type levelRule struct {
+	pattern string
+	level   LogLevel
+}
+
+// parseLevelRules parses a list of name=level pairs.
+func parseLevelRules(spec string) ([]levelRule, error) {
+	var rules []levelRule
+	for _, pair := range strings.Split(spec, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		pattern, name, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid logger level %q: expected name=level", pair)
+		}
+		pattern = strings.TrimSpace(pattern)
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid logger name pattern %q: %w", pattern, err)
+		}
+		level, err := ParseLogLevel(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, levelRule{pattern, level})
+	}
+	return rules, nil
+}
+
+// nameRegistry holds the named loggers of a logger and the levels set for
+// them.
+type nameRegistry struct {
+	mu    sync.Mutex
+	rules []levelRule
+	names map[string]*namedLevel
+}
+
+// namedLevel is the level of the loggers with the same name, or noLevel.
+type namedLevel struct {
+	name  string
+	level atomic.Int32
+}
+
+func newNameRegistry(rules []levelRule) *nameRegistry {
+	return &nameRegistry{rules: rules, names: make(map[string]*namedLevel)}
+}
+
+// get returns the level of the loggers named name.
+func (r *nameRegistry) get(name string) *namedLevel {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n, ok := r.names[name]
+	if !ok {
+		n = &namedLevel{name: name}
+		n.level.Store(r.levelOf(name))
+		r.names[name] = n
+	}
+	return n
+}
+
+// set replaces the rules and updates the levels of all names.
+func (r *nameRegistry) set(rules []levelRule) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules = rules
+	r.update()
+}
+
+// add adds a rule, replacing one with the same pattern.
+func (r *nameRegistry) add(rule levelRule) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rules := make([]levelRule, 0, len(r.rules)+1)
+	for _, old := range r.rules {
+		if old.pattern != rule.pattern {
+			rules = append(rules, old)
+		}
+	}
+	r.rules = append(rules, rule)
+	r.update()
+}
+
+// update recomputes the levels of all names. The lock must be held.
+func (r *nameRegistry) update() {
+	for name, n := range r.names {
+		n.level.Store(r.levelOf(name))
+	}
+}
+
+// levelOf returns the level the rules give name, or noLevel. The lock must
+// be held.
+func (r *nameRegistry) levelOf(name string) int32 {
+	level, best := int32(noLevel), -1
+	for _, rule := range r.rules {
+		if rule.pattern == name {
+			return int32(rule.level)
+		}
+		if ok, _ := path.Match(rule.pattern, name); ok && len(rule.pattern) > best {
+			level, best = int32(rule.level), len(rule.pattern)
+		}
+	}
+	return level
+}
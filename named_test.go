@@ -0,0 +1,80 @@
+package gologs
+
+import (
+	"strings"
+	"testing"
+)
+
+// tests that named loggers write their name
+func TestNamed(t *testing.T) {
+	sink := &memorySink{}
+	l := New(WithSinks(sink))
+	l.Named("db").Named("pool").Info("connected")
+	l.Named("http").With("method", "GET").Info("request")
+
+	if f := sink.entries[0].Fields; len(f) != 1 || f[0].str != "db.pool" {
+		t.Errorf("Expected the logger field db.pool, got %+v", f)
+	}
+	if f := sink.entries[1].Fields; len(f) != 2 || f[0].Key != "logger" || f[0].str != "http" {
+		t.Errorf("Expected the logger field http, got %+v", f)
+	}
+}
+
+// tests named loggers derived from the default logger
+func TestNamedDefault(t *testing.T) {
+	db := Named("db")
+	if f := db.fields; len(f) != 1 || f[0].str != "db" {
+		t.Errorf("Expected the logger field db, got %+v", f)
+	}
+	if db.names != defaultLogger.names {
+		t.Error("Expected the named levels of the default logger to apply")
+	}
+}
+
+// tests the levels of named loggers
+func TestNamedLevels(t *testing.T) {
+	sink := &memorySink{}
+	l := New(WithSinks(sink))
+	db := l.Named("db")
+	pool := db.Named("pool")
+	http := l.Named("http")
+	if err := l.SetNamedLevels("db=DEBUG, *=WARN"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// created after the levels were set
+	cache := l.Named("cache")
+
+	for _, logger := range []*Logger{l, db, pool, http, cache} {
+		logger.Debug("debug")
+		logger.Info("info")
+	}
+	if msgs := sink.messages(); strings.Join(msgs, ",") != "info,debug,info" {
+		t.Errorf("Expected INFO from the root and DEBUG from db, got %v", msgs)
+	}
+	if pool.GetLogLevel() != WARN {
+		t.Errorf("Expected db.pool to match *, got %v", pool.GetLogLevel())
+	}
+
+	if err := l.SetNamedLevel("db.*", TRACE); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if pool.GetLogLevel() != TRACE || db.GetLogLevel() != DEBUG {
+		t.Errorf("Expected the longest pattern to win, got %v and %v", pool.GetLogLevel(), db.GetLogLevel())
+	}
+
+	l.SetNamedLevels("")
+	l.SetLogLevel(ERROR)
+	if db.GetLogLevel() != ERROR || http.GetLogLevel() != ERROR {
+		t.Errorf("Expected named loggers to share the level again")
+	}
+}
+
+// tests that invalid level lists are rejected
+func TestNamedLevelsErrors(t *testing.T) {
+	l := New()
+	for _, spec := range []string{"db", "db=LOUD", "[=INFO"} {
+		if err := l.SetNamedLevels(spec); err == nil {
+			t.Errorf("Expected an error for %q", spec)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package gologs
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeNATSMessage is a message published to fakeNATS.
+type fakeNATSMessage struct {
+	subject string
+	payload string
+}
+
+// fakeNATS accepts a single connection, records the CONNECT options and the
+// published messages, and acknowledges messages with a reply subject using
+// ack.
+func fakeNATS(t *testing.T, ack string) (string, <-chan map[string]interface{}, <-chan fakeNATSMessage) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	connects := make(chan map[string]interface{}, 1)
+	messages := make(chan fakeNATSMessage, 10)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		fmt.Fprintf(conn, "INFO {\"server_id\":\"test\",\"headers\":true}\r\n")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			op, args, _ := strings.Cut(strings.TrimSpace(line), " ")
+			switch op {
+			case "CONNECT":
+				var options map[string]interface{}
+				json.Unmarshal([]byte(args), &options)
+				connects <- options
+			case "PING":
+				fmt.Fprintf(conn, "PONG\r\n")
+			case "PUB":
+				fields := strings.Fields(args)
+				size, _ := strconv.Atoi(fields[len(fields)-1])
+				payload := make([]byte, size+2)
+				if _, err := io.ReadFull(r, payload); err != nil {
+					return
+				}
+				messages <- fakeNATSMessage{fields[0], string(payload[:size])}
+				if len(fields) == 3 {
+					fmt.Fprintf(conn, "MSG %s 1 %d\r\n%s\r\n", fields[1], len(ack), ack)
+				}
+			}
+		}
+	}()
+	return ln.Addr().String(), connects, messages
+}
+
+// tests publishing entries to subjects filled in from the entries
+func TestNATSSink(t *testing.T) {
+	addr, connects, messages := fakeNATS(t, "")
+	sink, err := NewNATSSink("nats://app:secret@"+addr, "logs.{service}.{level}")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	l := New(WithSinks(sink), WithCallerInfo(false))
+	l.Info("first", String("service", "shop api"))
+	l.Warn("second")
+	if err := l.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	options := <-connects
+	if options["user"] != "app" || options["pass"] != "secret" {
+		t.Errorf("Expected credentials from the URL, got %v", options)
+	}
+	for _, want := range []string{"logs.shop_api.info", "logs._.warn"} {
+		select {
+		case msg := <-messages:
+			if msg.subject != want {
+				t.Errorf("Expected subject %s, got %s", want, msg.subject)
+			}
+			var entry map[string]interface{}
+			if err := json.Unmarshal([]byte(msg.payload), &entry); err != nil {
+				t.Errorf("Expected JSON payload, got %q", msg.payload)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected message on %s", want)
+		}
+	}
+}
+
+// tests waiting for JetStream acknowledgements
+func TestNATSSinkJetStream(t *testing.T) {
+	addr, _, messages := fakeNATS(t, `{"stream":"LOGS","seq":1}`)
+	sink, err := NewNATSSink(addr, "logs", WithNATSJetStream())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer sink.Close()
+	sink.Write(LogEntry{Level: "INFO", Data: "stored"})
+	if err := sink.Flush(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if msg := <-messages; !strings.Contains(msg.payload, "stored") {
+		t.Errorf("Expected entry to be published, got %q", msg.payload)
+	}
+}
+
+// tests that messages rejected by JetStream are reported and not published again
+func TestNATSSinkJetStreamRejected(t *testing.T) {
+	addr, _, messages := fakeNATS(t, `{"error":{"code":503,"description":"maximum messages exceeded"}}`)
+	sink, err := NewNATSSink(addr, "logs", WithNATSJetStream())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer sink.Close()
+	sink.Write(LogEntry{Level: "INFO", Data: "rejected"})
+	if err := sink.Flush(); err == nil || !strings.Contains(err.Error(), "maximum messages exceeded") {
+		t.Errorf("Expected rejection error, got %v", err)
+	}
+	<-messages
+	select {
+	case msg := <-messages:
+		t.Errorf("Expected no second publish, got %v", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// tests invalid subject templates
+func TestNATSSinkInvalidSubject(t *testing.T) {
+	for _, subject := range []string{"", "logs.{level"} {
+		if _, err := NewNATSSink("localhost", subject); err == nil {
+			t.Errorf("Expected error for subject %q", subject)
+		}
+	}
+}
@@ -0,0 +1,341 @@
+package gologs
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Reconnect backoff of a NetworkSink.
+const (
+	networkMinBackoff = 100 * time.Millisecond
+	networkMaxBackoff = 30 * time.Second
+)
+
+// NetworkSink is a Sink that sends entries to a collector over TCP or UDP,
+// one encoded entry per line (NDJSON by default).
+//
+// The connection is opened on the first write. If a write fails, the sink
+// reconnects and sends the entry again once. While the collector can't be
+// reached, entries are dropped with an error, and reconnects are attempted
+// with an exponential backoff of up to 30 seconds, so logging doesn't block
+// on a collector that is down.
+//
+// With WithNetworkAck, entries are sent in numbered batches that the
+// collector must acknowledge, for at-least-once delivery.
+type NetworkSink struct {
+	mu           sync.Mutex
+	network      string
+	addr         string
+	encoder      Encoder
+	dialTimeout  time.Duration
+	writeTimeout time.Duration
+	conn         net.Conn
+	closed       bool
+	buf          bytes.Buffer
+	// split, if set, splits an encoded entry into several datagrams.
+	split func([]byte) ([][]byte, error)
+
+	minBackoff time.Duration
+	backoff    time.Duration
+	nextDial   time.Time
+
+	// ackTimeout is set if batches must be acknowledged.
+	ackTimeout time.Duration
+	stream     string
+	seq        uint64
+	reader     *bufio.Reader
+	batch      *batcher
+	// stop is closed when the sink is closed, to stop waiting for the
+	// backoff.
+	stop chan struct{}
+}
+
+// NetworkOption configures a NetworkSink.
+type NetworkOption func(*NetworkSink)
+
+// WithNetworkEncoder sets the encoder of a NetworkSink. Defaults to
+// JSONEncoder.
+func WithNetworkEncoder(encoder Encoder) NetworkOption {
+	return func(s *NetworkSink) {
+		s.encoder = encoder
+	}
+}
+
+// WithDialTimeout sets how long a NetworkSink waits for a connection.
+// Defaults to 5 seconds.
+func WithDialTimeout(timeout time.Duration) NetworkOption {
+	return func(s *NetworkSink) {
+		s.dialTimeout = timeout
+	}
+}
+
+// WithWriteTimeout sets how long a NetworkSink waits for a write to
+// complete before the connection is considered broken. Defaults to 5
+// seconds; 0 waits indefinitely.
+func WithWriteTimeout(timeout time.Duration) NetworkOption {
+	return func(s *NetworkSink) {
+		s.writeTimeout = timeout
+	}
+}
+
+// WithNetworkAck makes the sink send entries in batches of up to 100, at
+// least once a second, from a background goroutine, and wait up to timeout
+// for the collector to acknowledge each batch. A batch that isn't
+// acknowledged is sent again over a new connection, up to three times in
+// all, so no entry is lost silently between the logger and the collector.
+// Before each reconnect, the sink waits for the reconnect backoff, except
+// while it is being closed.
+//
+// Each batch starts with a header line such as
+//
+//	{"stream":"9f3c2a7e1b6d4c08","seq":42,"count":100}
+//
+// followed by count entry lines. The collector acknowledges it with the
+// line {"ack":42} once the entries are stored. seq increases by one per
+// batch of a stream, and a batch that is sent again keeps its seq, so the
+// collector can drop duplicates. stream is random for each sink.
+func WithNetworkAck(timeout time.Duration) NetworkOption {
+	return func(s *NetworkSink) {
+		s.ackTimeout = timeout
+	}
+}
+
+// NewNetworkSink returns a sink sending entries to addr using network, which
+// is "tcp" or "udp" (or one of their variants accepted by net.Dial).
+func NewNetworkSink(network, addr string, opts ...NetworkOption) *NetworkSink {
+	s := &NetworkSink{
+		network:      network,
+		addr:         addr,
+		encoder:      JSONEncoder{},
+		dialTimeout:  5 * time.Second,
+		writeTimeout: 5 * time.Second,
+		minBackoff:   networkMinBackoff,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.ackTimeout > 0 {
+		var id [8]byte
+		rand.Read(id[:])
+		s.stream = hex.EncodeToString(id[:])
+		s.stop = make(chan struct{})
+		s.batch = newBatcher(100, time.Second, s.sendBatch)
+	}
+	return s
+}
+
+// Write sends the entry to the collector, or queues it if batches are
+// acknowledged.
+func (s *NetworkSink) Write(entry LogEntry) error {
+	if s.batch != nil {
+		return s.batch.add(entry)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errors.New("network: sink is closed")
+	}
+
+	s.buf.Reset()
+	if err := s.encoder.Encode(entry, &s.buf); err != nil {
+		return err
+	}
+	packets := [][]byte{s.buf.Bytes()}
+	if s.split != nil {
+		var err error
+		if packets, err = s.split(s.buf.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	if s.conn != nil {
+		if err := s.send(packets); err == nil {
+			return nil
+		}
+	}
+	if err := s.dial(); err != nil {
+		return err
+	}
+	return s.send(packets)
+}
+
+// send writes the packets to the connection, closing it on failure.
+func (s *NetworkSink) send(packets [][]byte) error {
+	if s.writeTimeout > 0 {
+		s.conn.SetWriteDeadline(time.Now().Add(s.writeTimeout))
+	}
+	for _, p := range packets {
+		if _, err := s.conn.Write(p); err != nil {
+			s.conn.Close()
+			s.conn = nil
+			return err
+		}
+	}
+	return nil
+}
+
+// dial connects to the collector, unless the last attempt failed less than
+// the backoff ago.
+func (s *NetworkSink) dial() error {
+	now := time.Now()
+	if now.Before(s.nextDial) {
+		return fmt.Errorf("network: %s unreachable, reconnecting in %v", s.addr, s.nextDial.Sub(now).Round(time.Millisecond))
+	}
+	conn, err := net.DialTimeout(s.network, s.addr, s.dialTimeout)
+	if err != nil {
+		s.backoff = min(max(2*s.backoff, s.minBackoff), networkMaxBackoff)
+		s.nextDial = now.Add(s.backoff)
+		return err
+	}
+	s.conn = conn
+	s.reader = bufio.NewReader(conn)
+	s.backoff = 0
+	s.nextDial = time.Time{}
+	return nil
+}
+
+// sendBatch sends a batch of entries and waits for its acknowledgement,
+// sending it again over a new connection if it isn't acknowledged.
+func (s *NetworkSink) sendBatch(entries []LogEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `{"stream":%q,"seq":%d,"count":%d}`+"\n", s.stream, s.seq, len(entries))
+	for _, e := range entries {
+		if err := s.encoder.Encode(e, &buf); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	for attempt := 0; attempt < 3; attempt++ {
+		if s.conn == nil {
+			s.waitBackoff()
+			if err = s.dial(); err != nil {
+				continue
+			}
+		}
+		if err = s.send([][]byte{buf.Bytes()}); err != nil {
+			continue
+		}
+		if err = s.waitAck(); err == nil {
+			return nil
+		}
+		s.conn.Close()
+		s.conn = nil
+	}
+	return fmt.Errorf("network: batch %d not acknowledged: %w", s.seq, err)
+}
+
+// waitBackoff waits until the reconnect backoff has passed, so that every
+// attempt to send a batch dials the collector. The lock is released while
+// waiting. Once the sink is being closed, it doesn't wait.
+func (s *NetworkSink) waitBackoff() {
+	wait := time.Until(s.nextDial)
+	if wait <= 0 {
+		return
+	}
+	s.mu.Unlock()
+	defer s.mu.Lock()
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-s.stop:
+	}
+}
+
+// waitAck reads lines until the acknowledgement of the current batch.
+// Acknowledgements of earlier batches, which were sent again, are skipped.
+func (s *NetworkSink) waitAck() error {
+	s.conn.SetReadDeadline(time.Now().Add(s.ackTimeout))
+	for {
+		line, err := s.reader.ReadBytes('\n')
+		if err != nil {
+			return err
+		}
+		var ack struct {
+			Ack *uint64 `json:"ack"`
+		}
+		if err := json.Unmarshal(line, &ack); err != nil || ack.Ack == nil {
+			return fmt.Errorf("invalid acknowledgement %q", bytes.TrimSpace(line))
+		}
+		if *ack.Ack == s.seq {
+			return nil
+		}
+		if *ack.Ack > s.seq {
+			return errors.New("acknowledgement for unknown batch " + strconv.FormatUint(*ack.Ack, 10))
+		}
+	}
+}
+
+// Flush sends all queued entries if batches are acknowledged, and does
+// nothing otherwise, as entries are sent as they are written.
+func (s *NetworkSink) Flush() error {
+	if s.batch != nil {
+		return s.batch.flush()
+	}
+	return nil
+}
+
+// Close sends all queued entries and closes the connection.
+func (s *NetworkSink) Close() error {
+	var err error
+	if s.batch != nil {
+		s.mu.Lock()
+		select {
+		case <-s.stop:
+		default:
+			close(s.stop)
+		}
+		s.mu.Unlock()
+		err = s.batch.close()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.conn == nil {
+		return err
+	}
+	err = errors.Join(err, s.conn.Close())
+	s.conn = nil
+	return err
+}
+
+// Health returns the results of the sent batches and the number of entries
+// waiting to be sent, with WithNetworkAck. Without it, entries are written
+// directly and the logger tracks the results of Write.
+func (s *NetworkSink) Health() SinkHealth {
+	if s.batch == nil {
+		return SinkHealth{}
+	}
+	return s.batch.health()
+}
+
+func (s *NetworkSink) batches() *batcher {
+	return s.batch
+}
+
+// Ping connects to the server if the sink isn't connected, and returns an
+// error if it can't.
+func (s *NetworkSink) Ping() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errors.New("network: sink is closed")
+	}
+	if s.conn != nil {
+		return nil
+	}
+	return s.dial()
+}
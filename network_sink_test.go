@@ -0,0 +1,264 @@
+package gologs
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tests sending NDJSON lines over TCP and reconnecting when the connection
+// is closed by the collector
+func TestNetworkSinkTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	lines := make(chan string, 100)
+	go func() {
+		for i := 0; ; i++ {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			scanner := bufio.NewScanner(conn)
+			if i == 0 {
+				// Read one line, then drop the connection.
+				scanner.Scan()
+				lines <- scanner.Text()
+				conn.Close()
+				continue
+			}
+			go func() {
+				defer conn.Close()
+				for scanner.Scan() {
+					lines <- scanner.Text()
+				}
+			}()
+		}
+	}()
+
+	sink := NewNetworkSink("tcp", ln.Addr().String())
+	defer sink.Close()
+	l := New(WithSinks(sink), WithCallerInfo(false))
+	l.Info("first")
+	if line := <-lines; !strings.Contains(line, `"data":"first"`) {
+		t.Errorf("Expected first entry, got %v", line)
+	}
+
+	deadline := time.After(5 * time.Second)
+	for {
+		l.Info("after reconnect")
+		select {
+		case line := <-lines:
+			if !strings.Contains(line, `"data":"after reconnect"`) {
+				t.Errorf("Expected entry after reconnect, got %v", line)
+			}
+			return
+		case <-deadline:
+			t.Fatal("Expected sink to reconnect")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// tests sending entries over UDP
+func TestNetworkSinkUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	sink := NewNetworkSink("udp", conn.LocalAddr().String(), WithNetworkEncoder(LogfmtEncoder{}))
+	defer sink.Close()
+	New(WithSinks(sink), WithCallerInfo(false)).Warn("Disk almost full")
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg := string(buf[:n]); !strings.HasSuffix(msg, "level=warn msg=\"Disk almost full\"\n") {
+		t.Errorf("Expected logfmt line, got %v", msg)
+	}
+}
+
+// tests that reconnects are delayed while the collector is unreachable
+func TestNetworkSinkBackoff(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	sink := NewNetworkSink("tcp", addr, WithDialTimeout(time.Second))
+	defer sink.Close()
+	if err := sink.Write(LogEntry{Data: "lost"}); err == nil {
+		t.Fatal("Expected error while collector is down")
+	}
+	err = sink.Write(LogEntry{Data: "lost"})
+	if err == nil || !strings.Contains(err.Error(), "reconnecting in") {
+		t.Errorf("Expected backoff error, got %v", err)
+	}
+
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	sink.mu.Lock()
+	sink.nextDial = time.Time{}
+	sink.mu.Unlock()
+	if err := sink.Write(LogEntry{Data: "delivered"}); err != nil {
+		t.Errorf("Expected write to succeed after backoff, got %v", err)
+	}
+	if sink.backoff != 0 {
+		t.Errorf("Expected backoff to be reset, got %v", sink.backoff)
+	}
+}
+
+// tests that batches are acknowledged and sent again with the same sequence
+// number when the acknowledgement is missing
+func TestNetworkSinkAck(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	headers := make(chan string, 10)
+	lines := make(chan string, 100)
+	go func() {
+		for i := 0; ; i++ {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(drop bool) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					header, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					var batch struct {
+						Seq   uint64 `json:"seq"`
+						Count int    `json:"count"`
+					}
+					json.Unmarshal([]byte(header), &batch)
+					headers <- strings.TrimSpace(header)
+					for j := 0; j < batch.Count; j++ {
+						line, _ := r.ReadString('\n')
+						lines <- strings.TrimSpace(line)
+					}
+					if drop {
+						// Lose the first batch without acknowledging it.
+						return
+					}
+					fmt.Fprintf(conn, "{\"ack\":%d}\n", batch.Seq)
+				}
+			}(i == 0)
+		}
+	}()
+
+	sink := NewNetworkSink("tcp", ln.Addr().String(), WithNetworkAck(time.Second))
+	sink.minBackoff = 0
+	l := New(WithSinks(sink), WithCallerInfo(false))
+	l.Info("one")
+	l.Info("two")
+	if err := l.Flush(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	l.Info("three")
+	if err := l.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var got []string
+	for len(headers) > 0 {
+		h := <-headers
+		got = append(got, h[strings.Index(h, `"seq"`):])
+	}
+	if want := `"seq":1,"count":2},"seq":1,"count":2},"seq":2,"count":1}`; strings.Join(got, ",") != want {
+		t.Errorf("Expected batch 1 twice and batch 2, got %v", got)
+	}
+	if len(lines) != 5 {
+		t.Errorf("Expected 5 entry lines, got %d", len(lines))
+	}
+}
+
+// tests that a batch is sent again once the reconnect backoff has passed,
+// instead of failing on the backoff
+func TestNetworkSinkAckBackoff(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	sink := NewNetworkSink("tcp", addr, WithNetworkAck(time.Second))
+	sink.minBackoff = 200 * time.Millisecond
+	defer sink.Close()
+	sink.Write(LogEntry{Data: "delayed"})
+	go func() {
+		// Start the collector after the first dial failed.
+		time.Sleep(50 * time.Millisecond)
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			return
+		}
+		defer ln.Close()
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		r.ReadString('\n')
+		r.ReadString('\n')
+		conn.Write([]byte("{\"ack\":1}\n"))
+		r.ReadString('\n')
+	}()
+	if err := sink.Flush(); err != nil {
+		t.Errorf("Expected the batch to be sent after the backoff, got %v", err)
+	}
+}
+
+// tests that a batch fails when the collector doesn't acknowledge it
+func TestNetworkSinkAckMissing(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				bufio.NewReader(conn).ReadString('\n')
+				conn.Write([]byte("OK\n"))
+			}()
+		}
+	}()
+
+	sink := NewNetworkSink("tcp", ln.Addr().String(), WithNetworkAck(100*time.Millisecond))
+	sink.minBackoff = 0
+	defer sink.Close()
+	sink.Write(LogEntry{Data: "lost"})
+	err = sink.Flush()
+	if err == nil || !strings.Contains(err.Error(), "batch 1 not acknowledged") {
+		t.Errorf("Expected missing acknowledgement error, got %v", err)
+	}
+}
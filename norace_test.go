@@ -0,0 +1,5 @@
+//go:build !race
+
+package gologs
+
+const raceEnabled = false
@@ -0,0 +1,29 @@
+package gologs
+
+// LogObjectMarshaler is implemented by types that log themselves as a set
+// of fields, such as domain structs, instead of being encoded with
+// reflection by encoding/json. The type decides which fields are written,
+// so sensitive data can be left out or redacted:
+//
+//	func (u User) MarshalLogObject(fields []gologs.Field) []gologs.Field {
+//		return append(fields, gologs.String("id", u.ID), gologs.Bool("admin", u.Admin))
+//	}
+//
+// MarshalLogObject appends the fields to fields and returns the result. It
+// is called once the entry passed the level check, sampling and rate
+// limits. Objects are written as nested objects, when passed to Object, as
+// field values, or as the message of Log.
+type LogObjectMarshaler interface {
+	MarshalLogObject(fields []Field) []Field
+}
+
+// Object creates a field holding the fields of obj as a nested object.
+func Object(key string, obj LogObjectMarshaler) Field {
+	return Field{Key: key, Type: AnyType, Value: obj}
+}
+
+// objectGroup returns the fields of obj, with its own lazy fields and
+// objects resolved.
+func objectGroup(obj LogObjectMarshaler) group {
+	return group(resolveFields(obj.MarshalLogObject(nil)))
+}
@@ -0,0 +1,56 @@
+package gologs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// testUser logs its id and a redacted email.
+type testUser struct {
+	ID, Email string
+	Address   *testAddress
+	calls     *int
+}
+
+func (u testUser) MarshalLogObject(fields []Field) []Field {
+	*u.calls++
+	fields = append(fields, String("id", u.ID), String("email", "***@"+u.Email[strings.IndexByte(u.Email, '@')+1:]))
+	if u.Address != nil {
+		fields = append(fields, Object("address", u.Address))
+	}
+	return fields
+}
+
+type testAddress struct{ City string }
+
+func (a *testAddress) MarshalLogObject(fields []Field) []Field {
+	return append(fields, String("city", a.City))
+}
+
+// tests logging types that marshal their own fields
+func TestObject(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(INFO, &out, WithCallerInfo(false))
+	calls := 0
+	user := testUser{ID: "u1", Email: "jane@example.com", Address: &testAddress{"Oslo"}, calls: &calls}
+
+	l.Info("login", Object("user", user), Any("by", user))
+	want := `"data":"login","user":{"id":"u1","email":"***@example.com","address":{"city":"Oslo"}},` +
+		`"by":{"id":"u1","email":"***@example.com","address":{"city":"Oslo"}}}`
+	if !strings.HasSuffix(strings.TrimSpace(out.String()), want) {
+		t.Errorf("Expected %s, got %s", want, out.String())
+	}
+
+	out.Reset()
+	l.Log(user).Info()
+	if !strings.Contains(out.String(), `"data":{"id":"u1",`) {
+		t.Errorf("Expected the object as the message, got %s", out.String())
+	}
+
+	calls = 0
+	l.Debug("hidden", Object("user", user))
+	if calls != 0 {
+		t.Errorf("Expected no call at a disabled level, got %d", calls)
+	}
+}
@@ -0,0 +1,284 @@
+package gologs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ObjectStorageSink is a Sink that archives entries in an object store such
+// as S3 or Google Cloud Storage, as gzip-compressed NDJSON objects. Entries
+// are collected for a minute, or until 10000 entries are queued, and then
+// uploaded from a background goroutine; see the HTTPOption functions for
+// batching, retries and authentication.
+//
+// Object keys are built from a template with placeholders in braces:
+// "{yyyy}", "{mm}", "{dd}" and "{hh}" are the UTC date and hour of the
+// entry, "{uuid}" is a random UUID for each object, "{level}" is the
+// lowercase level and any other name is the value of the field with that
+// key, such as
+//
+//	logs/{service}/{yyyy}/{mm}/{dd}/{hh}/{uuid}.json.gz
+//
+// Entries of a batch whose keys differ, for example because they belong to
+// different hours, are uploaded as separate objects.
+type ObjectStorageSink struct {
+	url     string
+	key     placeholderTemplate
+	encoder Encoder
+	upload  func(key string, body []byte) error
+	config  httpConfig
+	batch   *batcher
+}
+
+// S3Credentials are the credentials used to sign requests to S3.
+type S3Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is the token of temporary credentials, if any.
+	SessionToken string
+	// Region is the region of the bucket, such as "eu-west-1". Use "auto"
+	// for Google Cloud Storage.
+	Region string
+}
+
+// NewS3Sink returns a sink uploading objects to the bucket at bucketURL,
+// such as "https://my-bucket.s3.eu-west-1.amazonaws.com", or
+// "http://minio:9000/my-bucket" for path-style URLs. Requests are signed
+// with AWS Signature Version 4, so S3-compatible stores work as well,
+// including Google Cloud Storage with HMAC keys at
+// "https://storage.googleapis.com/my-bucket".
+func NewS3Sink(bucketURL, keyTemplate string, creds S3Credentials, opts ...HTTPOption) (*ObjectStorageSink, error) {
+	s, err := newObjectStorageSink(keyTemplate, opts)
+	if err != nil {
+		return nil, err
+	}
+	s.url = strings.TrimSuffix(bucketURL, "/")
+	s.upload = func(key string, body []byte) error {
+		sum := sha256.Sum256(body)
+		payloadHash := hex.EncodeToString(sum[:])
+		header := http.Header{
+			"Content-Type":         {"application/gzip"},
+			"X-Amz-Content-Sha256": {payloadHash},
+		}
+		_, err := s.config.request(http.MethodPut, s.url+"/"+s3EscapePath(key), header, body, func(req *http.Request) error {
+			signS3(req, creds, payloadHash, time.Now())
+			return nil
+		})
+		return err
+	}
+	s.batch = s.config.startBatcher(s.send)
+	return s, nil
+}
+
+// NewGCSSink returns a sink uploading objects to a Google Cloud Storage
+// bucket with the JSON API. The sink doesn't authenticate by itself; pass
+// an authenticated client with WithHTTPClient, for example from
+// golang.org/x/oauth2/google with the scope
+// "https://www.googleapis.com/auth/devstorage.read_write".
+func NewGCSSink(bucket, keyTemplate string, opts ...HTTPOption) (*ObjectStorageSink, error) {
+	s, err := newObjectStorageSink(keyTemplate, opts)
+	if err != nil {
+		return nil, err
+	}
+	s.url = "https://storage.googleapis.com/upload/storage/v1/b/" + url.PathEscape(bucket) + "/o"
+	s.upload = func(key string, body []byte) error {
+		return s.config.post(s.url+"?uploadType=media&name="+url.QueryEscape(key), "application/gzip", body)
+	}
+	s.batch = s.config.startBatcher(s.send)
+	return s, nil
+}
+
+// newObjectStorageSink returns a sink without upload function and batcher.
+func newObjectStorageSink(keyTemplate string, opts []HTTPOption) (*ObjectStorageSink, error) {
+	key, err := parsePlaceholders(keyTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("object storage: %w", err)
+	}
+	if !key.has("uuid") {
+		return nil, fmt.Errorf("object storage: key template %q must contain {uuid}", keyTemplate)
+	}
+	return &ObjectStorageSink{
+		key:     key,
+		encoder: JSONEncoder{},
+		config:  newHTTPConfig(append([]HTTPOption{WithBatchSize(10000), WithFlushInterval(time.Minute)}, opts...)),
+	}, nil
+}
+
+// Write queues the entry to be uploaded.
+func (s *ObjectStorageSink) Write(entry LogEntry) error {
+	return s.batch.add(entry)
+}
+
+// Flush uploads all queued entries.
+func (s *ObjectStorageSink) Flush() error {
+	return s.batch.flush()
+}
+
+// Close uploads all queued entries and stops the background goroutine.
+func (s *ObjectStorageSink) Close() error {
+	return s.batch.close()
+}
+
+// Health returns the results of the sent batches and the number of entries
+// waiting to be sent.
+func (s *ObjectStorageSink) Health() SinkHealth {
+	return s.batch.health()
+}
+
+func (s *ObjectStorageSink) batches() *batcher {
+	return s.batch
+}
+
+// send uploads a batch of entries, one object per distinct key.
+func (s *ObjectStorageSink) send(entries []LogEntry) error {
+	objects := make(map[string]*bytes.Buffer)
+	var keys []string
+	for _, e := range entries {
+		key, err := s.objectKey(e)
+		if err != nil {
+			return err
+		}
+		buf, ok := objects[key]
+		if !ok {
+			buf = new(bytes.Buffer)
+			objects[key] = buf
+			keys = append(keys, key)
+		}
+		if err := s.encoder.Encode(e, buf); err != nil {
+			return err
+		}
+	}
+
+	var errs []error
+	for _, key := range keys {
+		var compressed bytes.Buffer
+		zw := gzip.NewWriter(&compressed)
+		zw.Write(objects[key].Bytes())
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		key = strings.ReplaceAll(key, "{uuid}", newUUID())
+		if err := s.upload(key, compressed.Bytes()); err != nil {
+			errs = append(errs, fmt.Errorf("object storage: upload %s: %w", key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// objectKey returns the key of an entry's object, with "{uuid}" left in
+// place.
+func (s *ObjectStorageSink) objectKey(e LogEntry) (string, error) {
+	t := e.Timestamp.UTC()
+	return s.key.expand(func(key string) (string, error) {
+		switch key {
+		case "yyyy":
+			return t.Format("2006"), nil
+		case "mm":
+			return t.Format("01"), nil
+		case "dd":
+			return t.Format("02"), nil
+		case "hh":
+			return t.Format("15"), nil
+		case "uuid":
+			return "{uuid}", nil
+		}
+		value, err := placeholderValue(e, key)
+		if value == "" {
+			value = "_"
+		}
+		return strings.Map(func(r rune) rune {
+			if r == '/' || r == '{' || r == '}' || r < ' ' {
+				return '_'
+			}
+			return r
+		}, value), err
+	})
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	h := hex.EncodeToString(b[:])
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+// s3EscapePath escapes each segment of an object key as required by
+// Signature Version 4.
+func s3EscapePath(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c == '/' || c == '-' || c == '_' || c == '.' || c == '~' ||
+			c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// signS3 signs a request with AWS Signature Version 4. The host and all
+// headers of the request are signed.
+func signS3(req *http.Request, creds S3Credentials, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	values := map[string]string{"host": req.URL.Host}
+	names := []string{"host"}
+	for k, v := range req.Header {
+		name := strings.ToLower(k)
+		if name == "authorization" {
+			continue
+		}
+		trimmed := make([]string, len(v))
+		for i, value := range v {
+			trimmed[i] = strings.Join(strings.Fields(value), " ")
+		}
+		values[name] = strings.Join(trimmed, ",")
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + values[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		strings.ReplaceAll(req.URL.Query().Encode(), "+", "%20"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	scope := date + "/" + creds.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, creds.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+creds.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
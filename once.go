@@ -0,0 +1,67 @@
+package gologs
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// Once returns the logger the first time it is called with key, and a
+// logger that writes nothing after that, so that notices such as
+// deprecation warnings are logged once per process:
+//
+//	logger.Once("legacy-config").Warn("The legacy config format is deprecated")
+//
+// Loggers derived from the logger share the keys with it, also with Every.
+func (l *Logger) Once(key string) *Logger {
+	return l.occasionally(key, math.MaxInt64)
+}
+
+// Every returns the logger if it hasn't been returned for key within the
+// last interval, and a logger that writes nothing otherwise, so that a
+// recurring condition is logged periodically instead of on every call:
+//
+//	logger.Every(5*time.Minute, "queue-full").Info("The queue is full")
+func (l *Logger) Every(interval time.Duration, key string) *Logger {
+	return l.occasionally(key, int64(interval))
+}
+
+// occasionally returns the logger if key is due, and marks it as due again
+// after interval nanoseconds.
+func (l *Logger) occasionally(key string, interval int64) *Logger {
+	if l.marks == nil {
+		return l
+	}
+	v, ok := l.marks.Load(key)
+	if !ok {
+		v, _ = l.marks.LoadOrStore(key, new(atomic.Int64))
+	}
+	due := v.(*atomic.Int64)
+	now := time.Now().UnixNano()
+	for {
+		next := due.Load()
+		if next != 0 && now < next {
+			return l.discard()
+		}
+		if due.CompareAndSwap(next, satAdd(now, interval)) {
+			return l
+		}
+	}
+}
+
+// discard returns a copy of the logger that writes nothing, whatever its
+// level, the level of its name or an escalation. As with OFF, Fatal still
+// exits and Panic still panics.
+func (l *Logger) discard() *Logger {
+	child := *l
+	child.discarded = true
+	return &child
+}
+
+// satAdd adds two non-negative numbers, saturating at math.MaxInt64.
+func satAdd(a, b int64) int64 {
+	if a > math.MaxInt64-b {
+		return math.MaxInt64
+	}
+	return a + b
+}
@@ -0,0 +1,62 @@
+package gologs
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// tests that Once logs a key only once
+func TestOnce(t *testing.T) {
+	sink := &memorySink{}
+	l := New(WithSinks(sink))
+	child := l.Child(String("component", "config"))
+	for i := 0; i < 3; i++ {
+		l.Once("deprecated").Warn("deprecated")
+		child.Once("deprecated").Warn("deprecated")
+		l.Once("other").Info("other")
+	}
+	if msgs := sink.messages(); strings.Join(msgs, ",") != "deprecated,other" {
+		t.Errorf("Expected each key once, got %v", msgs)
+	}
+	if l.GetLogLevel() != INFO {
+		t.Errorf("Expected the level of the logger to be unchanged, got %v", l.GetLogLevel())
+	}
+}
+
+// tests that Once suppresses entries of named loggers with a level of their
+// own
+func TestOnceNamed(t *testing.T) {
+	sink := &memorySink{}
+	l := New(WithSinks(sink), WithLevel(WARN))
+	if err := l.SetNamedLevels("db=DEBUG"); err != nil {
+		t.Fatal(err)
+	}
+	db := l.Named("db")
+	for i := 0; i < 3; i++ {
+		db.Once("slow-query").Warn("slow query")
+	}
+	if msgs := sink.messages(); len(msgs) != 1 {
+		t.Errorf("Expected one entry, got %v", msgs)
+	}
+	if db.Once("slow-query").Enabled(ERROR) {
+		t.Error("Expected a discarded logger not to be enabled")
+	}
+}
+
+// tests that Every logs a key at most once per interval
+func TestEvery(t *testing.T) {
+	sink := &memorySink{}
+	l := New(WithSinks(sink))
+	for i := 0; i < 3; i++ {
+		l.Every(time.Hour, "slow").Info("slow")
+		l.Every(20*time.Millisecond, "fast").Info("fast")
+	}
+	time.Sleep(30 * time.Millisecond)
+	l.Every(time.Hour, "slow").Info("slow")
+	l.Every(20*time.Millisecond, "fast").Info("fast")
+
+	if msgs := sink.messages(); strings.Join(msgs, ",") != "slow,fast,fast" {
+		t.Errorf("Expected fast to be logged again after the interval, got %v", msgs)
+	}
+}
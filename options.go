@@ -0,0 +1,371 @@
+package gologs
+
+import (
+	"bufio"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Option configures a Logger created with New or NewLogger.
+type Option func(*options)
+
+// options holds the configuration collected from Options.
+type options struct {
+	level          LogLevel
+	outputs        []io.Writer
+	encoder        Encoder
+	autoConsole    bool
+	sinks          []Sink
+	fields         []Field
+	showCallerInfo bool
+	callerSkip     int
+	shortSource    bool
+	location       *time.Location
+	encoderConfig  []func(*EncoderConfig)
+	// indent is the indent of pretty JSON output, set by WithPrettyJSON
+	// and the "pretty" format and cleared by the "json" format.
+	indent string
+	// verbosity is the verbosity for loggers returned by V.
+	verbosity int
+	// namedLevels are the levels of named loggers.
+	namedLevels []levelRule
+	// sequence adds sequence numbers to entries.
+	sequence bool
+	// controlChars is how control characters in entries are handled.
+	controlChars ControlChars
+	// flattenMaps writes map messages as fields.
+	flattenMaps bool
+	// durationFormat is how durations are written.
+	durationFormat DurationFormat
+	// messageTypes adds the type of messages written as text.
+	messageTypes bool
+	// schemaVersion is the layout of JSON entries, if versioned.
+	schemaVersion SchemaVersion
+	// maxMessageSize and maxEntrySize limit the size of entries, if set.
+	maxMessageSize int
+	maxEntrySize   int
+	// async is the buffer size in async mode, or 0 to write synchronously.
+	async       int
+	asyncPolicy DropPolicy
+	// batchBytes is the batch size for batched writes in async mode.
+	batchBytes int
+	// sampler decides which entries are written, if set.
+	sampler Sampler
+	// rateLimit configures rate limiting, if set.
+	rateLimit *RateLimitConfig
+	// dedupWindow is the window in which repeated entries are collapsed.
+	dedupWindow time.Duration
+	// escalation configures level escalation, if set.
+	escalation *EscalationConfig
+	// recorderSize is the size of the flight recorder, and recorderLevel
+	// the lowest level it keeps.
+	recorderSize  int
+	recorderLevel LogLevel
+	// bufferSize is the buffer size of buffered outputs.
+	bufferSize int
+	// flushInterval is how often batched or buffered outputs are written.
+	flushInterval time.Duration
+	// files are outputs opened by the logger itself. They are closed when
+	// the logger is closed or the outputs are replaced.
+	files []io.WriteCloser
+	// configSinks are the sinks listed in a Config. Unlike sinks they are
+	// replaced, not added to, when a config is applied.
+	configSinks []Sink
+}
+
+// WithLevel sets the log level. Defaults to INFO.
+func WithLevel(level LogLevel) Option {
+	return func(o *options) {
+		o.level = level
+	}
+}
+
+// WithOutput sets the writer entries are written to. Defaults to os.Stdout,
+// unless sinks are added with WithSinks.
+func WithOutput(output io.Writer) Option {
+	return func(o *options) {
+		o.outputs = []io.Writer{output}
+		o.files = nil
+	}
+}
+
+// WithEncoder sets the encoder used to format entries written to the output.
+// Defaults to JSONEncoder.
+func WithEncoder(encoder Encoder) Option {
+	return func(o *options) {
+		o.encoder = encoder
+		o.autoConsole = false
+	}
+}
+
+// WithAutoConsole uses a colored ConsoleEncoder when the logger's output is a
+// terminal and keeps JSON output when it is piped or redirected.
+func WithAutoConsole() Option {
+	return func(o *options) {
+		o.autoConsole = true
+	}
+}
+
+// WithSinks adds sinks that receive every entry in addition to the output.
+func WithSinks(sinks ...Sink) Option {
+	return func(o *options) {
+		o.sinks = append(o.sinks, sinks...)
+	}
+}
+
+// WithFields adds fields to every entry written by the logger.
+func WithFields(fields ...Field) Option {
+	return func(o *options) {
+		o.fields = append(o.fields, fields...)
+	}
+}
+
+// WithCallerInfo sets whether to include the source file, line number and
+// calling function in entries. Defaults to true.
+func WithCallerInfo(show bool) Option {
+	return func(o *options) {
+		o.showCallerInfo = show
+	}
+}
+
+// WithCallerSkip skips skip more stack frames when looking up the caller of
+// a log call, so that a package wrapping the logger reports the caller of
+// its own functions rather than itself. See also Logger.AddCallerSkip.
+func WithCallerSkip(skip int) Option {
+	return func(o *options) {
+		o.callerSkip = skip
+	}
+}
+
+// WithShortSource writes the source location of entries as the package
+// directory, file name and line, such as "server/handler.go:42", instead
+// of the full path of the file.
+func WithShortSource(short bool) Option {
+	return func(o *options) {
+		o.shortSource = short
+	}
+}
+
+// WithTimeLocation sets the time zone of entry timestamps, regardless of the
+// local time zone of the host, so that the logs of machines in different
+// time zones are comparable. By default, timestamps use the local time.
+func WithTimeLocation(loc *time.Location) Option {
+	return func(o *options) {
+		o.location = loc
+	}
+}
+
+// WithUTC writes entry timestamps in UTC. It is short for
+// WithTimeLocation(time.UTC).
+func WithUTC() Option {
+	return WithTimeLocation(time.UTC)
+}
+
+// WithKeyNames renames the standard keys written by the JSON and logfmt
+// encoders.
+func WithKeyNames(keys KeyNames) Option {
+	return func(o *options) {
+		o.encoderConfig = append(o.encoderConfig, func(c *EncoderConfig) {
+			c.Keys = keys
+		})
+	}
+}
+
+// WithLevelNumbers makes the JSON and logfmt encoders write levels as
+// numbers. With an empty key, the number replaces the level name; otherwise
+// it is written under key alongside the name.
+func WithLevelNumbers(numbers LevelNumbers, key string) Option {
+	return func(o *options) {
+		o.encoderConfig = append(o.encoderConfig, func(c *EncoderConfig) {
+			c.LevelNumbers = numbers
+			c.LevelNumberKey = key
+		})
+	}
+}
+
+// prettyIndent is the indent of WithPrettyJSON and the "pretty" format.
+const prettyIndent = "  "
+
+// WithPrettyJSON makes the JSON encoder write each entry as indented,
+// multi-line JSON, which is easier to read in a terminal during
+// development. To switch between pretty and compact output at runtime,
+// apply a Config with the format "pretty" or "json" with ApplyConfig.
+func WithPrettyJSON() Option {
+	return func(o *options) {
+		o.indent = prettyIndent
+	}
+}
+
+// WithHTMLEscape sets whether the JSON encoder escapes &, < and > as
+// \u0026, \u003c and \u003e, as encoding/json does. Defaults to true.
+func WithHTMLEscape(escape bool) Option {
+	return func(o *options) {
+		o.encoderConfig = append(o.encoderConfig, func(c *EncoderConfig) {
+			c.NoHTMLEscape = !escape
+		})
+	}
+}
+
+// WithLowercaseLevels makes the JSON and console encoders write level names
+// in lower case, such as "info", as many parsers and label conventions
+// expect.
+func WithLowercaseLevels() Option {
+	return func(o *options) {
+		o.encoderConfig = append(o.encoderConfig, func(c *EncoderConfig) {
+			c.LowercaseLevels = true
+		})
+	}
+}
+
+// WithTimestampFormat sets the time.Format layout used for timestamps by the
+// built-in encoders, such as time.RFC3339, or one of the TimeFormatUnix
+// formats for the time since the Unix epoch.
+func WithTimestampFormat(layout string) Option {
+	return func(o *options) {
+		o.encoderConfig = append(o.encoderConfig, func(c *EncoderConfig) {
+			c.TimeFormat = layout
+		})
+	}
+}
+
+// withOutputs sets several outputs, of which files were opened by the logger.
+func withOutputs(outputs []io.Writer, files []io.WriteCloser) Option {
+	return func(o *options) {
+		o.outputs = outputs
+		o.files = files
+	}
+}
+
+// withConfigSinks sets the sinks listed in a Config.
+func withConfigSinks(sinks []Sink) Option {
+	return func(o *options) {
+		o.configSinks = sinks
+	}
+}
+
+// New creates a new Logger configured by the given options. Without options
+// it writes JSON entries at INFO level and above to stdout.
+func New(opts ...Option) *Logger {
+	o := options{
+		level:          INFO,
+		encoder:        JSONEncoder{},
+		showCallerInfo: true,
+		asyncPolicy:    BlockWhenFull,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.outputs == nil && len(o.sinks) == 0 && len(o.configSinks) == 0 {
+		o.outputs = []io.Writer{os.Stdout}
+	}
+
+	var stdlog *log.Logger
+	if len(o.outputs) > 0 {
+		stdlog = log.New(o.outputs[0], "", 0)
+	}
+	l := &Logger{
+		logLevel:       new(atomic.Int32),
+		verbosity:      new(atomic.Int32),
+		mu:             new(sync.RWMutex),
+		out:            new(atomic.Pointer[output]),
+		logger:         stdlog,
+		showCallerInfo: o.showCallerInfo,
+		callerSkip:     o.callerSkip,
+		shortSource:    o.shortSource,
+		location:       o.location,
+		fields:         o.fields,
+		sampler:        o.sampler,
+		marks:          new(sync.Map),
+		names:          newNameRegistry(o.namedLevels),
+		flattenMaps:    o.flattenMaps,
+		controlChars:   o.controlChars,
+		durationFormat: o.durationFormat,
+		messageTypes:   o.messageTypes,
+		schemaVersion:  o.schemaVersion,
+		maxMessageSize: o.maxMessageSize,
+		maxEntrySize:   o.maxEntrySize,
+	}
+	l.logLevel.Store(int32(o.level))
+	l.verbosity.Store(int32(o.verbosity))
+	l.out.Store(o.newOutput())
+	if o.async > 0 {
+		l.async = newAsyncWriter(o.async, o.asyncPolicy, l.deliver)
+	}
+	if o.bufferSize > 0 || o.async > 0 && o.batchBytes > 0 {
+		interval := o.flushInterval
+		if interval <= 0 {
+			interval = time.Second
+		}
+		l.loops = append(l.loops, startFlushLoop(interval, l.flushOutputs))
+	}
+	if o.rateLimit != nil {
+		l.limiter = newRateLimiter(*o.rateLimit)
+		l.loops = append(l.loops, startFlushLoop(l.limiter.cfg.SummaryInterval, l.reportSuppressed))
+	}
+	if o.sequence {
+		l.seq = new(atomic.Uint64)
+	}
+	if o.recorderSize > 0 {
+		l.recorder = newFlightRecorder(o.recorderSize, o.recorderLevel)
+	}
+	if o.escalation != nil {
+		l.escalation = newEscalation(*o.escalation)
+	}
+	if o.dedupWindow > 0 {
+		l.dedup = newDeduper(o.dedupWindow, l.emit)
+		l.loops = append(l.loops, startFlushLoop(o.dedupWindow, l.dedup.report))
+	}
+	return l
+}
+
+// newOutput builds the output described by the options.
+func (o *options) newOutput() *output {
+	out := &output{opts: *o}
+	for _, w := range o.outputs {
+		encoder := o.encoder
+		if o.autoConsole && isTerminal(w) {
+			encoder = NewConsoleEncoder(w)
+		}
+		if len(o.encoderConfig) > 0 || o.indent != "" {
+			if ce, ok := encoder.(configurableEncoder); ok {
+				encoder = ce.withConfig(func(c *EncoderConfig) {
+					for _, fn := range o.encoderConfig {
+						fn(c)
+					}
+					if o.indent != "" {
+						c.Indent = o.indent
+					}
+				})
+			}
+		}
+
+		writer := w
+		if o.bufferSize > 0 {
+			writer = bufio.NewWriterSize(w, o.bufferSize)
+		}
+		sink := NewWriterSink(writer, encoder)
+		for _, f := range o.files {
+			if w == io.Writer(f) {
+				sink.closer = f
+			}
+		}
+		if o.async > 0 {
+			sink.batchBytes = o.batchBytes
+		}
+		out.writers = append(out.writers, sink)
+	}
+	out.stats = make([]*sinkStats, len(out.writers)+len(o.sinks)+len(o.configSinks))
+	for i := range out.stats {
+		out.stats[i] = &sinkStats{}
+	}
+	out.stackLevel = OFF
+	out.each(func(s Sink) error {
+		out.stackLevel = min(out.stackLevel, sinkStackLevel(s))
+		return nil
+	})
+	return out
+}
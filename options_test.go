@@ -0,0 +1,76 @@
+package gologs
+
+import (
+	"bytes"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tests the defaults of New
+func TestNewDefaults(t *testing.T) {
+	l := New()
+	if l.GetLogLevel() != INFO {
+		t.Errorf("Expected INFO level by default, got %v", l.GetLogLevel())
+	}
+	if _, ok := l.out.Load().writers[0].encoder.(JSONEncoder); !ok {
+		t.Errorf("Expected JSON encoder by default, got %T", l.out.Load().writers[0].encoder)
+	}
+	if !l.showCallerInfo {
+		t.Error("Expected caller info to be enabled by default")
+	}
+}
+
+// tests configuring a logger with options
+func TestNewWithOptions(t *testing.T) {
+	var out bytes.Buffer
+	l := New(
+		WithLevel(WARN),
+		WithOutput(&out),
+		WithFields(String("service", "shop")),
+		WithCallerInfo(false),
+		WithTimestampFormat("2006-01-02"),
+	)
+	l.Info("filtered")
+	l.Warn("Disk almost full")
+	output := out.String()
+	if strings.Contains(output, "filtered") {
+		t.Errorf("Expected INFO entry to be filtered, got %v", output)
+	}
+	if !strings.Contains(output, `"service":"shop"`) {
+		t.Errorf("Expected service field in output, got %v", output)
+	}
+	if strings.Contains(output, `"source"`) {
+		t.Errorf("Expected no caller info in output, got %v", output)
+	}
+	if !regexp.MustCompile(`"timestamp":"\d{4}-\d{2}-\d{2}"`).MatchString(output) {
+		t.Errorf("Expected date-only timestamp in output, got %v", output)
+	}
+}
+
+// tests that the timestamp format applies regardless of option order
+func TestTimestampFormatWithEncoder(t *testing.T) {
+	var out bytes.Buffer
+	l := New(WithOutput(&out), WithTimestampFormat("2006"), WithEncoder(LogfmtEncoder{}))
+	l.Info("message")
+	if !regexp.MustCompile(`^ts=\d{4} `).MatchString(out.String()) {
+		t.Errorf("Expected year-only timestamp, got %v", out.String())
+	}
+}
+
+// tests the time zone of timestamps
+func TestTimeLocation(t *testing.T) {
+	sink := &memorySink{}
+	tokyo := time.FixedZone("JST", 9*60*60)
+	New(WithSinks(sink), WithUTC()).Info("utc")
+	New(WithSinks(sink), WithTimeLocation(tokyo)).Info("tokyo")
+	slog.New(SlogHandler(New(WithSinks(sink), WithUTC()))).Info("slog")
+
+	for i, want := range []*time.Location{time.UTC, tokyo, time.UTC} {
+		if got := sink.entries[i].Timestamp.Location(); got != want {
+			t.Errorf("Expected %v for %v, got %v", want, sink.entries[i].Data, got)
+		}
+	}
+}
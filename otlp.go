@@ -0,0 +1,528 @@
+package gologs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OTLPSink is a Sink that exports entries to an OpenTelemetry collector or
+// backend using OTLP/HTTP with JSON encoding, or OTLP over gRPC. Each entry
+// becomes a LogRecord with the level as severity, the message as body and
+// the fields as attributes. Entries are sent in batches from a background
+// goroutine; see the HTTPOption functions for batching and retries.
+type OTLPSink struct {
+	url      string
+	resource []otlpKeyValue
+	config   httpConfig
+	batch    *batcher
+	// grpc is set if batches are exported with the Export method of the
+	// gRPC logs service.
+	grpc bool
+}
+
+// NewOTLPSink returns a sink exporting to endpoint, such as
+// "http://localhost:4318". If endpoint has no path, "/v1/logs" is appended.
+// The resource fields describe the source of the logs and should include
+// "service.name".
+func NewOTLPSink(endpoint string, resource []Field, opts ...HTTPOption) *OTLPSink {
+	if u, err := url.Parse(endpoint); err == nil && (u.Path == "" || u.Path == "/") {
+		endpoint = strings.TrimSuffix(endpoint, "/") + "/v1/logs"
+	}
+	s := &OTLPSink{
+		url:      endpoint,
+		resource: otlpAttributes(resource),
+		config:   newHTTPConfig(opts),
+	}
+	s.batch = s.config.startBatcher(s.send)
+	return s
+}
+
+// NewOTLPGRPCSink returns a sink exporting to endpoint with OTLP over gRPC,
+// such as "http://localhost:4317" for the gRPC receiver of a collector.
+// "https" endpoints use TLS, "http" ones HTTP/2 without TLS. The resource
+// fields are the same as for NewOTLPSink.
+//
+// Requests go through the client set with WithHTTPClient, which must
+// support HTTP/2 for "https" endpoints; the default client does. Headers
+// set with WithHeader are sent as gRPC metadata, and failed requests are
+// retried if their status is one that OTLP marks as retryable, such as
+// UNAVAILABLE.
+func NewOTLPGRPCSink(endpoint string, resource []Field, opts ...HTTPOption) (*OTLPSink, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("otlp: %w", err)
+	}
+	s := &OTLPSink{
+		url:      strings.TrimSuffix(endpoint, "/") + otlpGRPCPath,
+		resource: otlpAttributes(resource),
+		config:   newHTTPConfig(opts),
+		grpc:     true,
+	}
+	switch u.Scheme {
+	case "https":
+	case "http":
+		s.config.client = h2cClient(s.config.client)
+	default:
+		return nil, fmt.Errorf("otlp: unsupported endpoint %q, expected an http or https URL", endpoint)
+	}
+	s.batch = s.config.startBatcher(s.send)
+	return s, nil
+}
+
+// h2cClient returns a copy of client speaking HTTP/2 without TLS, as gRPC
+// servers expect on plain connections. Clients with a transport other than
+// *http.Transport are returned as they are.
+func h2cClient(client *http.Client) *http.Client {
+	transport := http.DefaultTransport
+	if client.Transport != nil {
+		transport = client.Transport
+	}
+	t, ok := transport.(*http.Transport)
+	if !ok {
+		return client
+	}
+	t = t.Clone()
+	t.Protocols = new(http.Protocols)
+	t.Protocols.SetUnencryptedHTTP2(true)
+	c := *client
+	c.Transport = t
+	return &c
+}
+
+// Write queues the entry for export.
+func (s *OTLPSink) Write(entry LogEntry) error {
+	return s.batch.add(entry)
+}
+
+// Flush exports all queued entries.
+func (s *OTLPSink) Flush() error {
+	return s.batch.flush()
+}
+
+// Close exports all queued entries and stops the background goroutine.
+func (s *OTLPSink) Close() error {
+	return s.batch.close()
+}
+
+// Health returns the results of the sent batches and the number of entries
+// waiting to be sent.
+func (s *OTLPSink) Health() SinkHealth {
+	return s.batch.health()
+}
+
+func (s *OTLPSink) batches() *batcher {
+	return s.batch
+}
+
+// send exports a batch of entries.
+func (s *OTLPSink) send(entries []LogEntry) error {
+	records := make([]otlpLogRecord, 0, len(entries))
+	now := strconv.FormatInt(time.Now().UnixNano(), 10)
+	for _, e := range entries {
+		records = append(records, otlpRecord(e, now))
+	}
+	req := otlpRequest{ResourceLogs: []otlpResourceLogs{{
+		Resource: otlpResource{Attributes: s.resource},
+		ScopeLogs: []otlpScopeLogs{{
+			Scope:      otlpScope{Name: "github.com/phasi/go-logs"},
+			LogRecords: records,
+		}},
+	}}}
+	if s.grpc {
+		return s.config.grpcCall(s.url, appendOTLPRequest(nil, req))
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	return s.config.post(s.url, "application/json", body)
+}
+
+// otlpGRPCPath is the path of the Export method of the gRPC logs service.
+const otlpGRPCPath = "/opentelemetry.proto.collector.logs.v1.LogsService/Export"
+
+// grpcStatusError is returned for gRPC responses with a status other than
+// OK.
+type grpcStatusError struct {
+	code    int
+	message string
+}
+
+func (e *grpcStatusError) Error() string {
+	return fmt.Sprintf("grpc status %d: %s", e.code, e.message)
+}
+
+// retryable reports whether OTLP allows retrying a request that failed with
+// the status: CANCELLED, DEADLINE_EXCEEDED, ABORTED, OUT_OF_RANGE,
+// UNAVAILABLE or DATA_LOSS.
+func (e *grpcStatusError) retryable() bool {
+	switch e.code {
+	case 1, 4, 10, 11, 14, 15:
+		return true
+	}
+	return false
+}
+
+// grpcCall sends msg, a protobuf message, to the gRPC method at endpoint.
+// Network errors, retryable statuses, 429 and 5xx responses are retried
+// like post.
+func (c *httpConfig) grpcCall(endpoint string, msg []byte) error {
+	backoff := c.retryBackoff
+	for attempt := 0; ; attempt++ {
+		err := c.grpcCallOnce(endpoint, msg)
+		if err == nil {
+			return nil
+		}
+		var statusErr *httpStatusError
+		if errors.As(err, &statusErr) && statusErr.code != http.StatusTooManyRequests && statusErr.code < 500 {
+			return err
+		}
+		var grpcErr *grpcStatusError
+		if errors.As(err, &grpcErr) && !grpcErr.retryable() {
+			return err
+		}
+		if attempt >= c.maxRetries {
+			return err
+		}
+		c.wait(backoff)
+		backoff *= 2
+	}
+}
+
+// grpcCallOnce sends a single gRPC request. The status is read from the
+// trailers, or from the headers of a response without a body.
+func (c *httpConfig) grpcCallOnce(endpoint string, msg []byte) error {
+	body := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(body[1:], uint32(len(msg)))
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(append(body, msg...)))
+	if err != nil {
+		return err
+	}
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("Te", "trailers")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		if len(respBody) > 1024 {
+			respBody = respBody[:1024]
+		}
+		return &httpStatusError{code: resp.StatusCode, body: string(bytes.TrimSpace(respBody))}
+	}
+	if resp.ProtoMajor != 2 {
+		return fmt.Errorf("otlp: gRPC needs HTTP/2, got %s", resp.Proto)
+	}
+	header := resp.Trailer
+	if header.Get("Grpc-Status") == "" {
+		header = resp.Header
+	}
+	code, err := strconv.Atoi(header.Get("Grpc-Status"))
+	if err != nil {
+		return fmt.Errorf("otlp: invalid gRPC status %q", header.Get("Grpc-Status"))
+	}
+	if code != 0 {
+		message, err := url.PathUnescape(header.Get("Grpc-Message"))
+		if err != nil {
+			message = header.Get("Grpc-Message")
+		}
+		return &grpcStatusError{code: code, message: message}
+	}
+	return nil
+}
+
+// appendOTLPRequest appends the request as an ExportLogsServiceRequest
+// message of the OTLP protobuf schema.
+func appendOTLPRequest(buf []byte, req otlpRequest) []byte {
+	for _, rl := range req.ResourceLogs {
+		buf = appendOTLPMessage(buf, 1, func(buf []byte) []byte {
+			buf = appendOTLPMessage(buf, 1, func(buf []byte) []byte {
+				return appendOTLPKeyValues(buf, 1, rl.Resource.Attributes)
+			})
+			for _, sl := range rl.ScopeLogs {
+				buf = appendOTLPMessage(buf, 2, func(buf []byte) []byte {
+					buf = appendOTLPMessage(buf, 1, func(buf []byte) []byte {
+						return appendProtoString(buf, 1, sl.Scope.Name)
+					})
+					for _, r := range sl.LogRecords {
+						buf = appendOTLPMessage(buf, 2, func(buf []byte) []byte {
+							return appendOTLPLogRecord(buf, r)
+						})
+					}
+					return buf
+				})
+			}
+			return buf
+		})
+	}
+	return buf
+}
+
+// appendOTLPLogRecord appends the fields of a LogRecord message.
+func appendOTLPLogRecord(buf []byte, r otlpLogRecord) []byte {
+	buf = appendOTLPFixed64(buf, 1, r.TimeUnixNano)
+	buf = appendProtoUint(buf, 2, uint64(r.SeverityNumber))
+	buf = appendProtoString(buf, 3, r.SeverityText)
+	buf = appendOTLPMessage(buf, 5, func(buf []byte) []byte {
+		return appendOTLPAnyValue(buf, r.Body)
+	})
+	buf = appendOTLPKeyValues(buf, 6, r.Attributes)
+	return appendOTLPFixed64(buf, 11, r.ObservedTimeUnixNano)
+}
+
+// appendOTLPKeyValues appends a repeated KeyValue field.
+func appendOTLPKeyValues(buf []byte, num int, kvs []otlpKeyValue) []byte {
+	for _, kv := range kvs {
+		buf = appendOTLPMessage(buf, num, func(buf []byte) []byte {
+			buf = appendProtoString(buf, 1, kv.Key)
+			return appendOTLPMessage(buf, 2, func(buf []byte) []byte {
+				return appendOTLPAnyValue(buf, kv.Value)
+			})
+		})
+	}
+	return buf
+}
+
+// appendOTLPAnyValue appends the fields of an AnyValue message. A nil value
+// has none.
+func appendOTLPAnyValue(buf []byte, v otlpAnyValue) []byte {
+	switch {
+	case v.StringValue != nil:
+		return appendProtoString(buf, 1, *v.StringValue)
+	case v.BoolValue != nil:
+		var b uint64
+		if *v.BoolValue {
+			b = 1
+		}
+		return appendProtoUint(buf, 2, b)
+	case v.IntValue != nil:
+		n, _ := strconv.ParseInt(*v.IntValue, 10, 64)
+		return appendProtoUint(buf, 3, uint64(n))
+	case v.DoubleValue != nil:
+		return binary.LittleEndian.AppendUint64(appendProtoTag(buf, 4, protoFixed64), math.Float64bits(*v.DoubleValue))
+	case v.ArrayValue != nil:
+		return appendOTLPMessage(buf, 5, func(buf []byte) []byte {
+			for _, item := range v.ArrayValue.Values {
+				buf = appendOTLPMessage(buf, 1, func(buf []byte) []byte {
+					return appendOTLPAnyValue(buf, item)
+				})
+			}
+			return buf
+		})
+	case v.KvlistValue != nil:
+		return appendOTLPMessage(buf, 6, func(buf []byte) []byte {
+			return appendOTLPKeyValues(buf, 1, v.KvlistValue.Values)
+		})
+	}
+	return buf
+}
+
+// appendOTLPFixed64 appends a fixed64 field holding a number formatted as
+// text for the JSON mapping.
+func appendOTLPFixed64(buf []byte, num int, text string) []byte {
+	n, _ := strconv.ParseUint(text, 10, 64)
+	return binary.LittleEndian.AppendUint64(appendProtoTag(buf, num, protoFixed64), n)
+}
+
+// appendOTLPMessage appends an embedded message like appendProtoMessage,
+// for bodies that can't fail.
+func appendOTLPMessage(buf []byte, num int, body func([]byte) []byte) []byte {
+	buf = appendProtoTag(buf, num, protoBytes)
+	start := len(buf)
+	return insertProtoLength(body(buf), start)
+}
+
+// The types below follow the JSON mapping of the OTLP logs protocol.
+
+type otlpRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         string         `json:"timeUnixNano"`
+	ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+	SeverityNumber       int            `json:"severityNumber"`
+	SeverityText         string         `json:"severityText"`
+	Body                 otlpAnyValue   `json:"body"`
+	Attributes           []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string         `json:"stringValue,omitempty"`
+	BoolValue   *bool           `json:"boolValue,omitempty"`
+	IntValue    *string         `json:"intValue,omitempty"`
+	DoubleValue *float64        `json:"doubleValue,omitempty"`
+	ArrayValue  *otlpArrayValue `json:"arrayValue,omitempty"`
+	KvlistValue *otlpKvlist     `json:"kvlistValue,omitempty"`
+}
+
+type otlpArrayValue struct {
+	Values []otlpAnyValue `json:"values"`
+}
+
+type otlpKvlist struct {
+	Values []otlpKeyValue `json:"values"`
+}
+
+// otlpSeverity maps a LogLevel onto an OTLP severity number.
+func otlpSeverity(level LogLevel) int {
+	switch {
+	case level >= FATAL:
+		return 21
+	case level == PANIC:
+		return 19
+	case level == ERROR:
+		return 17
+	case level == WARN:
+		return 13
+	case level == INFO:
+		return 9
+	case level == DEBUG:
+		return 5
+	default:
+		return 1
+	}
+}
+
+// otlpRecord converts an entry into a log record.
+func otlpRecord(e LogEntry, observed string) otlpLogRecord {
+	record := otlpLogRecord{
+		TimeUnixNano:         strconv.FormatInt(e.Timestamp.UnixNano(), 10),
+		ObservedTimeUnixNano: observed,
+		SeverityNumber:       otlpSeverity(e.Severity),
+		SeverityText:         e.Level,
+		Body:                 otlpValue(e.Data),
+		Attributes:           otlpAttributes(e.Fields),
+	}
+	if i := strings.LastIndexByte(e.Source, ':'); i > 0 {
+		record.Attributes = append(record.Attributes,
+			otlpKeyValue{Key: "code.filepath", Value: otlpValue(e.Source[:i])})
+		if line, err := strconv.Atoi(e.Source[i+1:]); err == nil {
+			record.Attributes = append(record.Attributes,
+				otlpKeyValue{Key: "code.lineno", Value: otlpValue(line)})
+		}
+	}
+	if e.Caller != "" {
+		record.Attributes = append(record.Attributes,
+			otlpKeyValue{Key: "code.function", Value: otlpValue(e.Caller)})
+	}
+	return record
+}
+
+// otlpAttributes converts fields into attributes.
+func otlpAttributes(fields []Field) []otlpKeyValue {
+	attrs := make([]otlpKeyValue, 0, len(fields))
+	for _, f := range fields {
+		var v otlpAnyValue
+		switch f.Type {
+		case skipType:
+			continue
+		case StringType, ErrorType:
+			v = otlpValue(f.str)
+		case IntType, DurationType:
+			v = otlpValue(f.integer)
+		case BoolType:
+			v = otlpValue(f.integer == 1)
+		default:
+			v = otlpValue(f.Value)
+		}
+		attrs = append(attrs, otlpKeyValue{Key: f.Key, Value: v})
+	}
+	return attrs
+}
+
+// otlpValue converts a Go value into an OTLP AnyValue. Values other than
+// strings, numbers and booleans are converted through their JSON encoding.
+func otlpValue(value interface{}) otlpAnyValue {
+	switch v := value.(type) {
+	case string:
+		return otlpAnyValue{StringValue: &v}
+	case bool:
+		return otlpAnyValue{BoolValue: &v}
+	case int:
+		s := strconv.Itoa(v)
+		return otlpAnyValue{IntValue: &s}
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		return otlpAnyValue{IntValue: &s}
+	case float64:
+		return otlpAnyValue{DoubleValue: &v}
+	case []interface{}:
+		values := make([]otlpAnyValue, 0, len(v))
+		for _, item := range v {
+			values = append(values, otlpValue(item))
+		}
+		return otlpAnyValue{ArrayValue: &otlpArrayValue{Values: values}}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		values := make([]otlpKeyValue, 0, len(v))
+		for _, k := range keys {
+			values = append(values, otlpKeyValue{Key: k, Value: otlpValue(v[k])})
+		}
+		return otlpAnyValue{KvlistValue: &otlpKvlist{Values: values}}
+	case nil:
+		return otlpAnyValue{}
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		s := err.Error()
+		return otlpAnyValue{StringValue: &s}
+	}
+	var decoded interface{}
+	if json.Unmarshal(data, &decoded) != nil {
+		s := string(data)
+		return otlpAnyValue{StringValue: &s}
+	}
+	if f, ok := decoded.(float64); ok && f == float64(int64(f)) {
+		return otlpValue(int64(f))
+	}
+	return otlpValue(decoded)
+}
@@ -0,0 +1,174 @@
+package gologs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tests exporting entries as OTLP/HTTP JSON
+func TestOTLPSink(t *testing.T) {
+	bodies := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/logs" {
+			t.Errorf("Expected /v1/logs, got %v", r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		bodies <- string(body)
+	}))
+	defer server.Close()
+
+	sink := NewOTLPSink(server.URL, []Field{String("service.name", "shop")})
+	l := New(WithSinks(sink))
+	l.Warn("Payment declined", Int("amount", 100), Err(errors.New("card expired")),
+		Any("tags", []string{"a", "b"}))
+	if err := l.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	body := <-bodies
+	var req otlpRequest
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+	for _, expected := range []string{
+		`"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"shop"}}]}`,
+		`"severityNumber":13,"severityText":"WARN","body":{"stringValue":"Payment declined"}`,
+		`{"key":"amount","value":{"intValue":"100"}}`,
+		`{"key":"error","value":{"stringValue":"card expired"}}`,
+		`{"key":"tags","value":{"arrayValue":{"values":[{"stringValue":"a"},{"stringValue":"b"}]}}}`,
+		`{"key":"code.function","value":{"stringValue":"TestOTLPSink"}}`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected %v in request, got %v", expected, body)
+		}
+	}
+}
+
+// otlpGRPCHandler returns a handler answering Export requests with status,
+// and sends the messages of the requests to messages.
+func otlpGRPCHandler(t *testing.T, status, message string, messages chan<- []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 || r.URL.Path != otlpGRPCPath || r.Header.Get("Content-Type") != "application/grpc" {
+			t.Errorf("Expected a gRPC request to %s, got %s %s %v", otlpGRPCPath, r.Proto, r.URL.Path, r.Header)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if len(body) < 5 || body[0] != 0 || int(binary.BigEndian.Uint32(body[1:])) != len(body)-5 {
+			t.Errorf("Expected a length-prefixed message, got %x", body)
+		} else {
+			messages <- body[5:]
+		}
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Write([]byte{0, 0, 0, 0, 0})
+		w.Header().Set(http.TrailerPrefix+"Grpc-Status", status)
+		w.Header().Set(http.TrailerPrefix+"Grpc-Message", message)
+	})
+}
+
+// checkOTLPProto checks that an ExportLogsServiceRequest holds the entry
+// logged by the gRPC tests.
+func checkOTLPProto(t *testing.T, msg []byte) {
+	for name, expected := range map[string][]byte{
+		"resource":  appendOTLPKeyValues(nil, 1, otlpAttributes([]Field{String("service.name", "shop")})),
+		"severity":  appendProtoString(appendProtoUint(nil, 2, 13), 3, "WARN"),
+		"body":      appendOTLPMessage(nil, 5, func(buf []byte) []byte { return appendProtoString(buf, 1, "Payment declined") }),
+		"attribute": appendOTLPKeyValues(nil, 6, otlpAttributes([]Field{Int("amount", 100)})),
+		"scope":     appendProtoString(nil, 1, "github.com/phasi/go-logs"),
+	} {
+		if !bytes.Contains(msg, expected) {
+			t.Errorf("Expected the %s in the request, got %x", name, msg)
+		}
+	}
+}
+
+// tests exporting entries with OTLP over gRPC with TLS
+func TestOTLPGRPCSink(t *testing.T) {
+	messages := make(chan []byte, 10)
+	server := httptest.NewUnstartedServer(otlpGRPCHandler(t, "0", "", messages))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	sink, err := NewOTLPGRPCSink(server.URL, []Field{String("service.name", "shop")}, WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	l := New(WithSinks(sink))
+	l.Warn("Payment declined", Int("amount", 100))
+	if err := l.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	checkOTLPProto(t, <-messages)
+}
+
+// tests exporting entries with OTLP over gRPC without TLS
+func TestOTLPGRPCSinkH2C(t *testing.T) {
+	messages := make(chan []byte, 10)
+	server := httptest.NewUnstartedServer(otlpGRPCHandler(t, "0", "", messages))
+	server.Config.Protocols = new(http.Protocols)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	defer server.Close()
+
+	sink, err := NewOTLPGRPCSink(server.URL, []Field{String("service.name", "shop")})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	l := New(WithSinks(sink))
+	l.Warn("Payment declined", Int("amount", 100))
+	if err := l.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	checkOTLPProto(t, <-messages)
+}
+
+// tests that gRPC statuses are reported, and only retryable ones retried
+func TestOTLPGRPCSinkStatus(t *testing.T) {
+	for _, c := range []struct {
+		status   string
+		attempts int32
+		want     string
+	}{
+		{"3", 1, "grpc status 3: bad request"},
+		{"14", 3, "grpc status 14: bad request"},
+	} {
+		var attempts atomic.Int32
+		messages := make(chan []byte, 10)
+		handler := otlpGRPCHandler(t, c.status, "bad%20request", messages)
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			handler.ServeHTTP(w, r)
+		}))
+		server.EnableHTTP2 = true
+		server.StartTLS()
+
+		sink, err := NewOTLPGRPCSink(server.URL, nil, WithHTTPClient(server.Client()), WithMaxRetries(2))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		sink.config.retryBackoff = time.Millisecond
+		sink.Write(LogEntry{Level: "INFO", Data: "rejected"})
+		if err := sink.Close(); err == nil || err.Error() != c.want {
+			t.Errorf("Expected %q, got %v", c.want, err)
+		}
+		if n := attempts.Load(); n != c.attempts {
+			t.Errorf("Expected %d attempts for status %s, got %d", c.attempts, c.status, n)
+		}
+		server.Close()
+	}
+}
+
+// tests that endpoints other than http and https URLs are rejected
+func TestOTLPGRPCSinkInvalidEndpoint(t *testing.T) {
+	if _, err := NewOTLPGRPCSink("localhost:4317", nil); err == nil {
+		t.Error("Expected error for an endpoint without scheme")
+	}
+}
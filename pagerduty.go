@@ -0,0 +1,210 @@
+package gologs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// pagerDutyMaxSummary is the maximum length of an event summary.
+const pagerDutyMaxSummary = 1024
+
+// PagerDutyConfig configures which entries a PagerDutySink sends and how.
+type PagerDutyConfig struct {
+	// MinLevel is the lowest level sent. The zero value, DEBUG, means
+	// ERROR.
+	MinLevel LogLevel
+	// Filter, if set, selects the entries at or above MinLevel to send.
+	Filter func(LogEntry) bool
+	// DedupFields are the keys of the fields that identify an incident,
+	// together with the message. Entries with the same message and values
+	// of these fields update the same alert instead of opening new ones.
+	DedupFields []string
+	// Source is the affected system. Defaults to the host name.
+	Source string
+	// Component and Group are the optional component and logical group of
+	// the affected system, such as "database" and "prod-eu".
+	Component string
+	Group     string
+}
+
+// PagerDutySink is a Sink that triggers PagerDuty alerts through the Events
+// API v2 for entries at or above a minimum level (ERROR by default), so that
+// critical log lines page the on-call engineer directly. Levels are mapped
+// onto event severities, and fields are sent as custom details. Events are
+// sent one at a time from a background goroutine; see the HTTPOption
+// functions for retries.
+type PagerDutySink struct {
+	url        string
+	routingKey string
+	cfg        PagerDutyConfig
+	config     httpConfig
+	batch      *batcher
+}
+
+// NewPagerDutySink returns a sink triggering events for the integration
+// with the given routing key.
+func NewPagerDutySink(routingKey string, cfg PagerDutyConfig, opts ...HTTPOption) *PagerDutySink {
+	if cfg.MinLevel == DEBUG {
+		cfg.MinLevel = ERROR
+	}
+	if cfg.Source == "" {
+		cfg.Source = hostname()
+	}
+	s := &PagerDutySink{
+		url:        "https://events.pagerduty.com/v2/enqueue",
+		routingKey: routingKey,
+		cfg:        cfg,
+		config:     newHTTPConfig(opts),
+	}
+	s.batch = s.config.startBatcher(s.send)
+	return s
+}
+
+// Write queues an event for the entry if it is at or above the minimum
+// level and passes the filter.
+func (s *PagerDutySink) Write(entry LogEntry) error {
+	if entry.Severity < s.cfg.MinLevel || s.cfg.Filter != nil && !s.cfg.Filter(entry) {
+		return nil
+	}
+	return s.batch.add(entry)
+}
+
+// Flush sends all queued events.
+func (s *PagerDutySink) Flush() error {
+	return s.batch.flush()
+}
+
+// Close sends all queued events and stops the background goroutine.
+func (s *PagerDutySink) Close() error {
+	return s.batch.close()
+}
+
+// Health returns the results of the sent batches and the number of entries
+// waiting to be sent.
+func (s *PagerDutySink) Health() SinkHealth {
+	return s.batch.health()
+}
+
+func (s *PagerDutySink) batches() *batcher {
+	return s.batch
+}
+
+// pagerDutySeverity maps a LogLevel onto a PagerDuty event severity.
+func pagerDutySeverity(level LogLevel) string {
+	switch {
+	case level >= PANIC:
+		return "critical"
+	case level == ERROR:
+		return "error"
+	case level == WARN:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string           `json:"routing_key"`
+	EventAction string           `json:"event_action"`
+	DedupKey    string           `json:"dedup_key"`
+	Payload     pagerDutyPayload `json:"payload"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	Timestamp     string                 `json:"timestamp"`
+	Component     string                 `json:"component,omitempty"`
+	Group         string                 `json:"group,omitempty"`
+	Class         string                 `json:"class,omitempty"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+// send triggers one event per entry.
+func (s *PagerDutySink) send(entries []LogEntry) error {
+	var errs []error
+	for _, e := range entries {
+		event, err := s.event(e)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		body, err := json.Marshal(event)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := s.config.post(s.url, "application/json", body); err != nil {
+			errs = append(errs, fmt.Errorf("pagerduty: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// event returns the trigger event of an entry.
+func (s *PagerDutySink) event(e LogEntry) (pagerDutyEvent, error) {
+	var msg bytes.Buffer
+	if err := appendMessageText(&msg, e.Data); err != nil {
+		return pagerDutyEvent{}, err
+	}
+	payload := pagerDutyPayload{
+		Summary:   truncateUTF8(msg.String(), pagerDutyMaxSummary),
+		Source:    s.cfg.Source,
+		Severity:  pagerDutySeverity(e.Severity),
+		Timestamp: e.Timestamp.Format(time.RFC3339Nano),
+		Component: s.cfg.Component,
+		Group:     s.cfg.Group,
+		Class:     e.Source,
+	}
+	if len(e.Fields) > 0 || e.Caller != "" {
+		payload.CustomDetails = make(map[string]interface{}, len(e.Fields)+1)
+		for _, f := range e.Fields {
+			if f.Type != skipType {
+				payload.CustomDetails[f.Key] = fieldValue(f)
+			}
+		}
+		if e.Caller != "" {
+			payload.CustomDetails["caller"] = e.Caller
+		}
+	}
+
+	h := sha256.New()
+	h.Write(msg.Bytes())
+	for _, key := range s.cfg.DedupFields {
+		h.Write([]byte{0})
+		h.Write([]byte(key + "="))
+		for _, f := range e.Fields {
+			if f.Key == key && f.Type != skipType {
+				text, err := fieldText(f)
+				if err != nil {
+					return pagerDutyEvent{}, err
+				}
+				h.Write([]byte(text))
+			}
+		}
+	}
+	return pagerDutyEvent{
+		RoutingKey:  s.routingKey,
+		EventAction: "trigger",
+		DedupKey:    hex.EncodeToString(h.Sum(nil)[:16]),
+		Payload:     payload,
+	}, nil
+}
+
+// truncateUTF8 shortens s to at most n bytes without splitting a character.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
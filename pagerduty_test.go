@@ -0,0 +1,101 @@
+package gologs
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// tests that matching ERROR entries trigger events with a stable dedup key
+func TestPagerDutySink(t *testing.T) {
+	bodies := make(chan []byte, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sink := NewPagerDutySink("R0UT1NG", PagerDutyConfig{
+		Filter: func(e LogEntry) bool {
+			for _, f := range e.Fields {
+				if f.Key == "noisy" {
+					return false
+				}
+			}
+			return true
+		},
+		DedupFields: []string{"db"},
+		Source:      "api-1",
+		Component:   "database",
+	})
+	sink.url = server.URL
+	l := New(WithSinks(sink), WithCallerInfo(false))
+	l.Warn("Slow query", String("db", "orders"))
+	l.Error("Connection lost", String("db", "orders"), Int("attempt", 1))
+	l.Error("Connection lost", String("db", "orders"), Int("attempt", 2))
+	l.Error("Connection lost", String("db", "users"))
+	l.Error("Connection lost", Bool("noisy", true))
+	if err := l.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(bodies) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(bodies))
+	}
+	var events []pagerDutyEvent
+	for i := 0; i < 3; i++ {
+		var event pagerDutyEvent
+		if err := json.Unmarshal(<-bodies, &event); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, event)
+	}
+	event := events[0]
+	if event.RoutingKey != "R0UT1NG" || event.EventAction != "trigger" {
+		t.Errorf("Expected trigger event with routing key, got %+v", event)
+	}
+	p := event.Payload
+	if p.Summary != "Connection lost" || p.Severity != "error" || p.Source != "api-1" || p.Component != "database" {
+		t.Errorf("Expected event payload, got %+v", p)
+	}
+	if p.CustomDetails["db"] != "orders" || p.CustomDetails["attempt"] != float64(1) {
+		t.Errorf("Expected fields as custom details, got %v", p.CustomDetails)
+	}
+	if events[0].DedupKey != events[1].DedupKey {
+		t.Errorf("Expected equal dedup keys, got %s and %s", events[0].DedupKey, events[1].DedupKey)
+	}
+	if events[0].DedupKey == events[2].DedupKey {
+		t.Errorf("Expected different dedup keys for different db fields, got %s", events[2].DedupKey)
+	}
+}
+
+// tests the mapping of levels onto PagerDuty severities
+func TestPagerDutySeverity(t *testing.T) {
+	for level, want := range map[LogLevel]string{
+		INFO:  "info",
+		WARN:  "warning",
+		ERROR: "error",
+		PANIC: "critical",
+		FATAL: "critical",
+	} {
+		if got := pagerDutySeverity(level); got != want {
+			t.Errorf("Expected %s for %v, got %s", want, level, got)
+		}
+	}
+}
+
+// tests that long summaries are truncated on a character boundary
+func TestTruncateUTF8(t *testing.T) {
+	s := strings.Repeat("a", pagerDutyMaxSummary-1) + "é"
+	got := truncateUTF8(s, pagerDutyMaxSummary)
+	if len(got) != pagerDutyMaxSummary-1 {
+		t.Errorf("Expected %d bytes, got %d", pagerDutyMaxSummary-1, len(got))
+	}
+	if got := truncateUTF8("short", 10); got != "short" {
+		t.Errorf("Expected short, got %s", got)
+	}
+}
@@ -0,0 +1,80 @@
+package gologs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// placeholderTemplate is a string with placeholders in braces, such as
+// "logs.{service}.{level}", split into literal text and placeholders.
+type placeholderTemplate []placeholderPart
+
+// placeholderPart is literal text, or a placeholder if key is set.
+type placeholderPart struct {
+	text string
+	key  string
+}
+
+// parsePlaceholders splits a template into its parts.
+func parsePlaceholders(template string) (placeholderTemplate, error) {
+	var parts placeholderTemplate
+	for rest := template; rest != ""; {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			parts = append(parts, placeholderPart{text: rest})
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed placeholder in %q", template)
+		}
+		if start > 0 {
+			parts = append(parts, placeholderPart{text: rest[:start]})
+		}
+		parts = append(parts, placeholderPart{key: rest[start+1 : start+end]})
+		rest = rest[start+end+1:]
+	}
+	return parts, nil
+}
+
+// has reports whether the template contains the placeholder key.
+func (t placeholderTemplate) has(key string) bool {
+	for _, part := range t {
+		if part.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// expand replaces each placeholder with the result of value.
+func (t placeholderTemplate) expand(value func(key string) (string, error)) (string, error) {
+	var b strings.Builder
+	for _, part := range t {
+		if part.key == "" {
+			b.WriteString(part.text)
+			continue
+		}
+		v, err := value(part.key)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(v)
+	}
+	return b.String(), nil
+}
+
+// placeholderValue returns the value of a placeholder for an entry: the
+// lowercase level for "level", or else the text of the field with that key.
+// It returns "" if the entry has no such field.
+func placeholderValue(entry LogEntry, key string) (string, error) {
+	if key == "level" {
+		return strings.ToLower(entry.Level), nil
+	}
+	for i := len(entry.Fields) - 1; i >= 0; i-- {
+		if f := entry.Fields[i]; f.Key == key && f.Type != skipType {
+			return fieldText(f)
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,23 @@
+package gologs
+
+import "testing"
+
+// tests parsing and expanding placeholder templates
+func TestPlaceholders(t *testing.T) {
+	tmpl, err := parsePlaceholders("logs.{service}.{level}")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !tmpl.has("service") || tmpl.has("logs") {
+		t.Errorf("Expected only placeholders to be found, got %+v", tmpl)
+	}
+	entry := LogEntry{Level: "WARN", Fields: []Field{String("service", "shop"), String("service", "billing")}}
+	got, err := tmpl.expand(func(key string) (string, error) { return placeholderValue(entry, key) })
+	if err != nil || got != "logs.billing.warn" {
+		t.Errorf("Expected logs.billing.warn, got %q (%v)", got, err)
+	}
+
+	if _, err := parsePlaceholders("logs.{service"); err == nil {
+		t.Error("Expected error for unclosed placeholder")
+	}
+}
@@ -0,0 +1,584 @@
+package gologs
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PostgresSink is a Sink that copies entries into a PostgreSQL table with
+// COPY, which is much faster than inserting them one by one, for teams that
+// keep application or audit logs in their main database. Entries are
+// copied in batches of up to 100 entries, at least once a second, from a
+// background goroutine.
+//
+// The table has the columns id, ts, level, severity (the numeric LogLevel),
+// message, source, caller and fields, a jsonb column holding the fields,
+// plus a text column for each key given to WithPostgresColumns:
+//
+//	SELECT ts, message FROM logs WHERE fields->>'order_id' = 'A1' ORDER BY ts DESC;
+//
+// The sink speaks the PostgreSQL protocol itself. It supports password,
+// md5 and SCRAM-SHA-256 authentication, but not TLS. If a batch can't be
+// copied because the connection failed, the sink reconnects and copies it
+// again once.
+type PostgresSink struct {
+	addr     string
+	user     string
+	password string
+	database string
+	table    string
+	columns  []string
+	timeout  time.Duration
+	copy     string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+	batch  *batcher
+}
+
+// PostgresOption configures a PostgresSink.
+type PostgresOption func(*PostgresSink)
+
+// WithPostgresTable sets the table, which may be qualified with a schema
+// such as "audit.logs". Defaults to "logs".
+func WithPostgresTable(table string) PostgresOption {
+	return func(s *PostgresSink) {
+		s.table = table
+	}
+}
+
+// WithPostgresColumns adds an indexed text column for each of the given
+// field keys, holding the value of the field. The fields are still
+// included in the fields column.
+func WithPostgresColumns(keys ...string) PostgresOption {
+	return func(s *PostgresSink) {
+		s.columns = append(s.columns, keys...)
+	}
+}
+
+// WithPostgresTimeout sets the timeout for connecting and for each batch.
+// Defaults to 10 seconds.
+func WithPostgresTimeout(timeout time.Duration) PostgresOption {
+	return func(s *PostgresSink) {
+		s.timeout = timeout
+	}
+}
+
+// NewPostgresSink returns a sink copying entries into the database at
+// connURL, such as "postgres://app:secret@db:5432/app". It connects and
+// creates the table and its indexes if they don't exist.
+func NewPostgresSink(connURL string, opts ...PostgresOption) (*PostgresSink, error) {
+	u, err := url.Parse(connURL)
+	if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+		return nil, fmt.Errorf("postgres: invalid URL %q", connURL)
+	}
+	switch mode := u.Query().Get("sslmode"); mode {
+	case "", "disable", "allow", "prefer":
+	default:
+		return nil, fmt.Errorf("postgres: sslmode %s is not supported", mode)
+	}
+	s := &PostgresSink{
+		addr:     u.Host,
+		user:     u.User.Username(),
+		database: strings.TrimPrefix(u.Path, "/"),
+		table:    "logs",
+		timeout:  10 * time.Second,
+	}
+	if u.Port() == "" {
+		s.addr = net.JoinHostPort(u.Hostname(), "5432")
+	}
+	s.password, _ = u.User.Password()
+	if s.database == "" {
+		s.database = s.user
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	columns := append([]string{"ts", "level", "severity", "message", "source", "caller", "fields"}, s.columns...)
+	for i, column := range columns {
+		columns[i] = sqlIdentifier(column)
+	}
+	s.copy = "COPY " + pgTable(s.table) + " (" + strings.Join(columns, ", ") + ") FROM STDIN"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	if err := s.bootstrap(); err != nil {
+		s.conn.Close()
+		return nil, err
+	}
+	s.batch = newBatcher(100, time.Second, s.send)
+	return s, nil
+}
+
+// pgTable quotes a table name that may be qualified with a schema.
+func pgTable(table string) string {
+	parts := strings.Split(table, ".")
+	for i, part := range parts {
+		parts[i] = sqlIdentifier(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// bootstrap creates the table and its indexes.
+func (s *PostgresSink) bootstrap() error {
+	table := pgTable(s.table)
+	name := s.table[strings.LastIndexByte(s.table, '.')+1:]
+	stmts := []string{
+		"CREATE TABLE IF NOT EXISTS " + table + " (\n" +
+			"\tid bigserial PRIMARY KEY,\n" +
+			"\tts timestamptz NOT NULL,\n" +
+			"\tlevel text NOT NULL,\n" +
+			"\tseverity smallint NOT NULL,\n" +
+			"\tmessage text NOT NULL,\n" +
+			"\tsource text,\n" +
+			"\tcaller text,\n" +
+			"\tfields jsonb NOT NULL" + sqlColumnDefs(s.columns, "text") + "\n)",
+		"CREATE INDEX IF NOT EXISTS " + sqlIdentifier(name+"_ts") + " ON " + table + " (ts)",
+	}
+	for _, column := range s.columns {
+		stmts = append(stmts, "CREATE INDEX IF NOT EXISTS "+sqlIdentifier(name+"_"+column)+" ON "+table+" ("+sqlIdentifier(column)+")")
+	}
+	s.conn.SetDeadline(time.Now().Add(s.timeout))
+	for _, stmt := range stmts {
+		if err := s.query(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write queues the entry to be copied.
+func (s *PostgresSink) Write(entry LogEntry) error {
+	return s.batch.add(entry)
+}
+
+// Flush copies all queued entries.
+func (s *PostgresSink) Flush() error {
+	return s.batch.flush()
+}
+
+// Close copies all queued entries and closes the connection.
+func (s *PostgresSink) Close() error {
+	err := s.batch.close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		s.conn.Write([]byte{'X', 0, 0, 0, 4}) // Terminate
+		err = errors.Join(err, s.conn.Close())
+		s.conn = nil
+	}
+	return err
+}
+
+// Health returns the results of the sent batches and the number of entries
+// waiting to be sent.
+func (s *PostgresSink) Health() SinkHealth {
+	return s.batch.health()
+}
+
+func (s *PostgresSink) batches() *batcher {
+	return s.batch
+}
+
+// Ping connects to the server if the sink isn't connected, and returns an
+// error if it can't.
+func (s *PostgresSink) Ping() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		return nil
+	}
+	return s.connect()
+}
+
+// send copies a batch of entries.
+func (s *PostgresSink) send(entries []LogEntry) error {
+	var data bytes.Buffer
+	for _, e := range entries {
+		row, err := sqlRow(e, s.columns)
+		if err != nil {
+			return err
+		}
+		row[0] = e.Timestamp.Format("2006-01-02 15:04:05.999999Z07:00")
+		for i, value := range row {
+			if i > 0 {
+				data.WriteByte('\t')
+			}
+			appendPGCopyValue(&data, value)
+		}
+		data.WriteByte('\n')
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.copyOnce(data.Bytes())
+	var pgErr *pgError
+	if err != nil && !errors.As(err, &pgErr) {
+		err = s.copyOnce(data.Bytes())
+	}
+	return err
+}
+
+// appendPGCopyValue writes a value in the text format of COPY.
+func appendPGCopyValue(buf *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		buf.WriteString(`\N`)
+	case int64:
+		buf.WriteString(strconv.FormatInt(v, 10))
+	case string:
+		for i := 0; i < len(v); i++ {
+			switch c := v[i]; c {
+			case '\\':
+				buf.WriteString(`\\`)
+			case '\t':
+				buf.WriteString(`\t`)
+			case '\n':
+				buf.WriteString(`\n`)
+			case '\r':
+				buf.WriteString(`\r`)
+			default:
+				buf.WriteByte(c)
+			}
+		}
+	}
+}
+
+// copyOnce copies the rows, connecting first if needed. The connection is
+// closed unless the server reported an error.
+func (s *PostgresSink) copyOnce(rows []byte) error {
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return err
+		}
+	}
+	err := s.copyRows(rows)
+	var pgErr *pgError
+	if err != nil && !errors.As(err, &pgErr) {
+		s.conn.Close()
+		s.conn = nil
+	}
+	return err
+}
+
+// copyRows runs COPY FROM STDIN with the rows as data.
+func (s *PostgresSink) copyRows(rows []byte) error {
+	s.conn.SetDeadline(time.Now().Add(s.timeout))
+	if err := s.writeMessage('Q', append([]byte(s.copy), 0)); err != nil {
+		return err
+	}
+	for {
+		typ, body, err := s.readMessage()
+		if err != nil {
+			return err
+		}
+		switch typ {
+		case 'G': // CopyInResponse
+			msgs := pgMessage(nil, 'd', rows)
+			msgs = pgMessage(msgs, 'c', nil)
+			if _, err := s.conn.Write(msgs); err != nil {
+				return err
+			}
+		case 'E':
+			err := parsePGError(body)
+			if waitErr := s.waitReady(); waitErr != nil {
+				return waitErr
+			}
+			return err
+		case 'Z':
+			return nil
+		}
+	}
+}
+
+// query runs a statement with the simple query protocol.
+func (s *PostgresSink) query(stmt string) error {
+	if err := s.writeMessage('Q', append([]byte(stmt), 0)); err != nil {
+		return err
+	}
+	var queryErr error
+	for {
+		typ, body, err := s.readMessage()
+		if err != nil {
+			return err
+		}
+		switch typ {
+		case 'E':
+			queryErr = parsePGError(body)
+		case 'Z':
+			return queryErr
+		}
+	}
+}
+
+// waitReady reads messages until the server is ready for the next query.
+func (s *PostgresSink) waitReady() error {
+	for {
+		typ, _, err := s.readMessage()
+		if err != nil {
+			return err
+		}
+		if typ == 'Z' {
+			return nil
+		}
+	}
+}
+
+// connect opens the connection and authenticates.
+func (s *PostgresSink) connect() error {
+	conn, err := net.DialTimeout("tcp", s.addr, s.timeout)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+	s.reader = bufio.NewReader(conn)
+	if err := s.startup(); err != nil {
+		conn.Close()
+		s.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (s *PostgresSink) startup() error {
+	s.conn.SetDeadline(time.Now().Add(s.timeout))
+	msg := binary.BigEndian.AppendUint32(make([]byte, 4), 3<<16) // protocol 3.0
+	for _, kv := range [][2]string{{"user", s.user}, {"database", s.database}, {"application_name", "gologs"}} {
+		msg = append(msg, kv[0]...)
+		msg = append(msg, 0)
+		msg = append(msg, kv[1]...)
+		msg = append(msg, 0)
+	}
+	msg = append(msg, 0)
+	binary.BigEndian.PutUint32(msg, uint32(len(msg)))
+	if _, err := s.conn.Write(msg); err != nil {
+		return err
+	}
+
+	var scram *pgSCRAM
+	for {
+		typ, body, err := s.readMessage()
+		if err != nil {
+			return err
+		}
+		switch typ {
+		case 'E':
+			return parsePGError(body)
+		case 'Z':
+			return nil
+		case 'R':
+			if len(body) < 4 {
+				return errors.New("postgres: malformed authentication request")
+			}
+			data := body[4:]
+			switch code := binary.BigEndian.Uint32(body); code {
+			case 0: // AuthenticationOk
+			case 3: // AuthenticationCleartextPassword
+				err = s.writeMessage('p', append([]byte(s.password), 0))
+			case 5: // AuthenticationMD5Password
+				if len(data) < 4 {
+					return errors.New("postgres: malformed md5 request")
+				}
+				inner := md5.Sum([]byte(s.password + s.user))
+				outer := md5.Sum(append([]byte(hex.EncodeToString(inner[:])), data[:4]...))
+				err = s.writeMessage('p', append([]byte("md5"+hex.EncodeToString(outer[:])), 0))
+			case 10: // AuthenticationSASL
+				if !bytes.Contains(data, []byte("SCRAM-SHA-256\x00")) {
+					return errors.New("postgres: no supported SASL mechanism")
+				}
+				var nonce [18]byte
+				rand.Read(nonce[:])
+				scram = newPGSCRAM("", s.password, base64.StdEncoding.EncodeToString(nonce[:]))
+				first := scram.clientFirst()
+				resp := append([]byte("SCRAM-SHA-256"), 0)
+				resp = binary.BigEndian.AppendUint32(resp, uint32(len(first)))
+				err = s.writeMessage('p', append(resp, first...))
+			case 11: // AuthenticationSASLContinue
+				if scram == nil {
+					return errors.New("postgres: unexpected SASL message")
+				}
+				var final string
+				if final, err = scram.clientFinal(string(data)); err == nil {
+					err = s.writeMessage('p', []byte(final))
+				}
+			case 12: // AuthenticationSASLFinal
+				if scram == nil {
+					return errors.New("postgres: unexpected SASL message")
+				}
+				err = scram.verify(string(data))
+			default:
+				return fmt.Errorf("postgres: unsupported authentication method %d", code)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// writeMessage writes a message of the given type.
+func (s *PostgresSink) writeMessage(typ byte, body []byte) error {
+	_, err := s.conn.Write(pgMessage(nil, typ, body))
+	return err
+}
+
+// pgMessage appends a message of the given type.
+func pgMessage(buf []byte, typ byte, body []byte) []byte {
+	buf = append(buf, typ)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(body)+4))
+	return append(buf, body...)
+}
+
+// readMessage reads a message and returns its type and body.
+func (s *PostgresSink) readMessage() (byte, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(s.reader, header[:]); err != nil {
+		return 0, nil, err
+	}
+	n := binary.BigEndian.Uint32(header[1:])
+	if n < 4 || n > 64<<20 {
+		return 0, nil, fmt.Errorf("postgres: invalid message length %d", n)
+	}
+	body := make([]byte, n-4)
+	_, err := io.ReadFull(s.reader, body)
+	return header[0], body, err
+}
+
+// pgError is an error reported by the server.
+type pgError struct {
+	severity string
+	code     string
+	message  string
+}
+
+func (e *pgError) Error() string {
+	return fmt.Sprintf("postgres: %s: %s (SQLSTATE %s)", e.severity, e.message, e.code)
+}
+
+// parsePGError parses the fields of an ErrorResponse.
+func parsePGError(body []byte) error {
+	e := &pgError{}
+	for _, field := range bytes.Split(body, []byte{0}) {
+		if len(field) < 2 {
+			continue
+		}
+		switch field[0] {
+		case 'S':
+			e.severity = string(field[1:])
+		case 'C':
+			e.code = string(field[1:])
+		case 'M':
+			e.message = string(field[1:])
+		}
+	}
+	return e
+}
+
+// pgSCRAM is the client side of a SCRAM-SHA-256 exchange.
+type pgSCRAM struct {
+	password        string
+	nonce           string
+	clientFirstBare string
+	authMessage     string
+	saltedPassword  []byte
+}
+
+func newPGSCRAM(user, password, nonce string) *pgSCRAM {
+	return &pgSCRAM{
+		password:        password,
+		nonce:           nonce,
+		clientFirstBare: "n=" + user + ",r=" + nonce,
+	}
+}
+
+// clientFirst returns the client-first-message.
+func (c *pgSCRAM) clientFirst() string {
+	return "n,," + c.clientFirstBare
+}
+
+// clientFinal returns the client-final-message for the server-first-message.
+func (c *pgSCRAM) clientFinal(serverFirst string) (string, error) {
+	var nonce, salt string
+	iterations := 0
+	for _, attr := range strings.Split(serverFirst, ",") {
+		key, value, _ := strings.Cut(attr, "=")
+		switch key {
+		case "r":
+			nonce = value
+		case "s":
+			salt = value
+		case "i":
+			iterations, _ = strconv.Atoi(value)
+		}
+	}
+	saltBytes, err := base64.StdEncoding.DecodeString(salt)
+	if err != nil || !strings.HasPrefix(nonce, c.nonce) || iterations < 1 {
+		return "", errors.New("postgres: invalid SCRAM server message")
+	}
+
+	c.saltedPassword = pbkdf2SHA256([]byte(c.password), saltBytes, iterations)
+	clientKey := hmacSHA256(c.saltedPassword, "Client Key")
+	storedKey := sha256.Sum256(clientKey)
+	withoutProof := "c=biws,r=" + nonce
+	c.authMessage = c.clientFirstBare + "," + serverFirst + "," + withoutProof
+	proof := hmacSHA256(storedKey[:], c.authMessage)
+	for i := range proof {
+		proof[i] ^= clientKey[i]
+	}
+	return withoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof), nil
+}
+
+// verify checks the server signature of the server-final-message.
+func (c *pgSCRAM) verify(serverFinal string) error {
+	serverKey := hmacSHA256(c.saltedPassword, "Server Key")
+	want := "v=" + base64.StdEncoding.EncodeToString(hmacSHA256(serverKey, c.authMessage))
+	if !hmac.Equal([]byte(strings.TrimSpace(serverFinal)), []byte(want)) {
+		return errors.New("postgres: invalid SCRAM server signature")
+	}
+	return nil
+}
+
+func hmacSHA256(key []byte, msg string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(msg))
+	return h.Sum(nil)
+}
+
+// pbkdf2SHA256 derives a 32-byte key with PBKDF2-HMAC-SHA256.
+func pbkdf2SHA256(password, salt []byte, iterations int) []byte {
+	h := hmac.New(sha256.New, password)
+	h.Write(salt)
+	h.Write([]byte{0, 0, 0, 1})
+	u := h.Sum(nil)
+	key := append([]byte(nil), u...)
+	for i := 1; i < iterations; i++ {
+		h.Reset()
+		h.Write(u)
+		u = h.Sum(u[:0])
+		for j := range key {
+			key[j] ^= u[j]
+		}
+	}
+	return key
+}
@@ -0,0 +1,202 @@
+package gologs
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+// fakePostgres is a server accepting a single connection with md5
+// authentication. It records the queries and the data copied with COPY.
+// Queries containing "missing" fail.
+type fakePostgres struct {
+	addr    string
+	queries chan string
+	copied  chan string
+}
+
+func newFakePostgres(t *testing.T, user, password string) *fakePostgres {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	f := &fakePostgres{addr: ln.Addr().String(), queries: make(chan string, 20), copied: make(chan string, 10)}
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		var size [4]byte
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return
+		}
+		io.ReadFull(r, make([]byte, binary.BigEndian.Uint32(size[:])-4))
+
+		salt := []byte{1, 2, 3, 4}
+		conn.Write(pgMessage(nil, 'R', append(binary.BigEndian.AppendUint32(nil, 5), salt...)))
+		typ, body := readFakePGMessage(r)
+		inner := md5.Sum([]byte(password + user))
+		outer := md5.Sum(append([]byte(hex.EncodeToString(inner[:])), salt...))
+		if typ != 'p' || string(body) != "md5"+hex.EncodeToString(outer[:])+"\x00" {
+			conn.Write(pgMessage(nil, 'E', []byte("SFATAL\x00C28P01\x00Mpassword authentication failed\x00\x00")))
+			return
+		}
+		conn.Write(pgMessage(nil, 'R', binary.BigEndian.AppendUint32(nil, 0)))
+		conn.Write(pgMessage(nil, 'S', []byte("server_version\x0016.0\x00")))
+		conn.Write(pgMessage(nil, 'Z', []byte{'I'}))
+
+		for {
+			typ, body := readFakePGMessage(r)
+			switch typ {
+			case 'Q':
+				query := strings.TrimSuffix(string(body), "\x00")
+				f.queries <- query
+				switch {
+				case strings.Contains(query, "missing"):
+					conn.Write(pgMessage(nil, 'E', []byte("SERROR\x00C42P01\x00Mrelation \"missing\" does not exist\x00\x00")))
+				case strings.HasPrefix(query, "COPY"):
+					conn.Write(pgMessage(nil, 'G', []byte{0, 0, 0}))
+					var data []byte
+					for {
+						typ, body := readFakePGMessage(r)
+						if typ != 'd' {
+							break
+						}
+						data = append(data, body...)
+					}
+					f.copied <- string(data)
+					conn.Write(pgMessage(nil, 'C', []byte("COPY 1\x00")))
+				default:
+					conn.Write(pgMessage(nil, 'C', []byte("CREATE TABLE\x00")))
+				}
+				conn.Write(pgMessage(nil, 'Z', []byte{'I'}))
+			default:
+				return
+			}
+		}
+	}()
+	return f
+}
+
+func readFakePGMessage(r *bufio.Reader) (byte, []byte) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil
+	}
+	body := make([]byte, binary.BigEndian.Uint32(header[1:])-4)
+	io.ReadFull(r, body)
+	return header[0], body
+}
+
+// tests the schema bootstrap and copying entries
+func TestPostgresSink(t *testing.T) {
+	pg := newFakePostgres(t, "app", "secret")
+	sink, err := NewPostgresSink("postgres://app:secret@"+pg.addr+"/app", WithPostgresTable("audit.logs"), WithPostgresColumns("user_id"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	l := New(WithSinks(sink), WithCallerInfo(false))
+	l.Error("Line one\nline\ttwo \\ end", Int("user_id", 42))
+	if err := l.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var queries []string
+	for len(pg.queries) > 0 {
+		queries = append(queries, <-pg.queries)
+	}
+	all := strings.Join(queries, "\n")
+	for _, want := range []string{
+		`CREATE TABLE IF NOT EXISTS "audit"."logs"`,
+		"fields jsonb NOT NULL",
+		`CREATE INDEX IF NOT EXISTS "logs_ts" ON "audit"."logs" (ts)`,
+		`CREATE INDEX IF NOT EXISTS "logs_user_id" ON "audit"."logs" ("user_id")`,
+		`COPY "audit"."logs" ("ts", "level", "severity", "message", "source", "caller", "fields", "user_id") FROM STDIN`,
+	} {
+		if !strings.Contains(all, want) {
+			t.Errorf("Expected query %q, got:\n%s", want, all)
+		}
+	}
+
+	row := strings.Split(strings.TrimSuffix(<-pg.copied, "\n"), "\t")
+	if len(row) != 8 {
+		t.Fatalf("Expected 8 columns, got %q", row)
+	}
+	if row[1] != "ERROR" || row[2] != "3" || row[3] != `Line one\nline\ttwo \\ end` || row[4] != `\N` || row[6] != `{"user_id":42}` || row[7] != "42" {
+		t.Errorf("Expected escaped row values, got %q", row)
+	}
+}
+
+// tests that server errors are returned without retrying
+func TestPostgresSinkServerError(t *testing.T) {
+	pg := newFakePostgres(t, "app", "secret")
+	sink, err := NewPostgresSink("postgres://app:secret@"+pg.addr, WithPostgresTable("missing"))
+	if err == nil {
+		sink.Close()
+	}
+	if err == nil || !strings.Contains(err.Error(), `relation "missing" does not exist (SQLSTATE 42P01)`) {
+		t.Errorf("Expected server error, got %v", err)
+	}
+}
+
+// tests that failed authentication is reported
+func TestPostgresSinkAuthFailed(t *testing.T) {
+	pg := newFakePostgres(t, "app", "secret")
+	if _, err := NewPostgresSink("postgres://app:wrong@" + pg.addr + "/app"); err == nil || !strings.Contains(err.Error(), "password authentication failed") {
+		t.Errorf("Expected authentication error, got %v", err)
+	}
+}
+
+// tests unsupported connection URLs
+func TestPostgresSinkInvalidURL(t *testing.T) {
+	for _, u := range []string{"mysql://db/app", "postgres://db/app?sslmode=require"} {
+		if _, err := NewPostgresSink(u); err == nil {
+			t.Errorf("Expected error for %s", u)
+		}
+	}
+}
+
+// tests SCRAM-SHA-256 with the example of RFC 7677
+func TestPGSCRAM(t *testing.T) {
+	c := newPGSCRAM("user", "pencil", "rOprNGfwEbeRWgbNEkqO")
+	if got := c.clientFirst(); got != "n,,n=user,r=rOprNGfwEbeRWgbNEkqO" {
+		t.Errorf("Expected client-first-message, got %s", got)
+	}
+	final, err := c.clientFinal("r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if want := "c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,p=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ="; final != want {
+		t.Errorf("Expected %s, got %s", want, final)
+	}
+	if err := c.verify("v=6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4="); err != nil {
+		t.Errorf("Expected valid server signature, got %v", err)
+	}
+	if err := c.verify("v=AAAA"); err == nil {
+		t.Error("Expected invalid server signature")
+	}
+	if _, err := newPGSCRAM("", "pencil", "abc").clientFinal("r=xyz,s=AAAA,i=4096"); err == nil {
+		t.Error("Expected error for server nonce not starting with the client nonce")
+	}
+}
+
+// tests escaping in the text format of COPY
+func TestAppendPGCopyValue(t *testing.T) {
+	var buf bytes.Buffer
+	for _, v := range []interface{}{nil, int64(-3), "a\tb\nc\rd\\e"} {
+		appendPGCopyValue(&buf, v)
+		buf.WriteByte('|')
+	}
+	if got := buf.String(); got != `\N|-3|a\tb\nc\rd\\e|` {
+		t.Errorf("Expected escaped values, got %s", got)
+	}
+}
@@ -0,0 +1,281 @@
+package gologs
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// ProtoSchema is the protobuf schema of the entries written by
+// ProtoEncoder, for generating decoders in other languages. It is also
+// published as gologs.proto in the repository.
+//
+//go:embed gologs.proto
+var ProtoSchema string
+
+// ProtoEncoder encodes entries as protobuf LogEntry messages of the schema
+// in ProtoSchema, each prefixed with its length as a varint, so consumers in
+// other languages can decode the stream with generated code instead of
+// parsing JSON. Keys and time formats are fixed by the schema; of the
+// logger's encoder options, only WithLowercaseLevels applies.
+type ProtoEncoder struct {
+	EncoderConfig
+}
+
+// Protobuf wire types.
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+)
+
+// Encode appends the entry as a length-prefixed LogEntry message.
+func (e ProtoEncoder) Encode(entry LogEntry, buf *bytes.Buffer) error {
+	dst := buf.AvailableBuffer()
+	data, err := appendProtoEntry(dst, entry, e.EncoderConfig)
+	if err != nil {
+		return err
+	}
+	buf.Write(insertProtoLength(data, 0))
+	return nil
+}
+
+func (e ProtoEncoder) withConfig(fn func(*EncoderConfig)) Encoder {
+	fn(&e.EncoderConfig)
+	return e
+}
+
+// appendProtoEntry appends the fields of a LogEntry message.
+func appendProtoEntry(buf []byte, e LogEntry, cfg EncoderConfig) ([]byte, error) {
+	if e.Level != "" {
+		buf = appendProtoString(buf, 1, cfg.level(e.Level))
+		if e.Severity != 0 {
+			buf = appendProtoTag(buf, 2, protoVarint)
+			buf = binary.AppendUvarint(buf, protoZigZag(int64(e.Severity)))
+		}
+	}
+	buf = appendProtoTag(buf, 3, protoFixed64)
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(e.Timestamp.UnixNano()))
+	if e.Source != "" {
+		buf = appendProtoString(buf, 4, e.Source)
+	}
+	if e.Caller != "" {
+		buf = appendProtoString(buf, 5, e.Caller)
+	}
+	buf, err := appendProtoMessage(buf, 6, func(buf []byte) ([]byte, error) {
+		return appendProtoValue(buf, e.Data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range e.Fields {
+		if f.Type == skipType {
+			continue
+		}
+		buf, err = appendProtoMessage(buf, 7, func(buf []byte) ([]byte, error) {
+			buf = appendProtoString(buf, 1, f.Key)
+			return appendProtoMessage(buf, 2, func(buf []byte) ([]byte, error) {
+				return appendProtoFieldValue(buf, f)
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+// appendProtoFieldValue appends the fields of the Value message of a field.
+func appendProtoFieldValue(buf []byte, f Field) ([]byte, error) {
+	switch f.Type {
+	case StringType, ErrorType:
+		return appendProtoString(buf, 1, f.str), nil
+	case IntType:
+		return appendProtoSint(buf, 2, f.integer), nil
+	case DurationType:
+		return appendProtoSint(buf, 7, f.integer), nil
+	case BoolType:
+		return appendProtoValue(buf, f.integer == 1)
+	default:
+		return appendProtoValue(buf, f.Value)
+	}
+}
+
+// appendProtoValue appends the fields of the Value message of v. Strings,
+// numbers, booleans, byte slices, slices and maps with string keys are
+// encoded directly; other values are encoded through their JSON encoding.
+func appendProtoValue(buf []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return buf, nil
+	case bool:
+		buf = appendProtoTag(buf, 5, protoVarint)
+		if v {
+			return append(buf, 1), nil
+		}
+		return append(buf, 0), nil
+	case string:
+		return appendProtoString(buf, 1, v), nil
+	case []byte:
+		buf = appendProtoTag(buf, 6, protoBytes)
+		buf = binary.AppendUvarint(buf, uint64(len(v)))
+		return append(buf, v...), nil
+	case int:
+		return appendProtoSint(buf, 2, int64(v)), nil
+	case int8:
+		return appendProtoSint(buf, 2, int64(v)), nil
+	case int16:
+		return appendProtoSint(buf, 2, int64(v)), nil
+	case int32:
+		return appendProtoSint(buf, 2, int64(v)), nil
+	case int64:
+		return appendProtoSint(buf, 2, v), nil
+	case uint8:
+		return appendProtoUint(buf, 3, uint64(v)), nil
+	case uint16:
+		return appendProtoUint(buf, 3, uint64(v)), nil
+	case uint32:
+		return appendProtoUint(buf, 3, uint64(v)), nil
+	case uint:
+		return appendProtoUint(buf, 3, uint64(v)), nil
+	case uint64:
+		return appendProtoUint(buf, 3, v), nil
+	case float32:
+		return appendProtoDouble(buf, float64(v)), nil
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return appendProtoSint(buf, 2, int64(v)), nil
+		}
+		return appendProtoDouble(buf, v), nil
+	case time.Duration:
+		return appendProtoSint(buf, 7, int64(v)), nil
+	case time.Time:
+		return appendProtoString(buf, 1, v.Format(time.RFC3339Nano)), nil
+	case error:
+		return appendProtoString(buf, 1, v.Error()), nil
+	case []interface{}:
+		return appendProtoMessage(buf, 8, func(buf []byte) ([]byte, error) {
+			for _, item := range v {
+				var err error
+				buf, err = appendProtoMessage(buf, 1, func(buf []byte) ([]byte, error) {
+					return appendProtoValue(buf, item)
+				})
+				if err != nil {
+					return nil, err
+				}
+			}
+			return buf, nil
+		})
+	case group:
+		return appendProtoMessage(buf, 9, func(buf []byte) ([]byte, error) {
+			for _, f := range v {
+				if f.Type == skipType {
+					continue
+				}
+				var err error
+				buf, err = appendProtoMessage(buf, 1, func(buf []byte) ([]byte, error) {
+					buf = appendProtoString(buf, 1, f.Key)
+					return appendProtoMessage(buf, 2, func(buf []byte) ([]byte, error) {
+						return appendProtoFieldValue(buf, f)
+					})
+				})
+				if err != nil {
+					return nil, err
+				}
+			}
+			return buf, nil
+		})
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return appendProtoMessage(buf, 9, func(buf []byte) ([]byte, error) {
+			for _, k := range keys {
+				var err error
+				buf, err = appendProtoMessage(buf, 1, func(buf []byte) ([]byte, error) {
+					buf = appendProtoString(buf, 1, k)
+					return appendProtoMessage(buf, 2, func(buf []byte) ([]byte, error) {
+						return appendProtoValue(buf, v[k])
+					})
+				})
+				if err != nil {
+					return nil, err
+				}
+			}
+			return buf, nil
+		})
+	}
+
+	if _, ok := v.(json.Marshaler); !ok {
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.String {
+			return appendProtoString(buf, 1, rv.String()), nil
+		}
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	return appendProtoValue(buf, decoded)
+}
+
+func appendProtoTag(buf []byte, num int, wireType byte) []byte {
+	return binary.AppendUvarint(buf, uint64(num)<<3|uint64(wireType))
+}
+
+func appendProtoString(buf []byte, num int, s string) []byte {
+	buf = appendProtoTag(buf, num, protoBytes)
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+func appendProtoSint(buf []byte, num int, v int64) []byte {
+	return binary.AppendUvarint(appendProtoTag(buf, num, protoVarint), protoZigZag(v))
+}
+
+func appendProtoUint(buf []byte, num int, v uint64) []byte {
+	return binary.AppendUvarint(appendProtoTag(buf, num, protoVarint), v)
+}
+
+func appendProtoDouble(buf []byte, v float64) []byte {
+	return binary.LittleEndian.AppendUint64(appendProtoTag(buf, 4, protoFixed64), math.Float64bits(v))
+}
+
+// protoZigZag encodes v for the sint32 and sint64 types.
+func protoZigZag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+// appendProtoMessage appends an embedded message with field number num,
+// whose fields are appended by body.
+func appendProtoMessage(buf []byte, num int, body func([]byte) ([]byte, error)) ([]byte, error) {
+	buf = appendProtoTag(buf, num, protoBytes)
+	start := len(buf)
+	buf, err := body(buf)
+	if err != nil {
+		return nil, err
+	}
+	return insertProtoLength(buf, start), nil
+}
+
+// insertProtoLength inserts the length of buf[start:] as a varint before
+// it, as the lengths of messages are only known once they are encoded.
+func insertProtoLength(buf []byte, start int) []byte {
+	n := len(buf) - start
+	var length [binary.MaxVarintLen64]byte
+	size := binary.PutUvarint(length[:], uint64(n))
+	buf = append(buf, length[:size]...)
+	copy(buf[start+size:], buf[start:start+n])
+	copy(buf[start:], length[:size])
+	return buf
+}
@@ -0,0 +1,70 @@
+package gologs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tests encoding entries as length-prefixed protobuf messages
+func TestProtoEncoder(t *testing.T) {
+	entry := LogEntry{Level: "WARN", Severity: WARN, Timestamp: time.Unix(0, 1), Data: "hi",
+		Fields: []Field{Int("n", -1)}}
+	var buf bytes.Buffer
+	for i := 0; i < 2; i++ {
+		if err := (ProtoEncoder{}).Encode(entry, &buf); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	want := "20" + // length
+		"0a045741524e" + // level
+		"1004" + // severity
+		"190100000000000000" + // time_unix_nano
+		"32040a026869" + // data
+		"3a070a016e12021001" // fields
+	if got := hex.EncodeToString(buf.Bytes()); got != want+want {
+		t.Errorf("Expected %s, got %s", want+want, got)
+	}
+}
+
+// tests encoding nested values
+func TestProtoValue(t *testing.T) {
+	for _, tt := range []struct {
+		v    interface{}
+		want string
+	}{
+		{nil, ""},
+		{true, "2801"},
+		{uint64(300), "18ac02"},
+		{1.5, "21000000000000f83f"},
+		{time.Second, "3880a8d6b907"},
+		{[]byte{1}, "320101"},
+		{[]interface{}{"a", 1}, "4209" + "0a030a0161" + "0a021002"},
+		{map[string]interface{}{"k": false}, "4a09" + "0a070a016b12022800"},
+	} {
+		data, err := appendProtoValue(nil, tt.v)
+		if err != nil {
+			t.Fatalf("Expected no error encoding %v, got %v", tt.v, err)
+		}
+		if got := hex.EncodeToString(data); got != tt.want {
+			t.Errorf("Expected %s for %v, got %s", tt.want, tt.v, got)
+		}
+	}
+}
+
+// tests that long messages get a multi-byte length prefix
+func TestProtoLength(t *testing.T) {
+	var buf bytes.Buffer
+	msg := strings.Repeat("x", 300)
+	(ProtoEncoder{}).Encode(LogEntry{Data: msg}, &buf)
+	n, size := binary.Uvarint(buf.Bytes())
+	if size != 2 || int(n) != buf.Len()-size {
+		t.Errorf("Expected a 2-byte length of the rest, got %d in %d bytes for %d bytes", n, size, buf.Len())
+	}
+	if !strings.Contains(ProtoSchema, "message LogEntry") {
+		t.Error("Expected the schema to define LogEntry")
+	}
+}
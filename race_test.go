@@ -0,0 +1,7 @@
+//go:build race
+
+package gologs
+
+// raceEnabled reports whether tests run with the race detector, which
+// makes sync.Pool drop items at random and so changes allocation counts.
+const raceEnabled = true
@@ -0,0 +1,176 @@
+package gologs
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// RateLimitConfig configures rate limiting with WithRateLimit.
+type RateLimitConfig struct {
+	// Rate is the number of entries per second the logger writes in
+	// total, and Burst the number it writes at once before the rate
+	// applies. Zero means no overall limit. Burst defaults to Rate.
+	Rate  float64
+	Burst int
+	// KeyRate and KeyBurst limit the entries with the same key in the same
+	// way. Zero means no limit per key.
+	KeyRate  float64
+	KeyBurst int
+	// Key returns the key of an entry. Defaults to its message.
+	Key func(LogEntry) string
+	// SummaryInterval is how often a WARN entry reports the number of
+	// suppressed entries, if any. Defaults to a minute.
+	SummaryInterval time.Duration
+}
+
+// WithRateLimit limits how many entries per second the logger writes, in
+// total or per key, to protect disks and collectors from log storms such as
+// a tight error loop. Entries over the limit are suppressed and reported
+// periodically by a WARN entry with their number; PANIC and FATAL entries
+// are never suppressed.
+func WithRateLimit(cfg RateLimitConfig) Option {
+	return func(o *options) {
+		o.rateLimit = &cfg
+	}
+}
+
+// rateLimitSummaryKeys is the maximum number of keys listed in a summary.
+const rateLimitSummaryKeys = 10
+
+// rateLimiter suppresses entries over the configured rates.
+type rateLimiter struct {
+	cfg RateLimitConfig
+
+	mu         sync.Mutex
+	total      tokenBucket
+	keys       map[string]*tokenBucket
+	suppressed int
+	byKey      map[string]int
+}
+
+func newRateLimiter(cfg RateLimitConfig) *rateLimiter {
+	if cfg.Burst <= 0 {
+		cfg.Burst = max(int(cfg.Rate), 1)
+	}
+	if cfg.KeyBurst <= 0 {
+		cfg.KeyBurst = max(int(cfg.KeyRate), 1)
+	}
+	if cfg.Key == nil {
+		cfg.Key = func(e LogEntry) string {
+			msg, _ := e.Data.(string)
+			return msg
+		}
+	}
+	if cfg.SummaryInterval <= 0 {
+		cfg.SummaryInterval = time.Minute
+	}
+	return &rateLimiter{
+		cfg:   cfg,
+		total: tokenBucket{tokens: float64(cfg.Burst)},
+		keys:  make(map[string]*tokenBucket),
+		byKey: make(map[string]int),
+	}
+}
+
+// allow reports whether the entry is within the limits, and counts it as
+// suppressed if not.
+func (r *rateLimiter) allow(entry LogEntry) bool {
+	if entry.Severity >= PANIC {
+		return true
+	}
+	now := entry.Timestamp
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var key string
+	var bucket *tokenBucket
+	if r.cfg.KeyRate > 0 {
+		key = r.cfg.Key(entry)
+		if bucket = r.keys[key]; bucket == nil {
+			bucket = &tokenBucket{tokens: float64(r.cfg.KeyBurst), last: now}
+			r.keys[key] = bucket
+		}
+		bucket.refill(now, r.cfg.KeyRate, r.cfg.KeyBurst)
+	}
+	if r.cfg.Rate > 0 {
+		r.total.refill(now, r.cfg.Rate, r.cfg.Burst)
+	}
+	if bucket != nil && bucket.tokens < 1 || r.cfg.Rate > 0 && r.total.tokens < 1 {
+		r.suppressed++
+		if bucket != nil {
+			r.byKey[key]++
+		}
+		return false
+	}
+	if bucket != nil {
+		bucket.tokens--
+	}
+	if r.cfg.Rate > 0 {
+		r.total.tokens--
+	}
+	return true
+}
+
+// summary returns the number of entries suppressed since the last summary,
+// and the keys suppressed most often, and starts counting again. Buckets of
+// keys that are full again are forgotten, so that keys seen once don't use
+// memory forever.
+func (r *rateLimiter) summary(now time.Time) (int, map[string]int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, bucket := range r.keys {
+		if bucket.refill(now, r.cfg.KeyRate, r.cfg.KeyBurst); bucket.tokens >= float64(r.cfg.KeyBurst) {
+			delete(r.keys, key)
+		}
+	}
+	n, byKey := r.suppressed, r.byKey
+	r.suppressed, r.byKey = 0, make(map[string]int)
+	if len(byKey) > rateLimitSummaryKeys {
+		keys := make([]string, 0, len(byKey))
+		for key := range byKey {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool { return byKey[keys[i]] > byKey[keys[j]] })
+		for _, key := range keys[rateLimitSummaryKeys:] {
+			delete(byKey, key)
+		}
+	}
+	return n, byKey
+}
+
+// reportSuppressed logs a WARN entry with the number of suppressed entries,
+// if there are any. The entry itself is not rate limited.
+func (l *Logger) reportSuppressed() {
+	now := l.now()
+	n, byKey := l.limiter.summary(now)
+	if n == 0 {
+		return
+	}
+	fields := []Field{Int("suppressed", n)}
+	if len(byKey) > 0 {
+		fields = append(fields, Any("suppressed_keys", byKey))
+	}
+	l.emit(LogEntry{
+		Level:     logLevelString(WARN),
+		Severity:  WARN,
+		Timestamp: now,
+		Data:      "Rate limit exceeded, log entries suppressed",
+		Fields:    l.entryFields(fields),
+	})
+}
+
+// tokenBucket allows a number of events per second with bursts.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// refill adds the tokens earned since the last refill, up to burst.
+func (b *tokenBucket) refill(now time.Time, rate float64, burst int) {
+	if !b.last.IsZero() && now.After(b.last) {
+		b.tokens = min(b.tokens+now.Sub(b.last).Seconds()*rate, float64(burst))
+	}
+	if now.After(b.last) {
+		b.last = now
+	}
+}
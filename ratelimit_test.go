@@ -0,0 +1,82 @@
+package gologs
+
+import (
+	"testing"
+	"time"
+)
+
+// tests the overall rate limit with bursts
+func TestRateLimit(t *testing.T) {
+	r := newRateLimiter(RateLimitConfig{Rate: 2})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	count := func(msg string, n int, at time.Time) int {
+		kept := 0
+		for i := 0; i < n; i++ {
+			if r.allow(LogEntry{Severity: ERROR, Timestamp: at, Data: msg}) {
+				kept++
+			}
+		}
+		return kept
+	}
+
+	if kept := count("boom", 5, now); kept != 2 {
+		t.Errorf("Expected a burst of 2 entries, got %d", kept)
+	}
+	if kept := count("boom", 5, now.Add(time.Second)); kept != 2 {
+		t.Errorf("Expected 2 entries after a second, got %d", kept)
+	}
+	if !r.allow(LogEntry{Severity: FATAL, Timestamp: now.Add(time.Second)}) {
+		t.Errorf("Expected FATAL entries not to be limited")
+	}
+	if n, _ := r.summary(now.Add(time.Second)); n != 6 {
+		t.Errorf("Expected 6 suppressed entries, got %d", n)
+	}
+	if n, _ := r.summary(now.Add(time.Second)); n != 0 {
+		t.Errorf("Expected the count to start over, got %d", n)
+	}
+}
+
+// tests limiting each key on its own
+func TestRateLimitPerKey(t *testing.T) {
+	r := newRateLimiter(RateLimitConfig{KeyRate: 1})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	kept := 0
+	for i := 0; i < 3; i++ {
+		for _, msg := range []string{"a", "b"} {
+			if r.allow(LogEntry{Timestamp: now, Data: msg}) {
+				kept++
+			}
+		}
+	}
+	if kept != 2 {
+		t.Errorf("Expected one entry per key, got %d", kept)
+	}
+	n, byKey := r.summary(now.Add(time.Minute))
+	if n != 4 || byKey["a"] != 2 || byKey["b"] != 2 {
+		t.Errorf("Expected 2 suppressed entries per key, got %d %v", n, byKey)
+	}
+	if len(r.keys) != 0 {
+		t.Errorf("Expected idle keys to be forgotten, got %d", len(r.keys))
+	}
+}
+
+// tests that a logger reports the suppressed entries
+func TestWithRateLimit(t *testing.T) {
+	sink := &memorySink{}
+	l := New(WithSinks(sink), WithRateLimit(RateLimitConfig{Rate: 1, SummaryInterval: time.Hour}))
+	for i := 0; i < 5; i++ {
+		l.Error("connection refused")
+	}
+	if n := len(sink.messages()); n != 1 {
+		t.Errorf("Expected 1 entry within the limit, got %d", n)
+	}
+	l.Close()
+
+	if len(sink.entries) != 2 {
+		t.Fatalf("Expected a summary on Close, got %v", sink.messages())
+	}
+	summary := sink.entries[1]
+	if summary.Severity != WARN || len(summary.Fields) != 1 || summary.Fields[0].integer != 4 {
+		t.Errorf("Expected a WARN entry with 4 suppressed entries, got %+v", summary)
+	}
+}
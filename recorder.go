@@ -0,0 +1,55 @@
+package gologs
+
+import "sync"
+
+// WithFlightRecorder keeps the last size entries at level and above that
+// the logger's level filters out, and writes them before the next ERROR,
+// PANIC or FATAL entry. This gives the context of a failure without the
+// cost of writing DEBUG entries all the time, though these entries are
+// still formatted.
+func WithFlightRecorder(size int, level LogLevel) Option {
+	return func(o *options) {
+		o.recorderSize = size
+		o.recorderLevel = level
+	}
+}
+
+// flightRecorder is a ring buffer of the last entries filtered out.
+type flightRecorder struct {
+	level LogLevel
+
+	mu   sync.Mutex
+	ring []LogEntry
+	head int
+	n    int
+}
+
+func newFlightRecorder(size int, level LogLevel) *flightRecorder {
+	return &flightRecorder{level: level, ring: make([]LogEntry, size)}
+}
+
+// add keeps the entry, replacing the oldest one if the buffer is full.
+func (r *flightRecorder) add(entry LogEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ring[(r.head+r.n)%len(r.ring)] = entry
+	if r.n < len(r.ring) {
+		r.n++
+	} else {
+		r.head = (r.head + 1) % len(r.ring)
+	}
+}
+
+// drain returns the kept entries, oldest first, and empties the buffer.
+func (r *flightRecorder) drain() []LogEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := make([]LogEntry, r.n)
+	for i := range entries {
+		j := (r.head + i) % len(r.ring)
+		entries[i] = r.ring[j]
+		r.ring[j] = LogEntry{}
+	}
+	r.head, r.n = 0, 0
+	return entries
+}
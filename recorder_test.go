@@ -0,0 +1,46 @@
+package gologs
+
+import (
+	"strings"
+	"testing"
+)
+
+// tests that filtered entries are written before an error
+func TestFlightRecorder(t *testing.T) {
+	sink := &memorySink{}
+	l := New(WithSinks(sink), WithLevel(WARN), WithFlightRecorder(2, DEBUG))
+	l.Trace("trace")
+	l.Debug("a")
+	l.Info("b")
+	l.Debugw("c", "lazy", Lazy(func() any { return 1 }))
+	l.Warn("warn")
+	if msgs := sink.messages(); strings.Join(msgs, ",") != "warn" {
+		t.Errorf("Expected the filtered entries to be kept back, got %v", msgs)
+	}
+	if l.IsDebug() {
+		t.Errorf("Expected DEBUG to be reported as disabled")
+	}
+
+	l.Error("failed")
+	l.Error("again")
+	if msgs := sink.messages(); strings.Join(msgs, ",") != "warn,b,c,failed,again" {
+		t.Errorf("Expected the last 2 filtered entries before the error, got %v", msgs)
+	}
+	if f := sink.entries[2].Fields[0]; f.Type != IntType || f.integer != 1 {
+		t.Errorf("Expected the lazy field to be computed, got %+v", f)
+	}
+}
+
+// tests that the flight recorder doesn't keep the entries Once and Every
+// suppress
+func TestFlightRecorderOnce(t *testing.T) {
+	sink := &memorySink{}
+	l := New(WithSinks(sink), WithLevel(WARN), WithFlightRecorder(10, DEBUG))
+	for i := 0; i < 3; i++ {
+		l.Once("cache").Debug("cache miss")
+	}
+	l.Error("failed")
+	if msgs := sink.messages(); strings.Join(msgs, ",") != "cache miss,failed" {
+		t.Errorf("Expected the suppressed entries to be left out, got %v", msgs)
+	}
+}
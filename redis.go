@@ -0,0 +1,344 @@
+package gologs
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RedisSink is a Sink that adds entries to a Redis stream with XADD, so the
+// newest entries can be read with XRANGE or consumed by consumer groups.
+// Each entry becomes a stream entry with the same keys as JSONEncoder
+// writes; "data" holds the message text and fields are added as text.
+// Entries are sent in batches of up to 100 entries, at least once a second,
+// from a background goroutine, using a single pipelined round trip per
+// batch.
+//
+// If a batch can't be sent, the sink reconnects and sends it again once.
+// TLS is not supported.
+type RedisSink struct {
+	addr     string
+	user     string
+	password string
+	db       int
+	stream   string
+	maxLen   int64
+	timeout  time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+	batch  *batcher
+}
+
+// RedisOption configures a RedisSink.
+type RedisOption func(*RedisSink)
+
+// WithRedisTimeout sets the timeout for connecting and for each batch.
+// Defaults to 5 seconds.
+func WithRedisTimeout(timeout time.Duration) RedisOption {
+	return func(s *RedisSink) {
+		s.timeout = timeout
+	}
+}
+
+// NewRedisSink returns a sink adding entries to stream on the Redis server
+// at redisURL, such as "redis://:password@localhost:6379/0". The stream is
+// trimmed to about maxLen entries; Redis trims whole nodes of the stream, so
+// it may hold slightly more. A maxLen of 0 keeps all entries.
+func NewRedisSink(redisURL, stream string, maxLen int64, opts ...RedisOption) (*RedisSink, error) {
+	u, err := url.Parse(redisURL)
+	if err != nil || u.Host == "" {
+		u, err = url.Parse("redis://" + redisURL)
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid URL %q", redisURL)
+		}
+	}
+	s := &RedisSink{
+		addr:    u.Host,
+		stream:  stream,
+		maxLen:  maxLen,
+		timeout: 5 * time.Second,
+	}
+	if u.Port() == "" {
+		s.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		s.user = u.User.Username()
+		s.password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if s.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("redis: invalid database %q", db)
+		}
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.batch = newBatcher(100, time.Second, s.send)
+	return s, nil
+}
+
+// Write queues the entry to be added.
+func (s *RedisSink) Write(entry LogEntry) error {
+	return s.batch.add(entry)
+}
+
+// Flush adds all queued entries.
+func (s *RedisSink) Flush() error {
+	return s.batch.flush()
+}
+
+// Close adds all queued entries and closes the connection.
+func (s *RedisSink) Close() error {
+	err := s.batch.close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		err = errors.Join(err, s.conn.Close())
+		s.conn = nil
+	}
+	return err
+}
+
+// Health returns the results of the sent batches and the number of entries
+// waiting to be sent.
+func (s *RedisSink) Health() SinkHealth {
+	return s.batch.health()
+}
+
+func (s *RedisSink) batches() *batcher {
+	return s.batch
+}
+
+// Ping connects to the server if the sink isn't connected, and returns an
+// error if it can't.
+func (s *RedisSink) Ping() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		return nil
+	}
+	return s.connect()
+}
+
+// send adds a batch of entries.
+func (s *RedisSink) send(entries []LogEntry) error {
+	var cmds []byte
+	for _, e := range entries {
+		args := []string{"XADD", s.stream}
+		if s.maxLen > 0 {
+			args = append(args, "MAXLEN", "~", strconv.FormatInt(s.maxLen, 10))
+		}
+		args = append(args, "*")
+		fields, err := redisFields(e)
+		if err != nil {
+			return err
+		}
+		cmds = appendRESPCommand(cmds, append(args, fields...)...)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	replies, err := s.pipeline(cmds, len(entries))
+	if err != nil {
+		replies, err = s.pipeline(cmds, len(entries))
+	}
+	if err != nil {
+		return err
+	}
+	var failed []error
+	for _, reply := range replies {
+		if err, ok := reply.(redisError); ok {
+			failed = append(failed, err)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("redis: %d of %d entries rejected: %w", len(failed), len(entries), failed[0])
+	}
+	return nil
+}
+
+// redisFields returns the field/value pairs of an entry.
+func redisFields(e LogEntry) ([]string, error) {
+	var msg bytes.Buffer
+	if err := appendMessageText(&msg, e.Data); err != nil {
+		return nil, err
+	}
+	fields := []string{
+		"level", e.Level,
+		"timestamp", e.Timestamp.Format(time.RFC3339Nano),
+	}
+	if e.Source != "" {
+		fields = append(fields, "source", e.Source)
+	}
+	if e.Caller != "" {
+		fields = append(fields, "caller", e.Caller)
+	}
+	fields = append(fields, "data", msg.String())
+	for _, f := range e.Fields {
+		if f.Type == skipType {
+			continue
+		}
+		value, err := fieldText(f)
+		if err != nil {
+			return nil, err
+		}
+		key := f.Key
+		if reservedKeys[key] {
+			key = "fields." + key
+		}
+		fields = append(fields, key, value)
+	}
+	return fields, nil
+}
+
+// pipeline writes the commands, connecting first if needed, and reads n
+// replies. The connection is closed on error.
+func (s *RedisSink) pipeline(cmds []byte, n int) ([]interface{}, error) {
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return nil, err
+		}
+	}
+	replies, err := s.roundTrip(cmds, n)
+	if err != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+	return replies, err
+}
+
+func (s *RedisSink) roundTrip(cmds []byte, n int) ([]interface{}, error) {
+	s.conn.SetDeadline(time.Now().Add(s.timeout))
+	if _, err := s.conn.Write(cmds); err != nil {
+		return nil, err
+	}
+	replies := make([]interface{}, n)
+	for i := range replies {
+		var err error
+		if replies[i], err = readRESP(s.reader); err != nil {
+			return nil, err
+		}
+	}
+	return replies, nil
+}
+
+// connect opens the connection, authenticates and selects the database.
+func (s *RedisSink) connect() error {
+	conn, err := net.DialTimeout("tcp", s.addr, s.timeout)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+	s.reader = bufio.NewReader(conn)
+
+	var cmds []byte
+	n := 0
+	if s.password != "" {
+		if s.user != "" {
+			cmds = appendRESPCommand(cmds, "AUTH", s.user, s.password)
+		} else {
+			cmds = appendRESPCommand(cmds, "AUTH", s.password)
+		}
+		n++
+	}
+	if s.db != 0 {
+		cmds = appendRESPCommand(cmds, "SELECT", strconv.Itoa(s.db))
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+	replies, err := s.roundTrip(cmds, n)
+	if err == nil {
+		for _, reply := range replies {
+			if e, ok := reply.(redisError); ok {
+				err = e
+			}
+		}
+	}
+	if err != nil {
+		conn.Close()
+		s.conn = nil
+		return err
+	}
+	return nil
+}
+
+// appendRESPCommand appends a command as an array of bulk strings.
+func appendRESPCommand(buf []byte, args ...string) []byte {
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, "\r\n"...)
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, "\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+	return buf
+}
+
+// redisError is an error reply.
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// readRESP reads a reply. Simple and bulk strings are returned as string,
+// integers as int64, arrays as []interface{}, nil replies as nil and error
+// replies as redisError.
+func readRESP(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, line := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return line, nil
+	case '-':
+		return redisError(line), nil
+	case ':':
+		return strconv.ParseInt(line, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, 0, min(n, 1024))
+		for i := 0; i < n; i++ {
+			item, err := readRESP(r)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unsupported reply type %q", kind)
+}
@@ -0,0 +1,116 @@
+package gologs
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeRedis accepts a single connection and records the commands it
+// receives. XADD to the stream "bad" is rejected.
+func fakeRedis(t *testing.T) (string, <-chan []interface{}) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	commands := make(chan []interface{}, 10)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for id := 1; ; id++ {
+			cmd, err := readRESP(r)
+			if err != nil {
+				return
+			}
+			args := cmd.([]interface{})
+			commands <- args
+			switch {
+			case args[0] == "XADD" && args[1] == "bad":
+				fmt.Fprintf(conn, "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n")
+			case args[0] == "XADD":
+				reply := fmt.Sprintf("1700000000000-%d", id)
+				fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(reply), reply)
+			default:
+				fmt.Fprintf(conn, "+OK\r\n")
+			}
+		}
+	}()
+	return ln.Addr().String(), commands
+}
+
+// receiveRedis returns the next command.
+func receiveRedis(t *testing.T, commands <-chan []interface{}) []interface{} {
+	select {
+	case cmd := <-commands:
+		return cmd
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected command")
+		return nil
+	}
+}
+
+// tests adding entries with authentication, database selection and trimming
+func TestRedisSink(t *testing.T) {
+	addr, commands := fakeRedis(t)
+	sink, err := NewRedisSink("redis://:secret@"+addr+"/2", "logs", 1000)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	l := New(WithSinks(sink), WithCallerInfo(false))
+	l.Info("Request handled", Int("status", 200), String("level", "clash"))
+	if err := l.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if cmd := receiveRedis(t, commands); fmt.Sprint(cmd) != "[AUTH secret]" {
+		t.Errorf("Expected AUTH, got %v", cmd)
+	}
+	if cmd := receiveRedis(t, commands); fmt.Sprint(cmd) != "[SELECT 2]" {
+		t.Errorf("Expected SELECT 2, got %v", cmd)
+	}
+	cmd := receiveRedis(t, commands)
+	if got := fmt.Sprint(cmd[:6]); got != "[XADD logs MAXLEN ~ 1000 *]" {
+		t.Errorf("Expected XADD with MAXLEN, got %v", got)
+	}
+	fields := map[interface{}]interface{}{}
+	for i := 6; i+1 < len(cmd); i += 2 {
+		fields[cmd[i]] = cmd[i+1]
+	}
+	for key, want := range map[string]string{"level": "INFO", "data": "Request handled", "status": "200", "fields.level": "clash"} {
+		if fields[key] != want {
+			t.Errorf("Expected %s=%s, got %v", key, want, fields[key])
+		}
+	}
+	if fields["timestamp"] == nil {
+		t.Errorf("Expected timestamp, got %v", fields)
+	}
+}
+
+// tests that rejected entries are reported
+func TestRedisSinkRejected(t *testing.T) {
+	addr, _ := fakeRedis(t)
+	sink, err := NewRedisSink(addr, "bad", 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer sink.Close()
+	sink.Write(LogEntry{Level: "INFO", Data: "rejected"})
+	if err := sink.Flush(); err == nil || !strings.Contains(err.Error(), "WRONGTYPE") {
+		t.Errorf("Expected WRONGTYPE error, got %v", err)
+	}
+}
+
+// tests invalid database numbers
+func TestRedisSinkInvalidURL(t *testing.T) {
+	if _, err := NewRedisSink("redis://localhost/logs", "logs", 0); err == nil {
+		t.Error("Expected error for invalid database")
+	}
+}
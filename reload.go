@@ -0,0 +1,110 @@
+package gologs
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// ApplyConfig changes the level, outputs and format of a running logger.
+// Settings left unset in cfg keep their current value. The new outputs
+// replace the old ones atomically: every entry is written completely to
+// either the old or the new outputs. Files opened for the old outputs are
+// closed once they are replaced, and so are the sinks of the previous
+// config if cfg lists sinks. Sinks added with WithSinks are kept.
+//
+// Fields, caller info and sampling are only read when the logger is
+// created and are not changed by ApplyConfig.
+func (l *Logger) ApplyConfig(cfg Config) error {
+	opts, err := cfg.Options()
+	if err != nil {
+		return err
+	}
+
+	current := l.out.Load()
+	o := current.opts
+	o.level = l.GetLogLevel()
+	o.encoderConfig = o.encoderConfig[:len(o.encoderConfig):len(o.encoderConfig)]
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	l.SetLogLevel(o.level)
+	if len(cfg.Outputs) == 0 && len(cfg.Sinks) == 0 && cfg.Format == "" && cfg.TimestampFormat == "" {
+		return nil
+	}
+
+	l.mu.Lock()
+	old := l.out.Swap(o.newOutput())
+	l.mu.Unlock()
+	for _, w := range old.writers {
+		if len(cfg.Outputs) > 0 {
+			w.Close()
+		} else {
+			// The outputs are kept, but not their buffers.
+			w.Flush()
+		}
+	}
+	if len(cfg.Sinks) > 0 {
+		for _, s := range old.opts.configSinks {
+			s.Close()
+		}
+	}
+	return nil
+}
+
+// ConfigWatcher reloads a logger's configuration when its file changes.
+type ConfigWatcher struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+// WatchConfig polls the config file at path every interval and applies it
+// with ApplyConfig whenever its modification time or size changes, so the
+// level can be changed on a running process by editing the file. Errors
+// while reloading are reported with the standard log package and the
+// previous configuration stays in effect.
+func (l *Logger) WatchConfig(path string, interval time.Duration) *ConfigWatcher {
+	w := &ConfigWatcher{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	last, _ := os.Stat(path)
+
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			if last != nil && info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size() {
+				continue
+			}
+			last = info
+
+			cfg, err := LoadConfig(path)
+			if err == nil {
+				err = l.ApplyConfig(cfg)
+			}
+			if err != nil {
+				log.Printf("Failed to reload log config: %v", err)
+			}
+		}
+	}()
+	return w
+}
+
+// Close stops watching the config file.
+func (w *ConfigWatcher) Close() {
+	close(w.stop)
+	<-w.done
+}
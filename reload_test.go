@@ -0,0 +1,112 @@
+package gologs
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tests changing level, output and format of a running logger
+func TestApplyConfig(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(INFO, &out)
+	child := l.Child(String("component", "db"))
+
+	logPath := filepath.Join(t.TempDir(), "app.log")
+	err := l.ApplyConfig(Config{Level: "debug", Format: "logfmt", Outputs: []string{logPath}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	child.Debug("After reload")
+
+	if out.Len() != 0 {
+		t.Errorf("Expected nothing written to old output, got %v", out.String())
+	}
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `level=debug msg="After reload"`) {
+		t.Errorf("Expected child entry in new output, got %v", string(data))
+	}
+
+	if err := l.ApplyConfig(Config{Level: "loud"}); err == nil {
+		t.Error("Expected error for unknown level")
+	}
+	if l.GetLogLevel() != DEBUG {
+		t.Errorf("Expected level to be unchanged after error, got %v", l.GetLogLevel())
+	}
+}
+
+// tests reloading the configuration when the file changes
+func TestWatchConfig(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(INFO, &out)
+
+	configPath := filepath.Join(t.TempDir(), "logging.yaml")
+	if err := os.WriteFile(configPath, []byte("level: info\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	watcher := l.WatchConfig(configPath, 5*time.Millisecond)
+	defer watcher.Close()
+
+	if err := os.WriteFile(configPath, []byte("level: debug\nformat: json\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for l.GetLogLevel() != DEBUG {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected level to be reloaded")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// tests switching between pretty and compact JSON at runtime
+func TestApplyConfigPretty(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(INFO, &out, WithCallerInfo(false))
+	if err := l.ApplyConfig(Config{Format: "pretty"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	l.Info("pretty")
+	if lines := strings.Count(out.String(), "\n"); lines != 5 {
+		t.Errorf("Expected an indented entry, got %s", out.String())
+	}
+
+	out.Reset()
+	l.ApplyConfig(Config{Format: "json"})
+	l.Info("compact")
+	if lines := strings.Count(out.String(), "\n"); lines != 1 {
+		t.Errorf("Expected a single line, got %s", out.String())
+	}
+}
+
+// tests switching a logger created with WithPrettyJSON to compact JSON
+func TestApplyConfigPrettyToJSON(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(INFO, &out, WithCallerInfo(false), WithPrettyJSON())
+	l.Info("pretty")
+	if lines := strings.Count(out.String(), "\n"); lines != 5 {
+		t.Errorf("Expected an indented entry, got %s", out.String())
+	}
+
+	out.Reset()
+	if err := l.ApplyConfig(Config{Format: "json"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	l.Info("compact")
+	if lines := strings.Count(out.String(), "\n"); lines != 1 {
+		t.Errorf("Expected a single line, got %s", out.String())
+	}
+
+	out.Reset()
+	l.ApplyConfig(Config{Format: "pretty"})
+	l.Info("pretty again")
+	if lines := strings.Count(out.String(), "\n"); lines != 5 {
+		t.Errorf("Expected an indented entry, got %s", out.String())
+	}
+}
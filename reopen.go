@@ -0,0 +1,59 @@
+package gologs
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Reopener is implemented by sinks that write to files and can reopen them by
+// name, such as FileSink.
+type Reopener interface {
+	Reopen() error
+}
+
+// Reopen reopens the files of all sinks that implement Reopener. Use it after
+// logrotate or a similar tool has moved the log files away, so writing
+// continues at the original paths instead of in the rotated files.
+func (l *Logger) Reopen() error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.out.Load().each(func(s Sink) error {
+		if r, ok := s.(Reopener); ok {
+			return r.Reopen()
+		}
+		return nil
+	})
+}
+
+// ReopenOnSignal calls Reopen whenever the process receives one of the given
+// signals, SIGHUP if none are given. This matches logrotate configurations
+// that send SIGHUP after moving the files. Errors are reported with the
+// standard log package. The returned function stops listening for signals.
+func (l *Logger) ReopenOnSignal(signals ...os.Signal) (stop func()) {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGHUP}
+	}
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, signals...)
+
+	go func() {
+		for {
+			select {
+			case <-ch:
+				if err := l.Reopen(); err != nil {
+					log.Printf("Failed to reopen log files: %v", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
@@ -0,0 +1,87 @@
+package gologs
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// tests reopening files after they were moved by an external tool
+func TestReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	configPath := filepath.Join(dir, "config.log")
+	sink, err := NewFileSink(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := NewLoggerFromConfig(writeConfig(t, `{"outputs": ["`+configPath+`"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l = New(WithSinks(NewLevelSink(sink, DEBUG), l.out.Load().writers[0]))
+	defer l.Close()
+
+	l.Info("before rotation")
+	for _, p := range []string{path, configPath} {
+		if err := os.Rename(p, p+".1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Reopen(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	l.Info("after rotation")
+
+	for _, p := range []string{path, configPath} {
+		current, _ := os.ReadFile(p)
+		rotated, _ := os.ReadFile(p + ".1")
+		if strings.Contains(string(current), "before rotation") || !strings.Contains(string(current), "after rotation") {
+			t.Errorf("Expected only the new entry in %v, got %v", p, string(current))
+		}
+		if !strings.Contains(string(rotated), "before rotation") || strings.Contains(string(rotated), "after rotation") {
+			t.Errorf("Expected only the old entry in rotated %v, got %v", p, string(rotated))
+		}
+	}
+}
+
+// tests reopening files on SIGHUP
+func TestReopenOnSignal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGHUP can't be sent on Windows")
+	}
+	path := filepath.Join(t.TempDir(), "app.log")
+	sink, err := NewFileSink(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := New(WithSinks(sink))
+	defer l.Close()
+	stop := l.ReopenOnSignal()
+	defer stop()
+
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	self, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := self.Signal(syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected file to be reopened after SIGHUP")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
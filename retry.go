@@ -0,0 +1,281 @@
+package gologs
+
+import (
+	"errors"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DropPolicy decides which entry is dropped when a queue is full, such as
+// the queue of a RetrySink or the buffer of an async logger.
+type DropPolicy int
+
+const (
+	// DropNewest drops the entry being written.
+	DropNewest DropPolicy = iota
+	// DropOldest drops the oldest queued entry to make room.
+	DropOldest
+	// DropLowest drops the queued entry with the lowest severity, the
+	// oldest of them if several have it, to make room. The entry being
+	// written is dropped instead if its severity is lower still. Entries
+	// at ERROR and above are never dropped: if neither the entry being
+	// written nor any queued entry is below ERROR, the writer waits for
+	// room.
+	DropLowest
+	// BlockWhenFull drops nothing; the writer waits until there is room.
+	BlockWhenFull
+)
+
+// lowestSeverity returns the index of the oldest of the entries with the
+// lowest severity.
+func lowestSeverity(entries []LogEntry) int {
+	lowest := 0
+	for i, e := range entries {
+		if e.Severity < entries[lowest].Severity {
+			lowest = i
+		}
+	}
+	return lowest
+}
+
+// waitsForRoom reports whether the writer of entry waits for room in a full
+// queue rather than dropping an entry, given the queued entry with the
+// lowest severity.
+func waitsForRoom(policy DropPolicy, entry, lowest LogEntry) bool {
+	switch policy {
+	case BlockWhenFull:
+		return true
+	case DropLowest:
+		return min(entry.Severity, lowest.Severity) >= ERROR
+	}
+	return false
+}
+
+// RetrySink is a Sink that writes entries to another sink from a background
+// goroutine and retries failed writes, so a transient outage of a collector
+// neither loses entries nor blocks the caller. Failed writes are retried
+// with an exponential backoff with jitter, up to a maximum number of
+// attempts, after which the entry is dropped.
+//
+// Entries are held in a bounded queue while they wait; when it is full, the
+// drop policy decides which entry is lost.
+type RetrySink struct {
+	sink       Sink
+	attempts   int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	queueSize  int
+	policy     DropPolicy
+	dropped    atomic.Int64
+	stats      sinkStats
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queue  []LogEntry
+	busy   bool
+	closed bool
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// RetryOption configures a RetrySink.
+type RetryOption func(*RetrySink)
+
+// WithRetryAttempts sets how often a write is attempted before the entry is
+// dropped. Defaults to 5.
+func WithRetryAttempts(attempts int) RetryOption {
+	return func(s *RetrySink) {
+		s.attempts = attempts
+	}
+}
+
+// WithRetryBackoff sets the wait before the first retry, which doubles with
+// each further retry up to max. Defaults to 100 milliseconds and 30
+// seconds. A random jitter of up to half the wait is subtracted, so that
+// many clients don't retry in lockstep.
+func WithRetryBackoff(initial, max time.Duration) RetryOption {
+	return func(s *RetrySink) {
+		s.backoff = initial
+		s.maxBackoff = max
+	}
+}
+
+// WithRetryQueue sets the number of entries held while waiting and the
+// policy used when the queue is full. Defaults to 1000 entries and
+// DropNewest. With BlockWhenFull, Write waits for room.
+func WithRetryQueue(size int, policy DropPolicy) RetryOption {
+	return func(s *RetrySink) {
+		s.queueSize = size
+		s.policy = policy
+	}
+}
+
+// NewRetrySink returns a sink writing to sink with retries.
+func NewRetrySink(sink Sink, opts ...RetryOption) *RetrySink {
+	s := &RetrySink{
+		sink:       sink,
+		attempts:   5,
+		backoff:    100 * time.Millisecond,
+		maxBackoff: 30 * time.Second,
+		queueSize:  1000,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.attempts = max(s.attempts, 1)
+	s.queueSize = max(s.queueSize, 1)
+	s.cond = sync.NewCond(&s.mu)
+	go s.loop()
+	return s
+}
+
+// Write queues the entry. It returns an error if the entry is dropped
+// because the queue is full.
+func (s *RetrySink) Write(entry LogEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(s.queue) >= s.queueSize && !s.closed && waitsForRoom(s.policy, entry, s.queue[lowestSeverity(s.queue)]) {
+		s.cond.Wait()
+	}
+	if s.closed {
+		return errors.New("retry: sink is closed")
+	}
+	if len(s.queue) >= s.queueSize {
+		s.dropped.Add(1)
+		i := 0
+		switch s.policy {
+		case DropNewest:
+			return errors.New("retry: queue full, entry dropped")
+		case DropLowest:
+			if i = lowestSeverity(s.queue); entry.Severity < s.queue[i].Severity {
+				return errors.New("retry: queue full, entry dropped")
+			}
+		}
+		s.queue = append(s.queue[:i], s.queue[i+1:]...)
+	}
+	s.queue = append(s.queue, entry)
+	s.cond.Broadcast()
+	return nil
+}
+
+// Dropped returns the number of entries dropped so far, because the queue
+// was full or all attempts failed.
+func (s *RetrySink) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+func (s *RetrySink) stackLevel() LogLevel {
+	return sinkStackLevel(s.sink)
+}
+
+// Ping pings the wrapped sink, or returns ErrPingUnsupported if it doesn't
+// implement Pinger.
+func (s *RetrySink) Ping() error {
+	return pingSink(s.sink)
+}
+
+// Health returns the results of the writes to the wrapped sink and the
+// number of entries waiting to be written.
+func (s *RetrySink) Health() SinkHealth {
+	h := s.stats.health()
+	s.mu.Lock()
+	h.QueueDepth = len(s.queue)
+	if s.busy {
+		h.QueueDepth++
+	}
+	s.mu.Unlock()
+	return h
+}
+
+// loop writes queued entries until the sink is closed.
+func (s *RetrySink) loop() {
+	defer close(s.done)
+	for {
+		s.mu.Lock()
+		for len(s.queue) == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+			return
+		}
+		entry := s.queue[0]
+		s.queue = s.queue[1:]
+		s.busy = true
+		s.cond.Broadcast()
+		s.mu.Unlock()
+
+		s.deliver(entry)
+
+		s.mu.Lock()
+		s.busy = false
+		s.cond.Broadcast()
+		s.mu.Unlock()
+	}
+}
+
+// deliver writes an entry, retrying failed writes.
+func (s *RetrySink) deliver(entry LogEntry) {
+	for attempt := 1; ; attempt++ {
+		err := s.sink.Write(entry)
+		s.stats.record(err, time.Now())
+		if err == nil {
+			return
+		}
+		if attempt >= s.attempts {
+			s.dropped.Add(1)
+			log.Printf("Dropping log entry after %d attempts: %v", attempt, err)
+			return
+		}
+		select {
+		case <-s.stop:
+			// Closing; retry right away.
+		case <-time.After(s.wait(attempt)):
+		}
+	}
+}
+
+// wait returns the backoff before the given retry, with jitter.
+func (s *RetrySink) wait(attempt int) time.Duration {
+	d := s.backoff
+	for i := 1; i < attempt && d < s.maxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, s.maxBackoff)
+	if d <= 0 {
+		return 0
+	}
+	return d - rand.N(d/2+1)
+}
+
+// Flush waits until all queued entries have been written or dropped, and
+// then flushes the wrapped sink.
+func (s *RetrySink) Flush() error {
+	s.mu.Lock()
+	for len(s.queue) > 0 || s.busy {
+		s.cond.Wait()
+	}
+	s.mu.Unlock()
+	return s.sink.Flush()
+}
+
+// Close writes the queued entries, retrying without waiting, stops the
+// background goroutine and closes the wrapped sink.
+func (s *RetrySink) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.stop)
+	s.cond.Broadcast()
+	s.mu.Unlock()
+	<-s.done
+	return s.sink.Close()
+}
@@ -0,0 +1,202 @@
+package gologs
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakySink is a memorySink whose first writes fail.
+type flakySink struct {
+	memorySink
+	mu       sync.Mutex
+	failures int
+	attempts int
+}
+
+func (s *flakySink) Write(entry LogEntry) error {
+	s.mu.Lock()
+	s.attempts++
+	fail := s.attempts <= s.failures
+	s.mu.Unlock()
+	if fail {
+		return errors.New("collector down")
+	}
+	return s.memorySink.Write(entry)
+}
+
+// tests that failed writes are retried until they succeed
+func TestRetrySink(t *testing.T) {
+	flaky := &flakySink{failures: 3}
+	sink := NewRetrySink(flaky, WithRetryBackoff(time.Millisecond, 4*time.Millisecond))
+	for _, msg := range []string{"a", "b"} {
+		if err := sink.Write(LogEntry{Data: msg}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if err := sink.Flush(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if msgs := flaky.messages(); strings.Join(msgs, ",") != "a,b" {
+		t.Errorf("Expected both entries in order, got %v", msgs)
+	}
+	if flaky.attempts != 5 || flaky.flushed != 1 {
+		t.Errorf("Expected 5 attempts and 1 flush, got %d and %d", flaky.attempts, flaky.flushed)
+	}
+	if err := sink.Close(); err != nil || !flaky.closed {
+		t.Errorf("Expected closed sink, got %v", err)
+	}
+}
+
+// tests that entries are dropped after the maximum number of attempts
+func TestRetrySinkMaxAttempts(t *testing.T) {
+	flaky := &flakySink{failures: 100}
+	sink := NewRetrySink(flaky, WithRetryAttempts(3), WithRetryBackoff(time.Millisecond, time.Millisecond))
+	sink.Write(LogEntry{Data: "lost"})
+	sink.Flush()
+	if flaky.attempts != 3 || sink.Dropped() != 1 {
+		t.Errorf("Expected 3 attempts and 1 dropped entry, got %d and %d", flaky.attempts, sink.Dropped())
+	}
+	sink.Close()
+}
+
+// tests the drop policies of a full queue
+func TestRetrySinkQueueFull(t *testing.T) {
+	for policy, want := range map[DropPolicy]string{DropNewest: "1,2", DropOldest: "2,3"} {
+		blocked := make(chan struct{})
+		inner := &memorySink{}
+		sink := NewRetrySink(blockingSink{inner, blocked}, WithRetryQueue(2, policy))
+		sink.Write(LogEntry{Data: "0"})
+		// wait until the first entry is being written
+		for {
+			sink.mu.Lock()
+			busy := sink.busy
+			sink.mu.Unlock()
+			if busy {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		sink.Write(LogEntry{Data: "1"})
+		sink.Write(LogEntry{Data: "2"})
+		err := sink.Write(LogEntry{Data: "3"})
+		if policy == DropNewest && err == nil {
+			t.Errorf("Expected error for dropped entry, got %v", err)
+		}
+		close(blocked)
+		sink.Close()
+
+		if msgs := inner.messages(); strings.Join(msgs[1:], ",") != want {
+			t.Errorf("Expected %s for policy %d, got %v", want, policy, msgs)
+		}
+		if sink.Dropped() != 1 {
+			t.Errorf("Expected 1 dropped entry, got %d", sink.Dropped())
+		}
+	}
+}
+
+// tests that DropLowest keeps the entries with the highest severity, and
+// that BlockWhenFull waits for room
+func TestRetrySinkQueuePolicies(t *testing.T) {
+	for policy, want := range map[DropPolicy]string{DropLowest: "a,c", BlockWhenFull: "a,b,c"} {
+		blocked := make(chan struct{})
+		inner := &memorySink{}
+		sink := NewRetrySink(blockingSink{inner, blocked}, WithRetryQueue(2, policy))
+		sink.Write(LogEntry{Data: "0"})
+		for {
+			sink.mu.Lock()
+			busy := sink.busy
+			sink.mu.Unlock()
+			if busy {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		sink.Write(LogEntry{Severity: ERROR, Data: "a"})
+		sink.Write(LogEntry{Severity: DEBUG, Data: "b"})
+		written := make(chan error, 1)
+		if policy == BlockWhenFull {
+			go func() {
+				written <- sink.Write(LogEntry{Severity: INFO, Data: "c"})
+			}()
+			select {
+			case <-written:
+				t.Fatalf("Expected Write to wait for room")
+			case <-time.After(20 * time.Millisecond):
+			}
+		} else {
+			written <- sink.Write(LogEntry{Severity: INFO, Data: "c"})
+		}
+		close(blocked)
+		if err := <-written; err != nil {
+			t.Errorf("Expected no error for policy %d, got %v", policy, err)
+		}
+		sink.Close()
+
+		if msgs := inner.messages(); strings.Join(msgs[1:], ",") != want {
+			t.Errorf("Expected %s for policy %d, got %v", want, policy, msgs)
+		}
+	}
+}
+
+// blockingSink writes to a memorySink once unblocked.
+type blockingSink struct {
+	*memorySink
+	unblock chan struct{}
+}
+
+func (s blockingSink) Write(entry LogEntry) error {
+	<-s.unblock
+	return s.memorySink.Write(entry)
+}
+
+// tests that the backoff doubles up to the maximum, minus jitter
+func TestRetrySinkWait(t *testing.T) {
+	sink := &RetrySink{backoff: 100 * time.Millisecond, maxBackoff: time.Second}
+	for attempt, max := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 4: 800 * time.Millisecond, 10: time.Second} {
+		for i := 0; i < 20; i++ {
+			if wait := sink.wait(attempt); wait < max/2 || wait > max {
+				t.Errorf("Expected wait between %v and %v for attempt %d, got %v", max/2, max, attempt, wait)
+			}
+		}
+	}
+}
+
+// tests that DropLowest never drops errors from the queue
+func TestRetrySinkQueueKeepsErrors(t *testing.T) {
+	blocked := make(chan struct{})
+	inner := &memorySink{}
+	sink := NewRetrySink(blockingSink{inner, blocked}, WithRetryQueue(1, DropLowest))
+	sink.Write(LogEntry{Data: "0"})
+	for {
+		sink.mu.Lock()
+		busy := sink.busy
+		sink.mu.Unlock()
+		if busy {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	sink.Write(LogEntry{Severity: ERROR, Data: "a"})
+	written := make(chan error, 1)
+	go func() {
+		written <- sink.Write(LogEntry{Severity: FATAL, Data: "b"})
+	}()
+	select {
+	case <-written:
+		t.Fatalf("Expected Write to wait for room")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(blocked)
+	if err := <-written; err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	sink.Close()
+
+	if msgs := inner.messages(); strings.Join(msgs[1:], ",") != "a,b" {
+		t.Errorf("Expected both errors to be written, got %v", msgs)
+	}
+}
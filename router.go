@@ -0,0 +1,232 @@
+package gologs
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"regexp"
+)
+
+// Matcher reports whether an entry matches a routing rule.
+type Matcher func(entry LogEntry) bool
+
+// MatchLevel matches entries from level low up to and including level high.
+// Use FATAL as high to match low and above.
+func MatchLevel(low, high LogLevel) Matcher {
+	return func(e LogEntry) bool {
+		return e.Severity >= low && e.Severity <= high
+	}
+}
+
+// MatchField matches entries with a field with the given key whose value,
+// as text, equals value.
+func MatchField(key, value string) Matcher {
+	return func(e LogEntry) bool {
+		text, ok := lastFieldText(e, key)
+		return ok && text == value
+	}
+}
+
+// MatchFieldRegexp matches entries with a field with the given key whose
+// value, as text, matches re.
+func MatchFieldRegexp(key string, re *regexp.Regexp) Matcher {
+	return func(e LogEntry) bool {
+		text, ok := lastFieldText(e, key)
+		return ok && re.MatchString(text)
+	}
+}
+
+// MatchMessage matches entries whose message, as text, matches re.
+func MatchMessage(re *regexp.Regexp) Matcher {
+	return func(e LogEntry) bool {
+		var msg bytes.Buffer
+		if err := appendMessageText(&msg, e.Data); err != nil {
+			return false
+		}
+		return re.Match(msg.Bytes())
+	}
+}
+
+// MatchAll matches entries matched by all of the matchers.
+func MatchAll(matchers ...Matcher) Matcher {
+	return func(e LogEntry) bool {
+		for _, m := range matchers {
+			if !m(e) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// MatchAny matches entries matched by at least one of the matchers.
+func MatchAny(matchers ...Matcher) Matcher {
+	return func(e LogEntry) bool {
+		for _, m := range matchers {
+			if m(e) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchNot matches entries not matched by m.
+func MatchNot(m Matcher) Matcher {
+	return func(e LogEntry) bool {
+		return !m(e)
+	}
+}
+
+// lastFieldText returns the value of the last field with the given key as
+// text, as later fields override earlier ones.
+func lastFieldText(e LogEntry, key string) (string, bool) {
+	for i := len(e.Fields) - 1; i >= 0; i-- {
+		if f := e.Fields[i]; f.Key == key && f.Type != skipType {
+			text, err := fieldText(f)
+			return text, err == nil
+		}
+	}
+	return "", false
+}
+
+// Route sends the entries matched by Match to Sinks.
+type Route struct {
+	Match Matcher
+	Sinks []Sink
+	// Continue makes the router check the following routes after this one
+	// matched. By default, the first matching route wins.
+	Continue bool
+}
+
+// RouterSink is a Sink that passes each entry to the sinks of the routes it
+// matches, checked in order, or else to the fallback sinks. For example:
+//
+//	gologs.NewRouterSink([]gologs.Route{{
+//		Match: gologs.MatchAll(gologs.MatchField("tenant", "acme"), gologs.MatchLevel(gologs.ERROR, gologs.FATAL)),
+//		Sinks: []gologs.Sink{acmeWebhook},
+//	}}, fileSink)
+type RouterSink struct {
+	routes   []Route
+	fallback []Sink
+	// sinks are all sinks of the router, each once.
+	sinks []Sink
+}
+
+// NewRouterSink returns a sink routing entries with the given routes.
+// Entries matching no route go to the fallback sinks.
+func NewRouterSink(routes []Route, fallback ...Sink) *RouterSink {
+	s := &RouterSink{routes: routes, fallback: fallback}
+	for _, r := range routes {
+		s.addSinks(r.Sinks)
+	}
+	s.addSinks(fallback)
+	return s
+}
+
+// addSinks adds the sinks not known yet to s.sinks.
+func (s *RouterSink) addSinks(sinks []Sink) {
+next:
+	for _, sink := range sinks {
+		if reflect.TypeOf(sink).Comparable() {
+			for _, known := range s.sinks {
+				if known == sink {
+					continue next
+				}
+			}
+		}
+		s.sinks = append(s.sinks, sink)
+	}
+}
+
+// Write passes the entry to the sinks of the matching routes.
+func (s *RouterSink) Write(entry LogEntry) error {
+	var errs []error
+	matched := false
+	for _, r := range s.routes {
+		if !r.Match(entry) {
+			continue
+		}
+		matched = true
+		for _, sink := range r.Sinks {
+			if err := sink.Write(entry); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if !r.Continue {
+			break
+		}
+	}
+	if !matched {
+		for _, sink := range s.fallback {
+			if err := sink.Write(entry); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Flush flushes all sinks of the router.
+func (s *RouterSink) Flush() error {
+	var errs []error
+	for _, sink := range s.sinks {
+		if err := sink.Flush(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes all sinks of the router.
+func (s *RouterSink) Close() error {
+	var errs []error
+	for _, sink := range s.sinks {
+		if err := sink.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Reopen reopens all sinks of the router that implement Reopener.
+func (s *RouterSink) Reopen() error {
+	var errs []error
+	for _, sink := range s.sinks {
+		if r, ok := sink.(Reopener); ok {
+			if err := r.Reopen(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *RouterSink) stackLevel() LogLevel {
+	level := OFF
+	for _, sink := range s.sinks {
+		level = min(level, sinkStackLevel(sink))
+	}
+	return level
+}
+
+// Ping pings all sinks of the router that implement Pinger. If none does,
+// it returns ErrPingUnsupported.
+func (s *RouterSink) Ping() error {
+	var errs []error
+	pinged := false
+	for _, sink := range s.sinks {
+		err := pingSink(sink)
+		if errors.Is(err, ErrPingUnsupported) {
+			continue
+		}
+		pinged = true
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if !pinged {
+		return ErrPingUnsupported
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,71 @@
+package gologs
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// tests that entries go to the first matching route or the fallback sinks
+func TestRouterSink(t *testing.T) {
+	acme := &memorySink{}
+	audit := &memorySink{}
+	timeouts := &memorySink{}
+	file := &memorySink{}
+	router := NewRouterSink([]Route{
+		{Match: MatchField("audit", "true"), Sinks: []Sink{audit}, Continue: true},
+		{Match: MatchAll(MatchField("tenant", "acme"), MatchLevel(ERROR, FATAL)), Sinks: []Sink{acme}},
+		{Match: MatchMessage(regexp.MustCompile(`(?i)timeout`)), Sinks: []Sink{timeouts, file}},
+	}, file)
+	l := New(WithSinks(router))
+
+	l.Error("acme failed", String("tenant", "acme"))
+	l.Warn("acme slow", String("tenant", "acme"))
+	l.Error("other failed", String("tenant", "globex"))
+	l.Info("Request timeout")
+	l.Error("acme deleted", String("tenant", "acme"), Bool("audit", true))
+
+	if msgs := acme.messages(); strings.Join(msgs, ",") != "acme failed,acme deleted" {
+		t.Errorf("Expected acme errors in acme sink, got %v", msgs)
+	}
+	if msgs := audit.messages(); strings.Join(msgs, ",") != "acme deleted" {
+		t.Errorf("Expected audit entry in audit sink, got %v", msgs)
+	}
+	if msgs := timeouts.messages(); strings.Join(msgs, ",") != "Request timeout" {
+		t.Errorf("Expected timeout entry in timeouts sink, got %v", msgs)
+	}
+	if msgs := file.messages(); strings.Join(msgs, ",") != "acme slow,other failed,Request timeout" {
+		t.Errorf("Expected other entries in file sink, got %v", msgs)
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(router.sinks) != 4 || !file.closed || !acme.closed {
+		t.Errorf("Expected each sink closed once, got %d sinks", len(router.sinks))
+	}
+}
+
+// tests the matchers
+func TestMatchers(t *testing.T) {
+	entry := LogEntry{Severity: WARN, Data: "Disk almost full", Fields: []Field{String("host", "db-1"), Int("free", 5), String("host", "db-2")}}
+	for name, test := range map[string]struct {
+		m    Matcher
+		want bool
+	}{
+		"level in range":     {MatchLevel(INFO, WARN), true},
+		"level out of range": {MatchLevel(ERROR, FATAL), false},
+		"field":              {MatchField("free", "5"), true},
+		"overridden field":   {MatchField("host", "db-1"), false},
+		"missing field":      {MatchField("user", ""), false},
+		"field regexp":       {MatchFieldRegexp("host", regexp.MustCompile(`^db-\d$`)), true},
+		"message":            {MatchMessage(regexp.MustCompile(`full$`)), true},
+		"all":                {MatchAll(MatchLevel(WARN, WARN), MatchField("host", "db-1")), false},
+		"any":                {MatchAny(MatchLevel(WARN, WARN), MatchField("host", "db-1")), true},
+		"not":                {MatchNot(MatchField("host", "db-2")), false},
+	} {
+		if got := test.m(entry); got != test.want {
+			t.Errorf("Expected %v for %s, got %v", test.want, name, got)
+		}
+	}
+}
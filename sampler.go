@@ -0,0 +1,194 @@
+package gologs
+
+import (
+	"hash/maphash"
+	"sync/atomic"
+	"time"
+)
+
+// Sampler decides which entries a logger writes. Sample is called for
+// every entry that passes the level check and must be safe for concurrent
+// use.
+type Sampler interface {
+	Sample(entry LogEntry) bool
+}
+
+// WithSampler makes the logger write only the entries that s keeps, so that
+// bursts of DEBUG and INFO entries don't overwhelm the sinks. Loggers
+// derived from the logger share the sampler.
+func WithSampler(s Sampler) Option {
+	return func(o *options) {
+		o.sampler = s
+	}
+}
+
+// SamplingConfig configures the sampler returned by NewSampler.
+type SamplingConfig struct {
+	// Tick is the period over which entries are counted. Defaults to a
+	// second.
+	Tick time.Duration
+	// First is the number of entries with the same level and message kept
+	// per tick. Of the entries after those, every Thereafter-th is kept,
+	// or none if Thereafter is zero. If both are zero, entries are not
+	// sampled by message.
+	First      int
+	Thereafter int
+	// Rates sets the fraction of the entries of a level that are kept,
+	// for example 0.1 for every tenth DEBUG entry. Levels not listed are
+	// kept.
+	Rates map[LogLevel]float64
+	// KeepLevel is the level from which entries are always kept. The zero
+	// value, DEBUG, means ERROR.
+	KeepLevel LogLevel
+	// Budget enables adaptive sampling of the entries below WARN. When more
+	// than Budget of them are logged in a tick, only every nth of them is
+	// kept in the next tick, so that about Budget are; sampling relaxes
+	// again when the load drops. Zero means no adaptive sampling.
+	Budget int
+}
+
+// samplerCounters is the number of counters entries are spread over by
+// level and message. Entries sharing a counter are counted together.
+const samplerCounters = 4096
+
+// MessageSampler is a Sampler that limits how often the same message is
+// logged per tick, and samples levels at fixed rates. Both are
+// deterministic: with a rate of 0.25, exactly every fourth entry is kept.
+type MessageSampler struct {
+	tick       int64
+	first      uint64
+	thereafter uint64
+	keep       LogLevel
+	seed       maphash.Seed
+	rates      map[LogLevel]*levelRate
+	counters   [samplerCounters]samplerCounter
+	dropped    atomic.Int64
+	// budget, load and every implement adaptive sampling: load counts the
+	// entries of the current tick, and every is how many of them share a
+	// kept entry.
+	budget uint64
+	load   samplerCounter
+	every  atomic.Uint64
+}
+
+// samplerCounter counts entries with the same key in the current tick.
+type samplerCounter struct {
+	resetAt atomic.Int64
+	n       atomic.Uint64
+}
+
+// levelRate keeps a fraction of the entries of a level.
+type levelRate struct {
+	rate float64
+	n    atomic.Uint64
+}
+
+// NewSampler returns a sampler configured by cfg.
+func NewSampler(cfg SamplingConfig) *MessageSampler {
+	s := &MessageSampler{
+		tick:       int64(cfg.Tick),
+		first:      uint64(max(cfg.First, 0)),
+		thereafter: uint64(max(cfg.Thereafter, 0)),
+		keep:       cfg.KeepLevel,
+		seed:       maphash.MakeSeed(),
+		rates:      make(map[LogLevel]*levelRate),
+		budget:     uint64(max(cfg.Budget, 0)),
+	}
+	s.every.Store(1)
+	if s.tick <= 0 {
+		s.tick = int64(time.Second)
+	}
+	if s.keep == DEBUG {
+		s.keep = ERROR
+	}
+	for level, rate := range cfg.Rates {
+		s.rates[level] = &levelRate{rate: rate}
+	}
+	return s
+}
+
+// Sample reports whether the entry is kept.
+func (s *MessageSampler) Sample(entry LogEntry) bool {
+	if entry.Severity >= s.keep {
+		return true
+	}
+	if !s.sampleMessage(entry) || !s.sampleLevel(entry.Severity) || !s.sampleLoad(entry) {
+		s.dropped.Add(1)
+		return false
+	}
+	return true
+}
+
+// Dropped returns the number of entries dropped so far.
+func (s *MessageSampler) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// sampleMessage counts the entry with others of the same level and message
+// and reports whether it is among those kept in the current tick. Entries
+// whose message is not a string are counted by level alone.
+func (s *MessageSampler) sampleMessage(entry LogEntry) bool {
+	if s.first == 0 && s.thereafter == 0 {
+		return true
+	}
+	msg, _ := entry.Data.(string)
+	h := maphash.String(s.seed, msg) ^ uint64(entry.Severity+2)*0x9e3779b97f4a7c15
+	n := s.counters[h%samplerCounters].inc(entry.Timestamp.UnixNano(), s.tick)
+	if n <= s.first {
+		return true
+	}
+	return s.thereafter > 0 && (n-s.first)%s.thereafter == 0
+}
+
+// inc counts an entry logged at now and returns the count in the current
+// tick.
+func (c *samplerCounter) inc(now, tick int64) uint64 {
+	resetAt := c.resetAt.Load()
+	if now < resetAt {
+		return c.n.Add(1)
+	}
+	if c.resetAt.CompareAndSwap(resetAt, now+tick) {
+		c.n.Store(1)
+		return 1
+	}
+	return c.n.Add(1)
+}
+
+// sampleLoad reports whether an entry below WARN is kept by adaptive
+// sampling. At the start of each tick, it compares the number of entries
+// of the last tick with the budget.
+func (s *MessageSampler) sampleLoad(entry LogEntry) bool {
+	if s.budget == 0 || entry.Severity >= WARN {
+		return true
+	}
+	now := entry.Timestamp.UnixNano()
+	resetAt := s.load.resetAt.Load()
+	if now >= resetAt && s.load.resetAt.CompareAndSwap(resetAt, now+s.tick) {
+		every := uint64(1)
+		// Without entries for a whole tick, the load has dropped.
+		if n := s.load.n.Swap(0); n > s.budget && now < resetAt+s.tick {
+			every = (n + s.budget - 1) / s.budget
+		}
+		s.every.Store(every)
+	}
+	n := s.load.n.Add(1)
+	return n%s.every.Load() == 0
+}
+
+// Factor returns the current factor of adaptive sampling: 1 if all entries
+// are kept, n if every nth entry below WARN is.
+func (s *MessageSampler) Factor() uint64 {
+	return s.every.Load()
+}
+
+// sampleLevel reports whether an entry is kept by the rate of its level.
+func (s *MessageSampler) sampleLevel(level LogLevel) bool {
+	r, ok := s.rates[level]
+	if !ok {
+		return true
+	}
+	n := r.n.Add(1)
+	// Keep the entries at which the running total of rate crosses an
+	// integer.
+	return uint64(float64(n)*r.rate) != uint64(float64(n-1)*r.rate)
+}
@@ -0,0 +1,101 @@
+package gologs
+
+import (
+	"testing"
+	"time"
+)
+
+// tests keeping the first entries of a message per tick and every nth after
+func TestSamplerMessage(t *testing.T) {
+	s := NewSampler(SamplingConfig{First: 2, Thereafter: 3})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	count := func(msg string, n int, at time.Time) int {
+		kept := 0
+		for i := 0; i < n; i++ {
+			if s.Sample(LogEntry{Severity: INFO, Timestamp: at, Data: msg}) {
+				kept++
+			}
+		}
+		return kept
+	}
+
+	if kept := count("hot", 10, now); kept != 4 {
+		t.Errorf("Expected entries 1, 2, 5 and 8 to be kept, got %d", kept)
+	}
+	if kept := count("other", 2, now); kept != 2 {
+		t.Errorf("Expected another message to be counted separately, got %d kept", kept)
+	}
+	if kept := count("hot", 2, now.Add(time.Second)); kept != 2 {
+		t.Errorf("Expected the count to start over in the next tick, got %d kept", kept)
+	}
+	if s.Dropped() != 6 {
+		t.Errorf("Expected 6 dropped entries, got %d", s.Dropped())
+	}
+}
+
+// tests the per-level rates and that errors are never sampled
+func TestSamplerRates(t *testing.T) {
+	s := NewSampler(SamplingConfig{Rates: map[LogLevel]float64{DEBUG: 0.25, ERROR: 0}})
+	kept := map[LogLevel]int{}
+	for i := 0; i < 8; i++ {
+		for _, level := range []LogLevel{DEBUG, INFO, ERROR} {
+			if s.Sample(LogEntry{Severity: level, Data: "entry"}) {
+				kept[level]++
+			}
+		}
+	}
+	if kept[DEBUG] != 2 || kept[INFO] != 8 || kept[ERROR] != 8 {
+		t.Errorf("Expected 2 DEBUG, 8 INFO and 8 ERROR entries, got %v", kept)
+	}
+}
+
+// tests sampling the entries of a logger
+func TestWithSampler(t *testing.T) {
+	sink := &memorySink{}
+	l := New(WithSinks(sink), WithSampler(NewSampler(SamplingConfig{First: 1})))
+	for i := 0; i < 5; i++ {
+		l.Info("retrying")
+		l.Error("failed")
+	}
+	if n := len(sink.messages()); n != 6 {
+		t.Errorf("Expected 1 INFO and 5 ERROR entries, got %d", n)
+	}
+}
+
+// tests that adaptive sampling tightens under load and relaxes again
+func TestSamplerBudget(t *testing.T) {
+	s := NewSampler(SamplingConfig{Budget: 10})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	count := func(level LogLevel, n int, at time.Time) int {
+		kept := 0
+		for i := 0; i < n; i++ {
+			if s.Sample(LogEntry{Severity: level, Timestamp: at, Data: "request"}) {
+				kept++
+			}
+		}
+		return kept
+	}
+
+	for _, tick := range []struct {
+		at         time.Duration
+		n, kept    int
+		wantFactor uint64
+	}{
+		{0, 100, 100, 1},
+		{time.Second, 100, 10, 10},
+		{2 * time.Second, 5, 0, 10},
+		{3 * time.Second, 5, 5, 1},
+		{4 * time.Second, 50, 50, 1},
+		{10 * time.Second, 5, 5, 1},
+	} {
+		if kept := count(INFO, tick.n, now.Add(tick.at)); kept != tick.kept {
+			t.Errorf("Expected %d of %d entries kept at %v, got %d", tick.kept, tick.n, tick.at, kept)
+		}
+		if s.Factor() != tick.wantFactor {
+			t.Errorf("Expected factor %d at %v, got %d", tick.wantFactor, tick.at, s.Factor())
+		}
+	}
+	if kept := count(WARN, 100, now.Add(11*time.Second)); kept != 100 {
+		t.Errorf("Expected WARN entries not to be sampled, got %d kept", kept)
+	}
+}
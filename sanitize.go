@@ -0,0 +1,155 @@
+package gologs
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// ControlChars selects how control characters in messages and string
+// field values are handled before entries are encoded.
+type ControlChars int
+
+const (
+	// KeepControlChars leaves control characters to the encoders: JSON
+	// escapes them, while console output writes messages as they are.
+	KeepControlChars ControlChars = iota
+	// EscapeControlChars replaces control characters other than tabs with
+	// visible escapes such as \n and \x1b, so that untrusted input can't
+	// forge log lines or send escape sequences to a terminal.
+	EscapeControlChars
+	// StripControlChars removes ANSI escape sequences and control
+	// characters other than tabs. Line feeds become spaces.
+	StripControlChars
+)
+
+// WithControlChars sets how control characters in messages and string
+// field values are handled, to protect against log injection and terminal
+// escape attacks when logging untrusted input. Defaults to
+// KeepControlChars. Field keys are not changed.
+func WithControlChars(mode ControlChars) Option {
+	return func(o *options) {
+		o.controlChars = mode
+	}
+}
+
+// sanitizeEntry returns the entry with the control characters of its
+// message and string field values handled by mode. The fields are copied
+// only if one of them changes.
+func sanitizeEntry(entry LogEntry, mode ControlChars) LogEntry {
+	if s, ok := entry.Data.(string); ok {
+		if clean := sanitize(s, mode); clean != s {
+			entry.Data = clean
+		}
+	}
+	copied := false
+	for i, f := range entry.Fields {
+		changed := false
+		switch f.Type {
+		case StringType, ErrorType:
+			clean := sanitize(f.str, mode)
+			changed, f.str = clean != f.str, clean
+		case AnyType:
+			if s, ok := f.Value.(string); ok {
+				if clean := sanitize(s, mode); clean != s {
+					changed, f.Value = true, clean
+				}
+			}
+		}
+		if !changed {
+			continue
+		}
+		if !copied {
+			entry.Fields = append([]Field(nil), entry.Fields...)
+			copied = true
+		}
+		entry.Fields[i] = f
+	}
+	return entry
+}
+
+// isControl reports whether r is a control character handled by
+// sanitize: C0 controls other than tab, DEL and C1 controls.
+func isControl(r rune) bool {
+	return r < 0x20 && r != '\t' || r >= 0x7f && r <= 0x9f
+}
+
+// sanitize returns s with its control characters handled by mode, or s
+// itself if it has none.
+func sanitize(s string, mode ControlChars) string {
+	if mode == KeepControlChars || strings.IndexFunc(s, isControl) < 0 {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if !isControl(r) {
+			b.WriteString(s[i : i+size])
+			i += size
+			continue
+		}
+		if mode == EscapeControlChars {
+			switch {
+			case r == '\n':
+				b.WriteString(`\n`)
+			case r == '\r':
+				b.WriteString(`\r`)
+			case r < utf8.RuneSelf:
+				b.WriteString(`\x`)
+				b.WriteByte(hexDigits[r>>4])
+				b.WriteByte(hexDigits[r&0xF])
+			default:
+				b.WriteString(`\u00`)
+				b.WriteByte(hexDigits[r>>4&0xF])
+				b.WriteByte(hexDigits[r&0xF])
+			}
+			i += size
+			continue
+		}
+		if r == '\n' {
+			b.WriteByte(' ')
+		}
+		i = skipEscapeSequence(s, i, r, size)
+	}
+	return b.String()
+}
+
+// skipEscapeSequence returns the index after the control character r of
+// size bytes at s[i], and after the rest of the ANSI escape sequence it
+// starts, if any.
+func skipEscapeSequence(s string, i int, r rune, size int) int {
+	i += size
+	switch {
+	case r == 0x1b && i < len(s) && s[i] == '[', r == 0x9b:
+		// A control sequence ends with a byte from @ to ~.
+		if r == 0x1b {
+			i++
+		}
+		for i < len(s) {
+			b := s[i]
+			i++
+			if b >= 0x40 && b <= 0x7e {
+				break
+			}
+		}
+	case r == 0x1b && i < len(s) && s[i] == ']', r == 0x9d:
+		// An operating system command ends with BEL or ESC \.
+		if r == 0x1b {
+			i++
+		}
+		for i < len(s) {
+			if s[i] == 0x07 {
+				return i + 1
+			}
+			if s[i] == 0x1b && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+			i++
+		}
+	case r == 0x1b && i < len(s) && s[i] >= 0x20 && s[i] < utf8.RuneSelf:
+		// Other escape sequences are a single character.
+		i++
+	}
+	return i
+}
@@ -0,0 +1,43 @@
+package gologs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// tests escaping and stripping control characters and escape sequences
+func TestSanitize(t *testing.T) {
+	for _, tt := range []struct {
+		in, escaped, stripped string
+	}{
+		{"plain\ttext", "plain\ttext", "plain\ttext"},
+		{"forged\n{\"level\":\"ERROR\"}", `forged\n{"level":"ERROR"}`, `forged {"level":"ERROR"}`},
+		{"\x1b[31mred\x1b[0m", `\x1b[31mred\x1b[0m`, "red"},
+		{"\x1b]0;title\x07done", `\x1b]0;title\x07done`, "done"},
+		{"\x1b]8;;http://x\x1b\\link", `\x1b]8;;http://x\x1b\link`, "link"},
+		{"c1 \u009b2Jclear", `c1 \u009b2Jclear`, "c1 clear"},
+		{"bell\x07 del\x7f", `bell\x07 del\x7f`, "bell del"},
+	} {
+		if got := sanitize(tt.in, EscapeControlChars); got != tt.escaped {
+			t.Errorf("Expected %q escaped, got %q", tt.escaped, got)
+		}
+		if got := sanitize(tt.in, StripControlChars); got != tt.stripped {
+			t.Errorf("Expected %q stripped, got %q", tt.stripped, got)
+		}
+	}
+}
+
+// tests that a logger sanitizes messages and string fields of entries
+func TestWithControlChars(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(INFO, &out, WithEncoder(ConsoleEncoder{}), WithCallerInfo(false), WithControlChars(StripControlChars))
+	fields := []Field{String("user", "\x1b[2Jroot"), Any("agent", "a\nb"), Int("n", 1)}
+	l.Info("login\r\nfailed", fields[0], fields[1], fields[2])
+	if got := out.String(); !strings.HasSuffix(got, "login failed user=root agent=\"a b\" n=1\n") {
+		t.Errorf("Expected sanitized output, got %q", got)
+	}
+	if fields[0].str != "\x1b[2Jroot" {
+		t.Errorf("Expected the fields of the caller to be unchanged, got %q", fields[0].str)
+	}
+}
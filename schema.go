@@ -0,0 +1,87 @@
+package gologs
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// SchemaVersion is the version of the layout of JSON entries, written as
+// "schema_version" so downstream parsers can tell layouts apart while they
+// migrate from one to the next.
+type SchemaVersion int
+
+const (
+	// SchemaV1 is the original layout: fields are written as top-level
+	// keys after the standard ones, and the source location and function
+	// of the caller as "source" and "caller".
+	SchemaV1 SchemaVersion = 1
+	// SchemaV2 nests the fields under "fields", so they can no longer
+	// clash with the standard keys, and the caller under "caller" as an
+	// object with "source" and "function":
+	//
+	//	{"schema_version":2,"level":"INFO","timestamp":"...",
+	//	 "caller":{"source":"main.go:12","function":"main"},
+	//	 "data":"Server started","fields":{"port":8080}}
+	SchemaV2 SchemaVersion = 2
+)
+
+// WithSchemaVersion adds a "schema_version" key to JSON entries, and writes
+// them in the layout of that version. Without it, entries have no version
+// and use the SchemaV1 layout. The JSON encoder and the sinks writing JSON
+// entries, such as Elasticsearch and Loki, follow the version; other
+// formats keep their own layout.
+func WithSchemaVersion(version SchemaVersion) Option {
+	return func(o *options) {
+		o.schemaVersion = version
+	}
+}
+
+// schemaVersionKey is the key of the schema version in JSON entries.
+const schemaVersionKey = "schema_version"
+
+// appendJSONSchemaVersion writes the schema version of the entry, if it has
+// one, as the first key of a JSON object.
+func appendJSONSchemaVersion(buf *bytes.Buffer, version SchemaVersion) {
+	if version == 0 {
+		return
+	}
+	buf.WriteString(`"` + schemaVersionKey + `":`)
+	buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(version), 10))
+	buf.WriteByte(',')
+}
+
+// appendJSONEntryV2 writes the caller, message and fields of the entry in
+// the SchemaV2 layout, after the level and timestamp, and closes the
+// object.
+func appendJSONEntryV2(buf *bytes.Buffer, e LogEntry, cfg EncoderConfig) error {
+	if e.Source != "" || e.Caller != "" {
+		buf.WriteByte(',')
+		appendJSONKey(buf, cfg.Keys.Caller, `"caller"`)
+		buf.WriteByte('{')
+		if e.Source != "" {
+			buf.WriteString(`"source":`)
+			buf.Write(appendJSONString(buf.AvailableBuffer(), e.Source))
+		}
+		if e.Caller != "" {
+			if e.Source != "" {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(`"function":`)
+			buf.Write(appendJSONString(buf.AvailableBuffer(), e.Caller))
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte(',')
+	appendJSONKey(buf, cfg.Keys.Message, `"data"`)
+	if err := appendJSONValue(buf, e.Data); err != nil {
+		return err
+	}
+	if group(e.Fields).len() > 0 {
+		buf.WriteString(`,"fields":`)
+		if err := appendJSONGroup(buf, e.Fields); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
@@ -0,0 +1,69 @@
+package gologs
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tests that unversioned entries keep the original layout
+func TestSchemaUnversioned(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(INFO, &out, WithCallerInfo(false))
+	l.Info("Server started", Int("port", 8080))
+	if strings.Contains(out.String(), "schema_version") {
+		t.Errorf("Expected no schema version, got %s", out.String())
+	}
+}
+
+// tests the schema version in the v1 layout
+func TestSchemaV1(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(INFO, &out, WithCallerInfo(false), WithSchemaVersion(SchemaV1))
+	l.Info("Server started", Int("port", 8080), String("schema_version", "x"))
+	output := out.String()
+	if !strings.HasPrefix(output, `{"schema_version":1,"level":"INFO",`) {
+		t.Errorf("Expected the schema version first, got %s", output)
+	}
+	if !strings.HasSuffix(strings.TrimSpace(output), `"data":"Server started","port":8080,"fields.schema_version":"x"}`) {
+		t.Errorf("Expected top-level fields, got %s", output)
+	}
+}
+
+// tests the v2 layout
+func TestSchemaV2(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(INFO, &out, WithSchemaVersion(SchemaV2)).With("level", "shadowed")
+	l.Info("Server started", Int("port", 8080))
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected valid JSON, got %v: %s", err, out.String())
+	}
+	if entry["schema_version"] != 2.0 || entry["level"] != "INFO" || entry["data"] != "Server started" {
+		t.Errorf("Expected the standard keys, got %s", out.String())
+	}
+	caller, _ := entry["caller"].(map[string]interface{})
+	if !strings.Contains(caller["source"].(string), "schema_test.go") || caller["function"] != "TestSchemaV2" {
+		t.Errorf("Expected the caller as an object, got %s", out.String())
+	}
+	fields, _ := entry["fields"].(map[string]interface{})
+	if fields["level"] != "shadowed" || fields["port"] != 8080.0 || len(entry) != 6 {
+		t.Errorf("Expected the fields nested, got %s", out.String())
+	}
+}
+
+// tests that LogEntry.MarshalJSON follows the schema version
+func TestSchemaV2MarshalJSON(t *testing.T) {
+	entry := LogEntry{Level: "WARN", Timestamp: time.Unix(0, 0).UTC(), Data: "low disk", SchemaVersion: SchemaV2}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := `{"schema_version":2,"level":"WARN","timestamp":"1970-01-01T00:00:00Z","data":"low disk"}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+}
@@ -0,0 +1,332 @@
+package gologs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sentryPackage is the prefix of the functions of this package, whose
+// frames are left out of stack traces.
+const sentryPackage = "github.com/phasi/go-logs."
+
+// SentryConfig configures a SentrySink.
+type SentryConfig struct {
+	// MinLevel is the lowest level sent. The zero value, DEBUG, means
+	// ERROR.
+	MinLevel LogLevel
+	// Release is the version of the application, such as "shop@1.4.2".
+	Release string
+	// Environment is the deployment environment, such as "production".
+	Environment string
+	// ServerName is the name of the host. Defaults to the host name.
+	ServerName string
+}
+
+// SentrySink is a Sink that sends entries at or above a minimum level
+// (ERROR by default) to Sentry as events. The stack trace of the logging
+// call is captured by the logger when the entry is built, so it is kept in
+// async mode and for entries written out later by the flight recorder or
+// the dedup window, also when the sink is wrapped by LevelSink, RouterSink,
+// RetrySink or BreakerSink. Entries replayed from the disk log of a
+// SpillSink get the stack of the replay instead. An Err field is sent as
+// an exception, with the errors it wraps. Fields of type string, int, bool
+// and duration are sent as tags, all others as extra data.
+//
+// Events are sent one at a time from a background goroutine; see the
+// HTTPOption functions for retries.
+type SentrySink struct {
+	url    string
+	dsn    string
+	cfg    SentryConfig
+	config httpConfig
+	batch  *batcher
+}
+
+// NewSentrySink returns a sink sending events to the project of the given
+// DSN, such as "https://key@o1.ingest.sentry.io/42".
+func NewSentrySink(dsn string, cfg SentryConfig, opts ...HTTPOption) (*SentrySink, error) {
+	u, err := url.Parse(dsn)
+	if err != nil || u.Host == "" || u.User == nil {
+		return nil, fmt.Errorf("sentry: invalid DSN %q", dsn)
+	}
+	i := strings.LastIndex(u.Path, "/")
+	project := u.Path[i+1:]
+	if project == "" {
+		return nil, fmt.Errorf("sentry: DSN %q has no project ID", dsn)
+	}
+	if cfg.MinLevel == DEBUG {
+		cfg.MinLevel = ERROR
+	}
+	if cfg.ServerName == "" {
+		cfg.ServerName = hostname()
+	}
+	auth := "Sentry sentry_version=7, sentry_client=gologs/1.0, sentry_key=" + u.User.Username()
+	s := &SentrySink{
+		url:    u.Scheme + "://" + u.Host + u.Path[:i] + "/api/" + project + "/envelope/",
+		dsn:    dsn,
+		cfg:    cfg,
+		config: newHTTPConfig(append([]HTTPOption{WithHeader("X-Sentry-Auth", auth)}, opts...)),
+	}
+	s.batch = s.config.startBatcher(s.send)
+	return s, nil
+}
+
+// Write queues an event for the entry if it is at or above the minimum
+// level, as the Data of an entry. The stack of the logging call is captured
+// by the logger; entries written to the sink directly get the stack of the
+// call to Write.
+func (s *SentrySink) Write(entry LogEntry) error {
+	if entry.Severity < s.cfg.MinLevel {
+		return nil
+	}
+	pcs := entry.pcs
+	if pcs == nil {
+		pcs = callers(1)
+	}
+	event, err := s.event(entry, sentryStack(pcs))
+	if err != nil {
+		return err
+	}
+	return s.batch.add(LogEntry{Data: event})
+}
+
+func (s *SentrySink) stackLevel() LogLevel {
+	return s.cfg.MinLevel
+}
+
+// Flush sends all queued events.
+func (s *SentrySink) Flush() error {
+	return s.batch.flush()
+}
+
+// Close sends all queued events and stops the background goroutine.
+func (s *SentrySink) Close() error {
+	return s.batch.close()
+}
+
+// Health returns the results of the sent batches and the number of entries
+// waiting to be sent.
+func (s *SentrySink) Health() SinkHealth {
+	return s.batch.health()
+}
+
+func (s *SentrySink) batches() *batcher {
+	return s.batch
+}
+
+// send sends one envelope per event.
+func (s *SentrySink) send(entries []LogEntry) error {
+	var errs []error
+	for _, e := range entries {
+		event := e.Data.(*sentryEvent)
+		body, err := s.envelope(event)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := s.config.post(s.url, "application/x-sentry-envelope", body); err != nil {
+			errs = append(errs, fmt.Errorf("sentry: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// envelope returns the envelope holding an event.
+func (s *SentrySink) envelope(event *sentryEvent) ([]byte, error) {
+	item, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	header, err := json.Marshal(map[string]string{
+		"event_id": event.EventID,
+		"sent_at":  time.Now().UTC().Format(time.RFC3339Nano),
+		"dsn":      s.dsn,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.Write(header)
+	buf.WriteString("\n{\"type\":\"event\",\"length\":" + strconv.Itoa(len(item)) + "}\n")
+	buf.Write(item)
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// sentryLevel maps a LogLevel onto a Sentry level.
+func sentryLevel(level LogLevel) string {
+	switch {
+	case level >= PANIC:
+		return "fatal"
+	case level == ERROR:
+		return "error"
+	case level == WARN:
+		return "warning"
+	case level == INFO:
+		return "info"
+	default:
+		return "debug"
+	}
+}
+
+type sentryEvent struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   string                 `json:"timestamp"`
+	Platform    string                 `json:"platform"`
+	Level       string                 `json:"level"`
+	Message     *sentryMessage         `json:"message,omitempty"`
+	Release     string                 `json:"release,omitempty"`
+	Environment string                 `json:"environment,omitempty"`
+	ServerName  string                 `json:"server_name,omitempty"`
+	Tags        map[string]string      `json:"tags,omitempty"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+	Exception   *sentryValues          `json:"exception,omitempty"`
+	Threads     *sentryValues          `json:"threads,omitempty"`
+}
+
+type sentryMessage struct {
+	Formatted string `json:"formatted"`
+}
+
+type sentryValues struct {
+	Values []sentryValue `json:"values"`
+}
+
+// sentryValue is an exception or a thread.
+type sentryValue struct {
+	Type       string            `json:"type,omitempty"`
+	Value      string            `json:"value,omitempty"`
+	Current    bool              `json:"current,omitempty"`
+	Stacktrace *sentryStacktrace `json:"stacktrace,omitempty"`
+}
+
+type sentryStacktrace struct {
+	Frames []sentryFrame `json:"frames"`
+}
+
+type sentryFrame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+// event returns the event of an entry.
+func (s *SentrySink) event(e LogEntry, stack *sentryStacktrace) (*sentryEvent, error) {
+	var msg bytes.Buffer
+	if err := appendMessageText(&msg, e.Data); err != nil {
+		return nil, err
+	}
+	event := &sentryEvent{
+		EventID:     strings.ReplaceAll(newUUID(), "-", ""),
+		Timestamp:   e.Timestamp.UTC().Format(time.RFC3339Nano),
+		Platform:    "go",
+		Level:       sentryLevel(e.Severity),
+		Message:     &sentryMessage{msg.String()},
+		Release:     s.cfg.Release,
+		Environment: s.cfg.Environment,
+		ServerName:  s.cfg.ServerName,
+		Tags:        make(map[string]string),
+		Extra:       make(map[string]interface{}),
+	}
+	if e.Source != "" {
+		event.Extra["source"] = e.Source
+	}
+	if e.Caller != "" {
+		event.Extra["caller"] = e.Caller
+	}
+
+	var err error
+	for _, f := range e.Fields {
+		switch f.Type {
+		case skipType:
+			continue
+		case StringType, IntType, BoolType, DurationType:
+			text, _ := fieldText(f)
+			if len(f.Key) <= 32 && len(text) <= 200 {
+				event.Tags[f.Key] = text
+				continue
+			}
+		case ErrorType:
+			if v, ok := f.Value.(error); ok {
+				err = v
+			}
+		}
+		event.Extra[f.Key] = fieldValue(f)
+	}
+
+	if err == nil {
+		event.Threads = &sentryValues{[]sentryValue{{Current: true, Stacktrace: stack}}}
+		return event, nil
+	}
+	// Exceptions are listed from the innermost wrapped error to err itself,
+	// which gets the stack trace.
+	var chain []sentryValue
+	for ; err != nil; err = errors.Unwrap(err) {
+		chain = append(chain, sentryValue{Type: fmt.Sprintf("%T", err), Value: err.Error()})
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	chain[len(chain)-1].Stacktrace = stack
+	event.Exception = &sentryValues{chain}
+	return event, nil
+}
+
+// sentryStack returns the stack of pcs, oldest frame first, without the
+// frames of this package at the top.
+func sentryStack(pcs []uintptr) *sentryStacktrace {
+	frames := runtime.CallersFrames(pcs)
+	var stack []sentryFrame
+	logging := true
+	for {
+		frame, more := frames.Next()
+		if logging && strings.HasPrefix(frame.Function, sentryPackage) && !strings.HasSuffix(frame.File, "_test.go") {
+			if !more {
+				break
+			}
+			continue
+		}
+		logging = false
+		if frame.Function != "runtime.goexit" {
+			stack = append(stack, newSentryFrame(frame))
+		}
+		if !more {
+			break
+		}
+	}
+	for i, j := 0, len(stack)-1; i < j; i, j = i+1, j-1 {
+		stack[i], stack[j] = stack[j], stack[i]
+	}
+	return &sentryStacktrace{stack}
+}
+
+// newSentryFrame splits the function name of a frame into its package and
+// function, as in "github.com/acme/shop/api" and "(*Server).Handle".
+// Functions outside the standard library are marked as application code.
+func newSentryFrame(frame runtime.Frame) sentryFrame {
+	module, function := "", frame.Function
+	if i := strings.LastIndex(function, "/"); i >= 0 {
+		if j := strings.Index(function[i:], "."); j >= 0 {
+			module, function = function[:i+j], function[i+j+1:]
+		}
+	} else if j := strings.Index(function, "."); j >= 0 {
+		module, function = function[:j], function[j+1:]
+	}
+	first, _, _ := strings.Cut(module, "/")
+	return sentryFrame{
+		Function: function,
+		Module:   module,
+		AbsPath:  frame.File,
+		Lineno:   frame.Line,
+		InApp:    strings.Contains(first, ".") || module == "main",
+	}
+}
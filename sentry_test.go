@@ -0,0 +1,142 @@
+package gologs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// tests that an ERROR entry with an error is sent as an exception event
+func TestSentrySink(t *testing.T) {
+	type request struct {
+		path, auth string
+		body       []byte
+	}
+	requests := make(chan request, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{r.URL.Path, r.Header.Get("X-Sentry-Auth"), body}
+	}))
+	defer server.Close()
+
+	dsn := strings.Replace(server.URL, "http://", "http://pubkey@", 1) + "/42"
+	sink, err := NewSentrySink(dsn, SentryConfig{Release: "shop@1.4.2", Environment: "production"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	l := New(WithSinks(sink))
+	l.Warn("Slow query")
+	cause := errors.New("connection refused")
+	l.Error("Payment failed", String("order", "A1"), Any("items", []int{1, 2}), Err(fmt.Errorf("charge: %w", cause)))
+	if err := l.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(requests) != 1 {
+		t.Fatalf("Expected 1 request, got %d", len(requests))
+	}
+	req := <-requests
+	if req.path != "/api/42/envelope/" {
+		t.Errorf("Expected envelope endpoint, got %s", req.path)
+	}
+	if !strings.Contains(req.auth, "sentry_key=pubkey") {
+		t.Errorf("Expected sentry_key in auth header, got %s", req.auth)
+	}
+	lines := bytes.Split(bytes.TrimSpace(req.body), []byte("\n"))
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 envelope lines, got %d", len(lines))
+	}
+	var event sentryEvent
+	if err := json.Unmarshal(lines[2], &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Level != "error" || event.Message.Formatted != "Payment failed" || event.Release != "shop@1.4.2" || event.Environment != "production" {
+		t.Errorf("Expected event attributes, got %+v", event)
+	}
+	if event.Tags["order"] != "A1" {
+		t.Errorf("Expected order tag, got %v", event.Tags)
+	}
+	if event.Extra["items"] == nil {
+		t.Errorf("Expected items in extra, got %v", event.Extra)
+	}
+
+	values := event.Exception.Values
+	if len(values) != 2 {
+		t.Fatalf("Expected 2 exceptions, got %d", len(values))
+	}
+	if values[0].Value != "connection refused" || values[1].Value != "charge: connection refused" || values[1].Type != "*fmt.wrapError" {
+		t.Errorf("Expected error chain, got %+v", values)
+	}
+	if values[0].Stacktrace != nil {
+		t.Errorf("Expected stack trace on the outermost error only, got %+v", values[0].Stacktrace)
+	}
+	frames := values[1].Stacktrace.Frames
+	top := frames[len(frames)-1]
+	if top.Function != "TestSentrySink" || top.Module != "github.com/phasi/go-logs" || !top.InApp {
+		t.Errorf("Expected logging call as top frame, got %+v", top)
+	}
+}
+
+// tests that the stack of the logging call is sent in async mode, where
+// the sink writes on another goroutine
+func TestSentrySinkAsync(t *testing.T) {
+	bodies := make(chan []byte, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+	}))
+	defer server.Close()
+
+	sink, err := NewSentrySink(strings.Replace(server.URL, "http://", "http://pubkey@", 1)+"/42", SentryConfig{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	l := New(WithSinks(NewLevelSink(sink, DEBUG)), WithAsync(10))
+	l.Error("Payment failed")
+	if err := l.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(<-bodies), []byte("\n"))
+	var event sentryEvent
+	if err := json.Unmarshal(lines[2], &event); err != nil {
+		t.Fatal(err)
+	}
+	frames := event.Threads.Values[0].Stacktrace.Frames
+	if len(frames) == 0 || frames[len(frames)-1].Function != "TestSentrySinkAsync" {
+		t.Errorf("Expected logging call as top frame, got %+v", frames)
+	}
+}
+
+// tests that frame function names are split into package and function
+func TestNewSentryFrame(t *testing.T) {
+	for function, want := range map[string][2]string{
+		"github.com/acme/shop/api.(*Server).Handle": {"github.com/acme/shop/api", "(*Server).Handle"},
+		"net/http.HandlerFunc.ServeHTTP":            {"net/http", "HandlerFunc.ServeHTTP"},
+		"main.main":                                 {"main", "main"},
+	} {
+		frame := newSentryFrame(runtime.Frame{Function: function})
+		if frame.Module != want[0] || frame.Function != want[1] {
+			t.Errorf("Expected %v for %s, got %s %s", want, function, frame.Module, frame.Function)
+		}
+		if inApp := want[0] != "net/http"; frame.InApp != inApp {
+			t.Errorf("Expected in_app %v for %s, got %v", inApp, function, frame.InApp)
+		}
+	}
+}
+
+// tests that invalid DSNs are rejected
+func TestNewSentrySinkInvalidDSN(t *testing.T) {
+	for _, dsn := range []string{"", "https://o1.ingest.sentry.io/42", "https://key@o1.ingest.sentry.io/"} {
+		if _, err := NewSentrySink(dsn, SentryConfig{}); err == nil {
+			t.Errorf("Expected error for %q, got %v", dsn, err)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package gologs
+
+// WithSequence adds a "seq" field to every entry, numbering the entries of
+// the logger from 1, so that consumers can detect entries lost or
+// reordered on the way, for example by a full async buffer or a UDP
+// transport. Loggers derived from the logger share the counter. Entries
+// are numbered in the order they are passed to the sinks or queued, after
+// sampling and rate limiting; entries logged concurrently in async mode
+// may be queued in a different order than numbered.
+func WithSequence() Option {
+	return func(o *options) {
+		o.sequence = true
+	}
+}
+
+// withSeq returns the entry with the next sequence number added.
+func (l *Logger) withSeq(entry LogEntry) LogEntry {
+	fields := make([]Field, 0, len(entry.Fields)+1)
+	fields = append(fields, entry.Fields...)
+	entry.Fields = append(fields, Field{Key: "seq", Type: IntType, integer: int64(l.seq.Add(1))})
+	return entry
+}
@@ -0,0 +1,23 @@
+package gologs
+
+import "testing"
+
+// tests numbering the entries of a logger and its children
+func TestSequence(t *testing.T) {
+	sink := &memorySink{}
+	l := New(WithSinks(sink), WithSequence(), WithFields(String("service", "shop")))
+	l.Info("first")
+	l.Debug("filtered")
+	l.Child(String("component", "db")).Info("second")
+	l.Info("third")
+
+	for i, e := range sink.entries {
+		last := e.Fields[len(e.Fields)-1]
+		if last.Key != "seq" || last.integer != int64(i+1) {
+			t.Errorf("Expected seq %d for %v, got %+v", i+1, e.Data, last)
+		}
+	}
+	if len(sink.entries[0].Fields) != 2 {
+		t.Errorf("Expected the fields of the logger to be kept, got %+v", sink.entries[0].Fields)
+	}
+}
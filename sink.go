@@ -0,0 +1,316 @@
+package gologs
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// Sink receives log entries. A Logger writes every entry that passes its
+// level check to all of its sinks. Sinks must not modify the entry, as it is
+// shared between them.
+type Sink interface {
+	// Write handles a single entry.
+	Write(entry LogEntry) error
+	// Flush writes out any buffered entries.
+	Flush() error
+	// Close flushes the sink and releases its resources.
+	Close() error
+}
+
+// WriterSink is a Sink that encodes entries and writes them to an io.Writer.
+// Each entry is written with a single Write call, and writes are serialized.
+type WriterSink struct {
+	mu      sync.Mutex
+	writer  io.Writer
+	encoder Encoder
+	// closer is set when the sink owns the writer.
+	closer io.Closer
+	// batchBytes is the size at which batched entries are written, in
+	// async mode with WithAsyncBatch. Zero writes every entry right away.
+	batchBytes int
+	batch      bytes.Buffer
+}
+
+// NewWriterSink returns a sink writing to w with the given encoder. A nil
+// encoder means JSONEncoder. Closing the sink does not close w.
+func NewWriterSink(w io.Writer, encoder Encoder) *WriterSink {
+	if encoder == nil {
+		encoder = JSONEncoder{}
+	}
+	return &WriterSink{writer: w, encoder: encoder}
+}
+
+// Write encodes the entry and writes it to the writer.
+func (s *WriterSink) Write(entry LogEntry) error {
+	if s.batchBytes > 0 {
+		return s.writeBatched(entry)
+	}
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := s.encoder.Encode(entry, buf); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.writer.Write(buf.Bytes())
+	return err
+}
+
+// writeBatched adds the encoded entry to the batch, and writes the batch
+// once it is large enough.
+func (s *WriterSink) writeBatched(entry LogEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := s.batch.Len()
+	if err := s.encoder.Encode(entry, &s.batch); err != nil {
+		s.batch.Truncate(n)
+		return err
+	}
+	if s.batch.Len() < s.batchBytes {
+		return nil
+	}
+	return s.flushBatch()
+}
+
+// flushBatch writes the batched entries. s.mu must be held.
+func (s *WriterSink) flushBatch() error {
+	if s.batch.Len() == 0 {
+		return nil
+	}
+	_, err := s.writer.Write(s.batch.Bytes())
+	s.batch.Reset()
+	return err
+}
+
+// Flush writes any batched entries and flushes the writer if it has a Flush
+// method, such as *bufio.Writer.
+func (s *WriterSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.flush()
+}
+
+// flush writes the batched entries and flushes the writer. s.mu must be
+// held.
+func (s *WriterSink) flush() error {
+	err := s.flushBatch()
+	if f, ok := s.writer.(interface{ Flush() error }); ok {
+		err = errors.Join(err, f.Flush())
+	}
+	return err
+}
+
+// Close flushes the writer. The writer is only closed if the sink was
+// created by the logger itself, for example for a file from a Config.
+func (s *WriterSink) Close() error {
+	err := s.Flush()
+	if s.closer != nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		err = errors.Join(err, s.closer.Close())
+	}
+	return err
+}
+
+// Reopen reopens the writer by name if it is a file opened by the logger,
+// for example from a Config. Other writers are left alone.
+func (s *WriterSink) Reopen() error {
+	if rf, ok := s.closer.(*rotatingFile); ok {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if err := s.flush(); err != nil {
+			return err
+		}
+		return rf.sink.Reopen()
+	}
+	f, ok := s.closer.(*os.File)
+	if !ok {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := errors.Join(s.flush(), f.Close()); err != nil {
+		return err
+	}
+	reopened, err := os.OpenFile(f.Name(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if bw, ok := s.writer.(*bufio.Writer); ok {
+		bw.Reset(reopened)
+	} else {
+		s.writer = reopened
+	}
+	s.closer = reopened
+	return nil
+}
+
+// output is where a Logger writes its entries. It is replaced as a whole when
+// the configuration is reloaded.
+type output struct {
+	// opts are the options the output was built from.
+	opts options
+	// writers are the sinks for the logger's outputs. The sinks added with
+	// WithSinks are in opts.sinks, those from a Config in opts.configSinks.
+	writers []*WriterSink
+	// stats track the results of each sink, in the order of each.
+	stats []*sinkStats
+	// stackLevel is the lowest level of entries whose stack a sink sends,
+	// or OFF if none does.
+	stackLevel LogLevel
+	// errs reports the failed writes.
+	errs errorReporter
+}
+
+// stackSink is implemented by sinks that send the stack of the logging
+// call with entries at or above stackLevel, such as SentrySink. The logger
+// captures the stack when the entry is built, as sinks may write it later
+// on another goroutine, in async mode or when the flight recorder is
+// written out.
+type stackSink interface {
+	stackLevel() LogLevel
+}
+
+// sinkStackLevel returns the stack level of s, or OFF if it sends no
+// stacks.
+func sinkStackLevel(s Sink) LogLevel {
+	if ss, ok := s.(stackSink); ok {
+		return ss.stackLevel()
+	}
+	return OFF
+}
+
+// each calls fn for every sink of the output.
+func (o *output) each(fn func(Sink) error) error {
+	var errs []error
+	for _, s := range o.writers {
+		if err := fn(s); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, s := range o.opts.sinks {
+		if err := fn(s); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, s := range o.opts.configSinks {
+		if err := fn(s); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Flush flushes all sinks of the logger. In async mode, it first waits
+// until the queued entries have been written.
+func (l *Logger) Flush() error {
+	if l.async != nil {
+		l.async.wait()
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.out.Load().each(Sink.Flush)
+}
+
+// Close flushes and closes all sinks of the logger. Outputs passed in by the
+// caller, such as os.Stdout, are not closed. The logger, and loggers derived
+// from it, must not be used after Close. Queued entries in async mode, and
+// the last reports of repeated and suppressed entries, are written first.
+func (l *Logger) Close() error {
+	if l.async != nil {
+		l.async.close()
+	}
+	for _, loop := range l.loops {
+		loop.close()
+	}
+	if l.dedup != nil {
+		l.dedup.report()
+	}
+	if l.limiter != nil {
+		l.reportSuppressed()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.out.Load().each(Sink.Close)
+}
+
+// LevelSink passes entries at or above its own minimum level to another sink.
+// It lets each sink of a logger have its own threshold, for example DEBUG to
+// a file and WARN and above to stderr. The logger's level is checked first,
+// so it must be at or below the lowest sink level.
+type LevelSink struct {
+	sink  Sink
+	level atomic.Int32
+	stats sinkStats
+}
+
+// NewLevelSink returns a sink that passes entries at level and above to sink.
+func NewLevelSink(sink Sink, level LogLevel) *LevelSink {
+	s := &LevelSink{sink: sink}
+	s.level.Store(int32(level))
+	return s
+}
+
+// SetLevel changes the minimum level of the sink.
+func (s *LevelSink) SetLevel(level LogLevel) {
+	s.level.Store(int32(level))
+}
+
+// GetLevel returns the minimum level of the sink.
+func (s *LevelSink) GetLevel() LogLevel {
+	return LogLevel(s.level.Load())
+}
+
+// Write passes the entry on if it is at or above the sink's level.
+func (s *LevelSink) Write(entry LogEntry) error {
+	if entry.Severity < s.GetLevel() {
+		return nil
+	}
+	err := s.sink.Write(entry)
+	s.stats.record(err, entry.Timestamp)
+	return err
+}
+
+// Flush flushes the wrapped sink.
+func (s *LevelSink) Flush() error {
+	return s.sink.Flush()
+}
+
+// Close closes the wrapped sink.
+func (s *LevelSink) Close() error {
+	return s.sink.Close()
+}
+
+// Reopen reopens the wrapped sink if it implements Reopener.
+func (s *LevelSink) Reopen() error {
+	if r, ok := s.sink.(Reopener); ok {
+		return r.Reopen()
+	}
+	return nil
+}
+
+// Health returns the status of the wrapped sink if it implements
+// HealthReporter, or else the results of the entries passed on, so that
+// filtered entries don't count as delivered.
+func (s *LevelSink) Health() SinkHealth {
+	if r, ok := s.sink.(HealthReporter); ok {
+		return r.Health()
+	}
+	return s.stats.health()
+}
+
+// Ping pings the wrapped sink, or returns ErrPingUnsupported if it doesn't
+// implement Pinger.
+func (s *LevelSink) Ping() error {
+	return pingSink(s.sink)
+}
+
+func (s *LevelSink) stackLevel() LogLevel {
+	return sinkStackLevel(s.sink)
+}
@@ -0,0 +1,179 @@
+package gologs
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memorySink is a Sink that keeps entries in memory, used in tests.
+type memorySink struct {
+	mu      sync.Mutex
+	entries []LogEntry
+	flushed int
+	closed  bool
+}
+
+func (s *memorySink) Write(entry LogEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func (s *memorySink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushed++
+	return nil
+}
+
+func (s *memorySink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func (s *memorySink) messages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	messages := make([]string, len(s.entries))
+	for i, e := range s.entries {
+		messages[i], _ = e.Data.(string)
+	}
+	return messages
+}
+
+// tests writing one entry to several sinks
+func TestMultipleSinks(t *testing.T) {
+	var out bytes.Buffer
+	var logfmt bytes.Buffer
+	memory := &memorySink{}
+	l := NewLogger(INFO, &out, WithSinks(NewWriterSink(&logfmt, LogfmtEncoder{}), memory))
+
+	l.Child(String("component", "db")).Info("Connected")
+	if !strings.Contains(out.String(), `"data":"Connected"`) {
+		t.Errorf("Expected JSON entry in output, got %v", out.String())
+	}
+	if !strings.Contains(logfmt.String(), `msg=Connected`) {
+		t.Errorf("Expected logfmt entry in sink, got %v", logfmt.String())
+	}
+	if msgs := memory.messages(); len(msgs) != 1 || msgs[0] != "Connected" {
+		t.Errorf("Expected entry in memory sink, got %v", msgs)
+	}
+
+	if err := l.Flush(); err != nil || memory.flushed != 1 {
+		t.Errorf("Expected sinks to be flushed, got %v", err)
+	}
+	if err := l.Close(); err != nil || !memory.closed {
+		t.Errorf("Expected sinks to be closed, got %v", err)
+	}
+}
+
+// tests a logger with sinks only
+func TestSinksWithoutOutput(t *testing.T) {
+	memory := &memorySink{}
+	l := New(WithSinks(memory))
+	if len(l.out.Load().writers) != 0 {
+		t.Errorf("Expected no default stdout output when sinks are given")
+	}
+	l.Info("Only in memory")
+	if msgs := memory.messages(); len(msgs) != 1 {
+		t.Errorf("Expected entry in memory sink, got %v", msgs)
+	}
+}
+
+// tests flushing a buffered writer and closing files opened by the logger
+func TestWriterSinkFlushAndClose(t *testing.T) {
+	var out bytes.Buffer
+	buffered := bufio.NewWriter(&out)
+	l := NewLogger(INFO, buffered)
+	l.Info("Buffered")
+	if out.Len() != 0 {
+		t.Errorf("Expected entry to be buffered, got %v", out.String())
+	}
+	if err := l.Flush(); err != nil || !strings.Contains(out.String(), "Buffered") {
+		t.Errorf("Expected entry after Flush, got %v (%v)", out.String(), err)
+	}
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	fl, err := NewLoggerFromConfig(writeConfig(t, `{"outputs": ["stdout", "`+path+`"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := fl.out.Load().writers[1].closer.(*os.File)
+	if err := fl.Close(); err != nil {
+		t.Errorf("Expected no error on Close, got %v", err)
+	}
+	if _, err := file.Write([]byte("x")); err == nil {
+		t.Error("Expected file opened by the logger to be closed")
+	}
+	if fl.out.Load().writers[0].closer != nil {
+		t.Error("Expected stdout not to be owned by the logger")
+	}
+}
+
+// writeConfig writes a JSON config to a temporary file and returns its path.
+func writeConfig(t *testing.T, config string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "logging.json")
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// tests per-sink minimum levels
+func TestLevelSink(t *testing.T) {
+	debugSink := &memorySink{}
+	warnSink := &memorySink{}
+	errorSink := NewLevelSink(&memorySink{}, ERROR)
+	l := New(WithLevel(DEBUG), WithSinks(
+		NewLevelSink(debugSink, DEBUG),
+		NewLevelSink(warnSink, WARN),
+		errorSink,
+	))
+
+	l.Debug("debug")
+	l.Info("info")
+	l.Warn("warn")
+	l.Error("error")
+
+	if msgs := debugSink.messages(); len(msgs) != 4 {
+		t.Errorf("Expected 4 entries in DEBUG sink, got %v", msgs)
+	}
+	if msgs := warnSink.messages(); strings.Join(msgs, ",") != "warn,error" {
+		t.Errorf("Expected WARN and ERROR entries in WARN sink, got %v", msgs)
+	}
+	if msgs := errorSink.sink.(*memorySink).messages(); strings.Join(msgs, ",") != "error" {
+		t.Errorf("Expected ERROR entry in ERROR sink, got %v", msgs)
+	}
+
+	errorSink.SetLevel(INFO)
+	l.Info("info again")
+	if msgs := errorSink.sink.(*memorySink).messages(); len(msgs) != 2 || errorSink.GetLevel() != INFO {
+		t.Errorf("Expected INFO entry after lowering level, got %v", msgs)
+	}
+}
+
+// tests that writing an entry reuses pooled encode buffers
+func TestWriterSinkAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations differ under the race detector")
+	}
+	sink := NewWriterSink(io.Discard, JSONEncoder{})
+	entry := LogEntry{Level: "INFO", Timestamp: time.Now(), Data: "request served", Fields: []Field{Int("status", 200)}}
+	allocs := testing.AllocsPerRun(100, func() {
+		sink.Write(entry)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocations, got %v", allocs)
+	}
+}
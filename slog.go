@@ -0,0 +1,226 @@
+package gologs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+)
+
+// slogHandler is a slog.Handler that writes records through a Logger.
+type slogHandler struct {
+	logger *Logger
+	// groups holds the names of the groups opened with WithGroup, outermost
+	// first. attrs[i] holds the attributes added while groups[:i] were open.
+	groups []string
+	attrs  [][]slog.Attr
+}
+
+// SlogHandler returns a slog.Handler that writes records through l, so the
+// log/slog front-end can be used with gologs output:
+//
+//	slog.SetDefault(slog.New(gologs.SlogHandler(logger)))
+//
+// slog levels are mapped onto the closest LogLevel, attributes become fields
+// and groups become nested objects.
+func SlogHandler(l *Logger) slog.Handler {
+	return &slogHandler{logger: l, attrs: make([][]slog.Attr, 1)}
+}
+
+// levelFromSlog maps a slog level onto a LogLevel.
+func levelFromSlog(level slog.Level) LogLevel {
+	switch {
+	case level >= slog.LevelError:
+		return ERROR
+	case level >= slog.LevelWarn:
+		return WARN
+	case level >= slog.LevelInfo:
+		return INFO
+	case level >= slog.LevelDebug:
+		return DEBUG
+	default:
+		return TRACE
+	}
+}
+
+// Enabled reports whether the logger handles records at the given level,
+// including records below its level kept by the flight recorder.
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.accepts(levelFromSlog(level))
+}
+
+// Handle writes the record through the logger.
+func (h *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	level := levelFromSlog(r.Level)
+	if !h.logger.accepts(level) {
+		return nil
+	}
+
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+
+	timestamp := r.Time
+	if timestamp.IsZero() {
+		timestamp = h.logger.now()
+	} else if h.logger.location != nil {
+		timestamp = timestamp.In(h.logger.location)
+	}
+	entry := LogEntry{
+		Level:     logLevelString(level),
+		Severity:  level,
+		Timestamp: timestamp,
+		Data:      r.Message,
+		Fields:    h.logger.entryFields(h.fields(attrs)),
+	}
+
+	if h.logger.showCallerInfo && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		if frame.File != "" {
+			entry.Source = fmt.Sprintf("%s:%d", frame.File, frame.Line)
+			if h.logger.shortSource {
+				entry.Source = shortSource(entry.Source)
+			}
+			if frame.Function != "" {
+				entry.Caller = shortFuncName(frame.Function)
+			}
+		}
+	}
+
+	h.logger.write(entry)
+	return nil
+}
+
+// WithAttrs returns a handler that adds attrs to every record, nested under
+// the currently open groups.
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := h.clone()
+	last := len(h2.attrs) - 1
+	h2.attrs[last] = append(h2.attrs[last][:len(h2.attrs[last]):len(h2.attrs[last])], attrs...)
+	return h2
+}
+
+// WithGroup returns a handler that nests all following attributes under name.
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := h.clone()
+	h2.groups = append(h2.groups, name)
+	h2.attrs = append(h2.attrs, nil)
+	return h2
+}
+
+// clone returns a copy of the handler that can be modified independently.
+func (h *slogHandler) clone() *slogHandler {
+	return &slogHandler{
+		logger: h.logger,
+		groups: append([]string(nil), h.groups...),
+		attrs:  append([][]slog.Attr(nil), h.attrs...),
+	}
+}
+
+// fields converts the handler's attributes plus the record's attributes into
+// fields, nesting them under the open groups. Groups left empty are omitted.
+func (h *slogHandler) fields(recordAttrs []slog.Attr) []Field {
+	// Build from the innermost group outwards so empty groups can be
+	// dropped. inner holds the contents of group h.groups[i-1].
+	var inner map[string]interface{}
+	for i := len(h.groups); i >= 1; i-- {
+		attrs := h.attrs[i]
+		if i == len(h.groups) {
+			attrs = append(attrs[:len(attrs):len(attrs)], recordAttrs...)
+		}
+		m := make(map[string]interface{}, len(attrs)+1)
+		addAttrs(m, attrs)
+		if len(inner) > 0 {
+			m[h.groups[i]] = inner
+		}
+		inner = m
+	}
+
+	top := h.attrs[0]
+	if len(h.groups) == 0 {
+		top = append(top[:len(top):len(top)], recordAttrs...)
+	}
+	fields := make([]Field, 0, len(top)+1)
+	for _, a := range top {
+		fields = appendAttrField(fields, a)
+	}
+	if len(inner) > 0 {
+		fields = append(fields, Any(h.groups[0], inner))
+	}
+	return fields
+}
+
+// appendAttrField appends the field for a top-level attribute.
+func appendAttrField(fields []Field, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	v := a.Value
+	switch v.Kind() {
+	case slog.KindGroup:
+		if a.Key == "" {
+			for _, ga := range v.Group() {
+				fields = appendAttrField(fields, ga)
+			}
+			return fields
+		}
+		m := make(map[string]interface{})
+		addAttrs(m, v.Group())
+		if len(m) == 0 {
+			return fields
+		}
+		return append(fields, Any(a.Key, m))
+	case slog.KindString:
+		return append(fields, String(a.Key, v.String()))
+	case slog.KindInt64:
+		return append(fields, Int64(a.Key, v.Int64()))
+	case slog.KindBool:
+		return append(fields, Bool(a.Key, v.Bool()))
+	case slog.KindDuration:
+		return append(fields, Dur(a.Key, v.Duration()))
+	}
+	if err, ok := v.Any().(error); ok {
+		f := Err(err)
+		f.Key = a.Key
+		return append(fields, f)
+	}
+	return append(fields, Any(a.Key, v.Any()))
+}
+
+// addAttrs adds attributes to a nested group object.
+func addAttrs(m map[string]interface{}, attrs []slog.Attr) {
+	for _, a := range attrs {
+		a.Value = a.Value.Resolve()
+		if a.Equal(slog.Attr{}) {
+			continue
+		}
+		v := a.Value
+		if v.Kind() == slog.KindGroup {
+			if a.Key == "" {
+				addAttrs(m, v.Group())
+				continue
+			}
+			sub := make(map[string]interface{})
+			addAttrs(sub, v.Group())
+			if len(sub) > 0 {
+				m[a.Key] = sub
+			}
+			continue
+		}
+		if err, ok := v.Any().(error); ok {
+			m[a.Key] = err.Error()
+			continue
+		}
+		m[a.Key] = v.Any()
+	}
+}
@@ -0,0 +1,93 @@
+package gologs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// tests logging through the slog front-end
+func TestSlogHandler(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(DEBUG, &out)
+	sl := slog.New(SlogHandler(l))
+
+	sl.Info("User logged in", "user", "john", "id", 42, slog.Bool("admin", true))
+	output := out.String()
+	if !strings.Contains(output, `"level":"INFO"`) {
+		t.Errorf("Expected INFO level in output, got %v", output)
+	}
+	if !strings.Contains(output, `"data":"User logged in","user":"john","id":42,"admin":true`) {
+		t.Errorf("Expected message and attributes in output, got %v", output)
+	}
+	if !strings.Contains(output, `"caller":"TestSlogHandler"`) {
+		t.Errorf("Expected caller of slog call in output, got %v", output)
+	}
+	out.Reset()
+
+	sl.Error("Query failed", "err", errors.New("timeout"))
+	output = out.String()
+	if !strings.Contains(output, `"level":"ERROR"`) || !strings.Contains(output, `"err":"timeout"`) {
+		t.Errorf("Expected ERROR level with error attribute, got %v", output)
+	}
+}
+
+// tests mapping slog levels and level filtering
+func TestSlogLevels(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(WARN, &out)
+	sl := slog.New(SlogHandler(l))
+
+	if sl.Enabled(context.Background(), slog.LevelInfo) {
+		t.Error("Expected INFO to be disabled for WARN logger")
+	}
+	sl.Info("filtered")
+	if out.Len() != 0 {
+		t.Errorf("Expected no output, got %v", out.String())
+	}
+	sl.Log(context.Background(), slog.LevelWarn+2, "warning")
+	if !strings.Contains(out.String(), `"level":"WARN"`) {
+		t.Errorf("Expected WARN level in output, got %v", out.String())
+	}
+}
+
+// tests that records below the level go to the flight recorder
+func TestSlogFlightRecorder(t *testing.T) {
+	sink := &memorySink{}
+	l := New(WithSinks(sink), WithLevel(WARN), WithFlightRecorder(10, DEBUG))
+	sl := slog.New(SlogHandler(l))
+
+	sl.Debug("connecting")
+	sl.Info("connected")
+	if msgs := sink.messages(); len(msgs) != 0 {
+		t.Errorf("Expected the records to be kept back, got %v", msgs)
+	}
+	sl.Error("query failed")
+	if msgs := sink.messages(); strings.Join(msgs, ",") != "connecting,connected,query failed" {
+		t.Errorf("Expected the recorded records before the error, got %v", msgs)
+	}
+}
+
+// tests slog groups and pre-bound attributes
+func TestSlogGroups(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(DEBUG, &out)
+	sl := slog.New(SlogHandler(l)).With("service", "shop").WithGroup("db").With("table", "users")
+
+	sl.Info("Query", "rows", 3, slog.Group("conn", "host", "localhost"))
+	output := out.String()
+	expected := `"service":"shop","db":{"conn":{"host":"localhost"},"rows":3,"table":"users"}`
+	if !strings.Contains(output, expected) {
+		t.Errorf("Expected %v in output, got %v", expected, output)
+	}
+	out.Reset()
+
+	slog.New(SlogHandler(l)).WithGroup("empty").Info("No attributes")
+	output = out.String()
+	if strings.Contains(output, "empty") {
+		t.Errorf("Expected empty group to be omitted, got %v", output)
+	}
+}
@@ -0,0 +1,616 @@
+package gologs
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// spillMagic starts every record of a spill segment, so that the next
+// record can be found after a corrupted one.
+const spillMagic = 0xa54c
+
+// spillHeaderLen is the length of a record header: magic, payload length
+// and CRC-32C of the payload.
+const spillHeaderLen = 10
+
+// spillMaxRecord is the largest payload accepted when reading a record.
+const spillMaxRecord = 16 << 20
+
+// spillCheckpointEvery is the number of drained records after which the
+// wrapped sink is flushed and the read position is saved.
+const spillCheckpointEvery = 100
+
+var spillCRC = crc32.MakeTable(crc32.Castagnoli)
+
+// SpillSink is a Sink that writes entries to another sink, typically a
+// network sink, and spills them to a write-ahead log on disk while that
+// sink fails. The log is drained into the sink in the background once it
+// works again, in the order the entries were written, and also after a
+// restart, so neither outages nor restarts lose entries. Entries written
+// while the log is not empty are appended to it, to keep their order.
+//
+// The log is kept in a directory as numbered segment files. Each record is
+// framed with a checksum, so a corrupted or half-written record, for
+// example after a crash, is skipped instead of stopping the drain. When the
+// log reaches its size cap, the oldest segment is dropped. Entries are
+// delivered at least once: after a crash, up to 100 entries may be sent
+// again.
+//
+// Sinks that send entries in batches from the background, such as the
+// Loki, Elasticsearch and Kafka sinks, hand the batches they fail to send
+// to the SpillSink, which appends them to the log. Other sinks that
+// deliver entries after Write returns, such as RetrySink, or batching
+// sinks wrapped in another sink, are only spilled when Write fails.
+type SpillSink struct {
+	sink         Sink
+	dir          string
+	maxBytes     int64
+	segmentBytes int64
+	interval     time.Duration
+	dropped      atomic.Int64
+	stats        sinkStats
+
+	drainMu  sync.Mutex
+	mu       sync.Mutex
+	segments []uint64
+	sizes    map[uint64]int64
+	writer   *os.File
+	readSeg  uint64
+	readOff  int64
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// SpillOption configures a SpillSink.
+type SpillOption func(*SpillSink)
+
+// WithSpillMaxBytes caps the size of the log on disk. Defaults to 100 MB.
+func WithSpillMaxBytes(n int64) SpillOption {
+	return func(s *SpillSink) {
+		s.maxBytes = n
+	}
+}
+
+// WithSpillRetryInterval sets how often the sink tries to drain the log
+// while the wrapped sink fails. Defaults to 5 seconds.
+func WithSpillRetryInterval(interval time.Duration) SpillOption {
+	return func(s *SpillSink) {
+		s.interval = interval
+	}
+}
+
+// NewSpillSink returns a sink writing to sink and spilling to the log in
+// dir, which is created if needed. A log left in dir by an earlier run is
+// drained first.
+func NewSpillSink(sink Sink, dir string, opts ...SpillOption) (*SpillSink, error) {
+	s := &SpillSink{
+		sink:     sink,
+		dir:      dir,
+		maxBytes: 100 << 20,
+		interval: 5 * time.Second,
+		sizes:    make(map[uint64]int64),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.segmentBytes = max(min(8<<20, s.maxBytes/4), 1)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("spill: %w", err)
+	}
+	if err := s.load(); err != nil {
+		return nil, fmt.Errorf("spill: %w", err)
+	}
+	if b, ok := sink.(batchingSink); ok && b.batches() != nil {
+		b.batches().onFailure(s.spillBatch)
+	}
+	go s.loop()
+	return s, nil
+}
+
+// load finds the segments and the read position left by an earlier run.
+func (s *SpillSink) load() error {
+	names, err := filepath.Glob(filepath.Join(s.dir, "*.wal"))
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		id, err := strconv.ParseUint(strings.TrimSuffix(filepath.Base(name), ".wal"), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			return err
+		}
+		s.segments = append(s.segments, id)
+		s.sizes[id] = info.Size()
+	}
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i] < s.segments[j] })
+	if len(s.segments) == 0 {
+		return nil
+	}
+
+	s.readSeg = s.segments[0]
+	if data, err := os.ReadFile(filepath.Join(s.dir, "checkpoint")); err == nil {
+		var seg uint64
+		var off int64
+		if _, err := fmt.Sscanf(string(data), "%d %d", &seg, &off); err == nil {
+			if _, ok := s.sizes[seg]; ok {
+				s.readSeg, s.readOff = seg, off
+			}
+		}
+	}
+	// Segments before the checkpoint were already drained.
+	for len(s.segments) > 0 && s.segments[0] < s.readSeg {
+		s.removeSegment(s.segments[0])
+	}
+	last := s.segments[len(s.segments)-1]
+	s.writer, err = os.OpenFile(s.segmentPath(last), os.O_WRONLY|os.O_APPEND, 0644)
+	return err
+}
+
+func (s *SpillSink) segmentPath(id uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d.wal", id))
+}
+
+// spilling reports whether the log holds entries that weren't drained.
+func (s *SpillSink) spilling() bool {
+	return len(s.segments) > 0
+}
+
+// Write writes the entry to the wrapped sink, or appends it to the log if
+// the log isn't empty or the sink fails. It only returns an error if the
+// entry can't be written to either.
+func (s *SpillSink) Write(entry LogEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.spilling() {
+		err := s.sink.Write(entry)
+		s.stats.record(err, time.Now())
+		if err == nil {
+			return nil
+		}
+		log.Printf("Spilling log entries to %s: %v", s.dir, err)
+	}
+	return s.append(entry)
+}
+
+// spillBatch appends a batch that a batching sink failed to send to the
+// log, as its Write only queued the entries.
+func (s *SpillSink) spillBatch(batch []LogEntry, err error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.record(err, time.Now())
+	log.Printf("Spilling log entries to %s: %v", s.dir, err)
+	var errs []error
+	for _, entry := range batch {
+		if err := s.append(entry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Dropped returns the number of entries lost because the log was full or
+// corrupted.
+func (s *SpillSink) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+func (s *SpillSink) stackLevel() LogLevel {
+	return sinkStackLevel(s.sink)
+}
+
+// Ping pings the wrapped sink, or returns ErrPingUnsupported if it doesn't
+// implement Pinger.
+func (s *SpillSink) Ping() error {
+	return pingSink(s.sink)
+}
+
+// Health returns the results of the writes to the wrapped sink and the
+// number of entries in the log.
+func (s *SpillSink) Health() SinkHealth {
+	h := s.stats.health()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range s.segments {
+		if n, err := s.countRecords(id); err == nil {
+			h.QueueDepth += n
+		}
+	}
+	return h
+}
+
+// append writes an entry to the log.
+func (s *SpillSink) append(entry LogEntry) error {
+	payload, err := marshalSpillEntry(entry)
+	if err != nil {
+		return fmt.Errorf("spill: %w", err)
+	}
+	record := make([]byte, spillHeaderLen, spillHeaderLen+len(payload))
+	binary.BigEndian.PutUint16(record, spillMagic)
+	binary.BigEndian.PutUint32(record[2:], uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[6:], crc32.Checksum(payload, spillCRC))
+	record = append(record, payload...)
+	n := int64(len(record))
+
+	if n > s.maxBytes {
+		s.dropped.Add(1)
+		return errors.New("spill: entry larger than the log, dropped")
+	}
+	for s.size()+n > s.maxBytes && len(s.segments) > 1 {
+		s.dropSegment()
+	}
+	if s.size()+n > s.maxBytes {
+		s.dropped.Add(1)
+		return errors.New("spill: log full, entry dropped")
+	}
+
+	var current int64
+	if s.writer != nil {
+		current = s.sizes[s.segments[len(s.segments)-1]]
+	}
+	if s.writer == nil || current > 0 && current+n > s.segmentBytes {
+		if err := s.newSegment(); err != nil {
+			return fmt.Errorf("spill: %w", err)
+		}
+	}
+	last := s.segments[len(s.segments)-1]
+	if _, err := s.writer.Write(record); err != nil {
+		return fmt.Errorf("spill: %w", err)
+	}
+	s.sizes[last] += n
+	return nil
+}
+
+// size returns the size of all segments.
+func (s *SpillSink) size() int64 {
+	var size int64
+	for _, id := range s.segments {
+		size += s.sizes[id]
+	}
+	return size
+}
+
+// newSegment starts a new segment for writing.
+func (s *SpillSink) newSegment() error {
+	var id uint64 = 1
+	if len(s.segments) > 0 {
+		id = s.segments[len(s.segments)-1] + 1
+	}
+	f, err := os.OpenFile(s.segmentPath(id), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if s.writer != nil {
+		s.writer.Close()
+	}
+	if len(s.segments) == 0 {
+		s.readSeg, s.readOff = id, 0
+	}
+	s.writer = f
+	s.segments = append(s.segments, id)
+	s.sizes[id] = 0
+	return nil
+}
+
+// dropSegment removes the oldest segment to make room, losing its entries.
+func (s *SpillSink) dropSegment() {
+	id := s.segments[0]
+	if n, err := s.countRecords(id); err == nil {
+		s.dropped.Add(int64(n))
+	}
+	log.Printf("Spill log %s is full, dropping segment %d", s.dir, id)
+	s.removeSegment(id)
+	s.readSeg, s.readOff = s.segments[0], 0
+	s.saveCheckpoint()
+}
+
+// removeSegment deletes a segment other than the one written to.
+func (s *SpillSink) removeSegment(id uint64) {
+	os.Remove(s.segmentPath(id))
+	delete(s.sizes, id)
+	s.segments = s.segments[1:]
+}
+
+// countRecords returns the number of undrained records of a segment.
+func (s *SpillSink) countRecords(id uint64) (int, error) {
+	data, err := os.ReadFile(s.segmentPath(id))
+	if err != nil {
+		return 0, err
+	}
+	off := int64(0)
+	if id == s.readSeg {
+		off = s.readOff
+	}
+	n := 0
+	for {
+		_, next, ok := nextSpillRecord(data, off)
+		if !ok {
+			return n, nil
+		}
+		n++
+		off = next
+	}
+}
+
+// loop drains the log until the sink is closed.
+func (s *SpillSink) loop() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if err := s.drain(); err != nil {
+			log.Printf("Failed to drain spill log %s: %v", s.dir, err)
+		}
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain writes the entries of the log to the wrapped sink, oldest first,
+// until the log is empty or the sink fails. The read position only moves
+// on once the sink was flushed, so entries a batching sink merely queued
+// are drained again after a crash.
+func (s *SpillSink) drain() error {
+	s.drainMu.Lock()
+	defer s.drainMu.Unlock()
+	for {
+		s.mu.Lock()
+		if !s.spilling() {
+			s.mu.Unlock()
+			return nil
+		}
+		seg, off := s.readSeg, s.readOff
+		next, written, err := s.writeRecords()
+		if next == off && err == nil {
+			s.nextSegment()
+			s.mu.Unlock()
+			continue
+		}
+		s.mu.Unlock()
+
+		// The lock is released while flushing, as a batching sink spills
+		// the batches it fails to send.
+		if written > 0 {
+			if flushErr := s.sink.Flush(); flushErr != nil {
+				return errors.Join(err, flushErr)
+			}
+		}
+		s.mu.Lock()
+		// The segment may have been dropped meanwhile.
+		if s.readSeg == seg && s.readOff == off {
+			s.readOff = next
+			s.saveCheckpoint()
+		}
+		s.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// writeRecords writes up to spillCheckpointEvery entries of the read
+// segment, starting at the read position, to the wrapped sink. It returns
+// the offset after the last record handled and the number of entries
+// written.
+func (s *SpillSink) writeRecords() (int64, int, error) {
+	data, err := os.ReadFile(s.segmentPath(s.readSeg))
+	if err != nil {
+		return s.readOff, 0, err
+	}
+	off, written := s.readOff, 0
+	for written < spillCheckpointEvery {
+		payload, next, ok := nextSpillRecord(data, off)
+		if !ok {
+			break
+		}
+		if skipped := next - spillHeaderLen - int64(len(payload)); skipped > off {
+			s.dropped.Add(1)
+			log.Printf("Skipped %d corrupted bytes in spill log %s", skipped-off, s.dir)
+		}
+		entry, err := unmarshalSpillEntry(payload)
+		if err == nil {
+			err := s.sink.Write(entry)
+			s.stats.record(err, time.Now())
+			if err != nil {
+				return off, written, err
+			}
+			written++
+		} else {
+			s.dropped.Add(1)
+		}
+		off = next
+	}
+	return off, written, nil
+}
+
+// nextSegment removes the read segment, whose entries were all drained,
+// and continues with the next one.
+func (s *SpillSink) nextSegment() {
+	id := s.readSeg
+	if info, err := os.Stat(s.segmentPath(id)); err == nil && info.Size() > s.readOff {
+		s.dropped.Add(1)
+		log.Printf("Skipped %d corrupted bytes in spill log %s", info.Size()-s.readOff, s.dir)
+	}
+	if id != s.segments[len(s.segments)-1] {
+		s.removeSegment(id)
+		s.readSeg, s.readOff = s.segments[0], 0
+	} else {
+		// The log is empty.
+		s.writer.Close()
+		s.writer = nil
+		s.removeSegment(id)
+		s.readSeg, s.readOff = 0, 0
+	}
+	s.saveCheckpoint()
+}
+
+// saveCheckpoint records the read position, so that drained entries aren't
+// sent again after a restart.
+func (s *SpillSink) saveCheckpoint() {
+	path := filepath.Join(s.dir, "checkpoint")
+	if !s.spilling() {
+		os.Remove(path)
+		return
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(fmt.Sprintf("%d %d\n", s.readSeg, s.readOff)), 0644); err == nil {
+		os.Rename(tmp, path)
+	}
+}
+
+// nextSpillRecord returns the payload of the first valid record in data at
+// or after off, and the offset following it. Corrupted bytes before the
+// record are skipped.
+func nextSpillRecord(data []byte, off int64) ([]byte, int64, bool) {
+	for ; off+spillHeaderLen <= int64(len(data)); off++ {
+		header := data[off:]
+		if binary.BigEndian.Uint16(header) != spillMagic {
+			continue
+		}
+		n := int64(binary.BigEndian.Uint32(header[2:]))
+		end := off + spillHeaderLen + n
+		if n > spillMaxRecord || end > int64(len(data)) {
+			continue
+		}
+		payload := data[off+spillHeaderLen : end]
+		if crc32.Checksum(payload, spillCRC) != binary.BigEndian.Uint32(header[6:]) {
+			continue
+		}
+		return payload, end, true
+	}
+	return nil, off, false
+}
+
+// Flush drains the log if possible and flushes the wrapped sink.
+func (s *SpillSink) Flush() error {
+	err := s.drain()
+	return errors.Join(err, s.sink.Flush())
+}
+
+// Close tries to drain the log once more, stops the background goroutine
+// and closes the wrapped sink. Entries still in the log are drained by the
+// next SpillSink using the same directory.
+func (s *SpillSink) Close() error {
+	s.mu.Lock()
+	select {
+	case <-s.stop:
+		s.mu.Unlock()
+		return nil
+	default:
+	}
+	close(s.stop)
+	s.mu.Unlock()
+	<-s.done
+
+	// Batches the sink fails to send while closing are spilled too.
+	err := errors.Join(s.drain(), s.sink.Close())
+	s.mu.Lock()
+	if s.writer != nil {
+		err = errors.Join(err, s.writer.Close())
+		s.writer = nil
+	}
+	s.mu.Unlock()
+	return err
+}
+
+// spillEntry is the JSON form of a LogEntry in the log.
+type spillEntry struct {
+	Level         string          `json:"level"`
+	Severity      LogLevel        `json:"severity"`
+	Timestamp     time.Time       `json:"timestamp"`
+	Source        string          `json:"source,omitempty"`
+	Caller        string          `json:"caller,omitempty"`
+	Data          json.RawMessage `json:"data"`
+	Fields        []spillField    `json:"fields,omitempty"`
+	SchemaVersion SchemaVersion   `json:"schema_version,omitempty"`
+}
+
+type spillField struct {
+	Key     string          `json:"key"`
+	Type    FieldType       `json:"type"`
+	Str     string          `json:"str,omitempty"`
+	Integer int64           `json:"int,omitempty"`
+	Value   json.RawMessage `json:"value,omitempty"`
+}
+
+// marshalSpillEntry encodes an entry for the log. Messages and Any values
+// are stored as JSON, so they are read back as generic JSON values.
+func marshalSpillEntry(e LogEntry) ([]byte, error) {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return nil, err
+	}
+	se := spillEntry{
+		Level:         e.Level,
+		Severity:      e.Severity,
+		Timestamp:     e.Timestamp,
+		Source:        e.Source,
+		Caller:        e.Caller,
+		Data:          data,
+		SchemaVersion: e.SchemaVersion,
+	}
+	for _, f := range e.Fields {
+		if f.Type == skipType {
+			continue
+		}
+		sf := spillField{Key: f.Key, Type: f.Type, Str: f.str, Integer: f.integer}
+		if f.Type == AnyType {
+			if sf.Value, err = json.Marshal(f.Value); err != nil {
+				return nil, err
+			}
+		}
+		se.Fields = append(se.Fields, sf)
+	}
+	return json.Marshal(se)
+}
+
+// unmarshalSpillEntry decodes an entry of the log.
+func unmarshalSpillEntry(payload []byte) (LogEntry, error) {
+	var se spillEntry
+	if err := json.Unmarshal(payload, &se); err != nil {
+		return LogEntry{}, err
+	}
+	e := LogEntry{
+		Level:         se.Level,
+		Severity:      se.Severity,
+		Timestamp:     se.Timestamp,
+		Source:        se.Source,
+		Caller:        se.Caller,
+		SchemaVersion: se.SchemaVersion,
+	}
+	if err := json.Unmarshal(se.Data, &e.Data); err != nil {
+		return LogEntry{}, err
+	}
+	for _, sf := range se.Fields {
+		f := Field{Key: sf.Key, Type: sf.Type, str: sf.Str, integer: sf.Integer}
+		switch sf.Type {
+		case AnyType:
+			if err := json.Unmarshal(sf.Value, &f.Value); err != nil {
+				return LogEntry{}, err
+			}
+		case ErrorType:
+			f.Value = errors.New(sf.Str)
+		}
+		e.Fields = append(e.Fields, f)
+	}
+	return e, nil
+}
@@ -0,0 +1,235 @@
+package gologs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tests that entries are spilled while the sink fails and drained in order
+// once it works again
+func TestSpillSink(t *testing.T) {
+	primary := &failingSink{}
+	sink, err := NewSpillSink(primary, t.TempDir(), WithSpillRetryInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer sink.Close()
+
+	sink.Write(LogEntry{Data: "a"})
+	primary.fail = true
+	for _, msg := range []string{"b", "c"} {
+		if err := sink.Write(LogEntry{Data: msg}); err != nil {
+			t.Fatalf("Expected no error while spilling, got %v", err)
+		}
+	}
+	if err := sink.Flush(); err == nil {
+		t.Errorf("Expected error while the sink fails, got %v", err)
+	}
+	primary.fail = false
+	// written after the spilled entries, although the sink works again
+	sink.Write(LogEntry{Data: "d"})
+	if err := sink.Flush(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	sink.Write(LogEntry{Data: "e"})
+
+	if msgs := primary.messages(); strings.Join(msgs, ",") != "a,b,c,d,e" {
+		t.Errorf("Expected all entries in order, got %v", msgs)
+	}
+	if files, _ := filepath.Glob(filepath.Join(sink.dir, "*")); len(files) != 0 {
+		t.Errorf("Expected empty log directory, got %v", files)
+	}
+}
+
+// tests that batches a batching sink fails to send are spilled, although
+// its Write succeeded, and sent again once it works
+func TestSpillSinkBatching(t *testing.T) {
+	var mu sync.Mutex
+	fail := true
+	var sent []string
+	primary := &reportingSink{batch: newBatcher(10, time.Hour, func(entries []LogEntry) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if fail {
+			return errors.New("unavailable")
+		}
+		for _, e := range entries {
+			sent = append(sent, e.Data.(string))
+		}
+		return nil
+	})}
+	sink, err := NewSpillSink(primary, t.TempDir(), WithSpillRetryInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer sink.Close()
+
+	for _, msg := range []string{"a", "b"} {
+		if err := sink.Write(LogEntry{Data: msg}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if err := primary.Flush(); err != nil {
+		t.Errorf("Expected no error for a spilled batch, got %v", err)
+	}
+	if h := sink.Health(); h.QueueDepth != 2 || h.LastError == nil {
+		t.Errorf("Expected 2 spilled entries and the error, got %+v", h)
+	}
+	sink.Write(LogEntry{Data: "c"})
+
+	mu.Lock()
+	fail = false
+	mu.Unlock()
+	if err := sink.Flush(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if got := strings.Join(sent, ","); got != "a,b,c" {
+		t.Errorf("Expected all entries in order, got %v", got)
+	}
+}
+
+// tests that a log left by an earlier run is drained, with its fields
+func TestSpillSinkRestart(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewSpillSink(&failingSink{fail: true}, dir, WithSpillRetryInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	ts := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	sink.Write(LogEntry{Level: "ERROR", Severity: ERROR, Timestamp: ts, Source: "main.go:10", Data: "lost?", SchemaVersion: SchemaV2,
+		Fields: []Field{String("user", "ada"), Int("n", 3), Dur("took", time.Second), Err(errors.New("boom")), Any("tags", []string{"x"})}})
+	sink.Close()
+
+	primary := &memorySink{}
+	sink, err = NewSpillSink(primary, dir, WithSpillRetryInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(primary.entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(primary.entries))
+	}
+	e := primary.entries[0]
+	if e.Level != "ERROR" || e.Severity != ERROR || !e.Timestamp.Equal(ts) || e.Source != "main.go:10" || e.Data != "lost?" || e.SchemaVersion != SchemaV2 {
+		t.Errorf("Expected the spilled entry, got %+v", e)
+	}
+	var buf strings.Builder
+	for _, f := range e.Fields {
+		text, _ := fieldText(f)
+		buf.WriteString(f.Key + "=" + text + " ")
+	}
+	if want := "user=ada n=3 took=1s error=boom tags=[\"x\"] "; buf.String() != want {
+		t.Errorf("Expected fields %q, got %q", want, buf.String())
+	}
+}
+
+// unflushedSink queues entries but fails to deliver them on Flush.
+type unflushedSink struct {
+	memorySink
+}
+
+func (s *unflushedSink) Flush() error {
+	return errors.New("not delivered")
+}
+
+// tests that the read position isn't saved for entries the sink only
+// queued, so they are drained again after a restart
+func TestSpillSinkCheckpointAfterFlush(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewSpillSink(&failingSink{fail: true}, dir, WithSpillRetryInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	sink.Write(LogEntry{Data: "a"})
+	sink.Write(LogEntry{Data: "b"})
+	sink.Close()
+
+	queued := &unflushedSink{}
+	sink, err = NewSpillSink(queued, dir, WithSpillRetryInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := sink.Close(); err == nil {
+		t.Error("Expected error while the sink fails to flush")
+	}
+	if msgs := queued.messages(); len(msgs) == 0 {
+		t.Error("Expected the entries to be queued")
+	}
+
+	primary := &memorySink{}
+	sink, err = NewSpillSink(primary, dir, WithSpillRetryInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	sink.Close()
+	if msgs := primary.messages(); strings.Join(msgs, ",") != "a,b" {
+		t.Errorf("Expected the queued entries to be drained again, got %v", msgs)
+	}
+}
+
+// tests that corrupted records are skipped
+func TestSpillSinkCorruption(t *testing.T) {
+	primary := &failingSink{fail: true}
+	sink, err := NewSpillSink(primary, t.TempDir(), WithSpillRetryInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer sink.Close()
+	for _, msg := range []string{"first", "second", "third"} {
+		sink.Write(LogEntry{Data: msg})
+	}
+
+	path := sink.segmentPath(sink.segments[0])
+	data, _ := os.ReadFile(path)
+	i := strings.Index(string(data), "second")
+	data[i] = 'S'
+	os.WriteFile(path, append(data, 0xa5, 0x4c, 0, 0), 0644)
+
+	primary.fail = false
+	if err := sink.Flush(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if msgs := primary.messages(); strings.Join(msgs, ",") != "first,third" {
+		t.Errorf("Expected the intact entries, got %v", msgs)
+	}
+	if sink.Dropped() != 2 {
+		t.Errorf("Expected 2 dropped records, got %d", sink.Dropped())
+	}
+}
+
+// tests that the oldest segment is dropped when the log is full
+func TestSpillSinkMaxBytes(t *testing.T) {
+	primary := &failingSink{fail: true}
+	sink, err := NewSpillSink(primary, t.TempDir(), WithSpillMaxBytes(400), WithSpillRetryInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer sink.Close()
+	for i := 0; i < 20; i++ {
+		if err := sink.Write(LogEntry{Data: strings.Repeat("x", 20)}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if size := sink.size(); size > 400 {
+		t.Errorf("Expected at most 400 bytes, got %d", size)
+	}
+	if sink.Dropped() == 0 {
+		t.Errorf("Expected dropped entries, got %d", sink.Dropped())
+	}
+
+	primary.fail = false
+	sink.Flush()
+	if n := int64(len(primary.messages())); n+sink.Dropped() != 20 {
+		t.Errorf("Expected 20 entries drained or dropped, got %d and %d", n, sink.Dropped())
+	}
+}
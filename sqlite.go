@@ -0,0 +1,255 @@
+package gologs
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// sqliteTimeFormat is a fixed-width UTC format, so timestamps sort as text
+// and can be used with SQLite's date and time functions.
+const sqliteTimeFormat = "2006-01-02T15:04:05.000000000Z"
+
+// SQLiteSink is a Sink that inserts entries into a table of a SQLite
+// database, so that the history of a CLI tool or desktop app can be queried
+// locally:
+//
+//	SELECT ts, message FROM logs WHERE severity >= 3 ORDER BY id DESC LIMIT 20;
+//	SELECT message FROM logs WHERE json_extract(fields, '$.user_id') = 42;
+//
+// The table has the columns id, ts, level, severity (the numeric LogLevel),
+// message, source, caller and fields, which holds the fields as a JSON
+// object, plus a column for each key given to WithSQLiteColumns. ts,
+// severity and the extra columns are indexed. Entries are inserted in
+// batches of up to 100 entries, at least once a second, from a background
+// goroutine, one transaction per batch.
+//
+// The sink uses a *sql.DB opened by the caller, so any SQLite driver can be
+// used, such as modernc.org/sqlite or github.com/mattn/go-sqlite3.
+type SQLiteSink struct {
+	db      *sql.DB
+	table   string
+	columns []string
+	maxRows int64
+	insert  string
+	trim    string
+	batch   *batcher
+}
+
+// SQLiteOption configures a SQLiteSink.
+type SQLiteOption func(*SQLiteSink)
+
+// WithSQLiteTable sets the name of the table. Defaults to "logs".
+func WithSQLiteTable(table string) SQLiteOption {
+	return func(s *SQLiteSink) {
+		s.table = table
+	}
+}
+
+// WithSQLiteColumns adds an indexed column for each of the given field
+// keys, holding the value of the field. The fields are still included in
+// the fields column.
+func WithSQLiteColumns(keys ...string) SQLiteOption {
+	return func(s *SQLiteSink) {
+		s.columns = append(s.columns, keys...)
+	}
+}
+
+// WithSQLiteMaxRows caps the table to about the newest n entries; older
+// entries are deleted after each batch. By default, all entries are kept.
+func WithSQLiteMaxRows(n int64) SQLiteOption {
+	return func(s *SQLiteSink) {
+		s.maxRows = n
+	}
+}
+
+// NewSQLiteSink returns a sink inserting entries into db. It switches the
+// database to write-ahead logging, so that entries can be read while they
+// are written, and creates the table and its indexes if they don't exist.
+func NewSQLiteSink(db *sql.DB, opts ...SQLiteOption) (*SQLiteSink, error) {
+	s := &SQLiteSink{db: db, table: "logs"}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	table := sqlIdentifier(s.table)
+	schema := []string{
+		"PRAGMA journal_mode=WAL",
+		"CREATE TABLE IF NOT EXISTS " + table + " (\n" +
+			"\tid INTEGER PRIMARY KEY AUTOINCREMENT,\n" +
+			"\tts TEXT NOT NULL,\n" +
+			"\tlevel TEXT NOT NULL,\n" +
+			"\tseverity INTEGER NOT NULL,\n" +
+			"\tmessage TEXT NOT NULL,\n" +
+			"\tsource TEXT,\n" +
+			"\tcaller TEXT,\n" +
+			"\tfields TEXT NOT NULL" + sqlColumnDefs(s.columns, "TEXT") + "\n)",
+		sqlIndex(s.table, "ts"),
+		sqlIndex(s.table, "severity"),
+	}
+	for _, column := range s.columns {
+		schema = append(schema, sqlIndex(s.table, column))
+	}
+	for _, stmt := range schema {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, fmt.Errorf("sqlite: %w", err)
+		}
+	}
+
+	columns := append([]string{"ts", "level", "severity", "message", "source", "caller", "fields"}, s.columns...)
+	for i, column := range columns {
+		columns[i] = sqlIdentifier(column)
+	}
+	s.insert = "INSERT INTO " + table + " (" + strings.Join(columns, ", ") + ") VALUES (?" + strings.Repeat(", ?", len(columns)-1) + ")"
+	s.trim = "DELETE FROM " + table + " WHERE id <= (SELECT MAX(id) FROM " + table + ") - ?"
+	s.batch = newBatcher(100, time.Second, s.send)
+	return s, nil
+}
+
+// Write queues the entry to be inserted.
+func (s *SQLiteSink) Write(entry LogEntry) error {
+	return s.batch.add(entry)
+}
+
+// Flush inserts all queued entries.
+func (s *SQLiteSink) Flush() error {
+	return s.batch.flush()
+}
+
+// Close inserts all queued entries. The database is not closed.
+func (s *SQLiteSink) Close() error {
+	return s.batch.close()
+}
+
+// Health returns the results of the sent batches and the number of entries
+// waiting to be sent.
+func (s *SQLiteSink) Health() SinkHealth {
+	return s.batch.health()
+}
+
+func (s *SQLiteSink) batches() *batcher {
+	return s.batch
+}
+
+// Ping checks that the database is reachable.
+func (s *SQLiteSink) Ping() error {
+	return s.db.Ping()
+}
+
+// send inserts a batch of entries in a transaction.
+func (s *SQLiteSink) send(entries []LogEntry) error {
+	rows := make([][]interface{}, len(entries))
+	for i, e := range entries {
+		row, err := sqlRow(e, s.columns)
+		if err != nil {
+			return err
+		}
+		row[0] = e.Timestamp.UTC().Format(sqliteTimeFormat)
+		rows[i] = row
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("sqlite: %w", err)
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(s.insert)
+	if err != nil {
+		return fmt.Errorf("sqlite: %w", err)
+	}
+	defer stmt.Close()
+	for _, row := range rows {
+		if _, err := stmt.Exec(row...); err != nil {
+			return fmt.Errorf("sqlite: %w", err)
+		}
+	}
+	if s.maxRows > 0 {
+		if _, err := tx.Exec(s.trim, s.maxRows); err != nil {
+			return fmt.Errorf("sqlite: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("sqlite: %w", err)
+	}
+	return nil
+}
+
+// sqlRow returns the values of the columns ts, level, severity, message,
+// source, caller and fields, followed by the values of the given field
+// keys. Empty source and caller and missing fields are nil.
+func sqlRow(e LogEntry, keys []string) ([]interface{}, error) {
+	var msg bytes.Buffer
+	if err := appendMessageText(&msg, e.Data); err != nil {
+		return nil, err
+	}
+	var fields bytes.Buffer
+	if err := appendFieldsJSON(&fields, e.Fields); err != nil {
+		return nil, err
+	}
+	row := []interface{}{e.Timestamp, e.Level, int64(e.Severity), msg.String(), nil, nil, fields.String()}
+	if e.Source != "" {
+		row[4] = e.Source
+	}
+	if e.Caller != "" {
+		row[5] = e.Caller
+	}
+	for _, key := range keys {
+		var value interface{}
+		for _, f := range e.Fields {
+			if f.Key == key && f.Type != skipType {
+				text, err := fieldText(f)
+				if err != nil {
+					return nil, err
+				}
+				value = text
+			}
+		}
+		row = append(row, value)
+	}
+	return row, nil
+}
+
+// appendFieldsJSON writes the fields as a JSON object.
+func appendFieldsJSON(buf *bytes.Buffer, fields []Field) error {
+	buf.WriteByte('{')
+	first := true
+	for _, f := range fields {
+		if f.Type == skipType {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		buf.Write(appendJSONString(buf.AvailableBuffer(), f.Key))
+		buf.WriteByte(':')
+		if err := appendFieldValue(buf, f); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// sqlIdentifier quotes a table or column name.
+func sqlIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// sqlColumnDefs returns the definitions of the given columns, each
+// preceded by a comma.
+func sqlColumnDefs(columns []string, typ string) string {
+	var b strings.Builder
+	for _, column := range columns {
+		b.WriteString(",\n\t" + sqlIdentifier(column) + " " + typ)
+	}
+	return b.String()
+}
+
+// sqlIndex returns the statement creating an index on a column.
+func sqlIndex(table, column string) string {
+	return "CREATE INDEX IF NOT EXISTS " + sqlIdentifier(table+"_"+column) +
+		" ON " + sqlIdentifier(table) + " (" + sqlIdentifier(column) + ")"
+}
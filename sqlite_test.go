@@ -0,0 +1,156 @@
+package gologs
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeSQL is a database/sql driver recording the statements it executes.
+// Statements containing fail return an error.
+type fakeSQL struct {
+	mu    sync.Mutex
+	execs []fakeSQLExec
+	fail  string
+}
+
+type fakeSQLExec struct {
+	query string
+	args  []driver.Value
+}
+
+func (d *fakeSQL) Connect(context.Context) (driver.Conn, error) { return fakeSQLConn{d}, nil }
+func (d *fakeSQL) Driver() driver.Driver                        { return nil }
+
+// queries returns the executed statements.
+func (d *fakeSQL) queries() []fakeSQLExec {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]fakeSQLExec(nil), d.execs...)
+}
+
+func (d *fakeSQL) exec(query string, args []driver.Value) (driver.Result, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.fail != "" && strings.Contains(query, d.fail) {
+		return nil, errors.New("disk I/O error")
+	}
+	d.execs = append(d.execs, fakeSQLExec{query, args})
+	return driver.RowsAffected(1), nil
+}
+
+type fakeSQLConn struct{ d *fakeSQL }
+
+func (c fakeSQLConn) Prepare(query string) (driver.Stmt, error) { return fakeSQLStmt{c.d, query}, nil }
+func (c fakeSQLConn) Close() error                              { return nil }
+func (c fakeSQLConn) Begin() (driver.Tx, error) {
+	c.d.exec("BEGIN", nil)
+	return fakeSQLTx{c.d}, nil
+}
+
+type fakeSQLTx struct{ d *fakeSQL }
+
+func (tx fakeSQLTx) Commit() error {
+	_, err := tx.d.exec("COMMIT", nil)
+	return err
+}
+
+func (tx fakeSQLTx) Rollback() error {
+	_, err := tx.d.exec("ROLLBACK", nil)
+	return err
+}
+
+type fakeSQLStmt struct {
+	d     *fakeSQL
+	query string
+}
+
+func (s fakeSQLStmt) Close() error  { return nil }
+func (s fakeSQLStmt) NumInput() int { return -1 }
+func (s fakeSQLStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.d.exec(s.query, args)
+}
+func (s fakeSQLStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+// tests the schema and the inserted rows
+func TestSQLiteSink(t *testing.T) {
+	fake := &fakeSQL{}
+	sink, err := NewSQLiteSink(sql.OpenDB(fake), WithSQLiteColumns("user_id"), WithSQLiteMaxRows(1000))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	l := New(WithSinks(sink), WithCallerInfo(false))
+	l.Warn("Login failed", Int("user_id", 42), Bool("locked", true))
+	if err := l.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	execs := fake.queries()
+	var all []string
+	for _, e := range execs {
+		all = append(all, e.query)
+	}
+	got := strings.Join(all, "\n")
+	for _, want := range []string{
+		"PRAGMA journal_mode=WAL",
+		`CREATE TABLE IF NOT EXISTS "logs"`,
+		`"user_id" TEXT`,
+		`CREATE INDEX IF NOT EXISTS "logs_ts" ON "logs" ("ts")`,
+		`CREATE INDEX IF NOT EXISTS "logs_user_id" ON "logs" ("user_id")`,
+		`INSERT INTO "logs" ("ts", "level", "severity", "message", "source", "caller", "fields", "user_id") VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		`DELETE FROM "logs" WHERE id <= (SELECT MAX(id) FROM "logs") - ?`,
+		"COMMIT",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected statement %q, got:\n%s", want, got)
+		}
+	}
+
+	for _, e := range execs {
+		if !strings.HasPrefix(e.query, "INSERT") {
+			continue
+		}
+		if ts := e.args[0].(string); len(ts) != len(sqliteTimeFormat) || !strings.HasSuffix(ts, "Z") {
+			t.Errorf("Expected fixed-width UTC timestamp, got %s", ts)
+		}
+		if e.args[1] != "WARN" || e.args[2] != int64(WARN) || e.args[3] != "Login failed" || e.args[4] != nil || e.args[7] != "42" {
+			t.Errorf("Expected row values, got %v", e.args)
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(e.args[6].(string)), &fields); err != nil || fields["locked"] != true {
+			t.Errorf("Expected fields as JSON, got %v", e.args[6])
+		}
+	}
+}
+
+// tests that a failed batch is rolled back and reported
+func TestSQLiteSinkError(t *testing.T) {
+	fake := &fakeSQL{fail: "INSERT"}
+	sink, err := NewSQLiteSink(sql.OpenDB(fake), WithSQLiteTable("app_logs"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer sink.Close()
+	sink.Write(LogEntry{Level: "INFO", Data: "lost"})
+	if err := sink.Flush(); err == nil || !strings.Contains(err.Error(), "disk I/O error") {
+		t.Errorf("Expected insert error, got %v", err)
+	}
+	execs := fake.queries()
+	if last := execs[len(execs)-1].query; last != "ROLLBACK" {
+		t.Errorf("Expected ROLLBACK, got %s", last)
+	}
+}
+
+// tests that schema errors are returned
+func TestSQLiteSinkSchemaError(t *testing.T) {
+	if _, err := NewSQLiteSink(sql.OpenDB(&fakeSQL{fail: "CREATE TABLE"})); err == nil {
+		t.Error("Expected schema error")
+	}
+}
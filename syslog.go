@@ -0,0 +1,289 @@
+package gologs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SyslogFacility is the syslog facility entries are logged under.
+type SyslogFacility int
+
+// Syslog facilities.
+const (
+	SyslogKern SyslogFacility = iota
+	SyslogUser
+	SyslogMail
+	SyslogDaemon
+	SyslogAuth
+	SyslogSyslog
+	SyslogLPR
+	SyslogNews
+	SyslogUUCP
+	SyslogCron
+	SyslogAuthPriv
+	SyslogFTP
+	_
+	_
+	_
+	_
+	SyslogLocal0
+	SyslogLocal1
+	SyslogLocal2
+	SyslogLocal3
+	SyslogLocal4
+	SyslogLocal5
+	SyslogLocal6
+	SyslogLocal7
+)
+
+// Syslog severities, see RFC 5424 section 6.2.1.
+const (
+	syslogEmergency = iota
+	syslogAlert
+	syslogCritical
+	syslogError
+	syslogWarning
+	syslogNotice
+	syslogInfo
+	syslogDebug
+)
+
+// syslogSeverity maps a LogLevel onto a syslog severity.
+func syslogSeverity(level LogLevel) int {
+	switch {
+	case level >= FATAL:
+		return syslogAlert
+	case level == PANIC:
+		return syslogCritical
+	case level == ERROR:
+		return syslogError
+	case level == WARN:
+		return syslogWarning
+	case level == INFO:
+		return syslogInfo
+	default:
+		return syslogDebug
+	}
+}
+
+// localSyslogPaths are the sockets tried to reach the local syslog daemon.
+var localSyslogPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// SyslogSink is a Sink that sends entries to a syslog daemon. Entries are
+// sent as RFC 5424 messages over UDP or TCP, and in the traditional
+// "<PRI>TIMESTAMP TAG[PID]: MSG" format understood by all local daemons over
+// the local socket. The message is followed by the entry's fields in logfmt:
+//
+//	<14>1 2023-10-15T14:30:45.123456Z web01 shop 4242 - - Request handled status=200
+//
+// Levels are mapped onto syslog severities: TRACE and DEBUG to debug, INFO
+// to info, WARN to warning, ERROR to err, PANIC to crit and FATAL to alert.
+// If sending fails, the sink reconnects and retries once.
+type SyslogSink struct {
+	mu       sync.Mutex
+	network  string
+	addr     string
+	facility SyslogFacility
+	tag      string
+	hostname string
+	conn     net.Conn
+	local    bool
+	closed   bool
+	buf      bytes.Buffer
+}
+
+// SyslogOption configures a SyslogSink.
+type SyslogOption func(*SyslogSink)
+
+// WithSyslogFacility sets the facility of a SyslogSink. Defaults to
+// SyslogUser.
+func WithSyslogFacility(facility SyslogFacility) SyslogOption {
+	return func(s *SyslogSink) {
+		s.facility = facility
+	}
+}
+
+// WithSyslogTag sets the tag, or APP-NAME, of a SyslogSink. Defaults to the
+// name of the program.
+func WithSyslogTag(tag string) SyslogOption {
+	return func(s *SyslogSink) {
+		s.tag = tag
+	}
+}
+
+// NewSyslogSink connects to the syslog daemon at addr using network, which
+// is "udp", "tcp", "unix" or "unixgram". If network and addr are empty, the
+// local daemon is used through /dev/log or the platform's equivalent.
+func NewSyslogSink(network, addr string, opts ...SyslogOption) (*SyslogSink, error) {
+	s := &SyslogSink{
+		network:  network,
+		addr:     addr,
+		facility: SyslogUser,
+		tag:      filepath.Base(os.Args[0]),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.hostname, _ = os.Hostname(); s.hostname == "" {
+		s.hostname = "-"
+	}
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// connect opens the connection to the syslog daemon.
+func (s *SyslogSink) connect() error {
+	if s.network != "" || s.addr != "" {
+		conn, err := net.Dial(s.network, s.addr)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+		s.local = s.network == "unix" || s.network == "unixgram"
+		return nil
+	}
+
+	for _, path := range localSyslogPaths {
+		for _, network := range []string{"unixgram", "unix"} {
+			if conn, err := net.Dial(network, path); err == nil {
+				s.conn = conn
+				s.local = true
+				return nil
+			}
+		}
+	}
+	return errors.New("syslog: no local syslog daemon found")
+}
+
+// Write sends the entry to the syslog daemon.
+func (s *SyslogSink) Write(entry LogEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errors.New("syslog: sink is closed")
+	}
+
+	s.buf.Reset()
+	if err := s.format(entry, &s.buf); err != nil {
+		return err
+	}
+	if s.conn != nil {
+		if _, err := s.conn.Write(s.buf.Bytes()); err == nil {
+			return nil
+		}
+		s.conn.Close()
+		s.conn = nil
+	}
+	if err := s.connect(); err != nil {
+		return err
+	}
+	_, err := s.conn.Write(s.buf.Bytes())
+	return err
+}
+
+// format writes the syslog message for the entry to buf.
+func (s *SyslogSink) format(entry LogEntry, buf *bytes.Buffer) error {
+	var msg bytes.Buffer
+	if err := appendMessageFields(&msg, entry); err != nil {
+		return err
+	}
+
+	pri := int(s.facility)*8 + syslogSeverity(entry.Severity)
+	if s.local {
+		fmt.Fprintf(buf, "<%d>%s %s[%d]: ", pri, entry.Timestamp.Format(time.Stamp), s.tag, os.Getpid())
+		buf.Write(msg.Bytes())
+		buf.WriteByte('\n')
+		return nil
+	}
+
+	var frame bytes.Buffer
+	fmt.Fprintf(&frame, "<%d>1 %s %s %s %d - - ", pri,
+		entry.Timestamp.Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogHeaderField(s.hostname), syslogHeaderField(s.tag), os.Getpid())
+	frame.Write(msg.Bytes())
+	if s.network == "udp" || s.network == "udp4" || s.network == "udp6" {
+		buf.Write(frame.Bytes())
+		return nil
+	}
+	// Stream transports use octet counting framing, see RFC 6587.
+	buf.WriteString(strconv.Itoa(frame.Len()))
+	buf.WriteByte(' ')
+	buf.Write(frame.Bytes())
+	return nil
+}
+
+// appendMessageFields writes the entry's message followed by its fields in
+// logfmt, as used by sinks that take a single line of text.
+func appendMessageFields(buf *bytes.Buffer, entry LogEntry) error {
+	if err := appendMessageText(buf, entry.Data); err != nil {
+		return err
+	}
+	for _, f := range entry.Fields {
+		if f.Type == skipType {
+			continue
+		}
+		buf.WriteByte(' ')
+		buf.WriteString(logfmtKey(f.Key))
+		buf.WriteByte('=')
+		if err := appendFieldText(buf, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syslogHeaderField returns s as an RFC 5424 header field: printable ASCII
+// without spaces, or "-" if empty.
+func syslogHeaderField(s string) string {
+	if s == "" {
+		return "-"
+	}
+	b := []byte(s)
+	for i, c := range b {
+		if c <= ' ' || c > '~' {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// Flush does nothing; entries are sent as they are written.
+func (s *SyslogSink) Flush() error {
+	return nil
+}
+
+// Ping connects to the syslog daemon if the sink isn't connected, and
+// returns an error if it can't.
+func (s *SyslogSink) Ping() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errors.New("syslog: sink is closed")
+	}
+	if s.conn != nil {
+		return nil
+	}
+	return s.connect()
+}
+
+// Close closes the connection to the syslog daemon.
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
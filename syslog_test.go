@@ -0,0 +1,130 @@
+package gologs
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tests the mapping of levels onto syslog severities
+func TestSyslogSeverity(t *testing.T) {
+	expected := map[LogLevel]int{TRACE: 7, DEBUG: 7, INFO: 6, WARN: 4, ERROR: 3, PANIC: 2, FATAL: 1}
+	for level, severity := range expected {
+		if got := syslogSeverity(level); got != severity {
+			t.Errorf("Expected severity %v for %v, got %v", severity, logLevelString(level), got)
+		}
+	}
+}
+
+// tests sending RFC 5424 messages over UDP
+func TestSyslogSinkUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	sink, err := NewSyslogSink("udp", conn.LocalAddr().String(), WithSyslogFacility(SyslogLocal0), WithSyslogTag("shop"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer sink.Close()
+	l := New(WithSinks(sink))
+	l.Warn("Disk almost full", Int("free_mb", 12))
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(buf[:n])
+	// local0 (16) * 8 + warning (4)
+	if !strings.HasPrefix(msg, "<132>1 ") {
+		t.Errorf("Expected <132>1 header, got %v", msg)
+	}
+	expected := " shop " + strconv.Itoa(os.Getpid()) + " - - Disk almost full free_mb=12"
+	if !strings.HasSuffix(msg, expected) {
+		t.Errorf("Expected %v at end of message, got %v", expected, msg)
+	}
+}
+
+// tests octet counting framing over TCP
+func TestSyslogSinkTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		length, _ := r.ReadString(' ')
+		n, _ := strconv.Atoi(strings.TrimSpace(length))
+		frame := make([]byte, n)
+		r.Read(frame)
+		received <- string(frame)
+	}()
+
+	sink, err := NewSyslogSink("tcp", ln.Addr().String(), WithSyslogTag("shop"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer sink.Close()
+	New(WithSinks(sink)).Error("Payment failed")
+
+	select {
+	case msg := <-received:
+		if !strings.HasPrefix(msg, "<11>1 ") || !strings.HasSuffix(msg, " - - Payment failed") {
+			t.Errorf("Expected framed user.err message, got %v", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected message to be received")
+	}
+}
+
+// tests the traditional format used on local sockets
+func TestSyslogSinkLocal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets not available")
+	}
+	path := filepath.Join(t.TempDir(), "log")
+	conn, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer conn.Close()
+
+	sink, err := NewSyslogSink("unixgram", path, WithSyslogFacility(SyslogDaemon), WithSyslogTag("shop"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	New(WithSinks(sink)).Info("Started")
+	sink.Close()
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(buf[:n])
+	expected := " shop[" + strconv.Itoa(os.Getpid()) + "]: Started\n"
+	if !strings.HasPrefix(msg, "<30>") || !strings.HasSuffix(msg, expected) {
+		t.Errorf("Expected daemon.info message ending in %q, got %q", expected, msg)
+	}
+	if err := sink.Write(LogEntry{Data: "late"}); err == nil {
+		t.Error("Expected error writing to closed sink")
+	}
+}
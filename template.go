@@ -0,0 +1,122 @@
+package gologs
+
+import (
+	"bytes"
+	"errors"
+	"text/template"
+	"time"
+)
+
+// TemplateEncoder encodes entries as text with a text/template, so that the
+// output can reproduce an existing text format exactly:
+//
+//	{{.Timestamp}} [{{.Level}}] {{.Message}} {{.Fields}}
+//
+// The template is executed with a TemplateEntry. A newline is appended
+// unless the template ends with one. Timestamps default to RFC 3339.
+type TemplateEncoder struct {
+	EncoderConfig
+	tmpl *template.Template
+}
+
+// NewTemplateEncoder returns a TemplateEncoder for the template text, or an
+// error if the template can't be parsed.
+func NewTemplateEncoder(text string) (TemplateEncoder, error) {
+	tmpl, err := template.New("entry").Parse(text)
+	if err != nil {
+		return TemplateEncoder{}, err
+	}
+	return TemplateEncoder{tmpl: tmpl}, nil
+}
+
+// errNoTemplate is returned by a TemplateEncoder not created with
+// NewTemplateEncoder.
+var errNoTemplate = errors.New("template: encoder has no template")
+
+// TemplateEntry is the data a TemplateEncoder's template is executed with.
+type TemplateEntry struct {
+	// Timestamp is the time of the entry, formatted with the configured
+	// time format.
+	Timestamp string
+	// Level is the level name, in lower case if LowercaseLevels is set.
+	Level string
+	// Message is the message as text; non-string messages are written as
+	// JSON.
+	Message string
+	// Fields are the fields as key=value pairs separated by spaces, with
+	// values quoted when needed.
+	Fields string
+	// Source and Caller are the caller info, if any.
+	Source string
+	Caller string
+	// Entry is the entry itself, for templates that need more than the
+	// text above, such as the time or the severity as values.
+	Entry LogEntry
+}
+
+// Field returns the value of the field with key as text, or "" if the entry
+// has no such field, so templates can put fields at fixed positions:
+//
+//	{{.Timestamp}} {{.Field "request_id"}} {{.Message}}
+func (t TemplateEntry) Field(key string) string {
+	for i := len(t.Entry.Fields) - 1; i >= 0; i-- {
+		if f := t.Entry.Fields[i]; f.Key == key && f.Type != skipType {
+			var buf bytes.Buffer
+			if err := appendFieldText(&buf, f); err != nil {
+				return ""
+			}
+			return buf.String()
+		}
+	}
+	return ""
+}
+
+// Encode appends the entry as text produced by the template.
+func (e TemplateEncoder) Encode(entry LogEntry, buf *bytes.Buffer) error {
+	if e.tmpl == nil {
+		return errNoTemplate
+	}
+	data := TemplateEntry{
+		Timestamp: formatTime(entry.Timestamp, e.timeFormat(time.RFC3339)),
+		Level:     e.level(entry.Level),
+		Source:    entry.Source,
+		Caller:    entry.Caller,
+		Entry:     entry,
+	}
+	text := getBuffer()
+	defer putBuffer(text)
+	if err := appendMessageText(text, entry.Data); err != nil {
+		return err
+	}
+	data.Message = text.String()
+	text.Reset()
+	for _, f := range entry.Fields {
+		if f.Type == skipType {
+			continue
+		}
+		if text.Len() > 0 {
+			text.WriteByte(' ')
+		}
+		text.WriteString(f.Key)
+		text.WriteByte('=')
+		if err := appendFieldText(text, f); err != nil {
+			return err
+		}
+	}
+	data.Fields = text.String()
+
+	start := buf.Len()
+	if err := e.tmpl.Execute(buf, data); err != nil {
+		buf.Truncate(start)
+		return err
+	}
+	if b := buf.Bytes(); len(b) == start || b[len(b)-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+	return nil
+}
+
+func (e TemplateEncoder) withConfig(fn func(*EncoderConfig)) Encoder {
+	fn(&e.EncoderConfig)
+	return e
+}
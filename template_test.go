@@ -0,0 +1,41 @@
+package gologs
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// tests reproducing a text format with a template
+func TestTemplateEncoder(t *testing.T) {
+	enc, err := NewTemplateEncoder(`{{.Timestamp}} [{{.Level}}] {{.Field "request_id"}} {{.Message}} {{.Fields}}`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	entry := LogEntry{Level: "INFO", Timestamp: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), Data: "handled",
+		Fields: []Field{String("request_id", "r1"), String("agent", "curl 8.0"), Int("status", 200)}}
+
+	var buf bytes.Buffer
+	if err := enc.Encode(entry, &buf); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := `2024-01-01T12:00:00Z [INFO] r1 handled request_id=r1 agent="curl 8.0" status=200` + "\n"
+	if buf.String() != want {
+		t.Errorf("Expected %q, got %q", want, buf.String())
+	}
+
+	// the logger's encoder options apply to the template encoder too
+	buf.Reset()
+	l := NewLogger(INFO, &buf, WithEncoder(enc), WithTimestampFormat(time.DateOnly), WithLowercaseLevels())
+	l.Warn("slow")
+	if prefix := time.Now().Format(time.DateOnly) + " [warn]  slow"; !bytes.HasPrefix(buf.Bytes(), []byte(prefix)) {
+		t.Errorf("Expected %q, got %q", prefix, buf.String())
+	}
+
+	if _, err := NewTemplateEncoder("{{.Level"); err == nil {
+		t.Error("Expected an error for an invalid template")
+	}
+	if err := (TemplateEncoder{}).Encode(entry, &buf); err == nil {
+		t.Error("Expected an error without a template")
+	}
+}
@@ -0,0 +1,136 @@
+package gologs
+
+import "sort"
+
+// WithMaxMessageSize truncates string messages longer than n bytes to n
+// bytes, and marks the entry with a "truncated" field set to true.
+func WithMaxMessageSize(n int) Option {
+	return func(o *options) {
+		o.maxMessageSize = n
+	}
+}
+
+// WithMaxEntrySize limits entries to about n bytes in JSON, to protect UDP
+// sinks and downstream parsers from huge entries. The message and field
+// values of larger entries are shortened, the longest first, until the
+// entry fits, and the entry is marked with a "truncated" field set to
+// true. String values are cut, other values are replaced by the start of
+// their JSON encoding; numbers and booleans are kept. Escaping can make
+// the encoded entry slightly larger than n.
+func WithMaxEntrySize(n int) Option {
+	return func(o *options) {
+		o.maxEntrySize = n
+	}
+}
+
+// truncatedMarker is the size of the field marking truncated entries in
+// JSON.
+const truncatedMarker = len(`,"truncated":true`)
+
+// limitSize returns the entry with its message and values truncated to the
+// logger's size limits.
+func (l *Logger) limitSize(entry LogEntry) LogEntry {
+	truncated := false
+	if s, ok := entry.Data.(string); ok && l.maxMessageSize > 0 && len(s) > l.maxMessageSize {
+		entry.Data = truncateUTF8(s, l.maxMessageSize)
+		truncated = true
+	}
+	if l.maxEntrySize > 0 {
+		var fitted bool
+		if entry, fitted = fitEntry(entry, l.maxEntrySize); fitted {
+			truncated = true
+		}
+	}
+	if truncated {
+		fields := make([]Field, 0, len(entry.Fields)+1)
+		fields = append(fields, entry.Fields...)
+		entry.Fields = append(fields, Bool("truncated", true))
+	}
+	return entry
+}
+
+// fitEntry shortens the values of an entry larger than size bytes in JSON,
+// and reports whether it did.
+func fitEntry(entry LogEntry, size int) (LogEntry, bool) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := appendJSONEntry(buf, entry, EncoderConfig{}); err != nil || buf.Len()+truncatedMarker <= size {
+		return entry, false
+	}
+	total := buf.Len()
+
+	// sizes[0] is the size of the message, sizes[i+1] that of field i, or
+	// 0 for values that can't be shortened.
+	sizes := make([]int, len(entry.Fields)+1)
+	buf.Reset()
+	if appendJSONValue(buf, entry.Data) == nil {
+		sizes[0] = buf.Len()
+	}
+	for i, f := range entry.Fields {
+		switch f.Type {
+		case IntType, BoolType, DurationType, skipType:
+			continue
+		}
+		buf.Reset()
+		if appendFieldValue(buf, f) == nil {
+			sizes[i+1] = buf.Len()
+		}
+	}
+	limit := valueLimit(sizes, total+truncatedMarker-size)
+	// The quotes of a string take two bytes.
+	limit = max(limit-2, 0)
+
+	if sizes[0] > limit+2 {
+		entry.Data = truncateValue(entry.Data, limit)
+	}
+	fields := make([]Field, len(entry.Fields))
+	copy(fields, entry.Fields)
+	for i, f := range fields {
+		if sizes[i+1] <= limit+2 {
+			continue
+		}
+		switch f.Type {
+		case StringType, ErrorType:
+			fields[i].str = truncateUTF8(f.str, limit)
+		default:
+			fields[i] = String(f.Key, truncateValue(f.Value, limit))
+		}
+	}
+	entry.Fields = fields
+	return entry, true
+}
+
+// valueLimit returns the largest size that values of sizes can be cut to so
+// that together they shrink by at least excess bytes. The smallest values
+// are kept whole where possible.
+func valueLimit(sizes []int, excess int) int {
+	sorted := append([]int(nil), sizes...)
+	sort.Ints(sorted)
+	total := 0
+	for _, size := range sorted {
+		total += size
+	}
+	budget := max(total-excess, 0)
+	for i, size := range sorted {
+		share := budget / (len(sorted) - i)
+		if size > share {
+			return share
+		}
+		budget -= size
+	}
+	return budget
+}
+
+// truncateValue returns a string value cut to n bytes, or the start of the
+// JSON encoding of another value.
+func truncateValue(v interface{}, n int) string {
+	if s, ok := v.(string); ok {
+		return truncateUTF8(s, n)
+	}
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := appendJSONValue(buf, v); err != nil {
+		return ""
+	}
+	return truncateUTF8(buf.String(), n)
+}
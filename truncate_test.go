@@ -0,0 +1,56 @@
+package gologs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// tests truncating long messages
+func TestMaxMessageSize(t *testing.T) {
+	sink := &memorySink{}
+	l := New(WithSinks(sink), WithMaxMessageSize(5))
+	l.Info("short")
+	l.Info("größer")
+	entries := sink.entries
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Data != "short" || len(entries[0].Fields) != 0 {
+		t.Errorf("Expected the short message to be kept, got %v %v", entries[0].Data, entries[0].Fields)
+	}
+	if entries[1].Data != "grö" {
+		t.Errorf("Expected the message cut at a character boundary, got %q", entries[1].Data)
+	}
+	if f := entries[1].Fields; len(f) != 1 || f[0].Key != "truncated" || f[0].integer != 1 {
+		t.Errorf("Expected a truncated field, got %v", f)
+	}
+}
+
+// tests shrinking the longest values of large entries until they fit
+func TestMaxEntrySize(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLogger(INFO, &out, WithCallerInfo(false), WithTimestampFormat(TimeFormatUnix), WithMaxEntrySize(300))
+	long := strings.Repeat("x", 1000)
+	fields := []Field{String("id", "r1"), String("body", long), Any("items", []string{long}), Int("n", 1)}
+	l.Info("request", fields[0], fields[1], fields[2], fields[3])
+
+	line := strings.TrimSpace(out.String())
+	if len(line) > 300 {
+		t.Errorf("Expected at most 300 bytes, got %d: %s", len(line), line)
+	}
+	for _, want := range []string{`"data":"request"`, `"id":"r1"`, `"body":"xxx`, `"items":"[\"xxx`, `"n":1`, `"truncated":true`} {
+		if !strings.Contains(line, want) {
+			t.Errorf("Expected %s in %s", want, line)
+		}
+	}
+	if len(fields[1].str) != 1000 {
+		t.Errorf("Expected the fields of the caller to be unchanged")
+	}
+
+	out.Reset()
+	l.Info("small", String("id", "r1"))
+	if strings.Contains(out.String(), "truncated") {
+		t.Errorf("Expected a small entry to be kept, got %s", out.String())
+	}
+}
@@ -0,0 +1,40 @@
+package gologs
+
+// WithVerbosity sets the verbosity of the logger, which decides which of
+// the loggers returned by V write entries. Defaults to 0.
+func WithVerbosity(v int) Option {
+	return func(o *options) {
+		o.verbosity = v
+	}
+}
+
+// SetVerbosity sets the verbosity of the logger. Like the level, it is
+// shared with the loggers derived from the logger and may be changed while
+// other goroutines are logging.
+func (l *Logger) SetVerbosity(v int) {
+	l.verbosity.Store(int32(v))
+}
+
+// GetVerbosity returns the verbosity of the logger.
+func (l *Logger) GetVerbosity() int {
+	return int(l.verbosity.Load())
+}
+
+// verbose reports whether the verbosity is high enough for the logger.
+func (l *Logger) verbose() bool {
+	return l.v <= 0 || l.v <= l.verbosity.Load()
+}
+
+// V returns a logger that writes entries only while the verbosity is at
+// least v, in addition to the level check, like the V-levels of glog and
+// logr. This lets very chatty entries be enabled separately from the rest
+// of DEBUG:
+//
+//	logger.V(3).Debug("Received frame %x", frame)
+//
+// V of a logger returned by V replaces its verbosity level.
+func (l *Logger) V(v int) *Logger {
+	child := *l
+	child.v = int32(v)
+	return &child
+}
@@ -0,0 +1,33 @@
+package gologs
+
+import (
+	"strings"
+	"testing"
+)
+
+// tests that V loggers write only at a high enough verbosity
+func TestVerbosity(t *testing.T) {
+	sink := &memorySink{}
+	l := New(WithSinks(sink), WithLevel(DEBUG), WithVerbosity(2))
+	l.V(1).Debug("v1")
+	l.V(2).Debug("v2")
+	l.V(3).Debug("v3")
+	l.V(3).Trace("trace")
+	if l.V(3).IsDebug() || !l.V(2).IsDebug() {
+		t.Errorf("Expected IsDebug to take the verbosity into account")
+	}
+
+	v3 := l.V(3).Child(String("component", "wire"))
+	l.SetVerbosity(3)
+	v3.Debug("later")
+	l.SetVerbosity(0)
+	v3.Error("quiet")
+	l.V(0).Info("v0")
+
+	if msgs := sink.messages(); strings.Join(msgs, ",") != "v1,v2,later,v0" {
+		t.Errorf("Expected entries up to the verbosity, got %v", msgs)
+	}
+	if l.GetVerbosity() != 0 {
+		t.Errorf("Expected verbosity 0, got %d", l.GetVerbosity())
+	}
+}
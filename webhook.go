@@ -0,0 +1,178 @@
+package gologs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"text/template"
+	"time"
+)
+
+// WebhookConfig configures the requests of a WebhookSink.
+type WebhookConfig struct {
+	// Method is the HTTP method of the requests. Defaults to POST.
+	Method string
+	// Template is a text/template executed with a WebhookData for each
+	// entry to build the request body. Defaults to the JSON encoding of the
+	// entry.
+	Template string
+	// ContentType is the Content-Type of the requests. Defaults to
+	// "application/json".
+	ContentType string
+	// MinLevel is the lowest level sent; entries below it are dropped.
+	MinLevel LogLevel
+}
+
+// WebhookData is the data passed to the template of a WebhookSink.
+type WebhookData struct {
+	Level    string
+	Severity LogLevel
+	Time     time.Time
+	Message  string
+	Source   string
+	Caller   string
+	// Fields holds the fields of the entry by key.
+	Fields map[string]interface{}
+}
+
+// webhookFuncs are the functions available in webhook templates.
+var webhookFuncs = template.FuncMap{
+	// json returns the JSON encoding of a value, for use in JSON bodies:
+	// {"text": {{json .Message}}}
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// WebhookSink is a Sink that sends each entry at or above a minimum level
+// as a separate HTTP request, so services such as ticketing systems or chat
+// tools can receive selected entries. The request body is built from a
+// template. Requests are sent from a background goroutine; see the
+// HTTPOption functions for headers, retries and authentication.
+type WebhookSink struct {
+	url         string
+	method      string
+	contentType string
+	minLevel    LogLevel
+	tmpl        *template.Template
+	config      httpConfig
+	batch       *batcher
+}
+
+// NewWebhookSink returns a sink sending entries to url. It returns an error
+// if the template can't be parsed.
+func NewWebhookSink(url string, cfg WebhookConfig, opts ...HTTPOption) (*WebhookSink, error) {
+	s := &WebhookSink{
+		url:         url,
+		method:      cfg.Method,
+		contentType: cfg.ContentType,
+		minLevel:    cfg.MinLevel,
+		config:      newHTTPConfig(opts),
+	}
+	if s.method == "" {
+		s.method = http.MethodPost
+	}
+	if s.contentType == "" {
+		s.contentType = "application/json"
+	}
+	if cfg.Template != "" {
+		tmpl, err := template.New("webhook").Funcs(webhookFuncs).Option("missingkey=zero").Parse(cfg.Template)
+		if err != nil {
+			return nil, fmt.Errorf("webhook: %w", err)
+		}
+		s.tmpl = tmpl
+	}
+	s.batch = s.config.startBatcher(s.send)
+	return s, nil
+}
+
+// Write queues the entry to be sent if its level is at least the minimum
+// level.
+func (s *WebhookSink) Write(entry LogEntry) error {
+	if entry.Severity < s.minLevel {
+		return nil
+	}
+	return s.batch.add(entry)
+}
+
+// Flush sends all queued entries.
+func (s *WebhookSink) Flush() error {
+	return s.batch.flush()
+}
+
+// Close sends all queued entries and stops the background goroutine.
+func (s *WebhookSink) Close() error {
+	return s.batch.close()
+}
+
+// Health returns the results of the sent batches and the number of entries
+// waiting to be sent.
+func (s *WebhookSink) Health() SinkHealth {
+	return s.batch.health()
+}
+
+func (s *WebhookSink) batches() *batcher {
+	return s.batch
+}
+
+// send sends a batch of entries, one request per entry.
+func (s *WebhookSink) send(entries []LogEntry) error {
+	header := http.Header{"Content-Type": {s.contentType}}
+	var errs []error
+	for _, e := range entries {
+		body, err := s.body(e)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if _, err := s.config.request(s.method, s.url, header, body, nil); err != nil {
+			errs = append(errs, fmt.Errorf("webhook: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// body returns the request body of an entry.
+func (s *WebhookSink) body(e LogEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	if s.tmpl == nil {
+		if err := (JSONEncoder{}).Encode(e, &buf); err != nil {
+			return nil, err
+		}
+		return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+	}
+	data, err := newWebhookData(e)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("webhook: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// newWebhookData returns the template data of an entry.
+func newWebhookData(e LogEntry) (WebhookData, error) {
+	var msg bytes.Buffer
+	if err := appendMessageText(&msg, e.Data); err != nil {
+		return WebhookData{}, err
+	}
+	data := WebhookData{
+		Level:    e.Level,
+		Severity: e.Severity,
+		Time:     e.Timestamp,
+		Message:  msg.String(),
+		Source:   e.Source,
+		Caller:   e.Caller,
+		Fields:   make(map[string]interface{}, len(e.Fields)),
+	}
+	for _, f := range e.Fields {
+		if f.Type != skipType {
+			data.Fields[f.Key] = fieldValue(f)
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,93 @@
+package gologs
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// tests that entries at or above the minimum level are sent with the template
+func TestWebhookSink(t *testing.T) {
+	type request struct {
+		method      string
+		contentType string
+		token       string
+		body        string
+	}
+	requests := make(chan request, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{r.Method, r.Header.Get("Content-Type"), r.Header.Get("X-Token"), string(body)}
+	}))
+	defer server.Close()
+
+	sink, err := NewWebhookSink(server.URL, WebhookConfig{
+		Method:   http.MethodPut,
+		Template: `{"title":{{json .Message}},"level":"{{.Level}}","order":{{json .Fields.order}},"retries":{{.Fields.retries}}}`,
+		MinLevel: ERROR,
+	}, WithHeader("X-Token", "secret"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	l := New(WithSinks(sink), WithCallerInfo(false))
+	l.Warn("Slow payment", String("order", "A0"))
+	l.Error(`Payment "failed"`, String("order", "A1"), Int("retries", 3))
+	if err := l.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(requests) != 1 {
+		t.Fatalf("Expected 1 request, got %d", len(requests))
+	}
+	req := <-requests
+	if req.method != http.MethodPut {
+		t.Errorf("Expected PUT, got %s", req.method)
+	}
+	if req.contentType != "application/json" {
+		t.Errorf("Expected application/json, got %s", req.contentType)
+	}
+	if req.token != "secret" {
+		t.Errorf("Expected X-Token header, got %q", req.token)
+	}
+	want := `{"title":"Payment \"failed\"","level":"ERROR","order":"A1","retries":3}`
+	if req.body != want {
+		t.Errorf("Expected %s, got %s", want, req.body)
+	}
+}
+
+// tests that entries are sent as JSON without a template
+func TestWebhookSinkDefaultBody(t *testing.T) {
+	bodies := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+	}))
+	defer server.Close()
+
+	sink, err := NewWebhookSink(server.URL, WebhookConfig{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	l := New(WithSinks(sink), WithCallerInfo(false))
+	l.Info("Deployed", String("version", "1.2"))
+	if err := l.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(<-bodies, &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["data"] != "Deployed" || entry["version"] != "1.2" {
+		t.Errorf("Expected the JSON entry, got %v", entry)
+	}
+}
+
+// tests that an invalid template is rejected
+func TestWebhookSinkInvalidTemplate(t *testing.T) {
+	if _, err := NewWebhookSink("http://localhost", WebhookConfig{Template: "{{.Message"}); err == nil {
+		t.Errorf("Expected error, got %v", err)
+	}
+}